@@ -1,21 +1,41 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
 	"log"
+	"log/slog"
+	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 	_ "quizlet/docs"
 	"quizlet/internal/handlers"
+	"quizlet/internal/health"
+	"quizlet/internal/idempotency"
 	"quizlet/internal/repository"
 	"quizlet/internal/service"
-	"gorm.io/driver/postgres"
-	"gorm.io/gorm"
+	"quizlet/internal/tracing"
 	"quizlet/internal/auth"
+	"quizlet/internal/auth/oidc"
+	"quizlet/internal/auth/password"
+	"quizlet/internal/config"
+	"quizlet/internal/database"
+	"quizlet/internal/logging"
+	"quizlet/internal/metrics"
+	"quizlet/internal/problem"
+	"quizlet/internal/ratelimit"
+	"quizlet/internal/server"
+	"quizlet/migrations"
 )
 
 // @title           Quizlet API
@@ -36,117 +56,390 @@ import (
 // @securityDefinitions.apikey BearerAuth
 // @in header
 // @name Authorization
-// @description Type "Bearer" followed by a space and JWT token.
+// @description Type "Bearer" followed by a space and a JWT or personal access token.
 func main() {
-	// Database connection
-	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=disable",
-		os.Getenv("DB_HOST"),
-		os.Getenv("DB_USER"),
-		os.Getenv("DB_PASSWORD"),
-		os.Getenv("DB_NAME"),
-		os.Getenv("DB_PORT"),
-	)
-
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	configPath := flag.String("config", os.Getenv(config.FileEnv), "path to a YAML or TOML config file")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [-config file] [command]\n\nCommands:\n  serve                 run the API server (default)\n  config                validate the configuration and print it with secrets redacted\n  migrate up            apply all pending database migrations\n  migrate down [steps]  roll back the last migration, or the given number of migrations\n  migrate status        print the schema version and pending migrations\n\nFlags:\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Every package logs through the default logger, which adds request IDs
+	// and redacts credentials; the standard log package is routed to it too
+	logger, err := logging.New(os.Stderr, cfg.Log.Format, cfg.Log.Level)
 	if err != nil {
-		log.Fatal("Failed to connect to database:", err)
+		log.Fatal(err)
+	}
+	slog.SetDefault(logger)
+
+	switch command := flag.Arg(0); command {
+	case "", "serve":
+	case "config":
+		dump, err := cfg.Dump()
+		if err != nil {
+			fatal("rendering configuration failed", err)
+		}
+		os.Stdout.Write(dump)
+		return
+	case "migrate":
+		if err := runMigrate(cfg.Database, flag.Args()[1:]); err != nil {
+			fatal("migrate failed", err)
+		}
+		return
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	// SIGINT and SIGTERM cancel ctx, which aborts startup or drains the server
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	shutdownTracing, err := tracing.Setup(ctx, cfg.Tracing)
+	if err != nil {
+		fatal("setting up tracing failed", err)
+	}
+	// ctx is cancelled by then, so flushing the last spans gets its own deadline
+	defer func() {
+		flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(flushCtx); err != nil {
+			slog.Error("flushing traces failed", "error", err)
+		}
+	}()
+
+	db, err := database.Open(ctx, cfg.Database)
+	if err != nil {
+		fatal("connecting to database failed", err)
+	}
+	defer database.Close(db)
+
+	if err := database.PrepareSchema(ctx, db, cfg.Database); err != nil {
+		fatal("database schema is not usable", err)
+	}
+	if err := metrics.InstrumentDB(db); err != nil {
+		fatal("instrumenting database failed", err)
+	}
+	if err := tracing.InstrumentDB(db); err != nil {
+		fatal("instrumenting database failed", err)
 	}
 
 	// Initialize repositories
 	userRepo := repository.NewUserRepository(db)
 	quizRepo := repository.NewQuizRepository(db)
+	quizRevisionRepo := repository.NewQuizRevisionRepository(db)
 	quizSuiteRepo := repository.NewQuizSuiteRepository(db)
 	refreshTokenRepo := repository.NewRefreshTokenRepository(db)
 	quizAttemptRepo := repository.NewQuizAttemptRepository(db)
+	recoveryCodeRepo := repository.NewRecoveryCodeRepository(db)
+	externalIdentityRepo := repository.NewExternalIdentityRepository(db)
+	personalAccessTokenRepo := repository.NewPersonalAccessTokenRepository(db)
+	idempotencyKeyRepo := repository.NewIdempotencyKeyRepository(db)
+	trashRepo := repository.NewTrashRepository(db)
+	auditEntryRepo := repository.NewAuditEntryRepository(db)
+	unitOfWork := repository.NewUnitOfWork(db)
+
+	// Password hashing and strength policy
+	passwordHasher, err := password.NewHasher(cfg.Password.HasherConfig())
+	if err != nil {
+		fatal("invalid password hashing configuration", err)
+	}
+
+	// Token signing and session cookie settings
+	tokens := cfg.Auth.TokenConfig()
+	cookies := cfg.Session.CookieConfig()
 
 	// Initialize services
-	userService := service.NewUserService(userRepo, refreshTokenRepo)
-	quizService := service.NewQuizService(quizRepo)
-	quizSuiteService := service.NewQuizSuiteService(quizSuiteRepo, quizRepo)
-	quizAttemptService := service.NewQuizAttemptService(quizAttemptRepo)
+	userService := service.NewUserService(userRepo, refreshTokenRepo, recoveryCodeRepo, externalIdentityRepo, unitOfWork, passwordHasher, cfg.Password.Policy(), tokens.RefreshTokenTTL, logger.With("service", "users"))
+	quizService := service.NewQuizService(quizRepo, quizRevisionRepo, unitOfWork)
+	quizSuiteService := service.NewQuizSuiteService(quizSuiteRepo, unitOfWork)
+	quizAttemptService := service.NewQuizAttemptService(quizAttemptRepo, unitOfWork)
+	tokenService := service.NewPersonalAccessTokenService(personalAccessTokenRepo, logger.With("service", "personal_access_tokens"))
+	trashService := service.NewTrashService(trashRepo, unitOfWork, cfg.Trash.Retention)
+	auditService := service.NewAuditService(auditEntryRepo)
 
 	// Initialize handlers
-	userHandler := handlers.NewUserHandler(userService)
+	userHandler := handlers.NewUserHandler(userService, tokens, cookies)
 	quizHandler := handlers.NewQuizHandler(quizService)
 	quizSuiteHandler := handlers.NewQuizSuiteHandler(quizSuiteService)
 	quizAttemptHandler := handlers.NewQuizAttemptHandler(quizAttemptService)
+	tokenHandler := handlers.NewPersonalAccessTokenHandler(tokenService)
+	trashHandler := handlers.NewTrashHandler(trashService)
+	auditHandler := handlers.NewAuditHandler(auditService)
+
+	// Single sign-on providers
+	oidcProviders := oidc.NewProviders(cfg.OIDC.ProviderConfigs(), &http.Client{Timeout: 10 * time.Second})
+	oidcStateKey, err := oidc.StateKey(cfg.OIDC.StateSecret)
+	if err != nil {
+		fatal("creating OIDC state key failed", err)
+	}
+	oidcHandler := handlers.NewOIDCHandler(userService, oidcProviders, oidcStateKey, tokens, cookies)
 
-	r := gin.Default()
+	// Rate limits, shared between replicas when the store is Redis
+	limitStore, closeLimitStore, err := newRateLimitStore(cfg.RateLimit)
+	if err != nil {
+		fatal("creating rate limit store failed", err)
+	}
+	defer closeLimitStore()
+	limitPolicies := cfg.RateLimit.Policies()
+	authLimit := ratelimit.Middleware(limitStore, limitPolicies.Auth)
+	signupLimit := ratelimit.Middleware(limitStore, limitPolicies.Signup)
+	readLimit := ratelimit.Middleware(limitStore, limitPolicies.Read)
+	writeLimit := ratelimit.Middleware(limitStore, limitPolicies.Write)
+
+	// Retried creates with the same Idempotency-Key replay the first response
+	idempotent := idempotency.Middleware(idempotencyKeyRepo, cfg.Idempotency.KeyTTL)
+
+	r := gin.New()
+	if err := r.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		fatal("invalid trusted proxies", err)
+	}
+	r.Use(logging.RequestIDMiddleware())
+	r.Use(tracing.Middleware())
+	r.Use(logging.AccessLog(logger))
+	r.Use(metrics.Middleware())
+	r.Use(problem.Middleware())
+	r.Use(logging.Recovery())
+	r.NoRoute(problem.NoRoute)
 
 	// CORS middleware
 	r.Use(cors.New(cors.Config{
-		AllowOrigins:     []string{"http://localhost:4200", "http://localhost:3000"},
+		AllowOrigins:     cfg.Server.CORSOrigins,
 		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Length", "Content-Type", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowHeaders:     []string{"Origin", "Content-Length", "Content-Type", "Authorization", auth.CSRFHeader, auth.SessionModeHeader, logging.RequestIDHeader, idempotency.KeyHeader, "If-Match", "If-None-Match", "traceparent", "tracestate"},
+		ExposeHeaders:    []string{"Content-Length", logging.RequestIDHeader, ratelimit.LimitHeader, ratelimit.RemainingHeader, ratelimit.ResetHeader, ratelimit.PolicyHeader, ratelimit.RetryAfterHeader, idempotency.ReplayedHeader, "ETag"},
 		AllowCredentials: true,
 		MaxAge:           12 * 60 * 60, // 12 hours
 	}))
 
-	// Debug middleware to log all requests
-	r.Use(func(c *gin.Context) {
-		log.Printf("Request: %s %s", c.Request.Method, c.Request.URL.Path)
-		c.Next()
-	})
-
 	// Swagger setup
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
-	// Health check endpoint
-	r.GET("/health", func(c *gin.Context) {
-		c.JSON(200, gin.H{
-			"status": "ok",
-		})
-	})
+	// Prometheus metrics
+	r.GET("/metrics", gin.WrapH(metrics.Handler()))
+
+	// Health checks; /health is kept for existing probes and reports readiness
+	healthChecks := health.NewRegistry()
+	sqlDB, err := db.DB()
+	if err != nil {
+		fatal("reading database handle failed", err)
+	}
+	healthChecks.Register("database", health.Ping(sqlDB))
+	r.GET("/livez", health.Live)
+	r.GET("/readyz", healthChecks.Ready)
+	r.GET("/health", healthChecks.Ready)
 
 	// API routes
 	api := r.Group("/api")
+	api.Use(auth.CSRFMiddleware())
 	{
 		// Public user routes (no auth required)
-		api.POST("/users/login", userHandler.Login)
-		api.POST("/users/refresh", userHandler.RefreshToken)
-		api.POST("/users", userHandler.CreateUser)
+		api.POST("/users/login", authLimit, userHandler.Login)
+		api.POST("/users/login/mfa", authLimit, userHandler.LoginMFA)
+		api.POST("/users/refresh", authLimit, userHandler.RefreshToken)
+		api.POST("/users", signupLimit, userHandler.CreateUser)
 
-		// Protected routes
+		// Single sign-on routes
+		api.GET("/auth/oidc/providers", oidcHandler.ListProviders)
+		api.GET("/auth/oidc/:provider/login", oidcHandler.Login)
+		api.GET("/auth/oidc/:provider/callback", authLimit, oidcHandler.Callback)
+
+		// Protected routes, reachable with a JWT or a personal access token
 		protected := api.Group("")
-		protected.Use(auth.AuthMiddleware())
+		protected.Use(auth.AuthMiddleware(tokenService, tokens))
 		{
+			// Account and token management needs an interactive login
+			session := protected.Group("")
+			session.Use(auth.RequireSession())
+			{
+				session.POST("/users/me/mfa/totp", writeLimit, userHandler.BeginTOTPEnrollment)
+				session.POST("/users/me/mfa/totp/confirm", writeLimit, userHandler.ConfirmTOTPEnrollment)
+				session.POST("/users/me/mfa/totp/disable", writeLimit, userHandler.DisableTOTP)
+				session.GET("/users/:id", readLimit, userHandler.GetUser)
+				session.PUT("/users/:id", writeLimit, userHandler.UpdateUser)
+				session.DELETE("/users/:id", writeLimit, userHandler.DeleteUser)
+				session.POST("/users/logout", writeLimit, userHandler.Logout)
+				session.POST("/users/me/identities/:provider", writeLimit, oidcHandler.Link)
+
+				// Personal access token routes
+				session.POST("/users/me/tokens", writeLimit, tokenHandler.CreateToken)
+				session.GET("/users/me/tokens", readLimit, tokenHandler.ListTokens)
+				session.DELETE("/users/me/tokens/:id", writeLimit, tokenHandler.RevokeToken)
+
+				// Trash routes
+				session.GET("/trash", readLimit, trashHandler.ListTrash)
+				session.POST("/trash/quizzes/:id/restore", writeLimit, trashHandler.RestoreQuiz)
+				session.POST("/trash/quiz-suites/:id/restore", writeLimit, trashHandler.RestoreQuizSuite)
+				session.POST("/trash/quiz-attempts/:id/restore", writeLimit, trashHandler.RestoreQuizAttempt)
+				session.POST("/trash/users/:id/restore", writeLimit, auth.RequireRole(auth.RoleAdmin), trashHandler.RestoreUser)
+
+				// Audit routes
+				session.GET("/audit", readLimit, auth.RequireRole(auth.RoleAdmin), auditHandler.ListAuditEntries)
+			}
+
+			profileRead := auth.RequireScope(auth.ScopeProfileRead)
+			quizzesRead := auth.RequireScope(auth.ScopeQuizzesRead)
+			quizzesWrite := auth.RequireScope(auth.ScopeQuizzesWrite)
+			suitesRead := auth.RequireScope(auth.ScopeSuitesRead)
+			suitesWrite := auth.RequireScope(auth.ScopeSuitesWrite)
+			attemptsRead := auth.RequireScope(auth.ScopeAttemptsRead)
+			attemptsWrite := auth.RequireScope(auth.ScopeAttemptsWrite)
+
 			// Protected user routes
-			protected.GET("/users/me", userHandler.GetCurrentUser)
-			protected.GET("/users/:id", userHandler.GetUser)
-			protected.PUT("/users/:id", userHandler.UpdateUser)
-			protected.DELETE("/users/:id", userHandler.DeleteUser)
-			protected.POST("/users/logout", userHandler.Logout)
+			protected.GET("/users/me", readLimit, profileRead, userHandler.GetCurrentUser)
 
 			// Quiz routes
-			protected.POST("/quizzes", quizHandler.CreateQuiz)
-			protected.GET("/quizzes/:id", quizHandler.GetQuiz)
-			protected.PUT("/quizzes/:id", quizHandler.UpdateQuiz)
-			protected.DELETE("/quizzes/:id", quizHandler.DeleteQuiz)
-			protected.POST("/quizzes/:id/selections", quizHandler.AddSelection)
-			protected.DELETE("/quizzes/:id/selections/:selectionId", quizHandler.RemoveSelection)
-			protected.GET("/quizzes/user", quizHandler.GetQuizzes)
+			protected.POST("/quizzes", writeLimit, quizzesWrite, idempotent, quizHandler.CreateQuiz)
+			protected.GET("/quizzes/:id", readLimit, quizzesRead, quizHandler.GetQuiz)
+			protected.PUT("/quizzes/:id", writeLimit, quizzesWrite, quizHandler.UpdateQuiz)
+			protected.DELETE("/quizzes/:id", writeLimit, quizzesWrite, quizHandler.DeleteQuiz)
+			protected.POST("/quizzes/:id/selections", writeLimit, quizzesWrite, idempotent, quizHandler.AddSelection)
+			protected.DELETE("/quizzes/:id/selections/:selectionId", writeLimit, quizzesWrite, quizHandler.RemoveSelection)
+			protected.GET("/quizzes/user", readLimit, quizzesRead, quizHandler.GetQuizzes)
+			protected.GET("/quizzes/:id/revisions", readLimit, quizzesRead, quizHandler.ListQuizRevisions)
+			protected.GET("/quizzes/:id/revisions/diff", readLimit, quizzesRead, quizHandler.DiffQuizRevisions)
+			protected.GET("/quizzes/:id/revisions/:revision", readLimit, quizzesRead, quizHandler.GetQuizRevision)
+			protected.POST("/quizzes/:id/revisions/:revision/revert", writeLimit, quizzesWrite, idempotent, quizHandler.RevertQuiz)
+			protected.POST("/quizzes/:id/clone", writeLimit, quizzesWrite, idempotent, quizHandler.CloneQuiz)
 
 			// Quiz Suite routes
-			protected.POST("/quiz-suites", quizSuiteHandler.CreateQuizSuite)
-			protected.GET("/quiz-suites", quizSuiteHandler.GetQuizSuites)
-			protected.GET("/quiz-suites/:id", quizSuiteHandler.GetQuizSuite)
-			protected.PUT("/quiz-suites/:id", quizSuiteHandler.UpdateQuizSuite)
-			protected.DELETE("/quiz-suites/:id", quizSuiteHandler.DeleteQuizSuite)
-			protected.POST("/quiz-suites/:id/quizzes/:quizId", quizSuiteHandler.AddQuizToSuite)
-			protected.DELETE("/quiz-suites/:id/quizzes/:quizId", quizSuiteHandler.RemoveQuizFromSuite)
+			protected.POST("/quiz-suites", writeLimit, suitesWrite, idempotent, quizSuiteHandler.CreateQuizSuite)
+			protected.GET("/quiz-suites", readLimit, suitesRead, quizSuiteHandler.GetQuizSuites)
+			protected.GET("/quiz-suites/:id", readLimit, suitesRead, quizSuiteHandler.GetQuizSuite)
+			protected.PUT("/quiz-suites/:id", writeLimit, suitesWrite, quizSuiteHandler.UpdateQuizSuite)
+			protected.DELETE("/quiz-suites/:id", writeLimit, suitesWrite, quizSuiteHandler.DeleteQuizSuite)
+			protected.POST("/quiz-suites/:id/quizzes/:quizId", writeLimit, suitesWrite, idempotent, quizSuiteHandler.AddQuizToSuite)
+			protected.DELETE("/quiz-suites/:id/quizzes/:quizId", writeLimit, suitesWrite, quizSuiteHandler.RemoveQuizFromSuite)
+			// Copying the quizzes of a suite creates quizzes as well
+			protected.POST("/quiz-suites/:id/clone", writeLimit, suitesWrite, quizzesWrite, idempotent, quizSuiteHandler.CloneQuizSuite)
 
 			// Quiz Attempt routes
-			protected.GET("/quiz-suites/:id/attempts", quizAttemptHandler.ListQuizAttempts)
-			protected.POST("/quiz-suites/:id/attempts", quizAttemptHandler.CreateQuizAttempt)
-			protected.GET("/quiz-suites/:id/attempts/:attemptId", quizAttemptHandler.GetQuizAttempt)
-			protected.PUT("/quiz-suites/:id/attempts/:attemptId", quizAttemptHandler.UpdateQuizAttempt)
-			protected.DELETE("/quiz-suites/:id/attempts/:attemptId", quizAttemptHandler.DeleteQuizAttempt)
+			protected.GET("/quiz-suites/:id/attempts", readLimit, attemptsRead, quizAttemptHandler.ListQuizAttempts)
+			protected.POST("/quiz-suites/:id/attempts", writeLimit, attemptsWrite, idempotent, quizAttemptHandler.CreateQuizAttempt)
+			protected.GET("/quiz-suites/:id/attempts/:attemptId", readLimit, attemptsRead, quizAttemptHandler.GetQuizAttempt)
+			protected.PUT("/quiz-suites/:id/attempts/:attemptId", writeLimit, attemptsWrite, quizAttemptHandler.UpdateQuizAttempt)
+			protected.DELETE("/quiz-suites/:id/attempts/:attemptId", writeLimit, attemptsWrite, quizAttemptHandler.DeleteQuizAttempt)
+			protected.POST("/quiz-suites/:id/attempts/:attemptId/answers", writeLimit, attemptsWrite, idempotent, quizAttemptHandler.SubmitQuizAttemptAnswer)
 		}
 	}
 
-	log.Println("Server starting on :8080")
-	if err := r.Run(":8080"); err != nil {
-		log.Fatal("Failed to start server:", err)
+	srv := server.New(cfg.Server, r)
+	srv.AddWorker("refresh-token-cleanup", server.Every(cfg.Auth.RefreshTokenCleanupInterval, func(ctx context.Context) error {
+		return refreshTokenRepo.DeleteExpired(ctx)
+	}))
+	healthChecks.Register("worker:refresh-token-cleanup", srv.WorkerCheck("refresh-token-cleanup"))
+	srv.AddWorker("idempotency-key-cleanup", server.Every(cfg.Idempotency.CleanupInterval, idempotencyKeyRepo.DeleteExpired))
+	healthChecks.Register("worker:idempotency-key-cleanup", srv.WorkerCheck("idempotency-key-cleanup"))
+	srv.AddWorker("trash-purge", server.Every(cfg.Trash.PurgeInterval, func(ctx context.Context) error {
+		purged, err := trashService.Purge(ctx)
+		if purged > 0 {
+			slog.InfoContext(ctx, "purged trash", "records", purged)
+		}
+		return err
+	}))
+	healthChecks.Register("worker:trash-purge", srv.WorkerCheck("trash-purge"))
+	srv.OnShutdown(healthChecks.Drain)
+
+	slog.Info("server starting", "addr", cfg.Server.Addr)
+	if err := srv.Run(ctx); err != nil {
+		database.Close(db)
+		fatal("server stopped with error", err)
+	}
+	slog.Info("server stopped")
+} 
+
+// runMigrate implements the migrate up, down and status commands
+func runMigrate(cfg config.DatabaseConfig, args []string) error {
+	if len(args) == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	db, err := database.Open(ctx, cfg)
+	if err != nil {
+		return fmt.Errorf("connecting to database: %w", err)
+	}
+	defer database.Close(db)
+
+	fsys, err := migrations.ForDialect(db.Dialector.Name())
+	if err != nil {
+		return err
 	}
-} 
\ No newline at end of file
+	migrator, err := database.NewMigrator(db, fsys)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Applied %d migrations\n", applied)
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				return fmt.Errorf("migrate down: invalid number of steps %q", args[1])
+			}
+		}
+		rolledBack, err := migrator.Down(ctx, steps)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Rolled back %d migrations\n", rolledBack)
+	case "status":
+		status, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Version: %d\n", status.Version)
+		if status.Dirty {
+			fmt.Println("Dirty: yes, the last migration failed and must be repaired by hand")
+		}
+		fmt.Printf("Pending: %d\n", len(status.Pending))
+		for _, m := range status.Pending {
+			fmt.Printf("  %s\n", m)
+		}
+	default:
+		return fmt.Errorf("unknown migrate command %q, use up, down or status", args[0])
+	}
+	return nil
+}
+
+// newRateLimitStore creates the configured rate limit store and a function
+// that releases it
+func newRateLimitStore(cfg config.RateLimitConfig) (ratelimit.Store, func(), error) {
+	if cfg.Backend != config.RateLimitBackendRedis {
+		return ratelimit.NewMemoryStore(), func() {}, nil
+	}
+
+	options, err := redis.ParseURL(cfg.RedisURL)
+	if err != nil {
+		return nil, nil, err
+	}
+	client := redis.NewClient(options)
+	closeClient := func() {
+		if err := client.Close(); err != nil {
+			slog.Error("closing rate limit store failed", "error", err)
+		}
+	}
+	return ratelimit.NewRedisStore(client, "quizlet:ratelimit:"), closeClient, nil
+}
+
+// fatal logs err and exits; deferred cleanup is skipped
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
+}
@@ -0,0 +1,104 @@
+// Package apperr defines the typed errors the service layer returns. Each
+// error carries a kind, which decides the HTTP status, a stable code clients
+// can branch on and a message that is safe to show them. The cause, which
+// may hold database or provider details, is kept for logs only.
+package apperr
+
+import "errors"
+
+// Kind classifies an error independently of the transport
+type Kind int
+
+// Kinds of application errors
+const (
+	Internal Kind = iota
+	Invalid
+	Unauthenticated
+	Forbidden
+	NotFound
+	Conflict
+	Unprocessable
+	RateLimited
+	Upstream
+	PreconditionFailed
+)
+
+// FieldError describes why one request field was rejected
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Error is an application error with a client-safe message
+type Error struct {
+	Kind    Kind
+	Code    string
+	Message string
+	Fields  []FieldError
+	Err     error
+}
+
+// New creates an error; sentinels are declared with it at package level
+func New(kind Kind, code, message string) *Error {
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is matches errors with the same code, so a wrapped copy of a sentinel
+// still satisfies errors.Is against the sentinel
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// Wrap returns a copy of the error that records cause for the logs
+func (e *Error) Wrap(cause error) *Error {
+	wrapped := *e
+	wrapped.Err = cause
+	return &wrapped
+}
+
+// WithMessage returns a copy of the error with a more specific message
+func (e *Error) WithMessage(message string) *Error {
+	wrapped := *e
+	wrapped.Message = message
+	return &wrapped
+}
+
+// ErrValidation is returned when a request body or parameter is malformed
+var ErrValidation = New(Invalid, "validation_failed", "request validation failed")
+
+// Validation returns a validation error listing the rejected fields
+func Validation(fields ...FieldError) *Error {
+	err := *ErrValidation
+	err.Fields = fields
+	return &err
+}
+
+// As returns the application error in err's chain, if any
+func As(err error) (*Error, bool) {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr, true
+	}
+	return nil, false
+}
+
+// KindOf returns the kind of err, or Internal for unclassified errors
+func KindOf(err error) Kind {
+	if appErr, ok := As(err); ok {
+		return appErr.Kind
+	}
+	return Internal
+}
@@ -7,35 +7,79 @@ import (
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"quizlet/internal/apperr"
 )
 
 var (
-	// TODO: Move to configuration
-	secretKey = []byte("your-256-bit-secret")
-	
-	ErrInvalidToken = errors.New("invalid token")
-	ErrExpiredToken = errors.New("token has expired")
-	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrInvalidToken = apperr.New(apperr.Unauthenticated, "invalid_token", "invalid token")
+	ErrExpiredToken = apperr.New(apperr.Unauthenticated, "token_expired", "token has expired")
+	ErrInvalidRefreshToken = apperr.New(apperr.Unauthenticated, "invalid_refresh_token", "invalid refresh token")
 )
 
+const (
+	purposeAccess       = "access"
+	purposeMFAChallenge = "mfa_challenge"
+
+	// MFAChallengeTTL is how long a user has to submit their second factor after a password login
+	MFAChallengeTTL = 5 * time.Minute
+)
+
+// TokenConfig holds the signing secret and token lifetimes. It is passed to
+// the middleware and handlers that issue or check tokens.
+type TokenConfig struct {
+	Secret          []byte
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+}
+
+// DefaultTokenConfig returns the development secret with 15 minute access
+// tokens and 30 day refresh tokens
+func DefaultTokenConfig() TokenConfig {
+	return TokenConfig{
+		Secret:          []byte("your-256-bit-secret"),
+		AccessTokenTTL:  15 * time.Minute,
+		RefreshTokenTTL: 30 * 24 * time.Hour,
+	}
+}
+
 type Claims struct {
-	UserID uint `json:"user_id"`
+	UserID    uint     `json:"user_id"`
+	Purpose   string   `json:"purpose,omitempty"`
+	SessionID string   `json:"sid,omitempty"`
+	Roles     []string `json:"roles,omitempty"`
 	jwt.RegisteredClaims
 }
 
-// GenerateAccessToken creates a new JWT access token for a user
-func GenerateAccessToken(userID uint) (string, error) {
-	claims := Claims{
-		UserID: userID,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(15 * time.Minute)), // Access token expires in 15 minutes
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			NotBefore: jwt.NewNumericDate(time.Now()),
-		},
+// principal builds the request principal for a validated access token
+func (c *Claims) principal(method AuthMethod) *Principal {
+	return &Principal{
+		UserID:    c.UserID,
+		Roles:     c.Roles,
+		SessionID: c.SessionID,
+		Method:    method,
+	}
+}
+
+// GenerateAccessToken creates a new JWT access token for a user. The session
+// ID ties the token to the refresh token it was issued with.
+func (tc TokenConfig) GenerateAccessToken(userID uint, sessionID string, roles ...string) (string, error) {
+	return tc.generateToken(Claims{UserID: userID, Purpose: purposeAccess, SessionID: sessionID, Roles: roles}, tc.AccessTokenTTL)
+}
+
+// GenerateMFAChallengeToken creates a short-lived token proving the password step of a login succeeded
+func (tc TokenConfig) GenerateMFAChallengeToken(userID uint) (string, error) {
+	return tc.generateToken(Claims{UserID: userID, Purpose: purposeMFAChallenge}, MFAChallengeTTL)
+}
+
+func (tc TokenConfig) generateToken(claims Claims, ttl time.Duration) (string, error) {
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
+		IssuedAt:  jwt.NewNumericDate(time.Now()),
+		NotBefore: jwt.NewNumericDate(time.Now()),
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(secretKey)
+	return token.SignedString(tc.Secret)
 }
 
 // GenerateRefreshToken creates a new refresh token for a user
@@ -47,13 +91,22 @@ func GenerateRefreshToken() (string, error) {
 	return base64.URLEncoding.EncodeToString(b), nil
 }
 
-// ValidateToken validates the JWT token and returns the claims
-func ValidateToken(tokenString string) (*Claims, error) {
+// ValidateToken validates the JWT access token and returns the claims
+func (tc TokenConfig) ValidateToken(tokenString string) (*Claims, error) {
+	return tc.validateToken(tokenString, purposeAccess)
+}
+
+// ValidateMFAChallengeToken validates an MFA challenge token and returns the claims
+func (tc TokenConfig) ValidateMFAChallengeToken(tokenString string) (*Claims, error) {
+	return tc.validateToken(tokenString, purposeMFAChallenge)
+}
+
+func (tc TokenConfig) validateToken(tokenString, purpose string) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, errors.New("unexpected signing method")
 		}
-		return secretKey, nil
+		return tc.Secret, nil
 	})
 
 	if err != nil {
@@ -63,7 +116,7 @@ func ValidateToken(tokenString string) (*Claims, error) {
 		return nil, ErrInvalidToken
 	}
 
-	if claims, ok := token.Claims.(*Claims); ok && token.Valid {
+	if claims, ok := token.Claims.(*Claims); ok && token.Valid && claims.Purpose == purpose {
 		return claims, nil
 	}
 
@@ -71,8 +124,8 @@ func ValidateToken(tokenString string) (*Claims, error) {
 }
 
 // ExtractUserID extracts the user ID from the token string
-func ExtractUserID(tokenString string) (uint, error) {
-	claims, err := ValidateToken(tokenString)
+func (tc TokenConfig) ExtractUserID(tokenString string) (uint, error) {
+	claims, err := tc.ValidateToken(tokenString)
 	if err != nil {
 		return 0, err
 	}
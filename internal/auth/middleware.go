@@ -1,44 +1,87 @@
 package auth
 
 import (
-	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"quizlet/internal/apperr"
 )
 
-// AuthMiddleware is a Gin middleware that validates JWT tokens
-func AuthMiddleware() gin.HandlerFunc {
+var (
+	ErrMissingCredentials     = apperr.New(apperr.Unauthenticated, "missing_credentials", "authorization header is required")
+	ErrMalformedAuthorization = apperr.New(apperr.Unauthenticated, "malformed_authorization", "invalid authorization header format")
+	ErrMissingScope           = apperr.New(apperr.Forbidden, "missing_scope", "token is missing a required scope")
+	ErrSessionRequired        = apperr.New(apperr.Forbidden, "session_required", "this endpoint cannot be used with a personal access token")
+	ErrInvalidCSRFToken       = apperr.New(apperr.Forbidden, "invalid_csrf_token", "missing or invalid csrf token")
+	ErrMissingRole            = apperr.New(apperr.Forbidden, "missing_role", "you do not have the role required for this endpoint")
+)
+
+// abort stops the handler chain and records err, which the problem
+// middleware renders
+func abort(c *gin.Context, err error) {
+	_ = c.Error(err)
+	c.Abort()
+}
+
+// AuthMiddleware is a Gin middleware that validates JWT tokens signed with
+// the tokens config and, when a validator is given, personal access tokens.
+// Browser sessions without an Authorization header are authenticated by the
+// access token cookie; pair it with CSRFMiddleware to protect their
+// state-changing requests.
+func AuthMiddleware(patValidator PersonalAccessTokenValidator, tokens TokenConfig) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "authorization header is required"})
-			c.Abort()
+			if accessToken, err := c.Cookie(AccessTokenCookie); err == nil && accessToken != "" {
+				claims, err := tokens.ValidateToken(accessToken)
+				if err != nil {
+					abort(c, err)
+					return
+				}
+
+				SetPrincipal(c, claims.principal(AuthMethodCookie))
+				c.Next()
+				return
+			}
+
+			abort(c, ErrMissingCredentials)
 			return
 		}
 
 		// Check if the Authorization header has the correct format
 		parts := strings.Split(authHeader, " ")
 		if len(parts) != 2 || parts[0] != "Bearer" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid authorization header format"})
-			c.Abort()
+			abort(c, ErrMalformedAuthorization)
 			return
 		}
 
 		tokenString := parts[1]
-		claims, err := ValidateToken(tokenString)
-		if err != nil {
-			status := http.StatusUnauthorized
-			if err == ErrExpiredToken {
-				status = http.StatusUnauthorized
+		if IsPersonalAccessToken(tokenString) {
+			if patValidator == nil {
+				abort(c, ErrInvalidToken)
+				return
 			}
-			c.JSON(status, gin.H{"error": err.Error()})
-			c.Abort()
+
+			principal, err := patValidator.ValidatePersonalAccessToken(c.Request.Context(), tokenString)
+			if err != nil {
+				abort(c, ErrInvalidToken.Wrap(err))
+				return
+			}
+
+			// Store the token owner and its granted scopes for later use
+			SetPrincipal(c, principal)
+			c.Next()
+			return
+		}
+
+		claims, err := tokens.ValidateToken(tokenString)
+		if err != nil {
+			abort(c, err)
 			return
 		}
 
-		// Store user ID in the context for later use
-		c.Set("userID", claims.UserID)
+		// Store the authenticated principal in the context for later use
+		SetPrincipal(c, claims.principal(AuthMethodBearer))
 		c.Next()
 	}
 } 
\ No newline at end of file
@@ -0,0 +1,97 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"quizlet/internal/problem"
+)
+
+type stubTokenValidator struct {
+	tokens map[string][]string
+}
+
+func (v stubTokenValidator) ValidatePersonalAccessToken(ctx context.Context, token string) (*Principal, error) {
+	scopes, ok := v.tokens[token]
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+	return &Principal{UserID: 42, Scopes: scopes, SessionID: "pat:1", Method: AuthMethodPersonalAccessToken}, nil
+}
+
+func setupMiddlewareRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	validator := stubTokenValidator{tokens: map[string][]string{
+		"qlt_reader": {ScopeQuizzesRead},
+	}}
+
+	router := gin.New()
+	router.Use(problem.Middleware())
+	protected := router.Group("")
+	protected.Use(AuthMiddleware(validator, DefaultTokenConfig()))
+	protected.GET("/quizzes", RequireScope(ScopeQuizzesRead), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"user_id": c.GetUint("userID")})
+	})
+	protected.POST("/quizzes", RequireScope(ScopeQuizzesWrite), func(c *gin.Context) {
+		c.Status(http.StatusCreated)
+	})
+	protected.GET("/tokens", RequireSession(), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	return router
+}
+
+func TestAuthMiddlewarePersonalAccessTokens(t *testing.T) {
+	router := setupMiddlewareRouter()
+	jwt, err := DefaultTokenConfig().GenerateAccessToken(42, "1")
+	assert.NoError(t, err)
+
+	testCases := []struct {
+		name           string
+		method         string
+		path           string
+		token          string
+		expectedStatus int
+	}{
+		{"Token With Scope", http.MethodGet, "/quizzes", "qlt_reader", http.StatusOK},
+		{"Token Missing Scope", http.MethodPost, "/quizzes", "qlt_reader", http.StatusForbidden},
+		{"Unknown Token", http.MethodGet, "/quizzes", "qlt_unknown", http.StatusUnauthorized},
+		{"Token On Session Route", http.MethodGet, "/tokens", "qlt_reader", http.StatusForbidden},
+		{"JWT Is Not Scope Restricted", http.MethodPost, "/quizzes", jwt, http.StatusCreated},
+		{"JWT On Session Route", http.MethodGet, "/tokens", jwt, http.StatusOK},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(tc.method, tc.path, nil)
+			req.Header.Set("Authorization", "Bearer "+tc.token)
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestAuthMiddlewareWithoutValidator(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(problem.Middleware())
+	router.GET("/", AuthMiddleware(nil, DefaultTokenConfig()), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer qlt_reader")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, problem.ContentType, w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), `"code":"invalid_token"`)
+}
@@ -0,0 +1,29 @@
+package oidc
+
+import (
+	"crypto/rand"
+	"net/http"
+)
+
+// NewProviders builds the configured providers keyed by name
+func NewProviders(configs []ProviderConfig, client *http.Client) map[string]*Provider {
+	providers := make(map[string]*Provider, len(configs))
+	for _, config := range configs {
+		providers[config.Name] = NewProvider(config, client)
+	}
+	return providers
+}
+
+// StateKey returns the key used to sign login state cookies. Without a
+// configured secret a random key is generated, which only works when a single
+// instance serves both the login and the callback.
+func StateKey(secret string) ([]byte, error) {
+	if secret != "" {
+		return []byte(secret), nil
+	}
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
@@ -0,0 +1,175 @@
+package oidc
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"quizlet/internal/auth/oidc/oidctest"
+)
+
+func newTestProvider(t *testing.T) (*oidctest.Server, *Provider) {
+	server := oidctest.NewServer("quizlet-client", "quizlet-secret")
+	t.Cleanup(server.Close)
+
+	provider := NewProvider(ProviderConfig{
+		Name:         "mock",
+		Issuer:       server.URL,
+		ClientID:     "quizlet-client",
+		ClientSecret: "quizlet-secret",
+		RedirectURL:  "http://localhost:8080/api/auth/oidc/mock/callback",
+	}, server.Client())
+
+	return server, provider
+}
+
+// authorize follows the authorization URL and returns the code from the redirect
+func authorize(t *testing.T, server *oidctest.Server, authURL string) url.Values {
+	client := server.Client()
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+
+	resp, err := client.Get(authURL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+
+	location, err := url.Parse(resp.Header.Get("Location"))
+	require.NoError(t, err)
+	return location.Query()
+}
+
+func TestProviderCodeFlowWithPKCE(t *testing.T) {
+	server, provider := newTestProvider(t)
+	server.SetUser(oidctest.Claims{
+		Subject:       "user-123",
+		Email:         "Teacher@School.edu",
+		EmailVerified: true,
+		Name:          "Test Teacher",
+	})
+
+	state, err := NewAuthState(provider.Name())
+	require.NoError(t, err)
+
+	authURL, err := provider.AuthCodeURL(context.Background(), state.State, state.Nonce, CodeChallengeS256(state.CodeVerifier))
+	require.NoError(t, err)
+
+	callback := authorize(t, server, authURL)
+	assert.Equal(t, state.State, callback.Get("state"))
+
+	identity, err := provider.Exchange(context.Background(), callback.Get("code"), state.CodeVerifier, state.Nonce)
+	require.NoError(t, err)
+	assert.Equal(t, &Identity{
+		Provider:      "mock",
+		Subject:       "user-123",
+		Email:         "teacher@school.edu",
+		EmailVerified: true,
+		Name:          "Test Teacher",
+	}, identity)
+}
+
+func TestProviderRejectsWrongVerifier(t *testing.T) {
+	server, provider := newTestProvider(t)
+	server.SetUser(oidctest.Claims{Subject: "user-123"})
+
+	state, err := NewAuthState(provider.Name())
+	require.NoError(t, err)
+
+	authURL, err := provider.AuthCodeURL(context.Background(), state.State, state.Nonce, CodeChallengeS256(state.CodeVerifier))
+	require.NoError(t, err)
+	callback := authorize(t, server, authURL)
+
+	_, err = provider.Exchange(context.Background(), callback.Get("code"), "not-the-verifier", state.Nonce)
+	assert.ErrorIs(t, err, ErrExchangeFailed)
+}
+
+func TestProviderRejectsNonceMismatch(t *testing.T) {
+	server, provider := newTestProvider(t)
+	server.SetUser(oidctest.Claims{Subject: "user-123"})
+
+	state, err := NewAuthState(provider.Name())
+	require.NoError(t, err)
+
+	authURL, err := provider.AuthCodeURL(context.Background(), state.State, state.Nonce, CodeChallengeS256(state.CodeVerifier))
+	require.NoError(t, err)
+	callback := authorize(t, server, authURL)
+
+	_, err = provider.Exchange(context.Background(), callback.Get("code"), state.CodeVerifier, "other-nonce")
+	assert.ErrorIs(t, err, ErrInvalidIDToken)
+}
+
+func TestVerifyIDToken(t *testing.T) {
+	server, provider := newTestProvider(t)
+	now := time.Now()
+
+	testCases := []struct {
+		name   string
+		claims jwt.MapClaims
+		valid  bool
+	}{
+		{
+			name: "Valid",
+			claims: jwt.MapClaims{
+				"iss": server.URL, "aud": "quizlet-client", "sub": "abc", "nonce": "n",
+				"exp": now.Add(time.Minute).Unix(), "email_verified": "true",
+			},
+			valid: true,
+		},
+		{
+			name: "Wrong Audience",
+			claims: jwt.MapClaims{
+				"iss": server.URL, "aud": "someone-else", "sub": "abc", "nonce": "n",
+				"exp": now.Add(time.Minute).Unix(),
+			},
+		},
+		{
+			name: "Wrong Issuer",
+			claims: jwt.MapClaims{
+				"iss": "https://evil.example.com", "aud": "quizlet-client", "sub": "abc", "nonce": "n",
+				"exp": now.Add(time.Minute).Unix(),
+			},
+		},
+		{
+			name: "Expired",
+			claims: jwt.MapClaims{
+				"iss": server.URL, "aud": "quizlet-client", "sub": "abc", "nonce": "n",
+				"exp": now.Add(-time.Minute).Unix(),
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			identity, err := provider.verifyIDToken(context.Background(), server.IssueIDToken(tc.claims), "n")
+			if tc.valid {
+				require.NoError(t, err)
+				assert.True(t, identity.EmailVerified)
+			} else {
+				assert.ErrorIs(t, err, ErrInvalidIDToken)
+			}
+		})
+	}
+}
+
+func TestAuthStateRoundTrip(t *testing.T) {
+	key := []byte("state-key")
+	state, err := NewAuthState("mock")
+	require.NoError(t, err)
+
+	encoded, err := EncodeState(key, state)
+	require.NoError(t, err)
+
+	decoded, err := DecodeState(key, encoded)
+	require.NoError(t, err)
+	assert.Equal(t, state.State, decoded.State)
+	assert.Equal(t, state.CodeVerifier, decoded.CodeVerifier)
+
+	_, err = DecodeState([]byte("other-key"), encoded)
+	assert.ErrorIs(t, err, ErrInvalidState)
+}
@@ -0,0 +1,74 @@
+package oidc
+
+import (
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"quizlet/internal/apperr"
+)
+
+// StateTTL is how long a user has to complete the login at the identity provider
+const StateTTL = 10 * time.Minute
+
+var ErrInvalidState = apperr.New(apperr.Invalid, "invalid_login_state", "invalid or expired login state")
+
+// AuthState carries the per-login secrets between the login redirect and the
+// callback. It is signed and stored in a cookie so no server-side session is needed.
+type AuthState struct {
+	Provider     string `json:"provider"`
+	State        string `json:"state"`
+	Nonce        string `json:"nonce"`
+	CodeVerifier string `json:"code_verifier"`
+	// CookieSession makes the callback issue session cookies instead of
+	// returning tokens in the body
+	CookieSession bool `json:"cookie_session,omitempty"`
+	// LinkUserID makes the callback link the identity to this signed-in user
+	// instead of logging in
+	LinkUserID uint `json:"link_user_id,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// NewAuthState generates a fresh state, nonce and PKCE verifier for a provider
+func NewAuthState(provider string) (*AuthState, error) {
+	state, err := randomString(16)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := randomString(16)
+	if err != nil {
+		return nil, err
+	}
+	verifier, err := GenerateCodeVerifier()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	return &AuthState{
+		Provider:     provider,
+		State:        state,
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		RegisteredClaims: jwt.RegisteredClaims{
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(StateTTL)),
+		},
+	}, nil
+}
+
+// EncodeState signs the state for storage in a cookie
+func EncodeState(key []byte, state *AuthState) (string, error) {
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, state).SignedString(key)
+}
+
+// DecodeState verifies and decodes a state cookie value
+func DecodeState(key []byte, value string) (*AuthState, error) {
+	var state AuthState
+	_, err := jwt.ParseWithClaims(value, &state, func(token *jwt.Token) (interface{}, error) {
+		return key, nil
+	}, jwt.WithValidMethods([]string{"HS256"}), jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, ErrInvalidState
+	}
+	return &state, nil
+}
@@ -0,0 +1,66 @@
+package password
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// ConfigFromEnv reads PASSWORD_HASH_ALGORITHM (argon2id or bcrypt),
+// PASSWORD_ARGON2_MEMORY_KIB, PASSWORD_ARGON2_ITERATIONS,
+// PASSWORD_ARGON2_PARALLELISM and PASSWORD_BCRYPT_COST on top of the defaults.
+func ConfigFromEnv() (Config, error) {
+	config := DefaultConfig()
+	if algorithm := os.Getenv("PASSWORD_HASH_ALGORITHM"); algorithm != "" {
+		config.Algorithm = algorithm
+	}
+
+	if err := envUint("PASSWORD_ARGON2_MEMORY_KIB", 32, func(v uint64) { config.Argon2.Memory = uint32(v) }); err != nil {
+		return config, err
+	}
+	if err := envUint("PASSWORD_ARGON2_ITERATIONS", 32, func(v uint64) { config.Argon2.Iterations = uint32(v) }); err != nil {
+		return config, err
+	}
+	if err := envUint("PASSWORD_ARGON2_PARALLELISM", 8, func(v uint64) { config.Argon2.Parallelism = uint8(v) }); err != nil {
+		return config, err
+	}
+	if err := envUint("PASSWORD_BCRYPT_COST", 8, func(v uint64) { config.BcryptCost = int(v) }); err != nil {
+		return config, err
+	}
+	return config, nil
+}
+
+// PolicyFromEnv reads PASSWORD_MIN_LENGTH, PASSWORD_MAX_LENGTH and the
+// PASSWORD_REQUIRE_{UPPER,LOWER,DIGIT,SYMBOL} flags on top of the defaults.
+func PolicyFromEnv() (Policy, error) {
+	policy := DefaultPolicy()
+
+	if err := envUint("PASSWORD_MIN_LENGTH", 16, func(v uint64) { policy.MinLength = int(v) }); err != nil {
+		return policy, err
+	}
+	if err := envUint("PASSWORD_MAX_LENGTH", 16, func(v uint64) { policy.MaxLength = int(v) }); err != nil {
+		return policy, err
+	}
+	policy.RequireUpper = os.Getenv("PASSWORD_REQUIRE_UPPER") == "true"
+	policy.RequireLower = os.Getenv("PASSWORD_REQUIRE_LOWER") == "true"
+	policy.RequireDigit = os.Getenv("PASSWORD_REQUIRE_DIGIT") == "true"
+	policy.RequireSymbol = os.Getenv("PASSWORD_REQUIRE_SYMBOL") == "true"
+
+	if policy.MaxLength > 0 && policy.MinLength > policy.MaxLength {
+		return policy, fmt.Errorf("PASSWORD_MIN_LENGTH (%d) exceeds PASSWORD_MAX_LENGTH (%d)", policy.MinLength, policy.MaxLength)
+	}
+	return policy, nil
+}
+
+func envUint(name string, bits int, set func(uint64)) error {
+	raw := os.Getenv(name)
+	if raw == "" {
+		return nil
+	}
+	v, err := strconv.ParseUint(raw, 10, bits)
+	if err != nil {
+		return fmt.Errorf("invalid %s: %w", name, err)
+	}
+	set(v)
+	return nil
+}
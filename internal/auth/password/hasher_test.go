@@ -0,0 +1,127 @@
+package password
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// testArgon2Params keeps the tests fast; production uses DefaultArgon2Params
+var testArgon2Params = Argon2Params{Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+
+func newTestHasher(t *testing.T, config Config) Hasher {
+	t.Helper()
+	h, err := NewHasher(config)
+	require.NoError(t, err)
+	return h
+}
+
+func TestArgon2idHashAndVerify(t *testing.T) {
+	h := newTestHasher(t, Config{Algorithm: AlgorithmArgon2id, Argon2: testArgon2Params})
+
+	encoded, err := h.Hash("correct horse")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(encoded, "$argon2id$v=19$m=1024,t=1,p=1$"))
+	assert.NotContains(t, encoded, "correct horse")
+
+	ok, err := h.Verify("correct horse", encoded)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = h.Verify("battery staple", encoded)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	other, err := h.Hash("correct horse")
+	require.NoError(t, err)
+	assert.NotEqual(t, encoded, other, "salts should differ")
+	assert.False(t, h.NeedsRehash(encoded))
+}
+
+func TestVerifyLegacyBcryptHash(t *testing.T) {
+	legacy, err := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	h := newTestHasher(t, Config{Algorithm: AlgorithmArgon2id, Argon2: testArgon2Params})
+
+	ok, err := h.Verify("password123", string(legacy))
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = h.Verify("wrong", string(legacy))
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	assert.True(t, h.NeedsRehash(string(legacy)))
+}
+
+func TestNeedsRehash(t *testing.T) {
+	old := newTestHasher(t, Config{Algorithm: AlgorithmArgon2id, Argon2: testArgon2Params})
+	encoded, err := old.Hash("password123")
+	require.NoError(t, err)
+
+	stronger := testArgon2Params
+	stronger.Iterations = 2
+
+	testCases := []struct {
+		name     string
+		config   Config
+		expected bool
+	}{
+		{"Same Parameters", Config{Algorithm: AlgorithmArgon2id, Argon2: testArgon2Params}, false},
+		{"More Iterations", Config{Algorithm: AlgorithmArgon2id, Argon2: stronger}, true},
+		{"Different Algorithm", Config{Algorithm: AlgorithmBcrypt, BcryptCost: bcrypt.MinCost}, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := newTestHasher(t, tc.config)
+			assert.Equal(t, tc.expected, h.NeedsRehash(encoded))
+
+			// Hashes made with older parameters must keep verifying
+			ok, err := h.Verify("password123", encoded)
+			assert.NoError(t, err)
+			assert.True(t, ok)
+		})
+	}
+}
+
+func TestBcryptCostChange(t *testing.T) {
+	h := newTestHasher(t, Config{Algorithm: AlgorithmBcrypt, BcryptCost: bcrypt.MinCost})
+	encoded, err := h.Hash("password123")
+	require.NoError(t, err)
+	assert.False(t, h.NeedsRehash(encoded))
+
+	higher := newTestHasher(t, Config{Algorithm: AlgorithmBcrypt, BcryptCost: bcrypt.MinCost + 1})
+	assert.True(t, higher.NeedsRehash(encoded))
+}
+
+func TestVerifyRejectsMalformedHashes(t *testing.T) {
+	h := newTestHasher(t, Config{Algorithm: AlgorithmArgon2id, Argon2: testArgon2Params})
+
+	for _, encoded := range []string{
+		"plaintext",
+		"$argon2id$v=19$m=1024,t=1,p=1$c2FsdA",
+		"$argon2id$v=18$m=1024,t=1,p=1$c2FsdA$a2V5",
+		"$argon2id$v=19$m=0,t=1,p=1$c2FsdA$a2V5",
+	} {
+		ok, err := h.Verify("password123", encoded)
+		assert.Error(t, err, encoded)
+		assert.False(t, ok, encoded)
+		assert.True(t, h.NeedsRehash(encoded), encoded)
+	}
+}
+
+func TestNewHasherRejectsInvalidConfig(t *testing.T) {
+	_, err := NewHasher(Config{Algorithm: "md5"})
+	assert.ErrorIs(t, err, ErrUnsupportedAlgorithm)
+
+	_, err = NewHasher(Config{Algorithm: AlgorithmBcrypt, BcryptCost: 2})
+	assert.Error(t, err)
+
+	_, err = NewHasher(Config{Algorithm: AlgorithmArgon2id})
+	assert.Error(t, err)
+}
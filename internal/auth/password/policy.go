@@ -0,0 +1,101 @@
+package password
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ErrWeakPassword is matched by every PolicyError
+var ErrWeakPassword = errors.New("password does not meet the strength policy")
+
+// Policy describes the minimum strength required for new passwords
+type Policy struct {
+	MinLength     int
+	MaxLength     int
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+}
+
+// DefaultPolicy only enforces length; composition rules are opt-in
+func DefaultPolicy() Policy {
+	return Policy{
+		MinLength: 8,
+		MaxLength: 72,
+	}
+}
+
+// PolicyError lists every rule the password failed
+type PolicyError struct {
+	Violations []string
+}
+
+func (e *PolicyError) Error() string {
+	return "password must " + strings.Join(e.Violations, ", ")
+}
+
+func (e *PolicyError) Is(target error) bool {
+	return target == ErrWeakPassword
+}
+
+// Validate checks the password against the policy. Passwords that contain
+// one of the given personal values, such as the username or the local part of
+// the email address, are rejected as well.
+func (p Policy) Validate(password string, personal ...string) error {
+	var violations []string
+
+	length := utf8.RuneCountInString(password)
+	if p.MinLength > 0 && length < p.MinLength {
+		violations = append(violations, fmt.Sprintf("be at least %d characters long", p.MinLength))
+	}
+	if p.MaxLength > 0 && length > p.MaxLength {
+		violations = append(violations, fmt.Sprintf("be at most %d characters long", p.MaxLength))
+	}
+
+	var upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			symbol = true
+		}
+	}
+	if p.RequireUpper && !upper {
+		violations = append(violations, "contain an uppercase letter")
+	}
+	if p.RequireLower && !lower {
+		violations = append(violations, "contain a lowercase letter")
+	}
+	if p.RequireDigit && !digit {
+		violations = append(violations, "contain a digit")
+	}
+	if p.RequireSymbol && !symbol {
+		violations = append(violations, "contain a symbol")
+	}
+
+	lowered := strings.ToLower(password)
+	for _, value := range personal {
+		if i := strings.Index(value, "@"); i >= 0 {
+			value = value[:i]
+		}
+		value = strings.ToLower(strings.TrimSpace(value))
+		if len(value) >= 3 && strings.Contains(lowered, value) {
+			violations = append(violations, "not contain your username or email address")
+			break
+		}
+	}
+
+	if len(violations) > 0 {
+		return &PolicyError{Violations: violations}
+	}
+	return nil
+}
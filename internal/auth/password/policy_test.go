@@ -0,0 +1,40 @@
+package password
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPolicyValidate(t *testing.T) {
+	strict := Policy{MinLength: 10, MaxLength: 64, RequireUpper: true, RequireLower: true, RequireDigit: true, RequireSymbol: true}
+
+	testCases := []struct {
+		name          string
+		policy        Policy
+		password      string
+		personal      []string
+		expectedError string
+	}{
+		{"Default Accepts Long Password", DefaultPolicy(), "password123", nil, ""},
+		{"Default Rejects Short Password", DefaultPolicy(), "short", nil, "password must be at least 8 characters long"},
+		{"Strict Accepts Mixed Password", strict, "Tr0ub4dor&3x", nil, ""},
+		{"Strict Lists Every Violation", strict, "alllowercase", nil, "password must contain an uppercase letter, contain a digit, contain a symbol"},
+		{"Rejects Username", DefaultPolicy(), "alice-rocks-2024", []string{"alice", "a@example.com"}, "password must not contain your username or email address"},
+		{"Rejects Email Local Part", DefaultPolicy(), "xxbob.smithxx", []string{"someone", "bob.smith@example.com"}, "password must not contain your username or email address"},
+		{"Ignores Short Personal Values", DefaultPolicy(), "password123", []string{"pa"}, ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.policy.Validate(tc.password, tc.personal...)
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, tc.expectedError)
+			assert.True(t, errors.Is(err, ErrWeakPassword))
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PersonalAccessTokenPrefix marks bearer tokens that are personal access
+// tokens rather than JWTs
+const PersonalAccessTokenPrefix = "qlt_"
+
+// Scopes that can be granted to personal access tokens
+const (
+	ScopeQuizzesRead   = "quizzes:read"
+	ScopeQuizzesWrite  = "quizzes:write"
+	ScopeSuitesRead    = "suites:read"
+	ScopeSuitesWrite   = "suites:write"
+	ScopeAttemptsRead  = "attempts:read"
+	ScopeAttemptsWrite = "attempts:write"
+	ScopeProfileRead   = "profile:read"
+)
+
+// Scopes lists every scope a personal access token may request
+var Scopes = []string{
+	ScopeQuizzesRead,
+	ScopeQuizzesWrite,
+	ScopeSuitesRead,
+	ScopeSuitesWrite,
+	ScopeAttemptsRead,
+	ScopeAttemptsWrite,
+	ScopeProfileRead,
+}
+
+// PersonalAccessTokenValidator resolves a personal access token to a
+// principal carrying its owner and granted scopes
+type PersonalAccessTokenValidator interface {
+	ValidatePersonalAccessToken(ctx context.Context, token string) (*Principal, error)
+}
+
+// IsValidScope reports whether scope is a known personal access token scope
+func IsValidScope(scope string) bool {
+	for _, s := range Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// GeneratePersonalAccessToken creates a new random personal access token
+func GeneratePersonalAccessToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return PersonalAccessTokenPrefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// HashPersonalAccessToken returns the hex encoded SHA-256 hash stored for a token
+func HashPersonalAccessToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// IsPersonalAccessToken reports whether a bearer token is a personal access token
+func IsPersonalAccessToken(token string) bool {
+	return strings.HasPrefix(token, PersonalAccessTokenPrefix)
+}
+
+// RequireScope rejects personal access tokens that were not granted scope.
+// Interactive sessions authenticated with a JWT are not restricted.
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		p, ok := CurrentPrincipal(c)
+		if !ok || p.HasScope(scope) {
+			c.Next()
+			return
+		}
+
+		abort(c, ErrMissingScope.WithMessage("token is missing required scope "+scope))
+	}
+}
+
+// RequireSession rejects personal access tokens, for routes such as account
+// and token management that need an interactive login
+func RequireSession() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if p, ok := CurrentPrincipal(c); ok && !p.IsSession() {
+			abort(c, ErrSessionRequired)
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,116 @@
+package auth
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AuthMethod identifies how a request was authenticated
+type AuthMethod string
+
+const (
+	AuthMethodBearer              AuthMethod = "bearer"
+	AuthMethodCookie              AuthMethod = "cookie"
+	AuthMethodPersonalAccessToken AuthMethod = "personal_access_token"
+)
+
+// RoleAdmin is granted to administrators, who can act on other users' data
+const RoleAdmin = "admin"
+
+// principalKey holds the *Principal in the gin context
+const principalKey = "principal"
+
+type principalContextKey struct{}
+
+// Principal is the authenticated caller of a request
+type Principal struct {
+	UserID uint
+	Roles  []string
+	// Scopes limits what a personal access token may do; it is ignored for
+	// interactive sessions, which are not scope restricted
+	Scopes []string
+	// SessionID identifies the login session (the refresh token) or the
+	// personal access token the request was made with
+	SessionID string
+	Method    AuthMethod
+}
+
+// IsSession reports whether the principal comes from an interactive login
+// rather than a personal access token
+func (p *Principal) IsSession() bool {
+	return p.Method != AuthMethodPersonalAccessToken
+}
+
+// HasScope reports whether the principal may act within scope
+func (p *Principal) HasScope(scope string) bool {
+	if p.IsSession() {
+		return true
+	}
+	for _, granted := range p.Scopes {
+		if granted == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// HasRole reports whether the principal was granted role
+func (p *Principal) HasRole(role string) bool {
+	for _, r := range p.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// RequireRole rejects callers that were not granted role
+func RequireRole(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if p, ok := CurrentPrincipal(c); !ok || !p.HasRole(role) {
+			abort(c, ErrMissingRole)
+			return
+		}
+		c.Next()
+	}
+}
+
+// SetPrincipal stores the principal in the gin context and in the request
+// context, so services that only receive a context.Context can read it too
+func SetPrincipal(c *gin.Context, p *Principal) {
+	c.Set(principalKey, p)
+	if c.Request != nil {
+		c.Request = c.Request.WithContext(NewContext(c.Request.Context(), p))
+	}
+}
+
+// CurrentPrincipal returns the authenticated principal of the request
+func CurrentPrincipal(c *gin.Context) (*Principal, bool) {
+	value, ok := c.Get(principalKey)
+	if !ok {
+		return nil, false
+	}
+	p, ok := value.(*Principal)
+	return p, ok && p != nil
+}
+
+// CurrentUserID returns the ID of the authenticated user of the request
+func CurrentUserID(c *gin.Context) (uint, bool) {
+	p, ok := CurrentPrincipal(c)
+	if !ok {
+		return 0, false
+	}
+	return p.UserID, true
+}
+
+// NewContext returns a copy of ctx carrying the principal
+func NewContext(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalContextKey{}, p)
+}
+
+// FromContext returns the principal stored in ctx by NewContext
+func FromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalContextKey{}).(*Principal)
+	return p, ok && p != nil
+}
@@ -0,0 +1,90 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"quizlet/internal/problem"
+)
+
+func TestPrincipalHasScope(t *testing.T) {
+	session := &Principal{UserID: 1, Method: AuthMethodBearer}
+	token := &Principal{UserID: 1, Scopes: []string{ScopeQuizzesRead}, Method: AuthMethodPersonalAccessToken}
+
+	assert.True(t, session.IsSession())
+	assert.True(t, session.HasScope(ScopeQuizzesWrite))
+	assert.False(t, token.IsSession())
+	assert.True(t, token.HasScope(ScopeQuizzesRead))
+	assert.False(t, token.HasScope(ScopeQuizzesWrite))
+}
+
+func TestRequireRole(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(problem.Middleware())
+	router.GET("/", AuthMiddleware(nil, DefaultTokenConfig()), RequireRole(RoleAdmin), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	testCases := []struct {
+		name           string
+		roles          []string
+		expectedStatus int
+	}{
+		{name: "Admin", roles: []string{RoleAdmin}, expectedStatus: http.StatusOK},
+		{name: "No Roles", expectedStatus: http.StatusForbidden},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			token, err := DefaultTokenConfig().GenerateAccessToken(9, "12", tc.roles...)
+			assert.NoError(t, err)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestPrincipalFromMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var fromGin, fromRequest *Principal
+	router := gin.New()
+	router.GET("/", AuthMiddleware(nil, DefaultTokenConfig()), func(c *gin.Context) {
+		fromGin, _ = CurrentPrincipal(c)
+		fromRequest, _ = FromContext(c.Request.Context())
+		c.Status(http.StatusOK)
+	})
+
+	token, err := DefaultTokenConfig().GenerateAccessToken(9, "12", "admin")
+	assert.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	expected := &Principal{UserID: 9, Roles: []string{"admin"}, SessionID: "12", Method: AuthMethodBearer}
+	assert.Equal(t, expected, fromGin)
+	assert.Equal(t, expected, fromRequest)
+}
+
+func TestCurrentUserIDWithoutPrincipal(t *testing.T) {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	_, ok := CurrentUserID(c)
+	assert.False(t, ok)
+
+	_, ok = FromContext(context.Background())
+	assert.False(t, ok)
+}
@@ -0,0 +1,156 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// AccessTokenCookie and RefreshTokenCookie are httpOnly, so scripts in the
+	// page can never read the tokens
+	AccessTokenCookie  = "quizlet_access"
+	RefreshTokenCookie = "quizlet_refresh"
+
+	// CSRFCookie is readable by the page, which echoes it in CSRFHeader on
+	// every state-changing request (double-submit cookie pattern)
+	CSRFCookie = "quizlet_csrf"
+	CSRFHeader = "X-CSRF-Token"
+
+	// SessionModeHeader lets a browser client ask Login, LoginMFA and
+	// RefreshToken for cookies instead of tokens in the response body
+	SessionModeHeader = "X-Session-Mode"
+	SessionModeCookie = "cookie"
+
+	cookieSessionKey = "cookieSession"
+	csrfTokenSize    = 32
+)
+
+// CookieConfig controls the attributes of the session cookies
+type CookieConfig struct {
+	Domain   string
+	Path     string
+	Secure   bool
+	SameSite http.SameSite
+}
+
+// DefaultCookieConfig returns Secure, SameSite=Lax cookies scoped to the API
+func DefaultCookieConfig() CookieConfig {
+	return CookieConfig{
+		Path:     "/api",
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
+	}
+}
+
+// UseCookieSession marks the request as a cookie session login, for flows
+// such as single sign-on callbacks that cannot send SessionModeHeader
+func UseCookieSession(c *gin.Context) {
+	c.Set(cookieSessionKey, true)
+}
+
+// WantsCookieSession reports whether tokens should be issued as cookies
+func WantsCookieSession(c *gin.Context) bool {
+	if c.GetBool(cookieSessionKey) {
+		return true
+	}
+	return strings.EqualFold(c.GetHeader(SessionModeHeader), SessionModeCookie)
+}
+
+// SetSessionCookies stores the access and refresh tokens in httpOnly cookies
+// that expire with the tokens, and returns the CSRF token the client must
+// echo in CSRFHeader. An existing CSRF token is kept so concurrent requests
+// from other tabs stay valid.
+func (cc CookieConfig) SetSessionCookies(c *gin.Context, tokens TokenConfig, accessToken, refreshToken string) (string, error) {
+	csrfToken, err := c.Cookie(CSRFCookie)
+	if err != nil || csrfToken == "" {
+		if csrfToken, err = generateCSRFToken(); err != nil {
+			return "", err
+		}
+	}
+
+	cc.setCookie(c, AccessTokenCookie, accessToken, cc.Path, tokens.AccessTokenTTL, true)
+	if refreshToken != "" {
+		cc.setCookie(c, RefreshTokenCookie, refreshToken, cc.Path, tokens.RefreshTokenTTL, true)
+	}
+	// The CSRF cookie lives at the root so same-origin pages can read it
+	cc.setCookie(c, CSRFCookie, csrfToken, "/", tokens.RefreshTokenTTL, false)
+	return csrfToken, nil
+}
+
+// ClearSessionCookies expires all session cookies
+func (cc CookieConfig) ClearSessionCookies(c *gin.Context) {
+	cc.setCookie(c, AccessTokenCookie, "", cc.Path, -time.Second, true)
+	cc.setCookie(c, RefreshTokenCookie, "", cc.Path, -time.Second, true)
+	cc.setCookie(c, CSRFCookie, "", "/", -time.Second, false)
+}
+
+// RefreshTokenFromCookie returns the refresh token of a cookie session
+func RefreshTokenFromCookie(c *gin.Context) (string, bool) {
+	token, err := c.Cookie(RefreshTokenCookie)
+	if err != nil || token == "" {
+		return "", false
+	}
+	return token, true
+}
+
+// CSRFMiddleware rejects state-changing requests that rely on session cookies
+// unless CSRFHeader matches the CSRF cookie. Requests authenticated with an
+// Authorization header cannot be forged cross-site and are passed through.
+func CSRFMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+			return
+		}
+		if c.GetHeader("Authorization") != "" || !hasSessionCookie(c) {
+			c.Next()
+			return
+		}
+
+		cookie, err := c.Cookie(CSRFCookie)
+		header := c.GetHeader(CSRFHeader)
+		if err != nil || cookie == "" || header == "" ||
+			subtle.ConstantTimeCompare([]byte(cookie), []byte(header)) != 1 {
+			abort(c, ErrInvalidCSRFToken)
+			return
+		}
+		c.Next()
+	}
+}
+
+func hasSessionCookie(c *gin.Context) bool {
+	for _, name := range []string{AccessTokenCookie, RefreshTokenCookie} {
+		if value, err := c.Cookie(name); err == nil && value != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func (cc CookieConfig) setCookie(c *gin.Context, name, value, path string, ttl time.Duration, httpOnly bool) {
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     path,
+		Domain:   cc.Domain,
+		MaxAge:   int(ttl.Seconds()),
+		Secure:   cc.Secure,
+		HttpOnly: httpOnly,
+		SameSite: cc.SameSite,
+	})
+}
+
+func generateCSRFToken() (string, error) {
+	b := make([]byte, csrfTokenSize)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,98 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"quizlet/internal/problem"
+)
+
+func TestCookieSessionMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(problem.Middleware())
+	router.Use(CSRFMiddleware())
+	router.Use(AuthMiddleware(nil, DefaultTokenConfig()))
+	router.GET("/quizzes", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"user_id": c.GetUint("userID")})
+	})
+	router.POST("/quizzes", func(c *gin.Context) {
+		c.Status(http.StatusCreated)
+	})
+
+	accessToken, err := DefaultTokenConfig().GenerateAccessToken(7, "1")
+	assert.NoError(t, err)
+
+	testCases := []struct {
+		name           string
+		method         string
+		cookies        []*http.Cookie
+		headers        map[string]string
+		expectedStatus int
+	}{
+		{
+			name:           "Cookie Authenticates Safe Request",
+			method:         http.MethodGet,
+			cookies:        []*http.Cookie{{Name: AccessTokenCookie, Value: accessToken}},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Invalid Cookie",
+			method:         http.MethodGet,
+			cookies:        []*http.Cookie{{Name: AccessTokenCookie, Value: "garbage"}},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "Unsafe Request Without CSRF Token",
+			method:         http.MethodPost,
+			cookies:        []*http.Cookie{{Name: AccessTokenCookie, Value: accessToken}, {Name: CSRFCookie, Value: "csrf"}},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "Unsafe Request With Mismatched CSRF Token",
+			method:         http.MethodPost,
+			cookies:        []*http.Cookie{{Name: AccessTokenCookie, Value: accessToken}, {Name: CSRFCookie, Value: "csrf"}},
+			headers:        map[string]string{CSRFHeader: "other"},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "Unsafe Request With CSRF Token",
+			method:         http.MethodPost,
+			cookies:        []*http.Cookie{{Name: AccessTokenCookie, Value: accessToken}, {Name: CSRFCookie, Value: "csrf"}},
+			headers:        map[string]string{CSRFHeader: "csrf"},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "Bearer Token Needs No CSRF Token",
+			method:         http.MethodPost,
+			cookies:        []*http.Cookie{{Name: AccessTokenCookie, Value: accessToken}},
+			headers:        map[string]string{"Authorization": "Bearer " + accessToken},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "No Credentials",
+			method:         http.MethodPost,
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(tc.method, "/quizzes", nil)
+			for _, cookie := range tc.cookies {
+				req.AddCookie(cookie)
+			}
+			for name, value := range tc.headers {
+				req.Header.Set(name, value)
+			}
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+		})
+	}
+}
@@ -0,0 +1,398 @@
+// Package config loads the API configuration from defaults, an optional YAML
+// or TOML file and environment variables, in that order of precedence.
+//
+// Every setting has a file key (the `key` tags joined with dots, e.g.
+// database.host) and most have an environment variable (the `env` tag).
+// Settings tagged `secret` are redacted when the configuration is dumped.
+package config
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"quizlet/internal/auth"
+	"quizlet/internal/auth/oidc"
+	"quizlet/internal/auth/password"
+	"quizlet/internal/ratelimit"
+)
+
+// Database.SchemaCheck modes
+const (
+	SchemaCheckOff  = "off"
+	SchemaCheckWarn = "warn"
+	SchemaCheckFail = "fail"
+)
+
+// Database.Driver values
+const (
+	DatabaseDriverPostgres = "postgres"
+	DatabaseDriverSQLite   = "sqlite"
+)
+
+// Tracing.Exporter values
+const (
+	TracingExporterNone   = "none"
+	TracingExporterStdout = "stdout"
+	TracingExporterOTLP   = "otlp"
+)
+
+// RateLimit.Backend values
+const (
+	RateLimitBackendMemory = "memory"
+	RateLimitBackendRedis  = "redis"
+)
+
+// Config is the complete API configuration
+type Config struct {
+	Server   ServerConfig   `key:"server"`
+	Log      LogConfig      `key:"log"`
+	Tracing  TracingConfig  `key:"tracing"`
+	Database DatabaseConfig `key:"database"`
+	Auth     AuthConfig     `key:"auth"`
+	Session  SessionConfig  `key:"session"`
+	Password PasswordConfig `key:"password"`
+	OIDC     OIDCConfig     `key:"oidc"`
+
+	RateLimit   RateLimitConfig   `key:"rate_limit"`
+	Idempotency IdempotencyConfig `key:"idempotency"`
+	Trash       TrashConfig       `key:"trash"`
+}
+
+// ServerConfig controls the HTTP listener
+type ServerConfig struct {
+	Addr        string   `key:"addr" env:"HTTP_ADDR"`
+	CORSOrigins []string `key:"cors_origins" env:"CORS_ALLOWED_ORIGINS"`
+	// TrustedProxies lists the proxy addresses or CIDR ranges whose
+	// X-Forwarded-For header is believed; by default the client IP is the
+	// connection's peer
+	TrustedProxies []string `key:"trusted_proxies" env:"TRUSTED_PROXIES"`
+
+	ReadTimeout       time.Duration `key:"read_timeout" env:"HTTP_READ_TIMEOUT"`
+	ReadHeaderTimeout time.Duration `key:"read_header_timeout" env:"HTTP_READ_HEADER_TIMEOUT"`
+	WriteTimeout      time.Duration `key:"write_timeout" env:"HTTP_WRITE_TIMEOUT"`
+	IdleTimeout       time.Duration `key:"idle_timeout" env:"HTTP_IDLE_TIMEOUT"`
+	// ShutdownTimeout bounds how long in-flight requests and background
+	// workers get to finish after SIGINT or SIGTERM
+	ShutdownTimeout time.Duration `key:"shutdown_timeout" env:"HTTP_SHUTDOWN_TIMEOUT"`
+	// ShutdownDelay keeps serving with /readyz failing for this long before
+	// draining, so load balancers stop sending new requests first
+	ShutdownDelay time.Duration `key:"shutdown_delay" env:"HTTP_SHUTDOWN_DELAY"`
+}
+
+// LogConfig controls the structured logger
+type LogConfig struct {
+	Format string `key:"format" env:"LOG_FORMAT"`
+	Level  string `key:"level" env:"LOG_LEVEL"`
+}
+
+// TracingConfig controls OpenTelemetry tracing
+type TracingConfig struct {
+	// Exporter is none, stdout to print spans as JSON lines, or otlp to send
+	// them to a collector over OTLP/HTTP
+	Exporter string `key:"exporter" env:"TRACING_EXPORTER"`
+	// Endpoint is the collector URL, e.g. http://localhost:4318; when empty
+	// the OTEL_EXPORTER_OTLP_* variables or the exporter default apply
+	Endpoint    string  `key:"endpoint" env:"OTEL_EXPORTER_OTLP_ENDPOINT"`
+	ServiceName string  `key:"service_name" env:"OTEL_SERVICE_NAME"`
+	SampleRatio float64 `key:"sample_ratio" env:"TRACING_SAMPLE_RATIO"`
+}
+
+// DatabaseConfig holds the database connection settings
+type DatabaseConfig struct {
+	// Driver selects PostgreSQL, or SQLite for a file or in-memory database
+	// that needs no server
+	Driver string `key:"driver" env:"DB_DRIVER"`
+	// Path is the SQLite database file, or ":memory:"
+	Path string `key:"path" env:"DB_PATH"`
+
+	Host     string `key:"host" env:"DB_HOST"`
+	Port     int    `key:"port" env:"DB_PORT"`
+	User     string `key:"user" env:"DB_USER"`
+	Password string `key:"password" env:"DB_PASSWORD" secret:"true"`
+	Name     string `key:"name" env:"DB_NAME"`
+	SSLMode  string `key:"sslmode" env:"DB_SSLMODE"`
+
+	MaxOpenConns    int           `key:"max_open_conns" env:"DB_MAX_OPEN_CONNS"`
+	MaxIdleConns    int           `key:"max_idle_conns" env:"DB_MAX_IDLE_CONNS"`
+	ConnMaxLifetime time.Duration `key:"conn_max_lifetime" env:"DB_CONN_MAX_LIFETIME"`
+	ConnMaxIdleTime time.Duration `key:"conn_max_idle_time" env:"DB_CONN_MAX_IDLE_TIME"`
+	// ConnectTimeout is how long startup keeps retrying an unreachable database
+	ConnectTimeout time.Duration `key:"connect_timeout" env:"DB_CONNECT_TIMEOUT"`
+
+	// AutoMigrate applies pending migrations at startup
+	AutoMigrate bool `key:"auto_migrate" env:"DB_AUTO_MIGRATE"`
+	// SchemaCheck compares the models with the live schema at startup: off,
+	// warn logs the differences, fail also refuses to start when a model
+	// column or table is missing
+	SchemaCheck string `key:"schema_check" env:"DB_SCHEMA_CHECK"`
+}
+
+// AuthConfig holds the JWT signing secret and token lifetimes
+type AuthConfig struct {
+	JWTSecret       string        `key:"jwt_secret" env:"JWT_SECRET" secret:"true"`
+	AccessTokenTTL  time.Duration `key:"access_token_ttl" env:"ACCESS_TOKEN_TTL"`
+	RefreshTokenTTL time.Duration `key:"refresh_token_ttl" env:"REFRESH_TOKEN_TTL"`
+	// RefreshTokenCleanupInterval is how often expired and revoked refresh
+	// tokens are deleted
+	RefreshTokenCleanupInterval time.Duration `key:"refresh_token_cleanup_interval" env:"REFRESH_TOKEN_CLEANUP_INTERVAL"`
+}
+
+// SessionConfig controls the cookies of browser sessions
+type SessionConfig struct {
+	CookieDomain   string `key:"cookie_domain" env:"SESSION_COOKIE_DOMAIN"`
+	CookieSecure   bool   `key:"cookie_secure" env:"SESSION_COOKIE_SECURE"`
+	CookieSameSite string `key:"cookie_samesite" env:"SESSION_COOKIE_SAMESITE"`
+}
+
+// PasswordConfig selects the password hash and the strength policy
+type PasswordConfig struct {
+	Algorithm         string `key:"algorithm" env:"PASSWORD_HASH_ALGORITHM"`
+	Argon2MemoryKiB   uint32 `key:"argon2_memory_kib" env:"PASSWORD_ARGON2_MEMORY_KIB"`
+	Argon2Iterations  uint32 `key:"argon2_iterations" env:"PASSWORD_ARGON2_ITERATIONS"`
+	Argon2Parallelism uint8  `key:"argon2_parallelism" env:"PASSWORD_ARGON2_PARALLELISM"`
+	BcryptCost        int    `key:"bcrypt_cost" env:"PASSWORD_BCRYPT_COST"`
+	MinLength         int    `key:"min_length" env:"PASSWORD_MIN_LENGTH"`
+	MaxLength         int    `key:"max_length" env:"PASSWORD_MAX_LENGTH"`
+	RequireUpper      bool   `key:"require_upper" env:"PASSWORD_REQUIRE_UPPER"`
+	RequireLower      bool   `key:"require_lower" env:"PASSWORD_REQUIRE_LOWER"`
+	RequireDigit      bool   `key:"require_digit" env:"PASSWORD_REQUIRE_DIGIT"`
+	RequireSymbol     bool   `key:"require_symbol" env:"PASSWORD_REQUIRE_SYMBOL"`
+}
+
+// OIDCConfig lists the single sign-on providers. Providers come from the
+// file, or from OIDC_PROVIDERS and OIDC_<NAME>_* variables, which replace
+// the file's list when set.
+type OIDCConfig struct {
+	StateSecret string               `key:"state_secret" env:"OIDC_STATE_SECRET" secret:"true"`
+	Providers   []OIDCProviderConfig `key:"providers"`
+}
+
+// OIDCProviderConfig describes one OpenID Connect identity provider
+type OIDCProviderConfig struct {
+	Name         string   `key:"name"`
+	Issuer       string   `key:"issuer"`
+	ClientID     string   `key:"client_id"`
+	ClientSecret string   `key:"client_secret" secret:"true"`
+	RedirectURL  string   `key:"redirect_url"`
+	Scopes       []string `key:"scopes"`
+	TrustEmail   bool     `key:"trust_email"`
+}
+
+// RateLimitConfig controls request rate limiting. Limits are written as
+// requests/period, e.g. 10/1m, or off.
+type RateLimitConfig struct {
+	// Backend is memory to limit each replica on its own, or redis to share
+	// the limits between replicas through a Redis compatible server
+	Backend  string `key:"backend" env:"RATE_LIMIT_BACKEND"`
+	RedisURL string `key:"redis_url" env:"RATE_LIMIT_REDIS_URL" secret:"true"`
+
+	// Auth limits logins, MFA codes and token refreshes per client IP
+	Auth string `key:"auth" env:"RATE_LIMIT_AUTH"`
+	// Signup limits account creation per client IP
+	Signup string `key:"signup" env:"RATE_LIMIT_SIGNUP"`
+	// Read and Write limit authenticated requests per user
+	Read  string `key:"read" env:"RATE_LIMIT_READ"`
+	Write string `key:"write" env:"RATE_LIMIT_WRITE"`
+}
+
+// IdempotencyConfig controls how long Idempotency-Key responses are kept
+type IdempotencyConfig struct {
+	// KeyTTL is how long a key replays its response; afterwards it can be
+	// used for a new request
+	KeyTTL time.Duration `key:"key_ttl" env:"IDEMPOTENCY_KEY_TTL"`
+	// CleanupInterval is how often expired keys are deleted
+	CleanupInterval time.Duration `key:"cleanup_interval" env:"IDEMPOTENCY_CLEANUP_INTERVAL"`
+}
+
+// TrashConfig controls how long deleted content can be restored
+type TrashConfig struct {
+	// Retention is how long deleted quizzes, quiz suites, attempts and users
+	// stay in the trash before they are purged
+	Retention time.Duration `key:"retention" env:"TRASH_RETENTION"`
+	// PurgeInterval is how often the trash is purged
+	PurgeInterval time.Duration `key:"purge_interval" env:"TRASH_PURGE_INTERVAL"`
+}
+
+// Default returns the configuration used for settings that are not set
+func Default() *Config {
+	hasher := password.DefaultConfig()
+	policy := password.DefaultPolicy()
+
+	return &Config{
+		Server: ServerConfig{
+			Addr:        ":8080",
+			CORSOrigins: []string{"http://localhost:4200", "http://localhost:3000"},
+
+			ReadTimeout:       15 * time.Second,
+			ReadHeaderTimeout: 5 * time.Second,
+			WriteTimeout:      30 * time.Second,
+			IdleTimeout:       2 * time.Minute,
+			ShutdownTimeout:   30 * time.Second,
+		},
+		Log: LogConfig{
+			Format: "json",
+			Level:  "info",
+		},
+		Tracing: TracingConfig{
+			Exporter:    TracingExporterNone,
+			ServiceName: "quizlet-api",
+			SampleRatio: 1,
+		},
+		Database: DatabaseConfig{
+			Driver:  DatabaseDriverPostgres,
+			Path:    "quizlet.db",
+			Port:    5432,
+			SSLMode: "disable",
+
+			MaxOpenConns:    25,
+			MaxIdleConns:    10,
+			ConnMaxLifetime: 30 * time.Minute,
+			ConnMaxIdleTime: 5 * time.Minute,
+			ConnectTimeout:  time.Minute,
+			SchemaCheck:     SchemaCheckWarn,
+		},
+		Auth: AuthConfig{
+			AccessTokenTTL:              15 * time.Minute,
+			RefreshTokenTTL:             30 * 24 * time.Hour,
+			RefreshTokenCleanupInterval: time.Hour,
+		},
+		Session: SessionConfig{
+			CookieSecure:   true,
+			CookieSameSite: "lax",
+		},
+		Password: PasswordConfig{
+			Algorithm:         hasher.Algorithm,
+			Argon2MemoryKiB:   hasher.Argon2.Memory,
+			Argon2Iterations:  hasher.Argon2.Iterations,
+			Argon2Parallelism: hasher.Argon2.Parallelism,
+			BcryptCost:        hasher.BcryptCost,
+			MinLength:         policy.MinLength,
+			MaxLength:         policy.MaxLength,
+		},
+		RateLimit: RateLimitConfig{
+			Backend: RateLimitBackendMemory,
+			Auth:    "10/1m",
+			Signup:  "5/1h",
+			Read:    "300/1m",
+			Write:   "60/1m",
+		},
+		Idempotency: IdempotencyConfig{
+			KeyTTL:          24 * time.Hour,
+			CleanupInterval: time.Hour,
+		},
+		Trash: TrashConfig{
+			Retention:     30 * 24 * time.Hour,
+			PurgeInterval: time.Hour,
+		},
+	}
+}
+
+// DSN returns the PostgreSQL connection string
+func (d DatabaseConfig) DSN() string {
+	return fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%d sslmode=%s",
+		d.Host, d.User, d.Password, d.Name, d.Port, d.SSLMode)
+}
+
+// SQLiteDSN returns the SQLite connection string, with foreign keys enforced
+// as they are in PostgreSQL
+func (d DatabaseConfig) SQLiteDSN() string {
+	return "file:" + d.Path + "?_foreign_keys=on&_busy_timeout=5000"
+}
+
+// TokenConfig converts the settings for auth.AuthMiddleware and the handlers
+// that issue tokens
+func (a AuthConfig) TokenConfig() auth.TokenConfig {
+	return auth.TokenConfig{
+		Secret:          []byte(a.JWTSecret),
+		AccessTokenTTL:  a.AccessTokenTTL,
+		RefreshTokenTTL: a.RefreshTokenTTL,
+	}
+}
+
+// CookieConfig converts the settings for the handlers that start cookie
+// sessions
+func (s SessionConfig) CookieConfig() auth.CookieConfig {
+	config := auth.DefaultCookieConfig()
+	config.Domain = s.CookieDomain
+	config.Secure = s.CookieSecure
+	config.SameSite, _ = parseSameSite(s.CookieSameSite)
+	return config
+}
+
+// HasherConfig converts the settings for password.NewHasher
+func (p PasswordConfig) HasherConfig() password.Config {
+	config := password.DefaultConfig()
+	config.Algorithm = p.Algorithm
+	config.Argon2.Memory = p.Argon2MemoryKiB
+	config.Argon2.Iterations = p.Argon2Iterations
+	config.Argon2.Parallelism = p.Argon2Parallelism
+	config.BcryptCost = p.BcryptCost
+	return config
+}
+
+// Policy converts the settings to a password strength policy
+func (p PasswordConfig) Policy() password.Policy {
+	return password.Policy{
+		MinLength:     p.MinLength,
+		MaxLength:     p.MaxLength,
+		RequireUpper:  p.RequireUpper,
+		RequireLower:  p.RequireLower,
+		RequireDigit:  p.RequireDigit,
+		RequireSymbol: p.RequireSymbol,
+	}
+}
+
+// ProviderConfigs converts the settings for oidc.NewProviders
+func (o OIDCConfig) ProviderConfigs() []oidc.ProviderConfig {
+	configs := make([]oidc.ProviderConfig, 0, len(o.Providers))
+	for _, p := range o.Providers {
+		configs = append(configs, oidc.ProviderConfig{
+			Name:         p.Name,
+			Issuer:       p.Issuer,
+			ClientID:     p.ClientID,
+			ClientSecret: p.ClientSecret,
+			RedirectURL:  p.RedirectURL,
+			Scopes:       p.Scopes,
+			TrustEmail:   p.TrustEmail,
+		})
+	}
+	return configs
+}
+
+// RateLimitPolicies are the rate limit policies applied to the routes
+type RateLimitPolicies struct {
+	Auth   ratelimit.Policy
+	Signup ratelimit.Policy
+	Read   ratelimit.Policy
+	Write  ratelimit.Policy
+}
+
+// Policies converts the limits to policies; auth and signup are counted per
+// client IP, reads and writes per user
+func (r RateLimitConfig) Policies() RateLimitPolicies {
+	policy := func(name, value string, key ratelimit.KeyFunc) ratelimit.Policy {
+		limit, _ := ratelimit.ParseLimit(value)
+		return ratelimit.Policy{Name: name, Limit: limit, Key: key}
+	}
+	return RateLimitPolicies{
+		Auth:   policy("auth", r.Auth, ratelimit.ByIP),
+		Signup: policy("signup", r.Signup, ratelimit.ByIP),
+		Read:   policy("read", r.Read, ratelimit.ByUser),
+		Write:  policy("write", r.Write, ratelimit.ByUser),
+	}
+}
+
+func parseSameSite(value string) (http.SameSite, bool) {
+	switch strings.ToLower(value) {
+	case "lax":
+		return http.SameSiteLaxMode, true
+	case "strict":
+		return http.SameSiteStrictMode, true
+	case "none":
+		return http.SameSiteNoneMode, true
+	default:
+		return http.SameSiteLaxMode, false
+	}
+}
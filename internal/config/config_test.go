@@ -0,0 +1,326 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"quizlet/internal/ratelimit"
+)
+
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+func envFrom(values map[string]string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		value, ok := values[name]
+		return value, ok
+	}
+}
+
+func requiredEnv() map[string]string {
+	return map[string]string{
+		"DB_HOST":    "localhost",
+		"DB_USER":    "quizlet",
+		"DB_NAME":    "quizlet",
+		"JWT_SECRET": testSecret,
+	}
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoadDefaultsAndEnv(t *testing.T) {
+	env := requiredEnv()
+	env["HTTP_ADDR"] = ":9090"
+	env["CORS_ALLOWED_ORIGINS"] = "https://app.example.com, https://admin.example.com"
+	env["ACCESS_TOKEN_TTL"] = "5m"
+	env["PASSWORD_REQUIRE_DIGIT"] = "true"
+	env["PASSWORD_ARGON2_PARALLELISM"] = "4"
+
+	cfg, err := load("", envFrom(env))
+	require.NoError(t, err)
+
+	assert.Equal(t, ":9090", cfg.Server.Addr)
+	assert.Equal(t, []string{"https://app.example.com", "https://admin.example.com"}, cfg.Server.CORSOrigins)
+	assert.Equal(t, 5*time.Minute, cfg.Auth.AccessTokenTTL)
+	assert.Equal(t, 30*24*time.Hour, cfg.Auth.RefreshTokenTTL)
+	assert.Equal(t, 5432, cfg.Database.Port)
+	assert.True(t, cfg.Password.RequireDigit)
+	assert.Equal(t, uint8(4), cfg.Password.Argon2Parallelism)
+	assert.Equal(t, "host=localhost user=quizlet password= dbname=quizlet port=5432 sslmode=disable", cfg.Database.DSN())
+}
+
+func TestLoadServerAndPoolSettings(t *testing.T) {
+	env := requiredEnv()
+	env["HTTP_WRITE_TIMEOUT"] = "1m"
+	env["HTTP_SHUTDOWN_TIMEOUT"] = "10s"
+	env["DB_MAX_OPEN_CONNS"] = "50"
+	env["DB_CONN_MAX_LIFETIME"] = "1h"
+
+	cfg, err := load("", envFrom(env))
+	require.NoError(t, err)
+
+	assert.Equal(t, time.Minute, cfg.Server.WriteTimeout)
+	assert.Equal(t, 10*time.Second, cfg.Server.ShutdownTimeout)
+	assert.Equal(t, 15*time.Second, cfg.Server.ReadTimeout)
+	assert.Equal(t, 50, cfg.Database.MaxOpenConns)
+	assert.Equal(t, 10, cfg.Database.MaxIdleConns)
+	assert.Equal(t, time.Hour, cfg.Database.ConnMaxLifetime)
+
+	env["DB_MAX_IDLE_CONNS"] = "60"
+	env["HTTP_IDLE_TIMEOUT"] = "0s"
+	_, err = load("", envFrom(env))
+	var validationErr *ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, []string{
+		"server.idle_timeout (HTTP_IDLE_TIMEOUT) must be positive",
+		"database.max_idle_conns (DB_MAX_IDLE_CONNS) must be between 0 and database.max_open_conns",
+	}, validationErr.Problems)
+}
+
+func TestLoadTracingSettings(t *testing.T) {
+	env := requiredEnv()
+	env["TRACING_EXPORTER"] = "otlp"
+	env["OTEL_EXPORTER_OTLP_ENDPOINT"] = "http://collector:4318"
+	env["TRACING_SAMPLE_RATIO"] = "0.25"
+
+	cfg, err := load("", envFrom(env))
+	require.NoError(t, err)
+
+	assert.Equal(t, TracingExporterOTLP, cfg.Tracing.Exporter)
+	assert.Equal(t, "http://collector:4318", cfg.Tracing.Endpoint)
+	assert.Equal(t, "quizlet-api", cfg.Tracing.ServiceName)
+	assert.Equal(t, 0.25, cfg.Tracing.SampleRatio)
+
+	env["TRACING_EXPORTER"] = "jaeger"
+	env["TRACING_SAMPLE_RATIO"] = "2"
+	_, err = load("", envFrom(env))
+	var validationErr *ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, []string{
+		"tracing.exporter (TRACING_EXPORTER) must be none, stdout or otlp",
+		"tracing.sample_ratio (TRACING_SAMPLE_RATIO) must be between 0 and 1",
+	}, validationErr.Problems)
+}
+
+func TestLoadSQLiteSettings(t *testing.T) {
+	// The PostgreSQL settings are not required for SQLite
+	cfg, err := load("", envFrom(map[string]string{
+		"DB_DRIVER":  "sqlite",
+		"DB_PATH":    ":memory:",
+		"JWT_SECRET": testSecret,
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, DatabaseDriverSQLite, cfg.Database.Driver)
+	assert.Equal(t, "file::memory:?_foreign_keys=on&_busy_timeout=5000", cfg.Database.SQLiteDSN())
+
+	_, err = load("", envFrom(map[string]string{
+		"DB_DRIVER":  "mysql",
+		"JWT_SECRET": testSecret,
+	}))
+	var validationErr *ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, []string{"database.driver (DB_DRIVER) must be postgres or sqlite"}, validationErr.Problems)
+}
+
+func TestLoadRateLimitSettings(t *testing.T) {
+	env := requiredEnv()
+	env["RATE_LIMIT_BACKEND"] = "redis"
+	env["RATE_LIMIT_REDIS_URL"] = "redis://:secret@cache:6379/2"
+	env["RATE_LIMIT_AUTH"] = "3/s"
+	env["RATE_LIMIT_READ"] = "off"
+
+	cfg, err := load("", envFrom(env))
+	require.NoError(t, err)
+
+	policies := cfg.RateLimit.Policies()
+	assert.Equal(t, ratelimit.Limit{Burst: 3, Period: time.Second}, policies.Auth.Limit)
+	assert.Equal(t, ratelimit.Limit{Burst: 5, Period: time.Hour}, policies.Signup.Limit)
+	assert.False(t, policies.Read.Limit.Enabled())
+	assert.Equal(t, "write", policies.Write.Name)
+
+	env["RATE_LIMIT_BACKEND"] = "memcached"
+	env["RATE_LIMIT_WRITE"] = "60 per minute"
+	_, err = load("", envFrom(env))
+	var validationErr *ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, []string{
+		"rate_limit.backend (RATE_LIMIT_BACKEND) must be memory or redis",
+		"rate_limit.write (RATE_LIMIT_WRITE) must be requests/period such as 10/1m, or off",
+	}, validationErr.Problems)
+}
+
+func TestLoadFile(t *testing.T) {
+	testCases := []struct {
+		name    string
+		file    string
+		content string
+	}{
+		{
+			name: "YAML",
+			file: "config.yaml",
+			content: `
+server:
+  addr: ":7070"
+database:
+  host: db.internal
+  port: 6432
+auth:
+  refresh_token_ttl: 168h
+oidc:
+  providers:
+    - name: google
+      issuer: https://accounts.google.com
+      client_id: client
+      redirect_url: https://api.example.com/api/auth/oidc/google/callback
+      scopes: [openid, email]
+`,
+		},
+		{
+			name: "TOML",
+			file: "config.toml",
+			content: `
+[server]
+addr = ":7070"
+
+[database]
+host = "db.internal"
+port = 6432
+
+[auth]
+refresh_token_ttl = "168h"
+
+[[oidc.providers]]
+name = "google"
+issuer = "https://accounts.google.com"
+client_id = "client"
+redirect_url = "https://api.example.com/api/auth/oidc/google/callback"
+scopes = ["openid", "email"]
+`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			env := requiredEnv()
+			// The environment wins over the file
+			env["DB_HOST"] = "db.override"
+
+			cfg, err := load(writeFile(t, tc.file, tc.content), envFrom(env))
+			require.NoError(t, err)
+
+			assert.Equal(t, ":7070", cfg.Server.Addr)
+			assert.Equal(t, "db.override", cfg.Database.Host)
+			assert.Equal(t, 6432, cfg.Database.Port)
+			assert.Equal(t, 168*time.Hour, cfg.Auth.RefreshTokenTTL)
+			require.Len(t, cfg.OIDC.Providers, 1)
+			assert.Equal(t, "google", cfg.OIDC.Providers[0].Name)
+			assert.Equal(t, []string{"openid", "email"}, cfg.OIDC.Providers[0].Scopes)
+		})
+	}
+}
+
+func TestLoadRejectsBadInput(t *testing.T) {
+	testCases := []struct {
+		name          string
+		file          string
+		content       string
+		env           map[string]string
+		expectedError string
+	}{
+		{
+			name:          "Unknown Key",
+			file:          "config.yaml",
+			content:       "server:\n  adr: \":80\"\n",
+			expectedError: "unknown keys server.adr",
+		},
+		{
+			name:          "Unsupported Format",
+			file:          "config.json",
+			content:       "{}",
+			expectedError: "unsupported format",
+		},
+		{
+			name:          "Invalid Duration",
+			env:           map[string]string{"ACCESS_TOKEN_TTL": "15"},
+			expectedError: `ACCESS_TOKEN_TTL: invalid duration "15"`,
+		},
+		{
+			name:          "Invalid Integer",
+			env:           map[string]string{"DB_PORT": "postgres"},
+			expectedError: `DB_PORT: invalid integer "postgres"`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			env := requiredEnv()
+			for name, value := range tc.env {
+				env[name] = value
+			}
+			path := ""
+			if tc.file != "" {
+				path = writeFile(t, tc.file, tc.content)
+			}
+
+			_, err := load(path, envFrom(env))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.expectedError)
+		})
+	}
+}
+
+func TestValidateReportsAllProblems(t *testing.T) {
+	_, err := load("", envFrom(map[string]string{
+		"JWT_SECRET":              "short",
+		"SESSION_COOKIE_SAMESITE": "none",
+		"SESSION_COOKIE_SECURE":   "false",
+		"CORS_ALLOWED_ORIGINS":    "*",
+		"OIDC_PROVIDERS":          "okta",
+	}))
+
+	var validationErr *ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, []string{
+		"server.cors_origins (CORS_ALLOWED_ORIGINS) cannot contain * because credentials are allowed",
+		"database.host (DB_HOST) is required",
+		"database.user (DB_USER) is required",
+		"database.name (DB_NAME) is required",
+		"auth.jwt_secret (JWT_SECRET) must be at least 32 bytes",
+		"session.cookie_samesite=none requires session.cookie_secure (SESSION_COOKIE_SECURE)",
+		`oidc provider "okta" requires issuer, client_id and redirect_url (OIDC_OKTA_ISSUER, OIDC_OKTA_CLIENT_ID, OIDC_OKTA_REDIRECT_URL)`,
+	}, validationErr.Problems)
+}
+
+func TestDumpRedactsSecrets(t *testing.T) {
+	env := requiredEnv()
+	env["DB_PASSWORD"] = "hunter2"
+	env["OIDC_PROVIDERS"] = "google"
+	env["OIDC_GOOGLE_ISSUER"] = "https://accounts.google.com"
+	env["OIDC_GOOGLE_CLIENT_ID"] = "client"
+	env["OIDC_GOOGLE_CLIENT_SECRET"] = "google-secret"
+	env["OIDC_GOOGLE_REDIRECT_URL"] = "https://api.example.com/callback"
+
+	cfg, err := load("", envFrom(env))
+	require.NoError(t, err)
+
+	dump, err := cfg.Dump()
+	require.NoError(t, err)
+
+	out := string(dump)
+	for _, secret := range []string{testSecret, "hunter2", "google-secret"} {
+		assert.NotContains(t, out, secret)
+	}
+	assert.Contains(t, out, "password: '[redacted]'")
+	assert.Contains(t, out, "client_secret: '[redacted]'")
+	assert.Contains(t, out, "state_secret: \"\"")
+	assert.Contains(t, out, "access_token_ttl: 15m0s")
+	assert.Contains(t, out, "client_id: client")
+}
@@ -0,0 +1,64 @@
+package config
+
+import (
+	"bytes"
+	"reflect"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const redacted = "[redacted]"
+
+// Redacted returns the configuration as nested maps keyed like the config
+// file, with every secret that is set replaced by a placeholder
+func (c *Config) Redacted() map[string]any {
+	return redactStruct(reflect.ValueOf(c).Elem())
+}
+
+// Dump renders the redacted configuration as YAML, in the same layout the
+// config file uses
+func (c *Config) Dump() ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(c.Redacted()); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func redactStruct(v reflect.Value) map[string]any {
+	t := v.Type()
+	out := make(map[string]any, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		fv := v.Field(i)
+		key := field.Tag.Get("key")
+
+		switch {
+		case field.Tag.Get("secret") == "true":
+			if fv.IsZero() {
+				out[key] = ""
+			} else {
+				out[key] = redacted
+			}
+		case fv.Kind() == reflect.Struct:
+			out[key] = redactStruct(fv)
+		case fv.Kind() == reflect.Slice && fv.Type().Elem().Kind() == reflect.Struct:
+			items := make([]any, fv.Len())
+			for j := range items {
+				items[j] = redactStruct(fv.Index(j))
+			}
+			out[key] = items
+		case fv.Type() == durationType:
+			out[key] = time.Duration(fv.Int()).String()
+		default:
+			out[key] = fv.Interface()
+		}
+	}
+	return out
+}
@@ -0,0 +1,274 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// FileEnv names the environment variable holding the config file path
+const FileEnv = "CONFIG_FILE"
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// Load builds the configuration from the defaults, the optional file at path
+// (.yaml, .yml or .toml) and the process environment, then validates it
+func Load(path string) (*Config, error) {
+	return load(path, os.LookupEnv)
+}
+
+func load(path string, lookupEnv func(string) (string, bool)) (*Config, error) {
+	config := Default()
+
+	if path != "" {
+		values, err := readFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if err := applyValues(reflect.ValueOf(config).Elem(), values, ""); err != nil {
+			return nil, fmt.Errorf("config file %s: %w", path, err)
+		}
+	}
+
+	if err := applyEnv(reflect.ValueOf(config).Elem(), lookupEnv); err != nil {
+		return nil, err
+	}
+	if err := applyOIDCEnv(&config.OIDC, lookupEnv); err != nil {
+		return nil, err
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+func readFile(path string) (map[string]any, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading config file: %w", err)
+	}
+
+	values := make(map[string]any)
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &values)
+	case ".toml":
+		err = toml.Unmarshal(data, &values)
+	default:
+		return nil, fmt.Errorf("config file %s: unsupported format, use .yaml, .yml or .toml", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parsing config file %s: %w", path, err)
+	}
+	return values, nil
+}
+
+// applyValues copies the decoded file values onto the struct fields matching
+// their key tags. Unknown keys are rejected so typos do not go unnoticed.
+func applyValues(v reflect.Value, values map[string]any, prefix string) error {
+	t := v.Type()
+	known := make(map[string]bool, t.NumField())
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		key := field.Tag.Get("key")
+		known[key] = true
+
+		raw, ok := values[key]
+		if !ok || raw == nil {
+			continue
+		}
+
+		path := prefix + key
+		fv := v.Field(i)
+		switch {
+		case fv.Kind() == reflect.Struct:
+			nested, ok := raw.(map[string]any)
+			if !ok {
+				return fmt.Errorf("%s must be a table", path)
+			}
+			if err := applyValues(fv, nested, path+"."); err != nil {
+				return err
+			}
+		case fv.Kind() == reflect.Slice && fv.Type().Elem().Kind() == reflect.Struct:
+			items, ok := raw.([]any)
+			if !ok {
+				return fmt.Errorf("%s must be a list", path)
+			}
+			slice := reflect.MakeSlice(fv.Type(), len(items), len(items))
+			for j, item := range items {
+				nested, ok := item.(map[string]any)
+				if !ok {
+					return fmt.Errorf("%s[%d] must be a table", path, j)
+				}
+				if err := applyValues(slice.Index(j), nested, fmt.Sprintf("%s[%d].", path, j)); err != nil {
+					return err
+				}
+			}
+			fv.Set(slice)
+		default:
+			if err := setValue(fv, raw); err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+		}
+	}
+
+	var unknown []string
+	for key := range values {
+		if !known[key] {
+			unknown = append(unknown, prefix+key)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf("unknown keys %s", strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+// applyEnv overrides fields that have an env tag with the variable's value
+func applyEnv(v reflect.Value, lookupEnv func(string) (string, bool)) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		fv := v.Field(i)
+		if fv.Kind() == reflect.Struct {
+			if err := applyEnv(fv, lookupEnv); err != nil {
+				return err
+			}
+			continue
+		}
+
+		name := t.Field(i).Tag.Get("env")
+		if name == "" {
+			continue
+		}
+		raw, ok := lookupEnv(name)
+		if !ok || raw == "" {
+			continue
+		}
+		if err := setValue(fv, raw); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// applyOIDCEnv reads OIDC_PROVIDERS, a comma separated list of names, each
+// configured with OIDC_<NAME>_ISSUER, OIDC_<NAME>_CLIENT_ID,
+// OIDC_<NAME>_CLIENT_SECRET, OIDC_<NAME>_REDIRECT_URL, and optionally
+// OIDC_<NAME>_SCOPES and OIDC_<NAME>_TRUST_EMAIL
+func applyOIDCEnv(config *OIDCConfig, lookupEnv func(string) (string, bool)) error {
+	names, ok := lookupEnv("OIDC_PROVIDERS")
+	if !ok || strings.TrimSpace(names) == "" {
+		return nil
+	}
+
+	env := func(name string) string {
+		value, _ := lookupEnv(name)
+		return value
+	}
+
+	config.Providers = nil
+	for _, name := range strings.Split(names, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		prefix := "OIDC_" + strings.ToUpper(name) + "_"
+		provider := OIDCProviderConfig{
+			Name:         name,
+			Issuer:       env(prefix + "ISSUER"),
+			ClientID:     env(prefix + "CLIENT_ID"),
+			ClientSecret: env(prefix + "CLIENT_SECRET"),
+			RedirectURL:  env(prefix + "REDIRECT_URL"),
+		}
+		if scopes := env(prefix + "SCOPES"); scopes != "" {
+			provider.Scopes = strings.Fields(strings.ReplaceAll(scopes, ",", " "))
+		}
+		if trust := env(prefix + "TRUST_EMAIL"); trust != "" {
+			value, err := strconv.ParseBool(trust)
+			if err != nil {
+				return fmt.Errorf("%sTRUST_EMAIL: invalid boolean %q", prefix, trust)
+			}
+			provider.TrustEmail = value
+		}
+		config.Providers = append(config.Providers, provider)
+	}
+	return nil
+}
+
+// setValue converts a file or environment value to the field's type
+func setValue(fv reflect.Value, raw any) error {
+	if fv.Kind() == reflect.Slice {
+		var items []string
+		switch value := raw.(type) {
+		case []any:
+			for _, item := range value {
+				items = append(items, fmt.Sprint(item))
+			}
+		case string:
+			for _, item := range strings.Split(value, ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					items = append(items, item)
+				}
+			}
+		default:
+			return fmt.Errorf("expected a list, got %v", raw)
+		}
+		fv.Set(reflect.ValueOf(items))
+		return nil
+	}
+
+	text := strings.TrimSpace(fmt.Sprint(raw))
+
+	if fv.Type() == durationType {
+		d, err := time.ParseDuration(text)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q, use a value such as 15m or 720h", text)
+		}
+		fv.SetInt(int64(d))
+		return nil
+	}
+
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(text)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(text)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", text)
+		}
+		fv.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(text, 10, fv.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", text)
+		}
+		fv.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(text, 10, fv.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid non-negative integer %q", text)
+		}
+		fv.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(text, fv.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid number %q", text)
+		}
+		fv.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported setting type %s", fv.Type())
+	}
+	return nil
+}
@@ -0,0 +1,240 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"golang.org/x/crypto/bcrypt"
+	"quizlet/internal/auth/password"
+	"quizlet/internal/ratelimit"
+)
+
+// minJWTSecretLength is the HS256 key size recommended by RFC 7518
+const minJWTSecretLength = 32
+
+// ValidationError lists every problem found in the configuration
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid configuration:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+// Validate checks the configuration and reports all problems at once
+func (c *Config) Validate() error {
+	var problems []string
+	add := func(format string, args ...any) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if c.Server.Addr == "" {
+		add("server.addr (HTTP_ADDR) is required")
+	}
+	for _, origin := range c.Server.CORSOrigins {
+		if origin == "*" {
+			add("server.cors_origins (CORS_ALLOWED_ORIGINS) cannot contain * because credentials are allowed")
+			continue
+		}
+		if u, err := url.Parse(origin); err != nil || u.Scheme == "" || u.Host == "" {
+			add("server.cors_origins (CORS_ALLOWED_ORIGINS) entry %q must be an origin such as https://app.example.com", origin)
+		}
+	}
+
+	for _, proxy := range c.Server.TrustedProxies {
+		if net.ParseIP(proxy) == nil {
+			if _, _, err := net.ParseCIDR(proxy); err != nil {
+				add("server.trusted_proxies (TRUSTED_PROXIES) entry %q must be an IP address or CIDR range", proxy)
+			}
+		}
+	}
+
+	for _, timeout := range []struct {
+		name  string
+		value time.Duration
+	}{
+		{"server.read_timeout (HTTP_READ_TIMEOUT)", c.Server.ReadTimeout},
+		{"server.read_header_timeout (HTTP_READ_HEADER_TIMEOUT)", c.Server.ReadHeaderTimeout},
+		{"server.write_timeout (HTTP_WRITE_TIMEOUT)", c.Server.WriteTimeout},
+		{"server.idle_timeout (HTTP_IDLE_TIMEOUT)", c.Server.IdleTimeout},
+		{"server.shutdown_timeout (HTTP_SHUTDOWN_TIMEOUT)", c.Server.ShutdownTimeout},
+	} {
+		if timeout.value <= 0 {
+			add("%s must be positive", timeout.name)
+		}
+	}
+	if c.Server.ShutdownDelay < 0 {
+		add("server.shutdown_delay (HTTP_SHUTDOWN_DELAY) cannot be negative")
+	}
+
+	switch c.Log.Format {
+	case "json", "text":
+	default:
+		add("log.format (LOG_FORMAT) must be json or text")
+	}
+	switch strings.ToLower(c.Log.Level) {
+	case "debug", "info", "warn", "error":
+	default:
+		add("log.level (LOG_LEVEL) must be debug, info, warn or error")
+	}
+
+	switch c.Tracing.Exporter {
+	case TracingExporterNone, TracingExporterStdout, TracingExporterOTLP:
+	default:
+		add("tracing.exporter (TRACING_EXPORTER) must be %s, %s or %s", TracingExporterNone, TracingExporterStdout, TracingExporterOTLP)
+	}
+	if c.Tracing.Exporter != TracingExporterNone && c.Tracing.ServiceName == "" {
+		add("tracing.service_name (OTEL_SERVICE_NAME) is required when tracing is enabled")
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		add("tracing.sample_ratio (TRACING_SAMPLE_RATIO) must be between 0 and 1")
+	}
+	if c.Tracing.Endpoint != "" {
+		if u, err := url.Parse(c.Tracing.Endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			add("tracing.endpoint (OTEL_EXPORTER_OTLP_ENDPOINT) must be an http or https URL")
+		}
+	}
+
+	switch c.Database.Driver {
+	case DatabaseDriverPostgres:
+		if c.Database.Host == "" {
+			add("database.host (DB_HOST) is required")
+		}
+		if c.Database.Port <= 0 || c.Database.Port > 65535 {
+			add("database.port (DB_PORT) must be between 1 and 65535")
+		}
+		if c.Database.User == "" {
+			add("database.user (DB_USER) is required")
+		}
+		if c.Database.Name == "" {
+			add("database.name (DB_NAME) is required")
+		}
+	case DatabaseDriverSQLite:
+		if c.Database.Path == "" {
+			add("database.path (DB_PATH) is required for sqlite")
+		}
+	default:
+		add("database.driver (DB_DRIVER) must be %s or %s", DatabaseDriverPostgres, DatabaseDriverSQLite)
+	}
+
+	if c.Database.MaxOpenConns < 1 {
+		add("database.max_open_conns (DB_MAX_OPEN_CONNS) must be at least 1")
+	}
+	if c.Database.MaxIdleConns < 0 || c.Database.MaxIdleConns > c.Database.MaxOpenConns {
+		add("database.max_idle_conns (DB_MAX_IDLE_CONNS) must be between 0 and database.max_open_conns")
+	}
+	if c.Database.ConnMaxLifetime < 0 || c.Database.ConnMaxIdleTime < 0 {
+		add("database.conn_max_lifetime and conn_max_idle_time cannot be negative")
+	}
+	if c.Database.ConnectTimeout <= 0 {
+		add("database.connect_timeout (DB_CONNECT_TIMEOUT) must be positive")
+	}
+	switch c.Database.SchemaCheck {
+	case SchemaCheckOff, SchemaCheckWarn, SchemaCheckFail:
+	default:
+		add("database.schema_check (DB_SCHEMA_CHECK) must be %s, %s or %s", SchemaCheckOff, SchemaCheckWarn, SchemaCheckFail)
+	}
+
+	if len(c.Auth.JWTSecret) < minJWTSecretLength {
+		add("auth.jwt_secret (JWT_SECRET) must be at least %d bytes", minJWTSecretLength)
+	}
+	if c.Auth.AccessTokenTTL <= 0 {
+		add("auth.access_token_ttl (ACCESS_TOKEN_TTL) must be positive")
+	}
+	if c.Auth.RefreshTokenTTL <= c.Auth.AccessTokenTTL {
+		add("auth.refresh_token_ttl (REFRESH_TOKEN_TTL) must be longer than auth.access_token_ttl")
+	}
+	if c.Auth.RefreshTokenCleanupInterval <= 0 {
+		add("auth.refresh_token_cleanup_interval (REFRESH_TOKEN_CLEANUP_INTERVAL) must be positive")
+	}
+
+	sameSite, ok := parseSameSite(c.Session.CookieSameSite)
+	if !ok {
+		add("session.cookie_samesite (SESSION_COOKIE_SAMESITE) must be lax, strict or none")
+	} else if sameSite == http.SameSiteNoneMode && !c.Session.CookieSecure {
+		add("session.cookie_samesite=none requires session.cookie_secure (SESSION_COOKIE_SECURE)")
+	}
+
+	switch c.Password.Algorithm {
+	case password.AlgorithmArgon2id:
+		if c.Password.Argon2MemoryKiB == 0 || c.Password.Argon2Iterations == 0 || c.Password.Argon2Parallelism == 0 {
+			add("password.argon2_memory_kib, argon2_iterations and argon2_parallelism must be positive")
+		}
+	case password.AlgorithmBcrypt:
+		if c.Password.BcryptCost < bcrypt.MinCost || c.Password.BcryptCost > bcrypt.MaxCost {
+			add("password.bcrypt_cost (PASSWORD_BCRYPT_COST) must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+		}
+	default:
+		add("password.algorithm (PASSWORD_HASH_ALGORITHM) must be %s or %s", password.AlgorithmArgon2id, password.AlgorithmBcrypt)
+	}
+	if c.Password.MinLength < 1 {
+		add("password.min_length (PASSWORD_MIN_LENGTH) must be at least 1")
+	}
+	if c.Password.MaxLength > 0 && c.Password.MinLength > c.Password.MaxLength {
+		add("password.min_length (PASSWORD_MIN_LENGTH) exceeds password.max_length (PASSWORD_MAX_LENGTH)")
+	}
+
+	seen := make(map[string]bool)
+	for i, p := range c.OIDC.Providers {
+		name := p.Name
+		if name == "" {
+			add("oidc.providers[%d].name is required", i)
+			name = fmt.Sprintf("#%d", i)
+		} else if seen[name] {
+			add("oidc provider %q is configured twice", name)
+		}
+		seen[name] = true
+
+		if p.Issuer == "" || p.ClientID == "" || p.RedirectURL == "" {
+			env := "OIDC_" + strings.ToUpper(name) + "_"
+			add("oidc provider %q requires issuer, client_id and redirect_url (%sISSUER, %sCLIENT_ID, %sREDIRECT_URL)", name, env, env, env)
+		}
+	}
+
+	switch c.RateLimit.Backend {
+	case RateLimitBackendMemory:
+	case RateLimitBackendRedis:
+		if _, err := redis.ParseURL(c.RateLimit.RedisURL); err != nil {
+			add("rate_limit.redis_url (RATE_LIMIT_REDIS_URL) must be a redis:// or rediss:// URL when rate_limit.backend is redis")
+		}
+	default:
+		add("rate_limit.backend (RATE_LIMIT_BACKEND) must be %s or %s", RateLimitBackendMemory, RateLimitBackendRedis)
+	}
+	for _, limit := range []struct {
+		name  string
+		value string
+	}{
+		{"rate_limit.auth (RATE_LIMIT_AUTH)", c.RateLimit.Auth},
+		{"rate_limit.signup (RATE_LIMIT_SIGNUP)", c.RateLimit.Signup},
+		{"rate_limit.read (RATE_LIMIT_READ)", c.RateLimit.Read},
+		{"rate_limit.write (RATE_LIMIT_WRITE)", c.RateLimit.Write},
+	} {
+		if _, err := ratelimit.ParseLimit(limit.value); err != nil {
+			add("%s must be requests/period such as 10/1m, or off", limit.name)
+		}
+	}
+
+	if c.Idempotency.KeyTTL <= 0 {
+		add("idempotency.key_ttl (IDEMPOTENCY_KEY_TTL) must be positive")
+	}
+	if c.Idempotency.CleanupInterval <= 0 {
+		add("idempotency.cleanup_interval (IDEMPOTENCY_CLEANUP_INTERVAL) must be positive")
+	}
+
+	if c.Trash.Retention <= 0 {
+		add("trash.retention (TRASH_RETENTION) must be positive")
+	}
+	if c.Trash.PurgeInterval <= 0 {
+		add("trash.purge_interval (TRASH_PURGE_INTERVAL) must be positive")
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
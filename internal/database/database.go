@@ -0,0 +1,114 @@
+// Package database opens the connection pool used by the API, to
+// PostgreSQL or to a SQLite file or in-memory database.
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"quizlet/internal/config"
+)
+
+const (
+	initialBackoff = 500 * time.Millisecond
+	maxBackoff     = 10 * time.Second
+)
+
+// Open connects to the database, retrying with exponential backoff for up to
+// cfg.ConnectTimeout so the API can start before PostgreSQL is ready, and
+// applies the connection pool settings. SQLite always gets a single
+// connection that is kept open, see configureSQLite.
+func Open(ctx context.Context, cfg config.DatabaseConfig) (*gorm.DB, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ConnectTimeout)
+	defer cancel()
+
+	db, err := connectWithRetry(ctx, func() (*gorm.DB, error) {
+		db, err := gorm.Open(dialector(cfg), &gorm.Config{Logger: newGormLogger(slog.Default())})
+		if err != nil {
+			return nil, err
+		}
+		sqlDB, err := db.DB()
+		if err != nil {
+			return nil, err
+		}
+		if err := sqlDB.PingContext(ctx); err != nil {
+			sqlDB.Close()
+			return nil, err
+		}
+		return db, nil
+	}, initialBackoff, maxBackoff)
+	if err != nil {
+		return nil, err
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Driver == config.DatabaseDriverSQLite {
+		configureSQLite(sqlDB)
+		return db, nil
+	}
+	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+	return db, nil
+}
+
+func dialector(cfg config.DatabaseConfig) gorm.Dialector {
+	if cfg.Driver == config.DatabaseDriverSQLite {
+		return sqlite.Open(cfg.SQLiteDSN())
+	}
+	return postgres.Open(cfg.DSN())
+}
+
+// configureSQLite limits the pool to one connection that never expires.
+// SQLite allows a single writer anyway, and an in-memory database lives
+// only as long as the connection that created it.
+func configureSQLite(sqlDB *sql.DB) {
+	sqlDB.SetMaxOpenConns(1)
+	sqlDB.SetMaxIdleConns(1)
+	sqlDB.SetConnMaxLifetime(0)
+	sqlDB.SetConnMaxIdleTime(0)
+}
+
+// Close releases the connection pool
+func Close(db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
+
+// connectWithRetry calls open until it succeeds or ctx is done, doubling the
+// wait between attempts up to max
+func connectWithRetry(ctx context.Context, open func() (*gorm.DB, error), backoff, max time.Duration) (*gorm.DB, error) {
+	for attempt := 1; ; attempt++ {
+		db, err := open()
+		if err == nil {
+			return db, nil
+		}
+		slog.Warn("database connection failed, retrying", "attempt", attempt, "backoff", backoff.String(), "error", err)
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, fmt.Errorf("connecting to database after %d attempts: %w", attempt, err)
+		case <-timer.C:
+		}
+
+		backoff *= 2
+		if backoff > max {
+			backoff = max
+		}
+	}
+}
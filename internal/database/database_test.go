@@ -0,0 +1,45 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestConnectWithRetry(t *testing.T) {
+	t.Run("retries until the database is reachable", func(t *testing.T) {
+		attempts := 0
+		want := &gorm.DB{}
+		db, err := connectWithRetry(context.Background(), func() (*gorm.DB, error) {
+			attempts++
+			if attempts < 3 {
+				return nil, errors.New("connection refused")
+			}
+			return want, nil
+		}, time.Millisecond, 2*time.Millisecond)
+
+		require.NoError(t, err)
+		assert.Same(t, want, db)
+		assert.Equal(t, 3, attempts)
+	})
+
+	t.Run("gives up when the context ends", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		attempts := 0
+		db, err := connectWithRetry(ctx, func() (*gorm.DB, error) {
+			attempts++
+			return nil, errors.New("connection refused")
+		}, time.Millisecond, 5*time.Millisecond)
+
+		assert.Nil(t, db)
+		assert.ErrorContains(t, err, "connection refused")
+		assert.Greater(t, attempts, 1)
+	})
+}
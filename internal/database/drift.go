@@ -0,0 +1,176 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+
+	"gorm.io/gorm"
+	"quizlet/internal/config"
+	"quizlet/internal/models/audit_entry"
+	"quizlet/internal/models/idempotency_key"
+	"quizlet/internal/models/quiz"
+	"quizlet/internal/models/quiz_attempt"
+	"quizlet/internal/models/quiz_suite"
+	"quizlet/internal/models/user"
+	"quizlet/migrations"
+)
+
+// DriftKind classifies a difference between a model and the live schema
+type DriftKind string
+
+const (
+	// DriftMissingTable is a model whose table does not exist
+	DriftMissingTable DriftKind = "missing_table"
+	// DriftMissingColumn is a model field without a column; queries using
+	// the model fail
+	DriftMissingColumn DriftKind = "missing_column"
+	// DriftUnmappedColumn is a column no model field maps to; its data is
+	// never read or written by the API
+	DriftUnmappedColumn DriftKind = "unmapped_column"
+)
+
+// SchemaDrift is one difference between the models and the database
+type SchemaDrift struct {
+	Kind   DriftKind
+	Table  string
+	Column string
+}
+
+func (d SchemaDrift) String() string {
+	if d.Column == "" {
+		return fmt.Sprintf("%s: table %s", d.Kind, d.Table)
+	}
+	return fmt.Sprintf("%s: %s.%s", d.Kind, d.Table, d.Column)
+}
+
+// Breaking reports whether the drift makes queries against the table fail
+func (d SchemaDrift) Breaking() bool {
+	return d.Kind != DriftUnmappedColumn
+}
+
+// Models returns every model stored by the API, in migration order
+func Models() []any {
+	return []any{
+		&user.User{},
+		&quiz_suite.QuizSuite{},
+		&quiz.Quiz{},
+		&quiz.QuizSelection{},
+		&quiz_attempt.QuizAttempt{},
+		&quiz_attempt.QuizAttemptAnswer{},
+		&user.RefreshToken{},
+		&user.RecoveryCode{},
+		&user.ExternalIdentity{},
+		&user.PersonalAccessToken{},
+		&idempotency_key.IdempotencyKey{},
+		&audit_entry.AuditEntry{},
+		&quiz.QuizRevision{},
+	}
+}
+
+// CheckSchema compares the columns of each model with its table in the
+// database and returns the differences
+func CheckSchema(db *gorm.DB, models ...any) ([]SchemaDrift, error) {
+	var drift []SchemaDrift
+	for _, model := range models {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(model); err != nil {
+			return nil, fmt.Errorf("parsing model %T: %w", model, err)
+		}
+		table := stmt.Schema.Table
+
+		if !db.Migrator().HasTable(table) {
+			drift = append(drift, SchemaDrift{Kind: DriftMissingTable, Table: table})
+			continue
+		}
+		columnTypes, err := db.Migrator().ColumnTypes(table)
+		if err != nil {
+			return nil, fmt.Errorf("reading columns of %s: %w", table, err)
+		}
+
+		columns := make([]string, 0, len(columnTypes))
+		for _, column := range columnTypes {
+			columns = append(columns, column.Name())
+		}
+		drift = append(drift, compareColumns(table, stmt.Schema.DBNames, columns)...)
+	}
+	return drift, nil
+}
+
+func compareColumns(table string, fields, columns []string) []SchemaDrift {
+	inTable := make(map[string]bool, len(columns))
+	for _, column := range columns {
+		inTable[column] = true
+	}
+	inModel := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		inModel[field] = true
+	}
+
+	var drift []SchemaDrift
+	for _, field := range fields {
+		if !inTable[field] {
+			drift = append(drift, SchemaDrift{Kind: DriftMissingColumn, Table: table, Column: field})
+		}
+	}
+	for _, column := range columns {
+		if !inModel[column] {
+			drift = append(drift, SchemaDrift{Kind: DriftUnmappedColumn, Table: table, Column: column})
+		}
+	}
+	sort.SliceStable(drift, func(i, j int) bool { return drift[i].Column < drift[j].Column })
+	return drift
+}
+
+// PrepareSchema runs the startup schema steps selected by cfg: applying
+// pending migrations when AutoMigrate is set, warning about migrations that
+// were not applied otherwise, and checking the models against the schema
+func PrepareSchema(ctx context.Context, db *gorm.DB, cfg config.DatabaseConfig) error {
+	fsys, err := migrations.ForDialect(db.Dialector.Name())
+	if err != nil {
+		return err
+	}
+	migrator, err := NewMigrator(db, fsys)
+	if err != nil {
+		return err
+	}
+
+	if cfg.AutoMigrate {
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			return fmt.Errorf("applying migrations: %w", err)
+		}
+		slog.Info("database schema is up to date", "applied", applied)
+	} else {
+		status, err := migrator.Status(ctx)
+		if err != nil {
+			return fmt.Errorf("reading migration status: %w", err)
+		}
+		if status.Dirty {
+			slog.Warn("database schema is dirty", "version", status.Version)
+		}
+		if len(status.Pending) > 0 {
+			slog.Warn("database migrations are pending, run the migrate up command or set DB_AUTO_MIGRATE", "pending", len(status.Pending))
+		}
+	}
+
+	if cfg.SchemaCheck == config.SchemaCheckOff {
+		return nil
+	}
+	drift, err := CheckSchema(db.WithContext(ctx), Models()...)
+	if err != nil {
+		return fmt.Errorf("checking schema: %w", err)
+	}
+	breaking := 0
+	for _, d := range drift {
+		slog.Warn("schema drift", "kind", string(d.Kind), "table", d.Table, "column", d.Column)
+		if d.Breaking() {
+			breaking++
+		}
+	}
+	if breaking > 0 && cfg.SchemaCheck == config.SchemaCheckFail {
+		return fmt.Errorf("database schema is missing %d tables or columns used by the models", breaking)
+	}
+	return nil
+}
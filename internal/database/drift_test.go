@@ -0,0 +1,41 @@
+package database
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm/schema"
+	"quizlet/migrations"
+)
+
+func TestCompareColumns(t *testing.T) {
+	drift := compareColumns("quiz_selections",
+		[]string{"id", "quiz_id", "selection_text", "selection_display_name"},
+		[]string{"id", "quiz_id", "selection_text", "deleted_at"})
+
+	assert.Equal(t, []SchemaDrift{
+		{Kind: DriftUnmappedColumn, Table: "quiz_selections", Column: "deleted_at"},
+		{Kind: DriftMissingColumn, Table: "quiz_selections", Column: "selection_display_name"},
+	}, drift)
+	assert.False(t, drift[0].Breaking())
+	assert.True(t, drift[1].Breaking())
+	assert.Equal(t, "missing_column: quiz_selections.selection_display_name", drift[1].String())
+}
+
+func TestModelsHaveMigrations(t *testing.T) {
+	loaded, err := LoadMigrations(migrations.FS)
+	require.NoError(t, err)
+	var scripts strings.Builder
+	for _, m := range loaded {
+		scripts.WriteString(m.UpSQL)
+	}
+
+	for _, model := range Models() {
+		s, err := schema.Parse(model, &sync.Map{}, schema.NamingStrategy{})
+		require.NoError(t, err)
+		assert.Contains(t, scripts.String(), "CREATE TABLE IF NOT EXISTS "+s.Table+" (", "no migration creates the table of %T", model)
+	}
+}
@@ -0,0 +1,40 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+func TestGormLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := newGormLogger(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	query := func() (string, int64) { return "SELECT * FROM users WHERE email = $1", 0 }
+
+	logger.Trace(context.Background(), time.Now(), query, gorm.ErrRecordNotFound)
+	logger.Trace(context.Background(), time.Now(), query, nil)
+	assert.Empty(t, buf.String(), "fast queries and missing records are not logged at the default level")
+
+	logger.Trace(context.Background(), time.Now(), query, errors.New("connection reset"))
+	assert.Contains(t, buf.String(), "query failed")
+	assert.Contains(t, buf.String(), "connection reset")
+
+	buf.Reset()
+	logger.Trace(context.Background(), time.Now().Add(-time.Second), query, nil)
+	assert.Contains(t, buf.String(), "slow query")
+
+	buf.Reset()
+	logger.LogMode(gormlogger.Silent).Trace(context.Background(), time.Now(), query, errors.New("connection reset"))
+	assert.Empty(t, buf.String())
+
+	sql, params := logger.ParamsFilter(context.Background(), "SELECT $1", "secret")
+	assert.Equal(t, "SELECT $1", sql)
+	assert.Nil(t, params)
+}
@@ -0,0 +1,115 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"quizlet/internal/config"
+	"quizlet/migrations"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	fsys := fstest.MapFS{
+		"000002_add_email.up.sql":      {Data: []byte("ALTER TABLE users ADD COLUMN email TEXT;")},
+		"000002_add_email.down.sql":    {Data: []byte("ALTER TABLE users DROP COLUMN email;")},
+		"000001_create_users.up.sql":   {Data: []byte("CREATE TABLE users (id SERIAL);")},
+		"000001_create_users.down.sql": {Data: []byte("DROP TABLE users;")},
+		"migrations.go":                {Data: []byte("package migrations")},
+	}
+
+	loaded, err := LoadMigrations(fsys)
+	require.NoError(t, err)
+	require.Len(t, loaded, 2)
+	assert.Equal(t, uint(1), loaded[0].Version)
+	assert.Equal(t, "create_users", loaded[0].Name)
+	assert.Equal(t, "DROP TABLE users;", loaded[0].DownSQL)
+	assert.Equal(t, "000002_add_email", loaded[1].String())
+}
+
+func TestLoadMigrationsRejectsBadFiles(t *testing.T) {
+	testCases := []struct {
+		name          string
+		files         fstest.MapFS
+		expectedError string
+	}{
+		{
+			name: "Missing Down",
+			files: fstest.MapFS{
+				"000001_create_users.up.sql": {Data: []byte("CREATE TABLE users (id SERIAL);")},
+			},
+			expectedError: "000001_create_users needs both an up and a down file",
+		},
+		{
+			name: "Duplicate Version",
+			files: fstest.MapFS{
+				"000001_create_users.up.sql":   {Data: []byte("CREATE TABLE users (id SERIAL);")},
+				"000001_create_quizzes.up.sql": {Data: []byte("CREATE TABLE quizzes (id SERIAL);")},
+			},
+			expectedError: "migration version 1 is used by",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := LoadMigrations(tc.files)
+			assert.ErrorContains(t, err, tc.expectedError)
+		})
+	}
+}
+
+func TestEmbeddedMigrations(t *testing.T) {
+	loaded, err := LoadMigrations(migrations.FS)
+	require.NoError(t, err)
+	require.NotEmpty(t, loaded)
+
+	for i, m := range loaded {
+		assert.Equal(t, uint(i+1), m.Version, "migration versions must be consecutive")
+	}
+
+	// Every migration is written for both dialects
+	sqlite, err := LoadMigrations(migrations.SQLite)
+	require.NoError(t, err)
+	require.Len(t, sqlite, len(loaded))
+	for i, m := range sqlite {
+		assert.Equal(t, loaded[i].String(), m.String())
+	}
+}
+
+func TestSQLiteMigrations(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.Default().Database
+	cfg.Driver = config.DatabaseDriverSQLite
+	cfg.Path = ":memory:"
+	cfg.AutoMigrate = true
+	cfg.SchemaCheck = config.SchemaCheckFail
+
+	db, err := Open(ctx, cfg)
+	require.NoError(t, err)
+	t.Cleanup(func() { Close(db) })
+
+	require.NoError(t, PrepareSchema(ctx, db, cfg))
+	drift, err := CheckSchema(db, Models()...)
+	require.NoError(t, err)
+	for _, d := range drift {
+		assert.False(t, d.Breaking(), d.String())
+	}
+
+	migrator, err := NewMigrator(db, migrations.SQLite)
+	require.NoError(t, err)
+	status, err := migrator.Status(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, status.Pending)
+
+	// Every down migration runs, and the schema can be rebuilt after
+	rolledBack, err := migrator.Down(ctx, int(status.Version))
+	require.NoError(t, err)
+	assert.Equal(t, int(status.Version), rolledBack)
+	assert.False(t, db.Migrator().HasTable("users"))
+
+	applied, err := migrator.Up(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int(status.Version), applied)
+}
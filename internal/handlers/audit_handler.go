@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"quizlet/internal/models/audit_entry"
+	"quizlet/internal/service"
+)
+
+type AuditHandler struct {
+	auditService service.AuditService
+}
+
+func NewAuditHandler(auditService service.AuditService) *AuditHandler {
+	return &AuditHandler{
+		auditService: auditService,
+	}
+}
+
+// @Summary List the audit log
+// @Description List recorded changes to users, quizzes, quiz suites and quiz attempts, newest first. Requires the admin role.
+// @Tags audit
+// @Produce json
+// @Param resource_type query string false "Resource type" Enums(user, quiz, quiz_suite, quiz_attempt)
+// @Param resource_id query int false "Resource ID"
+// @Param actor_id query int false "ID of the user who made the change"
+// @Param since query string false "Earliest time, inclusive (RFC 3339)"
+// @Param until query string false "Latest time, exclusive (RFC 3339)"
+// @Param limit query int false "Maximum number of entries, 100 by default" minimum(1) maximum(500)
+// @Success 200 {array} audit_entry.AuditEntry
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /audit [get]
+func (h *AuditHandler) ListAuditEntries(c *gin.Context) {
+	var filter audit_entry.Filter
+	if !bindQuery(c, &filter) {
+		return
+	}
+
+	entries, err := h.auditService.List(c.Request.Context(), filter)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, entries)
+}
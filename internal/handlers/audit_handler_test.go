@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"quizlet/internal/models/audit_entry"
+	"quizlet/internal/service"
+)
+
+type MockAuditService struct {
+	mock.Mock
+}
+
+var _ service.AuditService = (*MockAuditService)(nil)
+
+func (m *MockAuditService) List(ctx context.Context, filter audit_entry.Filter) ([]*audit_entry.AuditEntry, error) {
+	args := m.Called(ctx, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*audit_entry.AuditEntry), args.Error(1)
+}
+
+func TestListAuditEntries(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := new(MockAuditService)
+	handler := NewAuditHandler(mockService)
+	actorID := uint(1)
+
+	testCases := []struct {
+		name           string
+		query          string
+		mockSetup      func()
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:  "Success",
+			query: "?resource_type=quiz&resource_id=3&actor_id=1&since=2024-03-20T00:00:00Z&limit=10",
+			mockSetup: func() {
+				mockService.On("List", mock.Anything, audit_entry.Filter{
+					ResourceType: audit_entry.ResourceQuiz,
+					ResourceID:   3,
+					ActorID:      1,
+					Since:        time.Date(2024, 3, 20, 0, 0, 0, 0, time.UTC),
+					Limit:        10,
+				}).Return([]*audit_entry.AuditEntry{{
+					ID:           7,
+					CreatedAt:    time.Date(2024, 3, 20, 15, 4, 5, 0, time.UTC),
+					ActorID:      &actorID,
+					Action:       audit_entry.ActionUpdate,
+					ResourceType: audit_entry.ResourceQuiz,
+					ResourceID:   3,
+					Changes:      audit_entry.Changes{"question": {Before: "What is 2 + 2?", After: "What is 3 + 3?"}},
+					RequestID:    "req-1",
+					IP:           "203.0.113.7",
+				}}, nil).Once()
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `[{"id":7,"created_at":"2024-03-20T15:04:05Z","actor_id":1,"action":"update","resource_type":"quiz","resource_id":3,"changes":{"question":{"before":"What is 2 + 2?","after":"What is 3 + 3?"}},"request_id":"req-1","ip":"203.0.113.7"}]`,
+		},
+		{
+			name:           "Unknown Resource Type",
+			query:          "?resource_type=token",
+			mockSetup:      func() {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"type":"urn:quizlet:problem:validation_failed","title":"Bad Request","status":400,"detail":"request validation failed","instance":"/audit","code":"validation_failed","errors":[{"field":"resource_type","code":"oneof","message":"must be one of: user, quiz, quiz_suite, quiz_attempt"}]}`,
+		},
+		{
+			name:           "Limit Too High",
+			query:          "?limit=1000",
+			mockSetup:      func() {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"type":"urn:quizlet:problem:validation_failed","title":"Bad Request","status":400,"detail":"request validation failed","instance":"/audit","code":"validation_failed","errors":[{"field":"limit","code":"max","message":"must be at most 500"}]}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+
+			c.Request = httptest.NewRequest(http.MethodGet, "/audit"+tc.query, nil)
+
+			tc.mockSetup()
+
+			serve(c, handler.ListAuditEntries)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			assert.JSONEq(t, tc.expectedBody, w.Body.String())
+		})
+	}
+
+	mockService.AssertExpectations(t)
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"quizlet/internal/apperr"
+	"quizlet/internal/models/quiz"
+	"quizlet/internal/models/quiz_suite"
+)
+
+// Quizzes and quiz suites carry a version that is incremented on every
+// change. It is sent as a strong entity tag, so clients can make updates and
+// deletes conditional with If-Match and revalidate reads with If-None-Match.
+
+var (
+	// ErrInvalidIfMatch is reported for an If-Match header that does not
+	// name a single version
+	ErrInvalidIfMatch = apperr.New(apperr.Invalid, "invalid_if_match", `If-Match must be "*" or a single entity tag`)
+	// ErrWeakIfMatch is reported for a weak tag, which never matches as
+	// If-Match uses the strong comparison
+	ErrWeakIfMatch = apperr.New(apperr.PreconditionFailed, "weak_if_match", "a weak entity tag cannot be used with If-Match")
+)
+
+// quizETag returns the entity tag of a quiz; selections only change through
+// the quiz, which increments its version
+func quizETag(q *quiz.Quiz) string {
+	return `"` + strconv.FormatUint(uint64(q.Version), 10) + `"`
+}
+
+// quizSuiteETag returns the entity tag of a quiz suite. The versions of the
+// embedded quizzes are hashed into it, as they change on their own.
+func quizSuiteETag(qs *quiz_suite.QuizSuite) string {
+	version := strconv.FormatUint(uint64(qs.Version), 10)
+	if len(qs.Quizzes) == 0 {
+		return `"` + version + `"`
+	}
+	hash := fnv.New32a()
+	for _, q := range qs.Quizzes {
+		fmt.Fprintf(hash, "%d:%d,", q.ID, q.Version)
+	}
+	return fmt.Sprintf(`"%s-%08x"`, version, hash.Sum32())
+}
+
+// ifMatchVersion returns the version named by the If-Match header. It is
+// zero, meaning unconditional, when the header is missing or "*".
+func ifMatchVersion(c *gin.Context) (uint, bool) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" || header == "*" {
+		return 0, true
+	}
+	if strings.HasPrefix(header, "W/") {
+		_ = c.Error(ErrWeakIfMatch)
+		return 0, false
+	}
+	if len(header) < 2 || header[0] != '"' || header[len(header)-1] != '"' {
+		_ = c.Error(ErrInvalidIfMatch)
+		return 0, false
+	}
+	// Suite tags add a hash of their quizzes after the version
+	tag, _, _ := strings.Cut(header[1:len(header)-1], "-")
+	version, err := strconv.ParseUint(tag, 10, 32)
+	if err != nil || version == 0 {
+		_ = c.Error(ErrInvalidIfMatch)
+		return 0, false
+	}
+	return uint(version), true
+}
+
+// notModified sets the ETag header and reports whether If-None-Match lists
+// the tag, in which case 304 Not Modified is sent and the caller must stop
+func notModified(c *gin.Context, etag string) bool {
+	c.Header("ETag", etag)
+	header := c.GetHeader("If-None-Match")
+	if header == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			c.Status(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"quizlet/internal/auth"
+	"quizlet/internal/models/quiz"
+	"quizlet/internal/models/quiz_suite"
+	"quizlet/internal/problem"
+	"quizlet/internal/service"
+)
+
+func TestQuizSuiteETag(t *testing.T) {
+	suite := &quiz_suite.QuizSuite{ID: 1, Version: 4}
+	assert.Equal(t, `"4"`, quizSuiteETag(suite))
+
+	suite.Quizzes = []*quiz.Quiz{{ID: 1, Version: 1}, {ID: 2, Version: 1}}
+	tag := quizSuiteETag(suite)
+	assert.Regexp(t, `^"4-[0-9a-f]{8}"$`, tag)
+
+	// Editing an embedded quiz changes the tag of the suite
+	suite.Quizzes[1].Version = 2
+	assert.NotEqual(t, tag, quizSuiteETag(suite))
+}
+
+func TestIfMatchVersion(t *testing.T) {
+	testCases := []struct {
+		header   string
+		expected uint
+		code     string
+	}{
+		{header: "", expected: 0},
+		{header: "*", expected: 0},
+		{header: `"7"`, expected: 7},
+		{header: `"7-0a1b2c3d"`, expected: 7},
+		{header: `W/"7"`, code: "weak_if_match"},
+		{header: `7`, code: "invalid_if_match"},
+		{header: `"7", "8"`, code: "invalid_if_match"},
+		{header: `"0"`, code: "invalid_if_match"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.header, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodPut, "/", nil)
+			c.Request.Header.Set("If-Match", tc.header)
+
+			version, ok := ifMatchVersion(c)
+			if tc.code != "" {
+				assert.False(t, ok)
+				assert.Equal(t, tc.code, problem.New(c.Errors.Last()).Code)
+				return
+			}
+			assert.True(t, ok)
+			assert.Equal(t, tc.expected, version)
+		})
+	}
+}
+
+func TestConditionalQuizRequests(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockQuizService := new(MockQuizService)
+	handler := NewQuizHandler(mockQuizService)
+
+	router := gin.New()
+	router.Use(problem.Middleware())
+	router.Use(func(c *gin.Context) {
+		auth.SetPrincipal(c, &auth.Principal{UserID: 1, Method: auth.AuthMethodBearer})
+	})
+	router.GET("/quizzes/:id", handler.GetQuiz)
+	router.PUT("/quizzes/:id", handler.UpdateQuiz)
+
+	stored := &quiz.Quiz{ID: 1, Version: 3, Question: "Why?", QuizType: quiz.QuizTypeTrueFalse, CreatedByID: 1}
+	mockQuizService.On("GetQuizByID", mock.Anything, uint(1)).Return(stored, nil)
+
+	send := func(method, body string, header ...string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, "/quizzes/1", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		for i := 0; i < len(header); i += 2 {
+			req.Header.Set(header[i], header[i+1])
+		}
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := send(http.MethodGet, "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `"3"`, w.Header().Get("ETag"))
+
+	w = send(http.MethodGet, "", "If-None-Match", `W/"2", "3"`)
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Empty(t, w.Body.String())
+	assert.Equal(t, `"3"`, w.Header().Get("ETag"))
+
+	w = send(http.MethodGet, "", "If-None-Match", `"2"`)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// If-Match is passed on as the expected version and wins over the body
+	mockQuizService.On("UpdateQuiz", mock.Anything, mock.MatchedBy(func(q *quiz.Quiz) bool {
+		return q.Version == 2
+	})).Return(service.ErrQuizModified).Once()
+	w = send(http.MethodPut, `{"question":"Why not?","quiz_type":"true_false","version":3}`, "If-Match", `"2"`)
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+	assert.Equal(t, "quiz_modified", decodeProblem(t, w).Code)
+
+	mockQuizService.On("UpdateQuiz", mock.Anything, mock.MatchedBy(func(q *quiz.Quiz) bool {
+		return q.Version == 3
+	})).Run(func(args mock.Arguments) {
+		args.Get(1).(*quiz.Quiz).Version = 4
+	}).Return(nil).Once()
+	w = send(http.MethodPut, `{"question":"Why not?","quiz_type":"true_false"}`, "If-Match", `"3"`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `"4"`, w.Header().Get("ETag"))
+
+	mockQuizService.AssertExpectations(t)
+}
@@ -0,0 +1,238 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+	"quizlet/internal/apperr"
+	"quizlet/internal/auth"
+	"quizlet/internal/auth/oidc"
+	"quizlet/internal/metrics"
+	"quizlet/internal/models/user"
+	"quizlet/internal/service"
+)
+
+const oidcStateCookie = "oidc_state"
+
+var (
+	ErrIdentityProviderUnavailable = apperr.New(apperr.Upstream, "identity_provider_unavailable", "identity provider unavailable")
+	ErrIdentityProviderLoginDenied = apperr.New(apperr.Unauthenticated, "identity_provider_login_incomplete", "login was not completed at the identity provider")
+	ErrIdentityProviderLoginFailed = apperr.New(apperr.Unauthenticated, "identity_provider_login_failed", "failed to verify identity provider login")
+)
+
+type OIDCHandler struct {
+	userService service.UserService
+	providers   map[string]*oidc.Provider
+	stateKey    []byte
+	sessions    sessionIssuer
+}
+
+func NewOIDCHandler(userService service.UserService, providers map[string]*oidc.Provider, stateKey []byte, tokens auth.TokenConfig, cookies auth.CookieConfig) *OIDCHandler {
+	return &OIDCHandler{
+		userService: userService,
+		providers:   providers,
+		stateKey:    stateKey,
+		sessions:    sessionIssuer{tokens: tokens, cookies: cookies},
+	}
+}
+
+type OIDCProvidersResponse struct {
+	Providers []string `json:"providers"`
+}
+
+// @Summary List single sign-on providers
+// @Description List the names of the configured OpenID Connect providers
+// @Tags auth
+// @Produce json
+// @Success 200 {object} OIDCProvidersResponse
+// @Router /auth/oidc/providers [get]
+func (h *OIDCHandler) ListProviders(c *gin.Context) {
+	names := make([]string, 0, len(h.providers))
+	for name := range h.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	c.JSON(http.StatusOK, OIDCProvidersResponse{Providers: names})
+}
+
+// @Summary Start single sign-on login
+// @Description Redirect to the identity provider using the authorization code flow with PKCE
+// @Tags auth
+// @Param provider path string true "Provider name"
+// @Param session query string false "Set to cookie for a browser cookie session"
+// @Success 302 "Redirect to the identity provider"
+// @Failure 404 {object} ErrorResponse
+// @Failure 502 {object} ErrorResponse
+// @Router /auth/oidc/{provider}/login [get]
+func (h *OIDCHandler) Login(c *gin.Context) {
+	provider, ok := h.providers[c.Param("provider")]
+	if !ok {
+		c.Error(oidc.ErrUnknownProvider)
+		return
+	}
+
+	state, err := oidc.NewAuthState(provider.Name())
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	state.CookieSession = c.Query("session") == auth.SessionModeCookie
+
+	authURL, ok := h.startAuthorization(c, provider, state)
+	if !ok {
+		return
+	}
+	c.Redirect(http.StatusFound, authURL)
+}
+
+type OIDCLinkResponse struct {
+	AuthorizationURL string `json:"authorization_url"`
+}
+
+// @Summary Link a single sign-on provider
+// @Description Start linking an identity provider account to the signed-in user. Navigate to the returned URL; the provider redirects back to the callback, which links the identity.
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Param provider path string true "Provider name"
+// @Success 200 {object} OIDCLinkResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 502 {object} ErrorResponse
+// @Router /users/me/identities/{provider} [post]
+func (h *OIDCHandler) Link(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	provider, ok := h.providers[c.Param("provider")]
+	if !ok {
+		c.Error(oidc.ErrUnknownProvider)
+		return
+	}
+
+	state, err := oidc.NewAuthState(provider.Name())
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	state.LinkUserID = userID
+
+	authURL, ok := h.startAuthorization(c, provider, state)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, OIDCLinkResponse{AuthorizationURL: authURL})
+}
+
+// startAuthorization stores the state in a cookie and returns the provider
+// URL the browser has to visit
+func (h *OIDCHandler) startAuthorization(c *gin.Context, provider *oidc.Provider, state *oidc.AuthState) (string, bool) {
+	authURL, err := provider.AuthCodeURL(c.Request.Context(), state.State, state.Nonce, oidc.CodeChallengeS256(state.CodeVerifier))
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "oidc discovery failed", "provider", provider.Name(), "error", err)
+		c.Error(ErrIdentityProviderUnavailable.Wrap(err))
+		return "", false
+	}
+
+	cookie, err := oidc.EncodeState(h.stateKey, state)
+	if err != nil {
+		c.Error(err)
+		return "", false
+	}
+
+	// SameSite=Lax so the cookie is sent on the top-level redirect back from the provider
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcStateCookie, cookie, int(oidc.StateTTL.Seconds()), "/", "", c.Request.TLS != nil, true)
+	return authURL, true
+}
+
+// @Summary Complete single sign-on login
+// @Description Handle the identity provider redirect and issue tokens for the linked or newly created account. An email that already belongs to an account is rejected; its owner has to link the provider first. For a link started at /users/me/identities/{provider} the linked identity is returned instead.
+// @Tags auth
+// @Produce json
+// @Param provider path string true "Provider name"
+// @Param code query string true "Authorization code"
+// @Param state query string true "Login state"
+// @Success 200 {object} LoginResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /auth/oidc/{provider}/callback [get]
+func (h *OIDCHandler) Callback(c *gin.Context) {
+	provider, ok := h.providers[c.Param("provider")]
+	if !ok {
+		c.Error(oidc.ErrUnknownProvider)
+		return
+	}
+
+	if errParam := c.Query("error"); errParam != "" {
+		c.Error(ErrIdentityProviderLoginDenied)
+		return
+	}
+
+	cookie, err := c.Cookie(oidcStateCookie)
+	if err != nil {
+		c.Error(oidc.ErrInvalidState)
+		return
+	}
+	// The state is single use
+	c.SetCookie(oidcStateCookie, "", -1, "/", "", c.Request.TLS != nil, true)
+
+	state, err := oidc.DecodeState(h.stateKey, cookie)
+	if err != nil || state.Provider != provider.Name() || state.State != c.Query("state") {
+		c.Error(oidc.ErrInvalidState)
+		return
+	}
+
+	code := c.Query("code")
+	if code == "" {
+		c.Error(apperr.Validation(apperr.FieldError{Field: "code", Code: "required", Message: "is required"}))
+		return
+	}
+
+	identity, err := provider.Exchange(c.Request.Context(), code, state.CodeVerifier, state.Nonce)
+	if err != nil {
+		slog.WarnContext(c.Request.Context(), "oidc code exchange failed", "provider", provider.Name(), "error", err)
+		metrics.LoginFailed(metrics.LoginMethodOIDC)
+		c.Error(ErrIdentityProviderLoginFailed.Wrap(err))
+		return
+	}
+
+	profile := &user.ExternalProfile{
+		Provider:      identity.Provider,
+		Subject:       identity.Subject,
+		Email:         identity.Email,
+		EmailVerified: identity.EmailVerified,
+		Name:          identity.Name,
+	}
+
+	if state.LinkUserID != 0 {
+		linked, err := h.userService.LinkExternalIdentity(c.Request.Context(), state.LinkUserID, profile)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, linked)
+		return
+	}
+
+	u, err := h.userService.LoginWithExternalIdentity(c.Request.Context(), profile)
+	if err != nil {
+		if errors.Is(err, service.ErrExternalEmailMissing) || errors.Is(err, service.ErrExternalEmailUnverified) || errors.Is(err, service.ErrExternalAccountExists) || errors.Is(err, service.ErrUserNotFound) {
+			metrics.LoginFailed(metrics.LoginMethodOIDC)
+		}
+		c.Error(err)
+		return
+	}
+
+	if state.CookieSession {
+		auth.UseCookieSession(c)
+	}
+	h.sessions.completeLogin(c, h.userService, u, metrics.LoginMethodOIDC)
+}
@@ -0,0 +1,242 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"quizlet/internal/auth"
+	"quizlet/internal/auth/oidc"
+	"quizlet/internal/auth/oidc/oidctest"
+	"quizlet/internal/models/user"
+	"quizlet/internal/problem"
+	"quizlet/internal/service"
+)
+
+func setupOIDCRouter(t *testing.T) (*gin.Engine, *oidctest.Server, *MockUserService) {
+	gin.SetMode(gin.TestMode)
+
+	server := oidctest.NewServer("quizlet-client", "quizlet-secret")
+	t.Cleanup(server.Close)
+
+	providers := map[string]*oidc.Provider{
+		"mock": oidc.NewProvider(oidc.ProviderConfig{
+			Name:         "mock",
+			Issuer:       server.URL,
+			ClientID:     "quizlet-client",
+			ClientSecret: "quizlet-secret",
+			RedirectURL:  "http://localhost:8080/api/auth/oidc/mock/callback",
+		}, server.Client()),
+	}
+
+	mockService := new(MockUserService)
+	handler := NewOIDCHandler(mockService, providers, []byte("state-key"), auth.DefaultTokenConfig(), auth.DefaultCookieConfig())
+
+	router := gin.New()
+	router.Use(problem.Middleware())
+	router.GET("/auth/oidc/providers", handler.ListProviders)
+	router.GET("/auth/oidc/:provider/login", handler.Login)
+	router.GET("/auth/oidc/:provider/callback", handler.Callback)
+	router.POST("/users/me/identities/:provider", func(c *gin.Context) {
+		auth.SetPrincipal(c, &auth.Principal{UserID: 3})
+	}, handler.Link)
+
+	return router, server, mockService
+}
+
+// authorize runs the provider authorization, returning the callback query
+// the provider redirected back with
+func authorize(t *testing.T, server *oidctest.Server, authURL string) url.Values {
+	client := server.Client()
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	resp, err := client.Get(authURL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+
+	location, err := url.Parse(resp.Header.Get("Location"))
+	require.NoError(t, err)
+	return location.Query()
+}
+
+// startLogin runs the login redirect and the provider authorization, returning
+// the state cookie and the callback query the provider redirected back with
+func startLogin(t *testing.T, router *gin.Engine, server *oidctest.Server) (*http.Cookie, url.Values) {
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/auth/oidc/mock/login", nil))
+	require.Equal(t, http.StatusFound, w.Code)
+
+	cookies := w.Result().Cookies()
+	require.Len(t, cookies, 1)
+	assert.True(t, cookies[0].HttpOnly)
+
+	return cookies[0], authorize(t, server, w.Header().Get("Location"))
+}
+
+func TestOIDCLogin(t *testing.T) {
+	router, server, mockService := setupOIDCRouter(t)
+	server.SetUser(oidctest.Claims{
+		Subject:       "google-123",
+		Email:         "teacher@school.edu",
+		EmailVerified: true,
+		Name:          "Test Teacher",
+	})
+
+	cookie, callback := startLogin(t, router, server)
+
+	mockService.On("LoginWithExternalIdentity", mock.Anything, &user.ExternalProfile{
+		Provider:      "mock",
+		Subject:       "google-123",
+		Email:         "teacher@school.edu",
+		EmailVerified: true,
+		Name:          "Test Teacher",
+	}).Return(&user.User{ID: 7, Username: "teacher", Email: "teacher@school.edu"}, nil).Once()
+	mockService.On("CreateRefreshToken", mock.Anything, uint(7)).Return(&user.RefreshToken{Token: "refresh-token-789", UserID: 7}, nil).Once()
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/auth/oidc/mock/callback?"+callback.Encode(), nil)
+	req.AddCookie(cookie)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.NotEmpty(t, response["access_token"])
+	assert.Equal(t, "refresh-token-789", response["refresh_token"])
+
+	mockService.AssertExpectations(t)
+}
+
+func TestOIDCLinkIdentity(t *testing.T) {
+	router, server, mockService := setupOIDCRouter(t)
+	server.SetUser(oidctest.Claims{Subject: "azure-456", Email: "teacher@school.edu", EmailVerified: true})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/users/me/identities/mock", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	var link OIDCLinkResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &link))
+	cookies := w.Result().Cookies()
+	require.Len(t, cookies, 1)
+	callback := authorize(t, server, link.AuthorizationURL)
+
+	// The callback links the identity to the user who started the link
+	// instead of logging in
+	mockService.On("LinkExternalIdentity", mock.Anything, uint(3), &user.ExternalProfile{
+		Provider:      "mock",
+		Subject:       "azure-456",
+		Email:         "teacher@school.edu",
+		EmailVerified: true,
+	}).Return(&user.ExternalIdentity{ID: 1, UserID: 3, Provider: "mock", Subject: "azure-456"}, nil).Once()
+
+	w = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/auth/oidc/mock/callback?"+callback.Encode(), nil)
+	req.AddCookie(cookies[0])
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var identity map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &identity))
+	assert.Equal(t, float64(3), identity["user_id"])
+	assert.NotContains(t, identity, "access_token")
+
+	mockService.AssertExpectations(t)
+}
+
+func TestOIDCCallbackErrors(t *testing.T) {
+	router, server, mockService := setupOIDCRouter(t)
+	server.SetUser(oidctest.Claims{Subject: "azure-456", Email: "student@school.edu"})
+
+	t.Run("Missing State Cookie", func(t *testing.T) {
+		_, callback := startLogin(t, router, server)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/auth/oidc/mock/callback?"+callback.Encode(), nil))
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, "invalid_login_state", decodeProblem(t, w).Code)
+	})
+
+	t.Run("State Mismatch", func(t *testing.T) {
+		cookie, callback := startLogin(t, router, server)
+		callback.Set("state", "forged")
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/auth/oidc/mock/callback?"+callback.Encode(), nil)
+		req.AddCookie(cookie)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Unverified Email", func(t *testing.T) {
+		cookie, callback := startLogin(t, router, server)
+		mockService.On("LoginWithExternalIdentity", mock.Anything, mock.AnythingOfType("*user.ExternalProfile")).
+			Return(nil, service.ErrExternalEmailUnverified).Once()
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/auth/oidc/mock/callback?"+callback.Encode(), nil)
+		req.AddCookie(cookie)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		p := decodeProblem(t, w)
+		assert.Equal(t, "external_email_unverified", p.Code)
+		assert.Equal(t, "identity provider email address is not verified", p.Detail)
+	})
+
+	t.Run("Email Of Existing Account", func(t *testing.T) {
+		cookie, callback := startLogin(t, router, server)
+		mockService.On("LoginWithExternalIdentity", mock.Anything, mock.AnythingOfType("*user.ExternalProfile")).
+			Return(nil, service.ErrExternalAccountExists).Once()
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/auth/oidc/mock/callback?"+callback.Encode(), nil)
+		req.AddCookie(cookie)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Equal(t, "external_account_exists", decodeProblem(t, w).Code)
+		assert.NotContains(t, w.Body.String(), "access_token")
+	})
+
+	t.Run("Linked User Deleted", func(t *testing.T) {
+		cookie, callback := startLogin(t, router, server)
+		mockService.On("LoginWithExternalIdentity", mock.Anything, mock.AnythingOfType("*user.ExternalProfile")).
+			Return(nil, service.ErrUserNotFound).Once()
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/auth/oidc/mock/callback?"+callback.Encode(), nil)
+		req.AddCookie(cookie)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Equal(t, "user_not_found", decodeProblem(t, w).Code)
+		assert.NotContains(t, w.Body.String(), "access_token")
+	})
+
+	t.Run("Unknown Provider", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/auth/oidc/other/login", nil))
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestOIDCListProviders(t *testing.T) {
+	router, _, _ := setupOIDCRouter(t)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/auth/oidc/providers", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"providers":["mock"]}`, w.Body.String())
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"quizlet/internal/models/user"
+	"quizlet/internal/service"
+)
+
+type PersonalAccessTokenHandler struct {
+	tokenService service.PersonalAccessTokenService
+}
+
+func NewPersonalAccessTokenHandler(tokenService service.PersonalAccessTokenService) *PersonalAccessTokenHandler {
+	return &PersonalAccessTokenHandler{
+		tokenService: tokenService,
+	}
+}
+
+// @Summary Create a personal access token
+// @Description Create a named, scoped and expiring token for API automation. The token is only returned in this response.
+// @Tags tokens
+// @Accept json
+// @Produce json
+// @Param token body user.CreatePersonalAccessTokenRequest true "Token name, scopes and lifetime"
+// @Success 201 {object} user.CreatePersonalAccessTokenResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /users/me/tokens [post]
+func (h *PersonalAccessTokenHandler) CreateToken(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req user.CreatePersonalAccessTokenRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	token, plaintext, err := h.tokenService.CreateToken(c.Request.Context(), userID, req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, user.CreatePersonalAccessTokenResponse{
+		PersonalAccessToken: *token,
+		Token:               plaintext,
+	})
+}
+
+// @Summary List personal access tokens
+// @Description List the current user's personal access tokens, including revoked and expired ones
+// @Tags tokens
+// @Produce json
+// @Success 200 {array} user.PersonalAccessToken
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /users/me/tokens [get]
+func (h *PersonalAccessTokenHandler) ListTokens(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	tokens, err := h.tokenService.ListTokens(c.Request.Context(), userID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	if tokens == nil {
+		tokens = []*user.PersonalAccessToken{}
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// @Summary Revoke a personal access token
+// @Description Revoke one of the current user's personal access tokens
+// @Tags tokens
+// @Produce json
+// @Param id path int true "Token ID"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /users/me/tokens/{id} [delete]
+func (h *PersonalAccessTokenHandler) RevokeToken(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.tokenService.RevokeToken(c.Request.Context(), userID, id); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "personal access token revoked"})
+}
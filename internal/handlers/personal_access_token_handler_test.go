@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"quizlet/internal/auth"
+	"quizlet/internal/models/user"
+	"quizlet/internal/service"
+)
+
+type MockPersonalAccessTokenService struct {
+	mock.Mock
+}
+
+var _ service.PersonalAccessTokenService = (*MockPersonalAccessTokenService)(nil)
+
+func (m *MockPersonalAccessTokenService) CreateToken(ctx context.Context, userID uint, req user.CreatePersonalAccessTokenRequest) (*user.PersonalAccessToken, string, error) {
+	args := m.Called(ctx, userID, req)
+	if args.Get(0) == nil {
+		return nil, "", args.Error(2)
+	}
+	return args.Get(0).(*user.PersonalAccessToken), args.String(1), args.Error(2)
+}
+
+func (m *MockPersonalAccessTokenService) ListTokens(ctx context.Context, userID uint) ([]*user.PersonalAccessToken, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*user.PersonalAccessToken), args.Error(1)
+}
+
+func (m *MockPersonalAccessTokenService) RevokeToken(ctx context.Context, userID, id uint) error {
+	args := m.Called(ctx, userID, id)
+	return args.Error(0)
+}
+
+func (m *MockPersonalAccessTokenService) ValidatePersonalAccessToken(ctx context.Context, token string) (*auth.Principal, error) {
+	args := m.Called(ctx, token)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*auth.Principal), args.Error(1)
+}
+
+func TestCreatePersonalAccessToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := new(MockPersonalAccessTokenService)
+	handler := NewPersonalAccessTokenHandler(mockService)
+
+	expiresAt := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name           string
+		requestBody    map[string]interface{}
+		mockSetup      func()
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name: "Success",
+			requestBody: map[string]interface{}{
+				"name":   "pipeline",
+				"scopes": []string{"quizzes:read"},
+			},
+			mockSetup: func() {
+				mockService.On("CreateToken", mock.Anything, uint(1), user.CreatePersonalAccessTokenRequest{
+					Name:   "pipeline",
+					Scopes: []string{"quizzes:read"},
+				}).Return(&user.PersonalAccessToken{
+					ID:          3,
+					UserID:      1,
+					Name:        "pipeline",
+					TokenPrefix: "qlt_abcd",
+					Scopes:      user.ScopeList{"quizzes:read"},
+					ExpiresAt:   expiresAt,
+				}, "qlt_abcdefgh", nil).Once()
+			},
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `{"id":3,"created_at":"0001-01-01T00:00:00Z","updated_at":"0001-01-01T00:00:00Z","user_id":1,"name":"pipeline","token_prefix":"qlt_abcd","scopes":["quizzes:read"],"expires_at":"2030-01-01T00:00:00Z","token":"qlt_abcdefgh"}`,
+		},
+		{
+			name: "Invalid Scope",
+			requestBody: map[string]interface{}{
+				"name":   "pipeline",
+				"scopes": []string{"admin"},
+			},
+			mockSetup: func() {
+				mockService.On("CreateToken", mock.Anything, uint(1), mock.Anything).
+					Return(nil, "", service.ErrInvalidScope.WithMessage("invalid scope: admin")).Once()
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"type":"urn:quizlet:problem:invalid_scope","title":"Bad Request","status":400,"detail":"invalid scope: admin","instance":"/users/me/tokens","code":"invalid_scope"}`,
+		},
+		{
+			name: "Expiry Too Long",
+			requestBody: map[string]interface{}{
+				"name":            "pipeline",
+				"scopes":          []string{"quizzes:read"},
+				"expires_in_days": 1000,
+			},
+			mockSetup:      func() {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"type":"urn:quizlet:problem:validation_failed","title":"Bad Request","status":400,"detail":"request validation failed","instance":"/users/me/tokens","code":"validation_failed","errors":[{"field":"expires_in_days","code":"max","message":"must be at most 365"}]}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+
+			body, _ := json.Marshal(tc.requestBody)
+			c.Request = httptest.NewRequest(http.MethodPost, "/users/me/tokens", bytes.NewBuffer(body))
+			c.Request.Header.Set("Content-Type", "application/json")
+			auth.SetPrincipal(c, &auth.Principal{UserID: 1, Method: auth.AuthMethodBearer})
+
+			tc.mockSetup()
+
+			serve(c, handler.CreateToken)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			assert.JSONEq(t, tc.expectedBody, w.Body.String())
+		})
+	}
+
+	mockService.AssertExpectations(t)
+}
+
+func TestRevokePersonalAccessToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := new(MockPersonalAccessTokenService)
+	handler := NewPersonalAccessTokenHandler(mockService)
+
+	testCases := []struct {
+		name           string
+		tokenID        string
+		mockSetup      func()
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:    "Success",
+			tokenID: "3",
+			mockSetup: func() {
+				mockService.On("RevokeToken", mock.Anything, uint(1), uint(3)).Return(nil).Once()
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"message":"personal access token revoked"}`,
+		},
+		{
+			name:    "Not Found",
+			tokenID: "4",
+			mockSetup: func() {
+				mockService.On("RevokeToken", mock.Anything, uint(1), uint(4)).Return(service.ErrPersonalAccessTokenNotFound).Once()
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `{"type":"urn:quizlet:problem:personal_access_token_not_found","title":"Not Found","status":404,"detail":"personal access token not found","instance":"/users/me/tokens/4","code":"personal_access_token_not_found"}`,
+		},
+		{
+			name:           "Invalid ID",
+			tokenID:        "abc",
+			mockSetup:      func() {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"type":"urn:quizlet:problem:validation_failed","title":"Bad Request","status":400,"detail":"request validation failed","instance":"/users/me/tokens/abc","code":"validation_failed","errors":[{"field":"id","code":"id","message":"must be a positive integer"}]}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+
+			c.Request = httptest.NewRequest(http.MethodDelete, "/users/me/tokens/"+tc.tokenID, nil)
+			c.Params = []gin.Param{{Key: "id", Value: tc.tokenID}}
+			auth.SetPrincipal(c, &auth.Principal{UserID: 1, Method: auth.AuthMethodBearer})
+
+			tc.mockSetup()
+
+			serve(c, handler.RevokeToken)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			assert.JSONEq(t, tc.expectedBody, w.Body.String())
+		})
+	}
+
+	mockService.AssertExpectations(t)
+}
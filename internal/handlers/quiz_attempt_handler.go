@@ -1,9 +1,7 @@
 package handlers
 
 import (
-	"errors"
 	"net/http"
-	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"quizlet/internal/models/quiz_attempt"
@@ -20,21 +18,6 @@ func NewQuizAttemptHandler(quizAttemptService service.QuizAttemptService) *QuizA
 	}
 }
 
-// getUserIDFromContext extracts and validates the user ID from the context
-func (h *QuizAttemptHandler) getUserIDFromContext(c *gin.Context) (int64, error) {
-	userID, exists := c.Get("userID")
-	if !exists {
-		return 0, errors.New("unauthorized")
-	}
-	
-	uid, ok := userID.(int64)
-	if !ok {
-		return 0, errors.New("unauthorized")
-	}
-	
-	return uid, nil
-}
-
 // ListQuizAttempts godoc
 // @Summary List quiz attempts for a quiz suite
 // @Description Get all quiz attempts for a specific quiz suite that belong to the authenticated user
@@ -49,21 +32,19 @@ func (h *QuizAttemptHandler) getUserIDFromContext(c *gin.Context) (int64, error)
 // @Failure 500 {object} ErrorResponse
 // @Router /quiz-suites/{id}/attempts [get]
 func (h *QuizAttemptHandler) ListQuizAttempts(c *gin.Context) {
-	userID, err := h.getUserIDFromContext(c)
-	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	userID, ok := currentUserID(c)
+	if !ok {
 		return
 	}
 
-	quizSuiteID, err := strconv.ParseInt(c.Param("id"), 10, 64)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid quiz suite id"})
+	quizSuiteID, ok := paramID(c, "id")
+	if !ok {
 		return
 	}
 
 	attempts, err := h.quizAttemptService.ListByQuizSuite(c.Request.Context(), quizSuiteID, userID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
@@ -85,27 +66,24 @@ func (h *QuizAttemptHandler) ListQuizAttempts(c *gin.Context) {
 // @Failure 500 {object} ErrorResponse
 // @Router /quiz-suites/{id}/attempts [post]
 func (h *QuizAttemptHandler) CreateQuizAttempt(c *gin.Context) {
-	userID, err := h.getUserIDFromContext(c)
-	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	userID, ok := currentUserID(c)
+	if !ok {
 		return
 	}
 
-	quizSuiteID, err := strconv.ParseInt(c.Param("id"), 10, 64)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid quiz suite id"})
+	quizSuiteID, ok := paramID(c, "id")
+	if !ok {
 		return
 	}
 
 	var req quiz_attempt.CreateQuizAttemptRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(c, &req) {
 		return
 	}
 
 	attempt, err := h.quizAttemptService.Create(c.Request.Context(), quizSuiteID, userID, req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
@@ -128,25 +106,19 @@ func (h *QuizAttemptHandler) CreateQuizAttempt(c *gin.Context) {
 // @Failure 500 {object} ErrorResponse
 // @Router /quiz-suites/{id}/attempts/{attemptId} [get]
 func (h *QuizAttemptHandler) GetQuizAttempt(c *gin.Context) {
-	userID, err := h.getUserIDFromContext(c)
-	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	userID, ok := currentUserID(c)
+	if !ok {
 		return
 	}
 
-	attemptID, err := strconv.ParseInt(c.Param("attemptId"), 10, 64)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid attempt id"})
+	attemptID, ok := paramID(c, "attemptId")
+	if !ok {
 		return
 	}
 
 	attempt, err := h.quizAttemptService.Get(c.Request.Context(), attemptID, userID)
 	if err != nil {
-		if err == service.ErrQuizAttemptNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
@@ -170,31 +142,24 @@ func (h *QuizAttemptHandler) GetQuizAttempt(c *gin.Context) {
 // @Failure 500 {object} ErrorResponse
 // @Router /quiz-suites/{id}/attempts/{attemptId} [put]
 func (h *QuizAttemptHandler) UpdateQuizAttempt(c *gin.Context) {
-	userID, err := h.getUserIDFromContext(c)
-	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	userID, ok := currentUserID(c)
+	if !ok {
 		return
 	}
 
-	attemptID, err := strconv.ParseInt(c.Param("attemptId"), 10, 64)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid attempt id"})
+	attemptID, ok := paramID(c, "attemptId")
+	if !ok {
 		return
 	}
 
 	var req quiz_attempt.UpdateQuizAttemptRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(c, &req) {
 		return
 	}
 
 	attempt, err := h.quizAttemptService.Update(c.Request.Context(), attemptID, userID, req)
 	if err != nil {
-		if err == service.ErrQuizAttemptNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
@@ -217,27 +182,63 @@ func (h *QuizAttemptHandler) UpdateQuizAttempt(c *gin.Context) {
 // @Failure 500 {object} ErrorResponse
 // @Router /quiz-suites/{id}/attempts/{attemptId} [delete]
 func (h *QuizAttemptHandler) DeleteQuizAttempt(c *gin.Context) {
-	userID, err := h.getUserIDFromContext(c)
-	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	userID, ok := currentUserID(c)
+	if !ok {
 		return
 	}
 
-	attemptID, err := strconv.ParseInt(c.Param("attemptId"), 10, 64)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid attempt id"})
+	attemptID, ok := paramID(c, "attemptId")
+	if !ok {
 		return
 	}
 
-	err = h.quizAttemptService.Delete(c.Request.Context(), attemptID, userID)
+	err := h.quizAttemptService.Delete(c.Request.Context(), attemptID, userID)
 	if err != nil {
-		if err == service.ErrQuizAttemptNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
 	c.Status(http.StatusNoContent)
-} 
\ No newline at end of file
+} 
+// SubmitQuizAttemptAnswer godoc
+// @Summary Answer a quiz of a quiz attempt
+// @Description Grade the selections chosen for one quiz against the snapshot of the quiz suite taken when the attempt started, and update the score of the attempt. Each quiz is answered once per attempt.
+// @Tags quiz-attempts
+// @Accept json
+// @Produce json
+// @Param id path int true "Quiz Suite ID"
+// @Param attemptId path int true "Quiz Attempt ID"
+// @Param request body quiz_attempt.SubmitAnswerRequest true "Answer"
+// @Security BearerAuth
+// @Success 200 {object} quiz_attempt.QuizAttempt
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /quiz-suites/{id}/attempts/{attemptId}/answers [post]
+func (h *QuizAttemptHandler) SubmitQuizAttemptAnswer(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	attemptID, ok := paramID(c, "attemptId")
+	if !ok {
+		return
+	}
+
+	var req quiz_attempt.SubmitAnswerRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	attempt, err := h.quizAttemptService.SubmitAnswer(c.Request.Context(), attemptID, userID, req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, attempt)
+}
@@ -4,16 +4,24 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"quizlet/internal/auth"
+	"quizlet/internal/models/quiz"
 	"quizlet/internal/models/quiz_attempt"
+	"quizlet/internal/models/quiz_suite"
+	"quizlet/internal/models/user"
+	"quizlet/internal/repository/memory"
 	"quizlet/internal/service"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"quizlet/internal/problem"
 )
 
 // MockQuizAttemptService is a mock implementation of the QuizAttemptService interface
@@ -24,7 +32,7 @@ type MockQuizAttemptService struct {
 // Ensure MockQuizAttemptService implements the QuizAttemptService interface
 var _ service.QuizAttemptService = (*MockQuizAttemptService)(nil)
 
-func (m *MockQuizAttemptService) ListByQuizSuite(ctx context.Context, quizSuiteID, userID int64) ([]quiz_attempt.QuizAttempt, error) {
+func (m *MockQuizAttemptService) ListByQuizSuite(ctx context.Context, quizSuiteID, userID uint) ([]quiz_attempt.QuizAttempt, error) {
 	args := m.Called(ctx, quizSuiteID, userID)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -32,7 +40,7 @@ func (m *MockQuizAttemptService) ListByQuizSuite(ctx context.Context, quizSuiteI
 	return args.Get(0).([]quiz_attempt.QuizAttempt), args.Error(1)
 }
 
-func (m *MockQuizAttemptService) Create(ctx context.Context, quizSuiteID, userID int64, req quiz_attempt.CreateQuizAttemptRequest) (*quiz_attempt.QuizAttempt, error) {
+func (m *MockQuizAttemptService) Create(ctx context.Context, quizSuiteID, userID uint, req quiz_attempt.CreateQuizAttemptRequest) (*quiz_attempt.QuizAttempt, error) {
 	args := m.Called(ctx, quizSuiteID, userID, req)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -40,7 +48,7 @@ func (m *MockQuizAttemptService) Create(ctx context.Context, quizSuiteID, userID
 	return args.Get(0).(*quiz_attempt.QuizAttempt), args.Error(1)
 }
 
-func (m *MockQuizAttemptService) Get(ctx context.Context, id, userID int64) (*quiz_attempt.QuizAttempt, error) {
+func (m *MockQuizAttemptService) Get(ctx context.Context, id, userID uint) (*quiz_attempt.QuizAttempt, error) {
 	args := m.Called(ctx, id, userID)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -48,7 +56,7 @@ func (m *MockQuizAttemptService) Get(ctx context.Context, id, userID int64) (*qu
 	return args.Get(0).(*quiz_attempt.QuizAttempt), args.Error(1)
 }
 
-func (m *MockQuizAttemptService) Update(ctx context.Context, id, userID int64, req quiz_attempt.UpdateQuizAttemptRequest) (*quiz_attempt.QuizAttempt, error) {
+func (m *MockQuizAttemptService) Update(ctx context.Context, id, userID uint, req quiz_attempt.UpdateQuizAttemptRequest) (*quiz_attempt.QuizAttempt, error) {
 	args := m.Called(ctx, id, userID, req)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -56,14 +64,23 @@ func (m *MockQuizAttemptService) Update(ctx context.Context, id, userID int64, r
 	return args.Get(0).(*quiz_attempt.QuizAttempt), args.Error(1)
 }
 
-func (m *MockQuizAttemptService) Delete(ctx context.Context, id, userID int64) error {
+func (m *MockQuizAttemptService) Delete(ctx context.Context, id, userID uint) error {
 	args := m.Called(ctx, id, userID)
 	return args.Error(0)
 }
 
+func (m *MockQuizAttemptService) SubmitAnswer(ctx context.Context, id, userID uint, req quiz_attempt.SubmitAnswerRequest) (*quiz_attempt.QuizAttempt, error) {
+	args := m.Called(ctx, id, userID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*quiz_attempt.QuizAttempt), args.Error(1)
+}
+
 func setupTestRouter() (*gin.Engine, *MockQuizAttemptService) {
 	gin.SetMode(gin.TestMode)
 	router := gin.New()
+	router.Use(problem.Middleware())
 	router.Use(gin.Recovery())
 
 	mockService := new(MockQuizAttemptService)
@@ -84,7 +101,7 @@ func TestListQuizAttempts(t *testing.T) {
 	handler := NewQuizAttemptHandler(mockService)
 	
 	router.GET("/quiz-suites/:id/attempts", func(c *gin.Context) {
-		c.Set("userID", int64(1))
+		auth.SetPrincipal(c, &auth.Principal{UserID: 1, Method: auth.AuthMethodBearer})
 		handler.ListQuizAttempts(c)
 	})
 
@@ -111,7 +128,7 @@ func TestListQuizAttempts(t *testing.T) {
 						CompletedAt: &now,
 					},
 				}
-				mockService.On("ListByQuizSuite", mock.Anything, int64(1), int64(1)).Return(attempts, nil)
+				mockService.On("ListByQuizSuite", mock.Anything, uint(1), uint(1)).Return(attempts, nil)
 			},
 			expectedStatus: http.StatusOK,
 			expectedBody:   `[{"id":1,"user_id":1,"quiz_suite_id":1,"score":80,"completed":true,"started_at":"` + formatTimeForTest(time.Now()) + `","completed_at":"` + formatTimeForTest(time.Now()) + `"}]`,
@@ -121,7 +138,7 @@ func TestListQuizAttempts(t *testing.T) {
 			quizSuiteID: "invalid",
 			setupMock:   func() {},
 			expectedStatus: http.StatusBadRequest,
-			expectedBody:   `{"error":"invalid quiz suite id"}`,
+			expectedBody:   `{"type":"urn:quizlet:problem:validation_failed","title":"Bad Request","status":400,"detail":"request validation failed","instance":"/quiz-suites/invalid/attempts","code":"validation_failed","errors":[{"field":"id","code":"id","message":"must be a positive integer"}]}`,
 		},
 	}
 
@@ -172,7 +189,7 @@ func TestCreateQuizAttempt(t *testing.T) {
 	handler := NewQuizAttemptHandler(mockService)
 	
 	router.POST("/quiz-suites/:id/attempts", func(c *gin.Context) {
-		c.Set("userID", int64(1))
+		auth.SetPrincipal(c, &auth.Principal{UserID: 1, Method: auth.AuthMethodBearer})
 		handler.CreateQuizAttempt(c)
 	})
 
@@ -199,8 +216,7 @@ func TestCreateQuizAttempt(t *testing.T) {
 					StartedAt:   now,
 					CompletedAt: &now,
 				}
-				mockService.On("Create", mock.Anything, int64(1), int64(1), quiz_attempt.CreateQuizAttemptRequest{
-					Score:     80,
+				mockService.On("Create", mock.Anything, uint(1), uint(1), quiz_attempt.CreateQuizAttemptRequest{
 					Completed: true,
 				}).Return(attempt, nil)
 			},
@@ -210,10 +226,10 @@ func TestCreateQuizAttempt(t *testing.T) {
 		{
 			name:           "Invalid Request Body",
 			quizSuiteID:    "1",
-			requestBody:    `{"score":"invalid"}`,
+			requestBody:    `{"completed":"invalid"}`,
 			setupMock:      func() {},
 			expectedStatus: http.StatusBadRequest,
-			expectedBody:   `{"error":"json: cannot unmarshal string into Go struct field CreateQuizAttemptRequest.score of type int"}`,
+			expectedBody:   `{"type":"urn:quizlet:problem:validation_failed","title":"Bad Request","status":400,"detail":"request validation failed","instance":"/quiz-suites/1/attempts","code":"validation_failed","errors":[{"field":"completed","code":"type","message":"must be a boolean"}]}`,
 		},
 	}
 
@@ -262,7 +278,7 @@ func TestGetQuizAttempt(t *testing.T) {
 	handler := NewQuizAttemptHandler(mockService)
 	
 	router.GET("/quiz-suites/:id/attempts/:attemptId", func(c *gin.Context) {
-		c.Set("userID", int64(1))
+		auth.SetPrincipal(c, &auth.Principal{UserID: 1, Method: auth.AuthMethodBearer})
 		handler.GetQuizAttempt(c)
 	})
 
@@ -289,7 +305,7 @@ func TestGetQuizAttempt(t *testing.T) {
 					StartedAt:   now,
 					CompletedAt: &now,
 				}
-				mockService.On("Get", mock.Anything, int64(1), int64(1)).Return(attempt, nil).Once()
+				mockService.On("Get", mock.Anything, uint(1), uint(1)).Return(attempt, nil).Once()
 			},
 			expectedStatus: http.StatusOK,
 			expectedBody:   `{"id":1,"user_id":1,"quiz_suite_id":1,"score":80,"completed":true,"started_at":"` + formatTimeForTest(time.Now()) + `","completed_at":"` + formatTimeForTest(time.Now()) + `"}`,
@@ -299,10 +315,10 @@ func TestGetQuizAttempt(t *testing.T) {
 			quizSuiteID:    "1",
 			attemptID:      "1",
 			setupMock:      func() {
-				mockService.On("Get", mock.Anything, int64(1), int64(1)).Return(nil, service.ErrQuizAttemptNotFound).Once()
+				mockService.On("Get", mock.Anything, uint(1), uint(1)).Return(nil, service.ErrQuizAttemptNotFound).Once()
 			},
 			expectedStatus: http.StatusNotFound,
-			expectedBody:   `{"error":"quiz attempt not found"}`,
+			expectedBody:   `{"type":"urn:quizlet:problem:quiz_attempt_not_found","title":"Not Found","status":404,"detail":"quiz attempt not found","instance":"/quiz-suites/1/attempts/1","code":"quiz_attempt_not_found"}`,
 		},
 	}
 
@@ -350,7 +366,7 @@ func TestUpdateQuizAttempt(t *testing.T) {
 	handler := NewQuizAttemptHandler(mockService)
 	
 	router.PUT("/quiz-suites/:id/attempts/:attemptId", func(c *gin.Context) {
-		c.Set("userID", int64(1))
+		auth.SetPrincipal(c, &auth.Principal{UserID: 1, Method: auth.AuthMethodBearer})
 		handler.UpdateQuizAttempt(c)
 	})
 
@@ -381,7 +397,7 @@ func TestUpdateQuizAttempt(t *testing.T) {
 				}
 				score := 90
 				completed := true
-				mockService.On("Update", mock.Anything, int64(1), int64(1), quiz_attempt.UpdateQuizAttemptRequest{
+				mockService.On("Update", mock.Anything, uint(1), uint(1), quiz_attempt.UpdateQuizAttemptRequest{
 					Score:     &score,
 					Completed: &completed,
 				}).Return(attempt, nil).Once()
@@ -397,13 +413,13 @@ func TestUpdateQuizAttempt(t *testing.T) {
 			setupMock:      func() {
 				score := 90
 				completed := true
-				mockService.On("Update", mock.Anything, int64(1), int64(1), quiz_attempt.UpdateQuizAttemptRequest{
+				mockService.On("Update", mock.Anything, uint(1), uint(1), quiz_attempt.UpdateQuizAttemptRequest{
 					Score:     &score,
 					Completed: &completed,
 				}).Return(nil, service.ErrQuizAttemptNotFound).Once()
 			},
 			expectedStatus: http.StatusNotFound,
-			expectedBody:   `{"error":"quiz attempt not found"}`,
+			expectedBody:   `{"type":"urn:quizlet:problem:quiz_attempt_not_found","title":"Not Found","status":404,"detail":"quiz attempt not found","instance":"/quiz-suites/1/attempts/1","code":"quiz_attempt_not_found"}`,
 		},
 	}
 
@@ -452,7 +468,7 @@ func TestDeleteQuizAttempt(t *testing.T) {
 	handler := NewQuizAttemptHandler(mockService)
 	
 	router.DELETE("/quiz-suites/:id/attempts/:attemptId", func(c *gin.Context) {
-		c.Set("userID", int64(1))
+		auth.SetPrincipal(c, &auth.Principal{UserID: 1, Method: auth.AuthMethodBearer})
 		handler.DeleteQuizAttempt(c)
 	})
 
@@ -468,7 +484,7 @@ func TestDeleteQuizAttempt(t *testing.T) {
 			name:           "Success",
 			quizSuiteID:    "1",
 			attemptID:      "1",
-			setupMock:      func() { mockService.On("Delete", mock.Anything, int64(1), int64(1)).Return(nil).Once() },
+			setupMock:      func() { mockService.On("Delete", mock.Anything, uint(1), uint(1)).Return(nil).Once() },
 			expectedStatus: http.StatusNoContent,
 			expectedBody:   "",
 		},
@@ -476,9 +492,9 @@ func TestDeleteQuizAttempt(t *testing.T) {
 			name:           "Not Found",
 			quizSuiteID:    "1",
 			attemptID:      "1",
-			setupMock:      func() { mockService.On("Delete", mock.Anything, int64(1), int64(1)).Return(service.ErrQuizAttemptNotFound).Once() },
+			setupMock:      func() { mockService.On("Delete", mock.Anything, uint(1), uint(1)).Return(service.ErrQuizAttemptNotFound).Once() },
 			expectedStatus: http.StatusNotFound,
-			expectedBody:   `{"error":"quiz attempt not found"}`,
+			expectedBody:   `{"type":"urn:quizlet:problem:quiz_attempt_not_found","title":"Not Found","status":404,"detail":"quiz attempt not found","instance":"/quiz-suites/1/attempts/1","code":"quiz_attempt_not_found"}`,
 		},
 	}
 
@@ -497,4 +513,147 @@ func TestDeleteQuizAttempt(t *testing.T) {
 			}
 		})
 	}
-} 
\ No newline at end of file
+} 
+// TestQuizAttemptsBehindAuthMiddleware checks the handler reads the user the
+// real middleware stores, not only the principal set up by the tests above
+func TestQuizAttemptsBehindAuthMiddleware(t *testing.T) {
+	router, mockService := setupTestRouter()
+	handler := NewQuizAttemptHandler(mockService)
+	router.GET("/quiz-suites/:id/attempts", auth.AuthMiddleware(nil, auth.DefaultTokenConfig()), handler.ListQuizAttempts)
+
+	mockService.On("ListByQuizSuite", mock.Anything, uint(3), uint(7)).Return([]quiz_attempt.QuizAttempt{}, nil).Once()
+
+	token, err := auth.DefaultTokenConfig().GenerateAccessToken(7, "1")
+	assert.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/quiz-suites/3/attempts", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `[]`, w.Body.String())
+	mockService.AssertExpectations(t)
+}
+
+func TestSubmitQuizAttemptAnswer(t *testing.T) {
+	router, mockService := setupTestRouter()
+	handler := NewQuizAttemptHandler(mockService)
+
+	router.POST("/quiz-suites/:id/attempts/:attemptId/answers", func(c *gin.Context) {
+		auth.SetPrincipal(c, &auth.Principal{UserID: 1, Method: auth.AuthMethodBearer})
+		handler.SubmitQuizAttemptAnswer(c)
+	})
+
+	tests := []struct {
+		name           string
+		body           string
+		setupMock      func()
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name: "Success",
+			body: `{"quiz_id":3,"selection_ids":[5]}`,
+			setupMock: func() {
+				mockService.On("SubmitAnswer", mock.Anything, uint(2), uint(1), quiz_attempt.SubmitAnswerRequest{QuizID: 3, SelectionIDs: []uint{5}}).
+					Return(&quiz_attempt.QuizAttempt{ID: 2, UserID: 1, QuizSuiteID: 1, Score: 50}, nil).Once()
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "No Selections",
+			body:           `{"quiz_id":3,"selection_ids":[]}`,
+			setupMock:      func() {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"type":"urn:quizlet:problem:validation_failed","title":"Bad Request","status":400,"detail":"request validation failed","instance":"/quiz-suites/1/attempts/2/answers","code":"validation_failed","errors":[{"field":"selection_ids","code":"min","message":"must be at least 1 items"}]}`,
+		},
+		{
+			name: "Completed",
+			body: `{"quiz_id":3,"selection_ids":[5]}`,
+			setupMock: func() {
+				mockService.On("SubmitAnswer", mock.Anything, uint(2), uint(1), quiz_attempt.SubmitAnswerRequest{QuizID: 3, SelectionIDs: []uint{5}}).
+					Return(nil, service.ErrQuizAttemptCompleted).Once()
+			},
+			expectedStatus: http.StatusConflict,
+			expectedBody:   `{"type":"urn:quizlet:problem:quiz_attempt_completed","title":"Conflict","status":409,"detail":"the quiz attempt is already completed","instance":"/quiz-suites/1/attempts/2/answers","code":"quiz_attempt_completed"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setupMock()
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/quiz-suites/1/attempts/2/answers", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedBody != "" {
+				assert.JSONEq(t, tt.expectedBody, w.Body.String())
+			}
+		})
+	}
+
+	mockService.AssertExpectations(t)
+}
+
+// TestQuizAttemptAnsweredOverHTTP runs an attempt through the handlers and the
+// real services: started incomplete, answered, and graded against its snapshot
+func TestQuizAttemptAnsweredOverHTTP(t *testing.T) {
+	ctx := context.Background()
+	store := memory.New()
+	uow := memory.NewUnitOfWork(store)
+	quizzes := service.NewQuizService(memory.NewQuizRepository(store), memory.NewQuizRevisionRepository(store), uow)
+	quizSuites := service.NewQuizSuiteService(memory.NewQuizSuiteRepository(store), uow)
+	handler := NewQuizAttemptHandler(service.NewQuizAttemptService(memory.NewQuizAttemptRepository(store), uow))
+
+	ann := &user.User{Username: "ann", Email: "ann@example.com", Password: "hash"}
+	require.NoError(t, memory.NewUserRepository(store).Create(ctx, ann))
+	q := &quiz.Quiz{Question: "What is 2 + 2?", QuizType: quiz.QuizTypeSingleChoice, CreatedByID: ann.ID, Selections: []quiz.QuizSelection{
+		{SelectionText: "4", IsCorrect: true},
+		{SelectionText: "5"},
+	}}
+	require.NoError(t, quizzes.CreateQuiz(ctx, q))
+	qs := &quiz_suite.QuizSuite{Title: "Arithmetic", CreatedByID: ann.ID}
+	require.NoError(t, quizSuites.CreateQuizSuite(ctx, qs))
+	require.NoError(t, quizSuites.AddQuizToSuite(ctx, qs.ID, q.ID))
+
+	router, _ := setupTestRouter()
+	attempts := router.Group("/quiz-suites/:id/attempts", func(c *gin.Context) {
+		auth.SetPrincipal(c, &auth.Principal{UserID: ann.ID, Method: auth.AuthMethodBearer})
+	})
+	attempts.POST("", handler.CreateQuizAttempt)
+	attempts.POST("/:attemptId/answers", handler.SubmitQuizAttemptAnswer)
+
+	send := func(path, body string) (int, quiz_attempt.QuizAttempt) {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		var attempt quiz_attempt.QuizAttempt
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &attempt), w.Body.String())
+		return w.Code, attempt
+	}
+
+	status, attempt := send(fmt.Sprintf("/quiz-suites/%d/attempts", qs.ID), `{"completed":false}`)
+	require.Equal(t, http.StatusCreated, status)
+	assert.False(t, attempt.Completed)
+	require.NotNil(t, attempt.Snapshot)
+	require.Len(t, attempt.Snapshot.Quizzes, 1)
+	// The answer key is withheld while the attempt is in progress
+	for _, selection := range attempt.Snapshot.Quizzes[0].Selections {
+		assert.Nil(t, selection.IsCorrect)
+	}
+
+	// The author replaces the correct selection while the attempt is running
+	four := q.Selections[0].ID
+	require.NoError(t, quizzes.RemoveSelection(ctx, q.ID, four))
+	require.NoError(t, quizzes.AddSelection(ctx, q.ID, quiz.QuizSelection{SelectionText: "four", IsCorrect: true}))
+
+	status, graded := send(fmt.Sprintf("/quiz-suites/%d/attempts/%d/answers", qs.ID, attempt.ID), fmt.Sprintf(`{"quiz_id":%d,"selection_ids":[%d]}`, q.ID, four))
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, 100, graded.Score)
+	require.Len(t, graded.Answers, 1)
+	assert.True(t, graded.Answers[0].IsCorrect)
+}
@@ -4,7 +4,6 @@ import (
 	"net/http"
 	"quizlet/internal/models/quiz"
 	"quizlet/internal/service"
-	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
@@ -26,31 +25,31 @@ func NewQuizHandler(quizService service.QuizService) *QuizHandler {
 // @Produce json
 // @Param quiz body quiz.Quiz true "Quiz information"
 // @Success 201 {object} quiz.Quiz
-// @Failure 400 {object} map[string]string
-// @Failure 401 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Header 201 {string} ETag "Version of the quiz"
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
 // @Security BearerAuth
 // @Router /quizzes [post]
 func (h *QuizHandler) CreateQuiz(c *gin.Context) {
 	var q quiz.Quiz
-	if err := c.ShouldBindJSON(&q); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(c, &q) {
 		return
 	}
 
 	// Get user ID from context (assuming you have middleware that sets this)
-	userID, exists := c.Get("userID")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+	userID, ok := currentUserID(c)
+	if !ok {
 		return
 	}
-	q.CreatedByID = userID.(uint)
+	q.CreatedByID = userID
 
-	if err := h.quizService.CreateQuiz(&q); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.quizService.CreateQuiz(c.Request.Context(), &q); err != nil {
+		c.Error(err)
 		return
 	}
 
+	c.Header("ETag", quizETag(&q))
 	c.JSON(http.StatusCreated, q)
 }
 
@@ -59,24 +58,29 @@ func (h *QuizHandler) CreateQuiz(c *gin.Context) {
 // @Tags quizzes
 // @Produce json
 // @Param id path int true "Quiz ID"
+// @Param If-None-Match header string false "ETag of a cached copy"
 // @Success 200 {object} quiz.Quiz
-// @Failure 400 {object} map[string]string
-// @Failure 404 {object} map[string]string
+// @Header 200 {string} ETag "Version of the quiz"
+// @Success 304 "Not Modified"
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
 // @Security BearerAuth
 // @Router /quizzes/{id} [get]
 func (h *QuizHandler) GetQuiz(c *gin.Context) {
-	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid quiz id"})
+	id, ok := paramID(c, "id")
+	if !ok {
 		return
 	}
 
-	quiz, err := h.quizService.GetQuizByID(uint(id))
+	quiz, err := h.quizService.GetQuizByID(c.Request.Context(), id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "quiz not found"})
+		c.Error(err)
 		return
 	}
 
+	if notModified(c, quizETag(quiz)) {
+		return
+	}
 	c.JSON(http.StatusOK, quiz)
 }
 
@@ -85,20 +89,19 @@ func (h *QuizHandler) GetQuiz(c *gin.Context) {
 // @Tags quizzes
 // @Produce json
 // @Success 200 {array} quiz.Quiz
-// @Failure 401 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
 // @Security BearerAuth
 // @Router /quizzes/user [get]
 func (h *QuizHandler) GetUserQuizzes(c *gin.Context) {
-	userID, exists := c.Get("userID")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+	userID, ok := currentUserID(c)
+	if !ok {
 		return
 	}
 
-	quizzes, err := h.quizService.GetQuizzesByUserID(userID.(uint))
+	quizzes, err := h.quizService.GetQuizzesByUserID(c.Request.Context(), userID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
@@ -111,31 +114,42 @@ func (h *QuizHandler) GetUserQuizzes(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param id path int true "Quiz ID"
+// @Param If-Match header string false "ETag the update is based on"
 // @Param quiz body quiz.Quiz true "Quiz information"
 // @Success 200 {object} quiz.Quiz
-// @Failure 400 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Header 200 {string} ETag "Version of the quiz"
+// @Failure 400 {object} ErrorResponse
+// @Failure 412 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
 // @Security BearerAuth
 // @Router /quizzes/{id} [put]
 func (h *QuizHandler) UpdateQuiz(c *gin.Context) {
-	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid quiz id"})
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+
+	version, ok := ifMatchVersion(c)
+	if !ok {
 		return
 	}
 
 	var quiz quiz.Quiz
-	if err := c.ShouldBindJSON(&quiz); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(c, &quiz) {
 		return
 	}
 
-	quiz.ID = uint(id)
-	if err := h.quizService.UpdateQuiz(&quiz); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	quiz.ID = id
+	// If-Match takes precedence over a version in the body
+	if version != 0 {
+		quiz.Version = version
+	}
+	if err := h.quizService.UpdateQuiz(c.Request.Context(), &quiz); err != nil {
+		c.Error(err)
 		return
 	}
 
+	c.Header("ETag", quizETag(&quiz))
 	c.JSON(http.StatusOK, quiz)
 }
 
@@ -144,20 +158,26 @@ func (h *QuizHandler) UpdateQuiz(c *gin.Context) {
 // @Tags quizzes
 // @Produce json
 // @Param id path int true "Quiz ID"
+// @Param If-Match header string false "ETag the deletion is based on"
 // @Success 200 {object} map[string]string
-// @Failure 400 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} ErrorResponse
+// @Failure 412 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
 // @Security BearerAuth
 // @Router /quizzes/{id} [delete]
 func (h *QuizHandler) DeleteQuiz(c *gin.Context) {
-	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid quiz id"})
+	id, ok := paramID(c, "id")
+	if !ok {
 		return
 	}
 
-	if err := h.quizService.DeleteQuiz(uint(id)); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
+	if err := h.quizService.DeleteQuiz(c.Request.Context(), id, version); err != nil {
+		c.Error(err)
 		return
 	}
 
@@ -172,27 +192,25 @@ func (h *QuizHandler) DeleteQuiz(c *gin.Context) {
 // @Param id path int true "Quiz ID"
 // @Param selection body quiz.QuizSelection true "Selection object"
 // @Success 200 {object} quiz.Quiz
-// @Failure 400 {object} map[string]string
-// @Failure 401 {object} map[string]string
-// @Failure 404 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
 // @Security BearerAuth
 // @Router /quizzes/{id}/selections [post]
 func (h *QuizHandler) AddSelection(c *gin.Context) {
-	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid quiz id"})
+	id, ok := paramID(c, "id")
+	if !ok {
 		return
 	}
 
 	var selection quiz.QuizSelection
-	if err := c.ShouldBindJSON(&selection); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(c, &selection) {
 		return
 	}
 
-	if err := h.quizService.AddSelection(uint(id), selection); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.quizService.AddSelection(c.Request.Context(), id, selection); err != nil {
+		c.Error(err)
 		return
 	}
 
@@ -206,25 +224,24 @@ func (h *QuizHandler) AddSelection(c *gin.Context) {
 // @Param id path int true "Quiz ID"
 // @Param selectionId path int true "Selection ID"
 // @Success 200 {object} map[string]string
-// @Failure 400 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
 // @Security BearerAuth
 // @Router /quizzes/{id}/selections/{selectionId} [delete]
 func (h *QuizHandler) RemoveSelection(c *gin.Context) {
-	quizID, err := strconv.ParseUint(c.Param("id"), 10, 32)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid quiz id"})
+	quizID, ok := paramID(c, "id")
+	if !ok {
 		return
 	}
 
-	selectionID, err := strconv.ParseUint(c.Param("selectionId"), 10, 32)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid selection id"})
+	selectionID, ok := paramID(c, "selectionId")
+	if !ok {
 		return
 	}
 
-	if err := h.quizService.RemoveSelection(uint(quizID), uint(selectionID)); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.quizService.RemoveSelection(c.Request.Context(), quizID, selectionID); err != nil {
+		c.Error(err)
 		return
 	}
 
@@ -236,22 +253,210 @@ func (h *QuizHandler) RemoveSelection(c *gin.Context) {
 // @Tags quizzes
 // @Produce json
 // @Success 200 {array} quiz.Quiz
-// @Failure 500 {object} map[string]string
+// @Failure 500 {object} ErrorResponse
 // @Security BearerAuth
 // @Router /quizzes [get]
 func (h *QuizHandler) GetQuizzes(c *gin.Context) {
 	// Get user ID from context (assuming you have middleware that sets this)
-	userID, exists := c.Get("userID")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+	userID, ok := currentUserID(c)
+	if !ok {
 		return
 	}
 
-	quizzes, err := h.quizService.GetQuizzesByUserID(userID.(uint))
+	quizzes, err := h.quizService.GetQuizzesByUserID(c.Request.Context(), userID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
 	c.JSON(http.StatusOK, quizzes)
-} 
\ No newline at end of file
+} 
+// @Summary List the revisions of a quiz
+// @Description List the saved states of a quiz, newest first. Every change to the quiz or its selections adds a revision.
+// @Tags quizzes
+// @Produce json
+// @Param id path int true "Quiz ID"
+// @Success 200 {array} quiz.QuizRevision
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /quizzes/{id}/revisions [get]
+func (h *QuizHandler) ListQuizRevisions(c *gin.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+
+	revisions, err := h.quizService.ListRevisions(c.Request.Context(), id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, revisions)
+}
+
+// @Summary Get a revision of a quiz
+// @Description Get a saved state of a quiz by its revision number
+// @Tags quizzes
+// @Produce json
+// @Param id path int true "Quiz ID"
+// @Param revision path int true "Revision number"
+// @Success 200 {object} quiz.QuizRevision
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /quizzes/{id}/revisions/{revision} [get]
+func (h *QuizHandler) GetQuizRevision(c *gin.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+
+	number, ok := paramID(c, "revision")
+	if !ok {
+		return
+	}
+
+	revision, err := h.quizService.GetRevision(c.Request.Context(), id, number)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, revision)
+}
+
+// revisionDiffQuery selects the revisions compared by DiffQuizRevisions
+type revisionDiffQuery struct {
+	From uint `form:"from" json:"from" binding:"required,min=1"`
+	To   uint `form:"to" json:"to" binding:"required,min=1"`
+}
+
+// @Summary Compare two revisions of a quiz
+// @Description List the changes to the question, type and selections of a quiz from one revision to another. Selections are matched by ID.
+// @Tags quizzes
+// @Produce json
+// @Param id path int true "Quiz ID"
+// @Param from query int true "Revision number to compare from"
+// @Param to query int true "Revision number to compare to"
+// @Success 200 {object} quiz.RevisionDiff
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /quizzes/{id}/revisions/diff [get]
+func (h *QuizHandler) DiffQuizRevisions(c *gin.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+
+	var query revisionDiffQuery
+	if !bindQuery(c, &query) {
+		return
+	}
+
+	diff, err := h.quizService.DiffRevisions(c.Request.Context(), id, query.From, query.To)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, diff)
+}
+
+// @Summary Revert a quiz to a revision
+// @Description Restore the question, type and selections saved by a revision. The result is recorded as a new revision; selections removed since are added again under new IDs.
+// @Tags quizzes
+// @Produce json
+// @Param id path int true "Quiz ID"
+// @Param revision path int true "Revision number"
+// @Param If-Match header string false "ETag the revert is based on"
+// @Success 200 {object} quiz.Quiz
+// @Header 200 {string} ETag "Version of the quiz"
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 412 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /quizzes/{id}/revisions/{revision}/revert [post]
+func (h *QuizHandler) RevertQuiz(c *gin.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+
+	number, ok := paramID(c, "revision")
+	if !ok {
+		return
+	}
+
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
+	quiz, err := h.quizService.RevertQuiz(c.Request.Context(), id, number, version)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Header("ETag", quizETag(quiz))
+	c.JSON(http.StatusOK, quiz)
+}
+
+// cloneQuery holds the options of CloneQuiz and CloneQuizSuite
+type cloneQuery struct {
+	ShareQuizzes bool  `form:"share_quizzes" json:"share_quizzes"`
+	Provenance   *bool `form:"provenance" json:"provenance"`
+}
+
+// provenance reports whether the clone records what it was cloned from,
+// which it does unless turned off
+func (q cloneQuery) provenance() bool {
+	return q.Provenance == nil || *q.Provenance
+}
+
+// @Summary Clone a quiz
+// @Description Copy a quiz and its selections into a new quiz owned by the caller
+// @Tags quizzes
+// @Produce json
+// @Param id path int true "Quiz ID"
+// @Param provenance query bool false "Record the original in cloned_from_id (default true)"
+// @Success 201 {object} quiz.Quiz
+// @Header 201 {string} ETag "Version of the quiz"
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /quizzes/{id}/clone [post]
+func (h *QuizHandler) CloneQuiz(c *gin.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+
+	var query cloneQuery
+	if !bindQuery(c, &query) {
+		return
+	}
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	clone, err := h.quizService.CloneQuiz(c.Request.Context(), id, userID, query.provenance())
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Header("ETag", quizETag(clone))
+	c.JSON(http.StatusCreated, clone)
+}
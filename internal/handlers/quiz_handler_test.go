@@ -1,10 +1,12 @@
 package handlers
 
 import (
+	"context"
 	"bytes"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"quizlet/internal/auth"
 	"quizlet/internal/models/quiz"
 	"testing"
 
@@ -15,47 +17,88 @@ import (
 	"quizlet/tests/mocks"
 	"gorm.io/gorm"
 	"quizlet/internal/models/user"
+	"quizlet/internal/service"
 )
 
 type MockQuizService struct {
 	mock.Mock
 }
 
-func (m *MockQuizService) CreateQuiz(quiz *quiz.Quiz) error {
-	args := m.Called(quiz)
+func (m *MockQuizService) CreateQuiz(ctx context.Context, quiz *quiz.Quiz) error {
+	args := m.Called(ctx, quiz)
 	return args.Error(0)
 }
 
-func (m *MockQuizService) GetQuizByID(id uint) (*quiz.Quiz, error) {
-	args := m.Called(id)
+func (m *MockQuizService) GetQuizByID(ctx context.Context, id uint) (*quiz.Quiz, error) {
+	args := m.Called(ctx, id)
 	return args.Get(0).(*quiz.Quiz), args.Error(1)
 }
 
-func (m *MockQuizService) GetQuizzesByUserID(userID uint) ([]*quiz.Quiz, error) {
-	args := m.Called(userID)
+func (m *MockQuizService) GetQuizzesByUserID(ctx context.Context, userID uint) ([]*quiz.Quiz, error) {
+	args := m.Called(ctx, userID)
 	return args.Get(0).([]*quiz.Quiz), args.Error(1)
 }
 
-func (m *MockQuizService) UpdateQuiz(quiz *quiz.Quiz) error {
-	args := m.Called(quiz)
+func (m *MockQuizService) UpdateQuiz(ctx context.Context, quiz *quiz.Quiz) error {
+	args := m.Called(ctx, quiz)
 	return args.Error(0)
 }
 
-func (m *MockQuizService) DeleteQuiz(id uint) error {
-	args := m.Called(id)
+func (m *MockQuizService) DeleteQuiz(ctx context.Context, id uint, version uint) error {
+	args := m.Called(ctx, id, version)
 	return args.Error(0)
 }
 
-func (m *MockQuizService) AddSelection(quizID uint, selection quiz.QuizSelection) error {
-	args := m.Called(quizID, selection)
+func (m *MockQuizService) AddSelection(ctx context.Context, quizID uint, selection quiz.QuizSelection) error {
+	args := m.Called(ctx, quizID, selection)
 	return args.Error(0)
 }
 
-func (m *MockQuizService) RemoveSelection(quizID uint, selectionID uint) error {
-	args := m.Called(quizID, selectionID)
+func (m *MockQuizService) RemoveSelection(ctx context.Context, quizID uint, selectionID uint) error {
+	args := m.Called(ctx, quizID, selectionID)
 	return args.Error(0)
 }
 
+func (m *MockQuizService) ListRevisions(ctx context.Context, quizID uint) ([]*quiz.QuizRevision, error) {
+	args := m.Called(ctx, quizID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*quiz.QuizRevision), args.Error(1)
+}
+
+func (m *MockQuizService) GetRevision(ctx context.Context, quizID uint, number uint) (*quiz.QuizRevision, error) {
+	args := m.Called(ctx, quizID, number)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*quiz.QuizRevision), args.Error(1)
+}
+
+func (m *MockQuizService) DiffRevisions(ctx context.Context, quizID uint, from uint, to uint) (*quiz.RevisionDiff, error) {
+	args := m.Called(ctx, quizID, from, to)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*quiz.RevisionDiff), args.Error(1)
+}
+
+func (m *MockQuizService) RevertQuiz(ctx context.Context, quizID uint, number uint, version uint) (*quiz.Quiz, error) {
+	args := m.Called(ctx, quizID, number, version)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*quiz.Quiz), args.Error(1)
+}
+
+func (m *MockQuizService) CloneQuiz(ctx context.Context, id uint, userID uint, provenance bool) (*quiz.Quiz, error) {
+	args := m.Called(ctx, id, userID, provenance)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*quiz.Quiz), args.Error(1)
+}
+
 func TestCreateQuiz(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	mockQuizService := new(MockQuizService)
@@ -77,14 +120,14 @@ func TestCreateQuiz(t *testing.T) {
 			},
 			userID: 1,
 			mockSetup: func() {
-				mockQuizService.On("CreateQuiz", mock.MatchedBy(func(q *quiz.Quiz) bool {
+				mockQuizService.On("CreateQuiz", mock.Anything, mock.MatchedBy(func(q *quiz.Quiz) bool {
 					return q.Question == "Test Question" &&
 						q.QuizType == quiz.QuizTypeMultiChoice &&
 						q.CreatedByID == uint(1)
 				})).Return(nil).Once()
 			},
 			expectedStatus: http.StatusCreated,
-			expectedBody:   `{"id":0,"created_at":"0001-01-01T00:00:00Z","updated_at":"0001-01-01T00:00:00Z","question":"Test Question","quiz_type":"multi_choice","created_by_id":1}`,
+			expectedBody:   `{"id":0,"created_at":"0001-01-01T00:00:00Z","updated_at":"0001-01-01T00:00:00Z","version":0,"question":"Test Question","quiz_type":"multi_choice","created_by_id":1}`,
 		},
 		{
 			name:           "Unauthorized",
@@ -92,7 +135,7 @@ func TestCreateQuiz(t *testing.T) {
 			userID:         0,
 			mockSetup:      func() {},
 			expectedStatus: http.StatusUnauthorized,
-			expectedBody:   `{"error":"unauthorized"}`,
+			expectedBody:   `{"type":"urn:quizlet:problem:unauthenticated","title":"Unauthorized","status":401,"detail":"authentication required","instance":"/","code":"unauthenticated"}`,
 		},
 		{
 			name:   "Service Error",
@@ -102,14 +145,14 @@ func TestCreateQuiz(t *testing.T) {
 			},
 			userID: 1,
 			mockSetup: func() {
-				mockQuizService.On("CreateQuiz", mock.MatchedBy(func(q *quiz.Quiz) bool {
+				mockQuizService.On("CreateQuiz", mock.Anything, mock.MatchedBy(func(q *quiz.Quiz) bool {
 					return q.Question == "Test Question" &&
 						q.QuizType == quiz.QuizTypeMultiChoice &&
 						q.CreatedByID == uint(1)
 				})).Return(gorm.ErrInvalidDB).Once()
 			},
 			expectedStatus: http.StatusInternalServerError,
-			expectedBody:   `{"error":"invalid db"}`,
+			expectedBody:   `{"type":"urn:quizlet:problem:internal_error","title":"Internal Server Error","status":500,"detail":"an unexpected error occurred","instance":"/","code":"internal_error"}`,
 		},
 	}
 
@@ -122,12 +165,12 @@ func TestCreateQuiz(t *testing.T) {
 			c.Request = httptest.NewRequest("POST", "/", bytes.NewBuffer(body))
 
 			if tc.userID > 0 {
-				c.Set("userID", tc.userID)
+				auth.SetPrincipal(c, &auth.Principal{UserID: tc.userID, Method: auth.AuthMethodBearer})
 			}
 
 			tc.mockSetup()
 
-			handler.CreateQuiz(c)
+			serve(c, handler.CreateQuiz)
 
 			assert.Equal(t, tc.expectedStatus, w.Code)
 			assert.JSONEq(t, tc.expectedBody, w.Body.String())
@@ -164,11 +207,11 @@ func TestGetQuiz(t *testing.T) {
 			name:   "Successful quiz retrieval",
 			quizID: "1",
 			setupAuth: func(c *gin.Context) {
-				c.Set("userID", uint(1))
+				auth.SetPrincipal(c, &auth.Principal{UserID: 1, Method: auth.AuthMethodBearer})
 			},
 			mockService: func() {
 				mockQuizService.EXPECT().
-					GetQuizByID(uint(1)).
+					GetQuizByID(gomock.Any(), uint(1)).
 					Return(&quiz.Quiz{
 						Question:    "What is the capital of France?",
 						QuizType:    quiz.QuizTypeSingleChoice,
@@ -197,46 +240,46 @@ func TestGetQuiz(t *testing.T) {
 			name:   "Invalid quiz ID",
 			quizID: "invalid",
 			setupAuth: func(c *gin.Context) {
-				c.Set("userID", uint(1))
+				auth.SetPrincipal(c, &auth.Principal{UserID: 1, Method: auth.AuthMethodBearer})
 			},
 			mockService: func() {
 				// No service calls expected
 			},
 			expectedStatus: http.StatusBadRequest,
 			expectedBody: map[string]interface{}{
-				"error": "invalid quiz id",
+				"code": "validation_failed",
 			},
 		},
 		{
 			name:   "Quiz not found",
 			quizID: "999",
 			setupAuth: func(c *gin.Context) {
-				c.Set("userID", uint(1))
+				auth.SetPrincipal(c, &auth.Principal{UserID: 1, Method: auth.AuthMethodBearer})
 			},
 			mockService: func() {
 				mockQuizService.EXPECT().
-					GetQuizByID(uint(999)).
-					Return(nil, gorm.ErrRecordNotFound)
+					GetQuizByID(gomock.Any(), uint(999)).
+					Return(nil, service.ErrQuizNotFound)
 			},
 			expectedStatus: http.StatusNotFound,
 			expectedBody: map[string]interface{}{
-				"error": "quiz not found",
+				"code": "quiz_not_found",
 			},
 		},
 		{
 			name:   "Service error",
 			quizID: "1",
 			setupAuth: func(c *gin.Context) {
-				c.Set("userID", uint(1))
+				auth.SetPrincipal(c, &auth.Principal{UserID: 1, Method: auth.AuthMethodBearer})
 			},
 			mockService: func() {
 				mockQuizService.EXPECT().
-					GetQuizByID(uint(1)).
+					GetQuizByID(gomock.Any(), uint(1)).
 					Return(nil, gorm.ErrInvalidDB)
 			},
-			expectedStatus: http.StatusNotFound,
+			expectedStatus: http.StatusInternalServerError,
 			expectedBody: map[string]interface{}{
-				"error": "quiz not found",
+				"code": "internal_error",
 			},
 		},
 	}
@@ -258,7 +301,7 @@ func TestGetQuiz(t *testing.T) {
 			tt.setupAuth(c)
 
 			// Call the handler
-			handler.GetQuiz(c)
+			serve(c, handler.GetQuiz)
 
 			// Assert response
 			assert.Equal(t, tt.expectedStatus, w.Code)
@@ -282,8 +325,208 @@ func TestGetQuiz(t *testing.T) {
 				_, hasPassword := createdBy["password"]
 				assert.False(t, hasPassword, "Password should not be exposed in response")
 			} else {
-				assert.Equal(t, tt.expectedBody["error"], response["error"])
+				assert.Equal(t, tt.expectedBody["code"], response["code"])
+			}
+		})
+	}
+} 
+func TestDiffQuizRevisions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockQuizService := new(MockQuizService)
+	handler := NewQuizHandler(mockQuizService)
+
+	testCases := []struct {
+		name           string
+		query          string
+		mockSetup      func()
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:  "Success",
+			query: "?from=1&to=2",
+			mockSetup: func() {
+				mockQuizService.On("DiffRevisions", mock.Anything, uint(1), uint(1), uint(2)).Return(&quiz.RevisionDiff{
+					From:              1,
+					To:                2,
+					Question:          &quiz.FieldChange{Before: "What is 2 + 2?", After: "What is 2 * 2?"},
+					SelectionsAdded:   []quiz.SnapshotSelection{{ID: 3, SelectionText: "8"}},
+					SelectionsRemoved: []quiz.SnapshotSelection{},
+					SelectionsChanged: []quiz.SelectionChange{},
+				}, nil).Once()
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"from":1,"to":2,"question":{"before":"What is 2 + 2?","after":"What is 2 * 2?"},"selections_added":[{"id":3,"selection_text":"8","is_correct":false}],"selections_removed":[],"selections_changed":[]}`,
+		},
+		{
+			name:           "Missing Revision",
+			query:          "?from=1",
+			mockSetup:      func() {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"type":"urn:quizlet:problem:validation_failed","title":"Bad Request","status":400,"detail":"request validation failed","instance":"/quizzes/1/revisions/diff","code":"validation_failed","errors":[{"field":"to","code":"required","message":"is required"}]}`,
+		},
+		{
+			name:  "Revision Not Found",
+			query: "?from=1&to=9",
+			mockSetup: func() {
+				mockQuizService.On("DiffRevisions", mock.Anything, uint(1), uint(1), uint(9)).Return(nil, service.ErrRevisionNotFound).Once()
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `{"type":"urn:quizlet:problem:revision_not_found","title":"Not Found","status":404,"detail":"revision not found","instance":"/quizzes/1/revisions/diff","code":"revision_not_found"}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+
+			c.Request = httptest.NewRequest(http.MethodGet, "/quizzes/1/revisions/diff"+tc.query, nil)
+			c.Params = gin.Params{{Key: "id", Value: "1"}}
+
+			tc.mockSetup()
+
+			serve(c, handler.DiffQuizRevisions)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			assert.JSONEq(t, tc.expectedBody, w.Body.String())
+		})
+	}
+
+	mockQuizService.AssertExpectations(t)
+}
+
+func TestRevertQuiz(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockQuizService := new(MockQuizService)
+	handler := NewQuizHandler(mockQuizService)
+
+	testCases := []struct {
+		name           string
+		ifMatch        string
+		mockSetup      func()
+		expectedStatus int
+		expectedETag   string
+	}{
+		{
+			name:    "Success",
+			ifMatch: `"4"`,
+			mockSetup: func() {
+				mockQuizService.On("RevertQuiz", mock.Anything, uint(1), uint(2), uint(4)).Return(&quiz.Quiz{
+					ID:       1,
+					Version:  5,
+					Question: "What is 2 + 2?",
+					QuizType: quiz.QuizTypeSingleChoice,
+				}, nil).Once()
+			},
+			expectedStatus: http.StatusOK,
+			expectedETag:   `"5"`,
+		},
+		{
+			name:    "Modified",
+			ifMatch: `"3"`,
+			mockSetup: func() {
+				mockQuizService.On("RevertQuiz", mock.Anything, uint(1), uint(2), uint(3)).Return(nil, service.ErrQuizModified).Once()
+			},
+			expectedStatus: http.StatusPreconditionFailed,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+
+			c.Request = httptest.NewRequest(http.MethodPost, "/quizzes/1/revisions/2/revert", nil)
+			c.Request.Header.Set("If-Match", tc.ifMatch)
+			c.Params = gin.Params{{Key: "id", Value: "1"}, {Key: "revision", Value: "2"}}
+
+			tc.mockSetup()
+
+			serve(c, handler.RevertQuiz)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			assert.Equal(t, tc.expectedETag, w.Header().Get("ETag"))
+		})
+	}
+
+	mockQuizService.AssertExpectations(t)
+}
+
+func TestCloneQuiz(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockQuizService := new(MockQuizService)
+	handler := NewQuizHandler(mockQuizService)
+	clonedFrom := uint(1)
+
+	testCases := []struct {
+		name           string
+		query          string
+		userID         uint
+		mockSetup      func()
+		expectedStatus int
+		expectedETag   string
+	}{
+		{
+			name:   "Success",
+			userID: 2,
+			mockSetup: func() {
+				mockQuizService.On("CloneQuiz", mock.Anything, uint(1), uint(2), true).Return(&quiz.Quiz{
+					ID:           3,
+					Version:      1,
+					Question:     "What is 2 + 2?",
+					QuizType:     quiz.QuizTypeSingleChoice,
+					CreatedByID:  2,
+					ClonedFromID: &clonedFrom,
+				}, nil).Once()
+			},
+			expectedStatus: http.StatusCreated,
+			expectedETag:   `"1"`,
+		},
+		{
+			name:   "Without Provenance",
+			query:  "?provenance=false",
+			userID: 2,
+			mockSetup: func() {
+				mockQuizService.On("CloneQuiz", mock.Anything, uint(1), uint(2), false).Return(&quiz.Quiz{ID: 4, Version: 1, CreatedByID: 2}, nil).Once()
+			},
+			expectedStatus: http.StatusCreated,
+			expectedETag:   `"1"`,
+		},
+		{
+			name:           "Unauthorized",
+			mockSetup:      func() {},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:   "Not Found",
+			userID: 2,
+			mockSetup: func() {
+				mockQuizService.On("CloneQuiz", mock.Anything, uint(1), uint(2), true).Return(nil, service.ErrQuizNotFound).Once()
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+
+			c.Request = httptest.NewRequest(http.MethodPost, "/quizzes/1/clone"+tc.query, nil)
+			c.Params = gin.Params{{Key: "id", Value: "1"}}
+			if tc.userID > 0 {
+				auth.SetPrincipal(c, &auth.Principal{UserID: tc.userID, Method: auth.AuthMethodBearer})
 			}
+
+			tc.mockSetup()
+
+			serve(c, handler.CloneQuiz)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			assert.Equal(t, tc.expectedETag, w.Header().Get("ETag"))
 		})
 	}
-} 
\ No newline at end of file
+
+	mockQuizService.AssertExpectations(t)
+}
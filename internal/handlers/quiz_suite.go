@@ -1,10 +1,12 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"quizlet/internal/models/quiz_suite"
+	"quizlet/internal/service"
 	"gorm.io/gorm"
 )
 
@@ -21,22 +23,20 @@ import (
 // @Router       /quiz-suites [post]
 func CreateQuizSuite(c *gin.Context) {
 	var qs quiz_suite.QuizSuite
-	if err := c.ShouldBindJSON(&qs); err != nil {
-		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	if !bindJSON(c, &qs) {
 		return
 	}
 
 	// Get user ID from context (set by auth middleware)
-	userID, exists := c.Get("userID")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "unauthorized"})
+	userID, ok := currentUserID(c)
+	if !ok {
 		return
 	}
-	qs.CreatedByID = userID.(uint)
+	qs.CreatedByID = userID
 
 	db := c.MustGet("db").(*gorm.DB)
 	if err := db.Create(&qs).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		c.Error(err)
 		return
 	}
 
@@ -53,16 +53,15 @@ func CreateQuizSuite(c *gin.Context) {
 // @Router       /quiz-suites [get]
 func GetQuizSuites(c *gin.Context) {
 	// Get user ID from context (set by auth middleware)
-	userID, exists := c.Get("userID")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "unauthorized"})
+	userID, ok := currentUserID(c)
+	if !ok {
 		return
 	}
 
 	var quizSuites []quiz_suite.QuizSuite
 	db := c.MustGet("db").(*gorm.DB)
 	if err := db.Where("created_by_id = ?", userID).Find(&quizSuites).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		c.Error(err)
 		return
 	}
 
@@ -84,7 +83,10 @@ func GetQuizSuite(c *gin.Context) {
 	var quizSuite quiz_suite.QuizSuite
 	db := c.MustGet("db").(*gorm.DB)
 	if err := db.First(&quizSuite, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, ErrorResponse{Error: "quiz suite not found"})
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = service.ErrQuizSuiteNotFound
+		}
+		c.Error(err)
 		return
 	}
 
@@ -107,20 +109,22 @@ func UpdateQuizSuite(c *gin.Context) {
 	id := c.Param("id")
 
 	var quizSuite quiz_suite.QuizSuite
-	if err := c.ShouldBindJSON(&quizSuite); err != nil {
-		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	if !bindJSON(c, &quizSuite) {
 		return
 	}
 
 	db := c.MustGet("db").(*gorm.DB)
 	if err := db.Model(&quiz_suite.QuizSuite{}).Where("id = ?", id).Updates(quizSuite).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		c.Error(err)
 		return
 	}
 
 	// Fetch the updated quiz suite
 	if err := db.First(&quizSuite, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, ErrorResponse{Error: "quiz suite not found"})
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = service.ErrQuizSuiteNotFound
+		}
+		c.Error(err)
 		return
 	}
 
@@ -141,7 +145,7 @@ func DeleteQuizSuite(c *gin.Context) {
 
 	db := c.MustGet("db").(*gorm.DB)
 	if err := db.Delete(&quiz_suite.QuizSuite{}, id).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		c.Error(err)
 		return
 	}
 
@@ -1,14 +1,13 @@
 package handlers
 
 import (
-	"errors"
+	"context"
 	"net/http"
+	"quizlet/internal/apperr"
 	"quizlet/internal/models/quiz_suite"
 	"quizlet/internal/service"
-	"strconv"
 
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
 )
 
 type QuizSuiteHandler struct {
@@ -21,33 +20,15 @@ func NewQuizSuiteHandler(quizSuiteService service.QuizSuiteService) *QuizSuiteHa
 	}
 }
 
-// getUserIDFromContext extracts and validates the user ID from the context
-func (h *QuizSuiteHandler) getUserIDFromContext(c *gin.Context) (uint, error) {
-	userID, exists := c.Get("userID")
-	if !exists {
-		return 0, errors.New("unauthorized")
-	}
-	
-	uid, ok := userID.(uint)
-	if !ok {
-		return 0, errors.New("unauthorized")
-	}
-	
-	return uid, nil
-}
-
 // validateQuizSuiteAccess checks if the user has access to the quiz suite
-func (h *QuizSuiteHandler) validateQuizSuiteAccess(suiteID, userID uint) error {
-	suite, err := h.quizSuiteService.GetQuizSuite(suiteID)
+func (h *QuizSuiteHandler) validateQuizSuiteAccess(ctx context.Context, suiteID, userID uint) error {
+	suite, err := h.quizSuiteService.GetQuizSuite(ctx, suiteID)
 	if err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return errors.New("quiz suite not found")
-		}
 		return err
 	}
 	
 	if suite.CreatedByID != userID {
-		return errors.New("unauthorized: you don't have permission to access this quiz suite")
+		return service.ErrQuizSuiteForbidden
 	}
 	
 	return nil
@@ -60,21 +41,20 @@ func (h *QuizSuiteHandler) validateQuizSuiteAccess(suiteID, userID uint) error {
 // @Produce json
 // @Param quiz_suite body quiz_suite.CreateQuizSuiteRequest true "Quiz Suite object"
 // @Success 201 {object} quiz_suite.QuizSuite
-// @Failure 400 {object} map[string]string "Bad Request - Title is required"
-// @Failure 401 {object} map[string]string "Unauthorized"
-// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Header 201 {string} ETag "Version of the quiz suite"
+// @Failure 400 {object} ErrorResponse "Bad Request - Title is required"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 500 {object} ErrorResponse "Internal Server Error"
 // @Security BearerAuth
 // @Router /quiz-suites [post]
 func (h *QuizSuiteHandler) CreateQuizSuite(c *gin.Context) {
 	var req quiz_suite.CreateQuizSuiteRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(c, &req) {
 		return
 	}
 
-	userID, err := h.getUserIDFromContext(c)
-	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	userID, ok := currentUserID(c)
+	if !ok {
 		return
 	}
 
@@ -85,36 +65,28 @@ func (h *QuizSuiteHandler) CreateQuizSuite(c *gin.Context) {
 	}
 
 	if qs.Title == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "quiz suite title is required"})
+		c.Error(apperr.Validation(apperr.FieldError{Field: "title", Code: "required", Message: "is required"}))
 		return
 	}
 
-	if err := h.quizSuiteService.CreateQuizSuite(qs); err != nil {
-		if err == gorm.ErrInvalidDB {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "gorm: invalid db"})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.quizSuiteService.CreateQuizSuite(c.Request.Context(), qs); err != nil {
+		c.Error(err)
 		return
 	}
 
+	c.Header("ETag", quizSuiteETag(qs))
 	c.JSON(http.StatusCreated, qs)
 }
 
 func (h *QuizSuiteHandler) GetQuizSuites(c *gin.Context) {
-	userID, err := h.getUserIDFromContext(c)
-	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	userID, ok := currentUserID(c)
+	if !ok {
 		return
 	}
 
-	quizSuites, err := h.quizSuiteService.GetUserQuizSuites(userID)
+	quizSuites, err := h.quizSuiteService.GetUserQuizSuites(c.Request.Context(), userID)
 	if err != nil {
-		if err == gorm.ErrInvalidDB {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "gorm: invalid db"})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
@@ -130,45 +102,41 @@ func (h *QuizSuiteHandler) GetQuizSuites(c *gin.Context) {
 // @Tags quiz-suites
 // @Produce json
 // @Param id path int true "Quiz Suite ID"
+// @Param If-None-Match header string false "ETag of a cached copy"
 // @Success 200 {object} quiz_suite.QuizSuite
+// @Header 200 {string} ETag "Version of the quiz suite and its quizzes"
+// @Success 304 "Not Modified"
 // @Failure 404 {object} ErrorResponse
 // @Security BearerAuth
 // @Router /quiz-suites/{id} [get]
 func (h *QuizSuiteHandler) GetQuizSuite(c *gin.Context) {
-	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid quiz suite id"})
+	id, ok := paramID(c, "id")
+	if !ok {
 		return
 	}
 
-	quizSuite, err := h.quizSuiteService.GetQuizSuite(uint(id))
+	quizSuite, err := h.quizSuiteService.GetQuizSuite(c.Request.Context(), id)
 	if err != nil {
-		if err == gorm.ErrInvalidDB {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "gorm: invalid db"})
-			return
-		}
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "quiz suite not found"})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
+	if notModified(c, quizSuiteETag(quizSuite)) {
+		return
+	}
 	c.JSON(http.StatusOK, quizSuite)
 }
 
 func (h *QuizSuiteHandler) GetUserQuizSuites(c *gin.Context) {
 	// Get user ID from context (assuming you have middleware that sets this)
-	userID, exists := c.Get("userID")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+	userID, ok := currentUserID(c)
+	if !ok {
 		return
 	}
 
-	quizSuites, err := h.quizSuiteService.GetUserQuizSuites(userID.(uint))
+	quizSuites, err := h.quizSuiteService.GetUserQuizSuites(c.Request.Context(), userID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
@@ -181,37 +149,35 @@ func (h *QuizSuiteHandler) GetUserQuizSuites(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param id path int true "Quiz Suite ID"
+// @Param If-Match header string false "ETag the update is based on"
 // @Param quiz_suite body quiz_suite.UpdateQuizSuiteRequest true "Quiz Suite update object"
 // @Success 200 {object} quiz_suite.QuizSuite
+// @Header 200 {string} ETag "Version of the quiz suite and its quizzes"
 // @Failure 400 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
+// @Failure 412 {object} ErrorResponse
 // @Security BearerAuth
 // @Router /quiz-suites/{id} [put]
 func (h *QuizSuiteHandler) UpdateQuizSuite(c *gin.Context) {
-	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid quiz suite id"})
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+
+	version, ok := ifMatchVersion(c)
+	if !ok {
 		return
 	}
 
 	var req quiz_suite.UpdateQuizSuiteRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(c, &req) {
 		return
 	}
 
 	// First check if the quiz suite exists
-	existingSuite, err := h.quizSuiteService.GetQuizSuite(uint(id))
+	existingSuite, err := h.quizSuiteService.GetQuizSuite(c.Request.Context(), id)
 	if err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "quiz suite not found"})
-			return
-		}
-		if err == gorm.ErrInvalidDB {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "gorm: invalid db"})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
@@ -219,23 +185,22 @@ func (h *QuizSuiteHandler) UpdateQuizSuite(c *gin.Context) {
 	existingSuite.Title = req.Title
 	existingSuite.Description = req.Description
 
-	userID, err := h.getUserIDFromContext(c)
-	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	userID, ok := currentUserID(c)
+	if !ok {
 		return
 	}
 	existingSuite.CreatedByID = userID
+	if version != 0 {
+		existingSuite.Version = version
+	}
 
-	err = h.quizSuiteService.UpdateQuizSuite(existingSuite)
+	err = h.quizSuiteService.UpdateQuizSuite(c.Request.Context(), existingSuite)
 	if err != nil {
-		if err == gorm.ErrInvalidDB {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "gorm: invalid db"})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
+	c.Header("ETag", quizSuiteETag(existingSuite))
 	c.JSON(http.StatusOK, existingSuite)
 }
 
@@ -244,28 +209,26 @@ func (h *QuizSuiteHandler) UpdateQuizSuite(c *gin.Context) {
 // @Tags quiz-suites
 // @Produce json
 // @Param id path int true "Quiz Suite ID"
+// @Param If-Match header string false "ETag the deletion is based on"
 // @Success 200 {object} SuccessResponse
 // @Failure 404 {object} ErrorResponse
+// @Failure 412 {object} ErrorResponse
 // @Security BearerAuth
 // @Router /quiz-suites/{id} [delete]
 func (h *QuizSuiteHandler) DeleteQuizSuite(c *gin.Context) {
-	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid quiz suite id"})
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+
+	version, ok := ifMatchVersion(c)
+	if !ok {
 		return
 	}
 
-	err = h.quizSuiteService.DeleteQuizSuite(uint(id))
+	err := h.quizSuiteService.DeleteQuizSuite(c.Request.Context(), id, version)
 	if err != nil {
-		if err == gorm.ErrInvalidDB {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "gorm: invalid db"})
-			return
-		}
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "quiz suite not found"})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
@@ -286,49 +249,34 @@ func (h *QuizSuiteHandler) DeleteQuizSuite(c *gin.Context) {
 // @Security BearerAuth
 // @Router /quiz-suites/{id}/quizzes/{quizId} [post]
 func (h *QuizSuiteHandler) AddQuizToSuite(c *gin.Context) {
-	suiteID, err := strconv.ParseUint(c.Param("id"), 10, 32)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid quiz suite id"})
+	suiteID, ok := paramID(c, "id")
+	if !ok {
 		return
 	}
 
-	quizID, err := strconv.ParseUint(c.Param("quizId"), 10, 32)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid quiz id"})
+	quizID, ok := paramID(c, "quizId")
+	if !ok {
 		return
 	}
 
-	userID, err := h.getUserIDFromContext(c)
-	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	userID, ok := currentUserID(c)
+	if !ok {
 		return
 	}
 
-	qs, err := h.quizSuiteService.GetQuizSuite(uint(suiteID))
+	qs, err := h.quizSuiteService.GetQuizSuite(c.Request.Context(), suiteID)
 	if err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "quiz suite not found"})
-			return
-		}
-		if err == gorm.ErrInvalidDB {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "gorm: invalid db"})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
 	if qs.CreatedByID != userID {
-		c.JSON(http.StatusForbidden, gin.H{"error": "unauthorized: you don't have permission to access this quiz suite"})
+		c.Error(service.ErrQuizSuiteForbidden)
 		return
 	}
 
-	if err := h.quizSuiteService.AddQuizToSuite(uint(suiteID), uint(quizID)); err != nil {
-		if err == gorm.ErrInvalidDB {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "gorm: invalid db"})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.quizSuiteService.AddQuizToSuite(c.Request.Context(), suiteID, quizID); err != nil {
+		c.Error(err)
 		return
 	}
 
@@ -349,22 +297,61 @@ func (h *QuizSuiteHandler) AddQuizToSuite(c *gin.Context) {
 // @Security BearerAuth
 // @Router /quiz-suites/{id}/quizzes/{quizId} [delete]
 func (h *QuizSuiteHandler) RemoveQuizFromSuite(c *gin.Context) {
-	suiteID, err := strconv.ParseUint(c.Param("id"), 10, 32)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid quiz suite id"})
+	suiteID, ok := paramID(c, "id")
+	if !ok {
 		return
 	}
 
-	quizID, err := strconv.ParseUint(c.Param("quizId"), 10, 32)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid quiz id"})
+	quizID, ok := paramID(c, "quizId")
+	if !ok {
 		return
 	}
 
-	if err := h.quizSuiteService.RemoveQuizFromSuite(uint(suiteID), uint(quizID)); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.quizSuiteService.RemoveQuizFromSuite(c.Request.Context(), suiteID, quizID); err != nil {
+		c.Error(err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "quiz removed from suite successfully"})
-} 
\ No newline at end of file
+} 
+// @Summary Clone a quiz suite
+// @Description Create a quiz suite owned by the caller with the title, description and quizzes of another, in one transaction. The quizzes are copied with their selections unless shared.
+// @Tags quiz-suites
+// @Produce json
+// @Param id path int true "Quiz Suite ID"
+// @Param share_quizzes query bool false "Put the quizzes of the suite in the clone instead of copies of them"
+// @Param provenance query bool false "Record the originals in cloned_from_id (default true)"
+// @Success 201 {object} quiz_suite.QuizSuite
+// @Header 201 {string} ETag "Version of the quiz suite and its quizzes"
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /quiz-suites/{id}/clone [post]
+func (h *QuizSuiteHandler) CloneQuizSuite(c *gin.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+
+	var query cloneQuery
+	if !bindQuery(c, &query) {
+		return
+	}
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	opts := service.CloneOptions{ShareQuizzes: query.ShareQuizzes, Provenance: query.provenance()}
+	clone, err := h.quizSuiteService.CloneQuizSuite(c.Request.Context(), id, userID, opts)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Header("ETag", quizSuiteETag(clone))
+	c.JSON(http.StatusCreated, clone)
+}
@@ -9,8 +9,10 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"quizlet/internal/auth"
 	"quizlet/internal/models/quiz_suite"
 	"quizlet/internal/services"
+	"quizlet/internal/service"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"gorm.io/gorm"
@@ -40,7 +42,7 @@ func TestCreateQuizSuite(t *testing.T) {
 				"description": "Test Description",
 			},
 			mockSetup: func() {
-				mockService.On("CreateQuizSuite", mock.MatchedBy(func(qs *quiz_suite.QuizSuite) bool {
+				mockService.On("CreateQuizSuite", mock.Anything, mock.MatchedBy(func(qs *quiz_suite.QuizSuite) bool {
 					return qs.Title == "Test Quiz Suite" &&
 						qs.Description == "Test Description" &&
 						qs.CreatedByID == uint(1)
@@ -55,6 +57,7 @@ func TestCreateQuizSuite(t *testing.T) {
 				"created_at":   "0001-01-01T00:00:00Z",
 				"updated_at":   "0001-01-01T00:00:00Z",
 				"deleted_at":   nil,
+				"version":      float64(0),
 			},
 		},
 		{
@@ -67,7 +70,7 @@ func TestCreateQuizSuite(t *testing.T) {
 			mockSetup:      func() {},
 			expectedStatus: http.StatusUnauthorized,
 			expectedBody: map[string]interface{}{
-				"error": "unauthorized",
+				"code": "unauthenticated",
 			},
 		},
 		{
@@ -78,7 +81,7 @@ func TestCreateQuizSuite(t *testing.T) {
 				"description": "Test Description",
 			},
 			mockSetup: func() {
-				mockService.On("CreateQuizSuite", mock.MatchedBy(func(qs *quiz_suite.QuizSuite) bool {
+				mockService.On("CreateQuizSuite", mock.Anything, mock.MatchedBy(func(qs *quiz_suite.QuizSuite) bool {
 					return qs.Title == "Test Quiz Suite" &&
 						qs.Description == "Test Description" &&
 						qs.CreatedByID == uint(1)
@@ -86,7 +89,7 @@ func TestCreateQuizSuite(t *testing.T) {
 			},
 			expectedStatus: http.StatusInternalServerError,
 			expectedBody: map[string]interface{}{
-				"error": "gorm: invalid db",
+				"code": "internal_error",
 			},
 		},
 	}
@@ -103,7 +106,7 @@ func TestCreateQuizSuite(t *testing.T) {
 
 			// Set user ID in context
 			if tc.userID > 0 {
-				c.Set("userID", tc.userID)
+				auth.SetPrincipal(c, &auth.Principal{UserID: tc.userID, Method: auth.AuthMethodBearer})
 			}
 
 			// Set up mock
@@ -111,7 +114,7 @@ func TestCreateQuizSuite(t *testing.T) {
 
 			// Create handler and execute
 			handler := NewQuizSuiteHandler(mockService)
-			handler.CreateQuizSuite(c)
+			serve(c, handler.CreateQuizSuite)
 
 			// Assert response
 			assert.Equal(t, tc.expectedStatus, w.Code)
@@ -128,7 +131,11 @@ func TestCreateQuizSuite(t *testing.T) {
 				assert.Equal(t, tc.expectedBody["created_by_id"], response["created_by_id"])
 				assert.Equal(t, tc.expectedBody["deleted_at"], response["deleted_at"])
 			} else {
-				assert.Equal(t, tc.expectedBody, response)
+				if code, ok := tc.expectedBody["code"]; ok {
+					assert.Equal(t, code, response["code"])
+				} else {
+					assert.Equal(t, tc.expectedBody, response)
+				}
 			}
 		})
 	}
@@ -163,7 +170,7 @@ func TestGetQuizSuites(t *testing.T) {
 						CreatedByID: 1,
 					},
 				}
-				mockService.On("GetUserQuizSuites", uint(1)).Return(quizSuites, nil).Once()
+				mockService.On("GetUserQuizSuites", mock.Anything, uint(1)).Return(quizSuites, nil).Once()
 			},
 			expectedStatus: http.StatusOK,
 			expectedBody: map[string]interface{}{
@@ -176,6 +183,7 @@ func TestGetQuizSuites(t *testing.T) {
 						"created_at":   "0001-01-01T00:00:00Z",
 						"updated_at":   "0001-01-01T00:00:00Z",
 						"deleted_at":   nil,
+						"version":      float64(0),
 					},
 					map[string]interface{}{
 						"id":           float64(2),
@@ -185,6 +193,7 @@ func TestGetQuizSuites(t *testing.T) {
 						"created_at":   "0001-01-01T00:00:00Z",
 						"updated_at":   "0001-01-01T00:00:00Z",
 						"deleted_at":   nil,
+						"version":      float64(0),
 					},
 				},
 			},
@@ -193,11 +202,11 @@ func TestGetQuizSuites(t *testing.T) {
 			name:   "Service Error",
 			userID: 1,
 			mockSetup: func() {
-				mockService.On("GetUserQuizSuites", uint(1)).Return(nil, gorm.ErrInvalidDB).Once()
+				mockService.On("GetUserQuizSuites", mock.Anything, uint(1)).Return(nil, gorm.ErrInvalidDB).Once()
 			},
 			expectedStatus: http.StatusInternalServerError,
 			expectedBody: map[string]interface{}{
-				"error": "gorm: invalid db",
+				"code": "internal_error",
 			},
 		},
 	}
@@ -212,7 +221,7 @@ func TestGetQuizSuites(t *testing.T) {
 
 			// Set user ID in context
 			if tc.userID > 0 {
-				c.Set("userID", tc.userID)
+				auth.SetPrincipal(c, &auth.Principal{UserID: tc.userID, Method: auth.AuthMethodBearer})
 			}
 
 			// Set up mock
@@ -220,7 +229,7 @@ func TestGetQuizSuites(t *testing.T) {
 
 			// Create handler and execute
 			handler := NewQuizSuiteHandler(mockService)
-			handler.GetQuizSuites(c)
+			serve(c, handler.GetQuizSuites)
 
 			// Assert response
 			assert.Equal(t, tc.expectedStatus, w.Code)
@@ -233,7 +242,11 @@ func TestGetQuizSuites(t *testing.T) {
 			if tc.expectedStatus == http.StatusOK {
 				assert.Equal(t, tc.expectedBody["quiz_suites"], response["quiz_suites"])
 			} else {
-				assert.Equal(t, tc.expectedBody, response)
+				if code, ok := tc.expectedBody["code"]; ok {
+					assert.Equal(t, code, response["code"])
+				} else {
+					assert.Equal(t, tc.expectedBody, response)
+				}
 			}
 		})
 	}
@@ -266,11 +279,11 @@ func TestGetQuizSuite(t *testing.T) {
 			name:    "Successful quiz suite retrieval",
 			suiteID: "1",
 			setupAuth: func(c *gin.Context) {
-				c.Set("userID", uint(1))
+				auth.SetPrincipal(c, &auth.Principal{UserID: 1, Method: auth.AuthMethodBearer})
 			},
 			mockService: func() {
 				mockQuizSuiteService.EXPECT().
-					GetQuizSuite(uint(1)).
+					GetQuizSuite(gomock.Any(), uint(1)).
 					Return(&quiz_suite.QuizSuite{
 						Title:       "Test Suite",
 						Description: "Test Description",
@@ -324,17 +337,17 @@ func TestGetQuizSuite(t *testing.T) {
 			name:   "Service Error",
 			suiteID: "1",
 			setupAuth: func(c *gin.Context) {
-				c.Set("userID", uint(1))
+				auth.SetPrincipal(c, &auth.Principal{UserID: 1, Method: auth.AuthMethodBearer})
 			},
 			mockService: func() {
 				mockQuizSuiteService.EXPECT().
-					GetQuizSuite(uint(1)).
+					GetQuizSuite(gomock.Any(), uint(1)).
 					Return(nil, gorm.ErrInvalidDB).
 					Times(1)
 			},
 			expectedStatus: http.StatusInternalServerError,
 			expectedBody: map[string]interface{}{
-				"error": "gorm: invalid db",
+				"code": "internal_error",
 			},
 		},
 		{
@@ -344,24 +357,24 @@ func TestGetQuizSuite(t *testing.T) {
 			mockService:    func() {}, // Add empty mock service function
 			expectedStatus: http.StatusBadRequest,
 			expectedBody: map[string]interface{}{
-				"error": "invalid quiz suite id",
+				"code": "validation_failed",
 			},
 		},
 		{
 			name:        "Not Found",
 			suiteID:     "1",
 			setupAuth: func(c *gin.Context) {
-				c.Set("userID", uint(1))
+				auth.SetPrincipal(c, &auth.Principal{UserID: 1, Method: auth.AuthMethodBearer})
 			},
 			mockService: func() {
 				mockQuizSuiteService.EXPECT().
-					GetQuizSuite(uint(1)).
-					Return(nil, gorm.ErrRecordNotFound).
+					GetQuizSuite(gomock.Any(), uint(1)).
+					Return(nil, service.ErrQuizSuiteNotFound).
 					Times(1)
 			},
 			expectedStatus: http.StatusNotFound,
 			expectedBody: map[string]interface{}{
-				"error": "quiz suite not found",
+				"code": "quiz_suite_not_found",
 			},
 		},
 	}
@@ -383,7 +396,7 @@ func TestGetQuizSuite(t *testing.T) {
 			tt.setupAuth(c)
 
 			// Call the handler
-			handler.GetQuizSuite(c)
+			serve(c, handler.GetQuizSuite)
 
 			// Assert response
 			assert.Equal(t, tt.expectedStatus, w.Code)
@@ -427,7 +440,7 @@ func TestGetQuizSuite(t *testing.T) {
 				_, hasQuizCreatorPassword := quizCreator["password"]
 				assert.False(t, hasQuizCreatorPassword, "Password should not be exposed in quiz creator data")
 			} else {
-				assert.Equal(t, tt.expectedBody["error"], response["error"])
+				assert.Equal(t, tt.expectedBody["code"], response["code"])
 			}
 		})
 	}
@@ -455,11 +468,11 @@ func TestUpdateQuizSuite(t *testing.T) {
 				Description: "Updated Description",
 			},
 			mockSetup: func() {
-				mockService.On("GetQuizSuite", uint(999)).Return(nil, gorm.ErrRecordNotFound)
+				mockService.On("GetQuizSuite", mock.Anything, uint(999)).Return(nil, service.ErrQuizSuiteNotFound)
 			},
 			expectedStatus: http.StatusNotFound,
 			expectedBody: map[string]interface{}{
-				"error": "quiz suite not found",
+				"code": "quiz_suite_not_found",
 			},
 		},
 		{
@@ -479,8 +492,8 @@ func TestUpdateQuizSuite(t *testing.T) {
 					CreatedAt:    time.Now(),
 					UpdatedAt:    time.Now(),
 				}
-				mockService.On("GetQuizSuite", uint(1)).Return(existingSuite, nil)
-				mockService.On("UpdateQuizSuite", mock.AnythingOfType("*quiz_suite.QuizSuite")).Return(nil)
+				mockService.On("GetQuizSuite", mock.Anything, uint(1)).Return(existingSuite, nil)
+				mockService.On("UpdateQuizSuite", mock.Anything, mock.AnythingOfType("*quiz_suite.QuizSuite")).Return(nil)
 			},
 			expectedStatus: http.StatusOK,
 			expectedBody: map[string]interface{}{
@@ -508,7 +521,7 @@ func TestUpdateQuizSuite(t *testing.T) {
 
 			// Set user ID in context
 			if tc.userID > 0 {
-				c.Set("userID", tc.userID)
+				auth.SetPrincipal(c, &auth.Principal{UserID: tc.userID, Method: auth.AuthMethodBearer})
 			}
 
 			// Set up mock
@@ -516,7 +529,7 @@ func TestUpdateQuizSuite(t *testing.T) {
 
 			// Create handler and execute
 			handler := NewQuizSuiteHandler(mockService)
-			handler.UpdateQuizSuite(c)
+			serve(c, handler.UpdateQuizSuite)
 
 			// Assert response
 			assert.Equal(t, tc.expectedStatus, w.Code)
@@ -533,7 +546,11 @@ func TestUpdateQuizSuite(t *testing.T) {
 				assert.Equal(t, tc.expectedBody["created_by_id"], response["created_by_id"])
 				assert.Equal(t, tc.expectedBody["deleted_at"], response["deleted_at"])
 			} else {
-				assert.Equal(t, tc.expectedBody, response)
+				if code, ok := tc.expectedBody["code"]; ok {
+					assert.Equal(t, code, response["code"])
+				} else {
+					assert.Equal(t, tc.expectedBody, response)
+				}
 			}
 		})
 	}
@@ -547,6 +564,7 @@ func TestDeleteQuizSuite(t *testing.T) {
 		name           string
 		userID         uint
 		quizSuiteID    string
+		ifMatch        string
 		mockSetup      func()
 		expectedStatus int
 		expectedBody   map[string]interface{}
@@ -556,7 +574,7 @@ func TestDeleteQuizSuite(t *testing.T) {
 			userID: 1,
 			quizSuiteID: "1",
 			mockSetup: func() {
-				mockService.On("DeleteQuizSuite", uint(1)).Return(nil).Once()
+				mockService.On("DeleteQuizSuite", mock.Anything, uint(1), uint(0)).Return(nil).Once()
 			},
 			expectedStatus: http.StatusOK,
 			expectedBody: map[string]interface{}{
@@ -568,11 +586,11 @@ func TestDeleteQuizSuite(t *testing.T) {
 			userID: 1,
 			quizSuiteID: "1",
 			mockSetup: func() {
-				mockService.On("DeleteQuizSuite", uint(1)).Return(gorm.ErrInvalidDB).Once()
+				mockService.On("DeleteQuizSuite", mock.Anything, uint(1), uint(0)).Return(gorm.ErrInvalidDB).Once()
 			},
 			expectedStatus: http.StatusInternalServerError,
 			expectedBody: map[string]interface{}{
-				"error": "gorm: invalid db",
+				"code": "internal_error",
 			},
 		},
 		{
@@ -582,7 +600,7 @@ func TestDeleteQuizSuite(t *testing.T) {
 			mockSetup:      func() {},
 			expectedStatus: http.StatusBadRequest,
 			expectedBody: map[string]interface{}{
-				"error": "invalid quiz suite id",
+				"code": "validation_failed",
 			},
 		},
 		{
@@ -590,11 +608,35 @@ func TestDeleteQuizSuite(t *testing.T) {
 			userID:      1,
 			quizSuiteID: "1",
 			mockSetup: func() {
-				mockService.On("DeleteQuizSuite", uint(1)).Return(gorm.ErrRecordNotFound).Once()
+				mockService.On("DeleteQuizSuite", mock.Anything, uint(1), uint(0)).Return(service.ErrQuizSuiteNotFound).Once()
 			},
 			expectedStatus: http.StatusNotFound,
 			expectedBody: map[string]interface{}{
-				"error": "quiz suite not found",
+				"code": "quiz_suite_not_found",
+			},
+		},
+		{
+			name:        "Stale If-Match",
+			userID:      1,
+			quizSuiteID: "1",
+			ifMatch:     `"3-5e2d1a9c"`,
+			mockSetup: func() {
+				mockService.On("DeleteQuizSuite", mock.Anything, uint(1), uint(3)).Return(service.ErrQuizSuiteModified).Once()
+			},
+			expectedStatus: http.StatusPreconditionFailed,
+			expectedBody: map[string]interface{}{
+				"code": "quiz_suite_modified",
+			},
+		},
+		{
+			name:           "Weak If-Match",
+			userID:         1,
+			quizSuiteID:    "1",
+			ifMatch:        `W/"3"`,
+			mockSetup:      func() {},
+			expectedStatus: http.StatusPreconditionFailed,
+			expectedBody: map[string]interface{}{
+				"code": "weak_if_match",
 			},
 		},
 	}
@@ -606,11 +648,14 @@ func TestDeleteQuizSuite(t *testing.T) {
 
 			// Set up request
 			c.Request = httptest.NewRequest(http.MethodDelete, "/quiz-suites/"+tc.quizSuiteID, nil)
+			if tc.ifMatch != "" {
+				c.Request.Header.Set("If-Match", tc.ifMatch)
+			}
 			c.Params = []gin.Param{{Key: "id", Value: tc.quizSuiteID}}
 
 			// Set user ID in context
 			if tc.userID > 0 {
-				c.Set("userID", tc.userID)
+				auth.SetPrincipal(c, &auth.Principal{UserID: tc.userID, Method: auth.AuthMethodBearer})
 			}
 
 			// Set up mock
@@ -618,7 +663,7 @@ func TestDeleteQuizSuite(t *testing.T) {
 
 			// Create handler and execute
 			handler := NewQuizSuiteHandler(mockService)
-			handler.DeleteQuizSuite(c)
+			serve(c, handler.DeleteQuizSuite)
 
 			// Assert response
 			assert.Equal(t, tc.expectedStatus, w.Code)
@@ -631,7 +676,11 @@ func TestDeleteQuizSuite(t *testing.T) {
 			if tc.expectedStatus == http.StatusOK {
 				assert.Equal(t, tc.expectedBody["message"], response["message"])
 			} else {
-				assert.Equal(t, tc.expectedBody, response)
+				if code, ok := tc.expectedBody["code"]; ok {
+					assert.Equal(t, code, response["code"])
+				} else {
+					assert.Equal(t, tc.expectedBody, response)
+				}
 			}
 		})
 	}
@@ -656,11 +705,11 @@ func TestAddQuizToSuite(t *testing.T) {
 			quizSuiteID: "1",
 			quizID:      "2",
 			mockSetup: func() {
-				mockService.On("GetQuizSuite", uint(1)).Return(&quiz_suite.QuizSuite{
+				mockService.On("GetQuizSuite", mock.Anything, uint(1)).Return(&quiz_suite.QuizSuite{
 					ID:          1,
 					CreatedByID: 1,
 				}, nil).Once()
-				mockService.On("AddQuizToSuite", uint(1), uint(2)).Return(nil).Once()
+				mockService.On("AddQuizToSuite", mock.Anything, uint(1), uint(2)).Return(nil).Once()
 			},
 			expectedStatus: http.StatusOK,
 			expectedBody: map[string]interface{}{
@@ -675,7 +724,7 @@ func TestAddQuizToSuite(t *testing.T) {
 			mockSetup:   func() {},
 			expectedStatus: http.StatusBadRequest,
 			expectedBody: map[string]interface{}{
-				"error": "invalid quiz suite id",
+				"code": "validation_failed",
 			},
 		},
 		{
@@ -686,7 +735,7 @@ func TestAddQuizToSuite(t *testing.T) {
 			mockSetup:   func() {},
 			expectedStatus: http.StatusBadRequest,
 			expectedBody: map[string]interface{}{
-				"error": "invalid quiz id",
+				"code": "validation_failed",
 			},
 		},
 		{
@@ -697,7 +746,7 @@ func TestAddQuizToSuite(t *testing.T) {
 			mockSetup:   func() {},
 			expectedStatus: http.StatusUnauthorized,
 			expectedBody: map[string]interface{}{
-				"error": "unauthorized",
+				"code": "unauthenticated",
 			},
 		},
 		{
@@ -706,11 +755,11 @@ func TestAddQuizToSuite(t *testing.T) {
 			quizSuiteID: "1",
 			quizID:      "2",
 			mockSetup: func() {
-				mockService.On("GetQuizSuite", uint(1)).Return(nil, gorm.ErrRecordNotFound).Once()
+				mockService.On("GetQuizSuite", mock.Anything, uint(1)).Return(nil, service.ErrQuizSuiteNotFound).Once()
 			},
 			expectedStatus: http.StatusNotFound,
 			expectedBody: map[string]interface{}{
-				"error": "quiz suite not found",
+				"code": "quiz_suite_not_found",
 			},
 		},
 		{
@@ -719,14 +768,14 @@ func TestAddQuizToSuite(t *testing.T) {
 			quizSuiteID: "1",
 			quizID:      "2",
 			mockSetup: func() {
-				mockService.On("GetQuizSuite", uint(1)).Return(&quiz_suite.QuizSuite{
+				mockService.On("GetQuizSuite", mock.Anything, uint(1)).Return(&quiz_suite.QuizSuite{
 					ID:          1,
 					CreatedByID: 1,
 				}, nil).Once()
 			},
 			expectedStatus: http.StatusForbidden,
 			expectedBody: map[string]interface{}{
-				"error": "unauthorized: you don't have permission to access this quiz suite",
+				"code": "quiz_suite_forbidden",
 			},
 		},
 		{
@@ -735,15 +784,15 @@ func TestAddQuizToSuite(t *testing.T) {
 			quizSuiteID: "1",
 			quizID:      "2",
 			mockSetup: func() {
-				mockService.On("GetQuizSuite", uint(1)).Return(&quiz_suite.QuizSuite{
+				mockService.On("GetQuizSuite", mock.Anything, uint(1)).Return(&quiz_suite.QuizSuite{
 					ID:          1,
 					CreatedByID: 1,
 				}, nil).Once()
-				mockService.On("AddQuizToSuite", uint(1), uint(2)).Return(gorm.ErrInvalidDB).Once()
+				mockService.On("AddQuizToSuite", mock.Anything, uint(1), uint(2)).Return(gorm.ErrInvalidDB).Once()
 			},
 			expectedStatus: http.StatusInternalServerError,
 			expectedBody: map[string]interface{}{
-				"error": "gorm: invalid db",
+				"code": "internal_error",
 			},
 		},
 	}
@@ -762,7 +811,7 @@ func TestAddQuizToSuite(t *testing.T) {
 
 			// Set user ID in context
 			if tc.userID > 0 {
-				c.Set("userID", tc.userID)
+				auth.SetPrincipal(c, &auth.Principal{UserID: tc.userID, Method: auth.AuthMethodBearer})
 			}
 
 			// Set up mock
@@ -770,7 +819,7 @@ func TestAddQuizToSuite(t *testing.T) {
 
 			// Create handler and execute
 			handler := NewQuizSuiteHandler(mockService)
-			handler.AddQuizToSuite(c)
+			serve(c, handler.AddQuizToSuite)
 
 			// Assert response
 			assert.Equal(t, tc.expectedStatus, w.Code)
@@ -778,7 +827,11 @@ func TestAddQuizToSuite(t *testing.T) {
 			var response map[string]interface{}
 			err := json.Unmarshal(w.Body.Bytes(), &response)
 			assert.NoError(t, err)
-			assert.Equal(t, tc.expectedBody, response)
+			if code, ok := tc.expectedBody["code"]; ok {
+				assert.Equal(t, code, response["code"])
+			} else {
+				assert.Equal(t, tc.expectedBody, response)
+			}
 		})
 	}
 }
@@ -800,7 +853,7 @@ func TestRemoveQuizFromSuite(t *testing.T) {
 			quizSuiteID: "1",
 			quizID:      "2",
 			mockSetup: func() {
-				mockService.On("RemoveQuizFromSuite", uint(1), uint(2)).Return(nil).Once()
+				mockService.On("RemoveQuizFromSuite", mock.Anything, uint(1), uint(2)).Return(nil).Once()
 			},
 			expectedStatus: http.StatusOK,
 			expectedBody: map[string]interface{}{
@@ -814,7 +867,7 @@ func TestRemoveQuizFromSuite(t *testing.T) {
 			mockSetup:   func() {},
 			expectedStatus: http.StatusBadRequest,
 			expectedBody: map[string]interface{}{
-				"error": "invalid quiz suite id",
+				"code": "validation_failed",
 			},
 		},
 		{
@@ -824,7 +877,7 @@ func TestRemoveQuizFromSuite(t *testing.T) {
 			mockSetup:   func() {},
 			expectedStatus: http.StatusBadRequest,
 			expectedBody: map[string]interface{}{
-				"error": "invalid quiz id",
+				"code": "validation_failed",
 			},
 		},
 		{
@@ -832,11 +885,11 @@ func TestRemoveQuizFromSuite(t *testing.T) {
 			quizSuiteID: "1",
 			quizID:      "2",
 			mockSetup: func() {
-				mockService.On("RemoveQuizFromSuite", uint(1), uint(2)).Return(gorm.ErrInvalidDB).Once()
+				mockService.On("RemoveQuizFromSuite", mock.Anything, uint(1), uint(2)).Return(gorm.ErrInvalidDB).Once()
 			},
 			expectedStatus: http.StatusInternalServerError,
 			expectedBody: map[string]interface{}{
-				"error": "invalid db",
+				"code": "internal_error",
 			},
 		},
 	}
@@ -858,7 +911,7 @@ func TestRemoveQuizFromSuite(t *testing.T) {
 
 			// Create handler and execute
 			handler := NewQuizSuiteHandler(mockService)
-			handler.RemoveQuizFromSuite(c)
+			serve(c, handler.RemoveQuizFromSuite)
 
 			// Assert response
 			assert.Equal(t, tc.expectedStatus, w.Code)
@@ -866,7 +919,95 @@ func TestRemoveQuizFromSuite(t *testing.T) {
 			var response map[string]interface{}
 			err := json.Unmarshal(w.Body.Bytes(), &response)
 			assert.NoError(t, err)
-			assert.Equal(t, tc.expectedBody, response)
+			if code, ok := tc.expectedBody["code"]; ok {
+				assert.Equal(t, code, response["code"])
+			} else {
+				assert.Equal(t, tc.expectedBody, response)
+			}
+		})
+	}
+} 
+func TestCloneQuizSuite(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := new(services.MockQuizSuiteService)
+	handler := NewQuizSuiteHandler(mockService)
+	clonedFrom := uint(1)
+
+	testCases := []struct {
+		name           string
+		query          string
+		mockSetup      func()
+		expectedStatus int
+		expectedCode   string
+	}{
+		{
+			name: "Success",
+			mockSetup: func() {
+				mockService.On("CloneQuizSuite", mock.Anything, uint(1), uint(2), service.CloneOptions{Provenance: true}).Return(&quiz_suite.QuizSuite{
+					ID:           3,
+					Version:      1,
+					Title:        "Test Quiz Suite",
+					CreatedByID:  2,
+					ClonedFromID: &clonedFrom,
+				}, nil).Once()
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:  "Shared Quizzes Without Provenance",
+			query: "?share_quizzes=true&provenance=false",
+			mockSetup: func() {
+				mockService.On("CloneQuizSuite", mock.Anything, uint(1), uint(2), service.CloneOptions{ShareQuizzes: true}).Return(&quiz_suite.QuizSuite{
+					ID:          3,
+					Version:     1,
+					Title:       "Test Quiz Suite",
+					CreatedByID: 2,
+				}, nil).Once()
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "Invalid Option",
+			query:          "?share_quizzes=maybe",
+			mockSetup:      func() {},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "validation_failed",
+		},
+		{
+			name: "Not Found",
+			mockSetup: func() {
+				mockService.On("CloneQuizSuite", mock.Anything, uint(1), uint(2), service.CloneOptions{Provenance: true}).Return(nil, service.ErrQuizSuiteNotFound).Once()
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedCode:   "quiz_suite_not_found",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+
+			c.Request = httptest.NewRequest(http.MethodPost, "/quiz-suites/1/clone"+tc.query, nil)
+			c.Params = gin.Params{{Key: "id", Value: "1"}}
+			auth.SetPrincipal(c, &auth.Principal{UserID: 2, Method: auth.AuthMethodBearer})
+
+			tc.mockSetup()
+
+			serve(c, handler.CloneQuizSuite)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+
+			var response map[string]interface{}
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			if tc.expectedCode != "" {
+				assert.Equal(t, tc.expectedCode, response["code"])
+			} else {
+				assert.Equal(t, float64(3), response["id"])
+				assert.Equal(t, float64(2), response["created_by_id"])
+			}
 		})
 	}
-} 
\ No newline at end of file
+
+	mockService.AssertExpectations(t)
+}
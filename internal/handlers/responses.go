@@ -1,13 +1,18 @@
 package handlers
 
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"quizlet/internal/apperr"
+	"quizlet/internal/auth"
+	"quizlet/internal/problem"
+)
+
 // ErrorResponse represents an error response
 // @model ErrorResponse
-// @Description Error response from the API
-type ErrorResponse struct {
-	// The error message
-	// @example "quiz attempt not found"
-	Error string `json:"error" example:"quiz attempt not found"`
-}
+// @Description RFC 7807 problem details, sent as application/problem+json
+type ErrorResponse = problem.Problem
 
 // SuccessResponse represents a success response
 // @model SuccessResponse
@@ -16,4 +21,54 @@ type SuccessResponse struct {
 	// The success message
 	// @example "Operation completed successfully"
 	Message string `json:"message" example:"Operation completed successfully"`
-} 
\ No newline at end of file
+}
+
+// ErrUnauthenticated is reported when a handler runs without a principal
+var ErrUnauthenticated = apperr.New(apperr.Unauthenticated, "unauthenticated", "authentication required")
+
+// Handlers report failures with c.Error and return; problem.Middleware
+// renders the response. The helpers below record the error and tell the
+// caller to stop.
+
+// currentUserID returns the authenticated user or records ErrUnauthenticated
+func currentUserID(c *gin.Context) (uint, bool) {
+	userID, ok := auth.CurrentUserID(c)
+	if !ok {
+		_ = c.Error(ErrUnauthenticated)
+	}
+	return userID, ok
+}
+
+// bindJSON binds the request body, recording a bind error when it is
+// malformed or fails validation
+func bindJSON(c *gin.Context, obj any) bool {
+	if err := c.ShouldBindJSON(obj); err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
+		return false
+	}
+	return true
+}
+
+// bindQuery binds the query string, recording a bind error when a parameter
+// is malformed or fails validation
+func bindQuery(c *gin.Context, obj any) bool {
+	if err := c.ShouldBindQuery(obj); err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
+		return false
+	}
+	return true
+}
+
+// paramID parses a numeric path parameter
+func paramID(c *gin.Context, name string) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param(name), 10, 32)
+	if err != nil {
+		_ = c.Error(apperr.Validation(apperr.FieldError{
+			Field:   name,
+			Code:    "id",
+			Message: "must be a positive integer",
+		}).Wrap(err))
+		return 0, false
+	}
+	return uint(id), true
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"quizlet/internal/apperr"
+	"quizlet/internal/problem"
+)
+
+// serve runs a handler on a test context the way the router does, with
+// recorded errors rendered by the problem middleware
+func serve(c *gin.Context, handler gin.HandlerFunc) {
+	handler(c)
+	problem.Render(c)
+}
+
+// decodeProblem decodes a problem response and checks its content type
+func decodeProblem(t *testing.T, w *httptest.ResponseRecorder) problem.Problem {
+	t.Helper()
+	assert.Equal(t, problem.ContentType, w.Header().Get("Content-Type"))
+	var p problem.Problem
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &p))
+	return p
+}
+
+func TestBindJSONReportsFieldErrors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(problem.Middleware())
+	router.POST("/users", newUserHandler(new(MockUserService)).CreateUser)
+	router.GET("/users/:id", newUserHandler(new(MockUserService)).GetUser)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/users", bytes.NewBufferString(`{"username":"ann","email":"nope"}`)))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	p := decodeProblem(t, w)
+	assert.Equal(t, "validation_failed", p.Code)
+	assert.Equal(t, "/users", p.Instance)
+	assert.Equal(t, []apperr.FieldError{
+		{Field: "email", Code: "email", Message: "must be a valid email address"},
+		{Field: "password", Code: "required", Message: "is required"},
+	}, p.Errors)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/users", bytes.NewBufferString(`{"username":`)))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "malformed_body", decodeProblem(t, w).Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/abc", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, []apperr.FieldError{
+		{Field: "id", Code: "id", Message: "must be a positive integer"},
+	}, decodeProblem(t, w).Errors)
+}
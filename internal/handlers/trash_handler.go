@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"quizlet/internal/service"
+)
+
+type TrashHandler struct {
+	trashService service.TrashService
+}
+
+func NewTrashHandler(trashService service.TrashService) *TrashHandler {
+	return &TrashHandler{
+		trashService: trashService,
+	}
+}
+
+// @Summary List the trash
+// @Description List the deleted quizzes, quiz suites and quiz attempts of the authenticated user that can still be restored, most recently deleted first
+// @Tags trash
+// @Produce json
+// @Success 200 {array} trash.Item
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /trash [get]
+func (h *TrashHandler) ListTrash(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	items, err := h.trashService.List(c.Request.Context(), userID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, items)
+}
+
+// @Summary Restore a quiz
+// @Description Take a deleted quiz of the authenticated user out of the trash
+// @Tags trash
+// @Produce json
+// @Param id path int true "Quiz ID"
+// @Success 200 {object} quiz.Quiz
+// @Header 200 {string} ETag "Version of the quiz"
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /trash/quizzes/{id}/restore [post]
+func (h *TrashHandler) RestoreQuiz(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+
+	q, err := h.trashService.RestoreQuiz(c.Request.Context(), id, userID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Header("ETag", quizETag(q))
+	c.JSON(http.StatusOK, q)
+}
+
+// @Summary Restore a quiz suite
+// @Description Take a deleted quiz suite of the authenticated user out of the trash, with the attempts deleted along with it
+// @Tags trash
+// @Produce json
+// @Param id path int true "Quiz Suite ID"
+// @Success 200 {object} quiz_suite.QuizSuite
+// @Header 200 {string} ETag "Version of the quiz suite"
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /trash/quiz-suites/{id}/restore [post]
+func (h *TrashHandler) RestoreQuizSuite(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+
+	quizSuite, err := h.trashService.RestoreQuizSuite(c.Request.Context(), id, userID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Header("ETag", quizSuiteETag(quizSuite))
+	c.JSON(http.StatusOK, quizSuite)
+}
+
+// @Summary Restore a quiz attempt
+// @Description Take a deleted quiz attempt of the authenticated user out of the trash. Its quiz suite must not be in the trash.
+// @Tags trash
+// @Produce json
+// @Param id path int true "Quiz Attempt ID"
+// @Success 200 {object} quiz_attempt.QuizAttempt
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /trash/quiz-attempts/{id}/restore [post]
+func (h *TrashHandler) RestoreQuizAttempt(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+
+	attempt, err := h.trashService.RestoreQuizAttempt(c.Request.Context(), id, userID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, attempt)
+}
+
+// @Summary Restore a user
+// @Description Bring back a deleted user account with the content deleted along with it. Requires the admin role.
+// @Tags trash
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {object} user.User
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /trash/users/{id}/restore [post]
+func (h *TrashHandler) RestoreUser(c *gin.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+
+	u, err := h.trashService.RestoreUser(c.Request.Context(), id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, u)
+}
@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"quizlet/internal/auth"
+	"quizlet/internal/models/quiz"
+	"quizlet/internal/models/quiz_attempt"
+	"quizlet/internal/models/quiz_suite"
+	"quizlet/internal/models/trash"
+	"quizlet/internal/models/user"
+	"quizlet/internal/service"
+)
+
+type MockTrashService struct {
+	mock.Mock
+}
+
+var _ service.TrashService = (*MockTrashService)(nil)
+
+func (m *MockTrashService) List(ctx context.Context, userID uint) ([]trash.Item, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]trash.Item), args.Error(1)
+}
+
+func (m *MockTrashService) RestoreQuiz(ctx context.Context, id, userID uint) (*quiz.Quiz, error) {
+	args := m.Called(ctx, id, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*quiz.Quiz), args.Error(1)
+}
+
+func (m *MockTrashService) RestoreQuizSuite(ctx context.Context, id, userID uint) (*quiz_suite.QuizSuite, error) {
+	args := m.Called(ctx, id, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*quiz_suite.QuizSuite), args.Error(1)
+}
+
+func (m *MockTrashService) RestoreQuizAttempt(ctx context.Context, id, userID uint) (*quiz_attempt.QuizAttempt, error) {
+	args := m.Called(ctx, id, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*quiz_attempt.QuizAttempt), args.Error(1)
+}
+
+func (m *MockTrashService) RestoreUser(ctx context.Context, id uint) (*user.User, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*user.User), args.Error(1)
+}
+
+func (m *MockTrashService) Purge(ctx context.Context) (int64, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func TestRestoreQuizSuite(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := new(MockTrashService)
+	handler := NewTrashHandler(mockService)
+
+	testCases := []struct {
+		name           string
+		quizSuiteID    string
+		mockSetup      func()
+		expectedStatus int
+		expectedETag   string
+		expectedBody   string
+	}{
+		{
+			name:        "Success",
+			quizSuiteID: "2",
+			mockSetup: func() {
+				mockService.On("RestoreQuizSuite", mock.Anything, uint(2), uint(1)).
+					Return(&quiz_suite.QuizSuite{ID: 2, Title: "Basic Math", Version: 3, CreatedByID: 1}, nil).Once()
+			},
+			expectedStatus: http.StatusOK,
+			expectedETag:   `"3"`,
+		},
+		{
+			name:        "Not In Trash",
+			quizSuiteID: "4",
+			mockSetup: func() {
+				mockService.On("RestoreQuizSuite", mock.Anything, uint(4), uint(1)).Return(nil, service.ErrTrashItemNotFound).Once()
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `{"type":"urn:quizlet:problem:trash_item_not_found","title":"Not Found","status":404,"detail":"item not found in the trash","instance":"/trash/quiz-suites/4/restore","code":"trash_item_not_found"}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+
+			c.Request = httptest.NewRequest(http.MethodPost, "/trash/quiz-suites/"+tc.quizSuiteID+"/restore", nil)
+			c.Params = []gin.Param{{Key: "id", Value: tc.quizSuiteID}}
+			auth.SetPrincipal(c, &auth.Principal{UserID: 1, Method: auth.AuthMethodBearer})
+
+			tc.mockSetup()
+
+			serve(c, handler.RestoreQuizSuite)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			assert.Equal(t, tc.expectedETag, w.Header().Get("ETag"))
+			if tc.expectedBody != "" {
+				assert.JSONEq(t, tc.expectedBody, w.Body.String())
+			}
+		})
+	}
+
+	mockService.AssertExpectations(t)
+}
+
+func TestRestoreQuizAttemptInTrashedSuite(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := new(MockTrashService)
+	handler := NewTrashHandler(mockService)
+	mockService.On("RestoreQuizAttempt", mock.Anything, uint(5), uint(1)).Return(nil, service.ErrQuizSuiteInTrash).Once()
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/trash/quiz-attempts/5/restore", nil)
+	c.Params = []gin.Param{{Key: "id", Value: "5"}}
+	auth.SetPrincipal(c, &auth.Principal{UserID: 1, Method: auth.AuthMethodBearer})
+
+	serve(c, handler.RestoreQuizAttempt)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.JSONEq(t, `{"type":"urn:quizlet:problem:quiz_suite_in_trash","title":"Conflict","status":409,"detail":"the quiz suite of the attempt is in the trash; restore it first","instance":"/trash/quiz-attempts/5/restore","code":"quiz_suite_in_trash"}`, w.Body.String())
+	mockService.AssertExpectations(t)
+}
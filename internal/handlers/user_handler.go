@@ -1,45 +1,86 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"quizlet/internal/models/user"
 	"quizlet/internal/service"
 	"strconv"
-	"log"
+	"log/slog"
 
 	"github.com/gin-gonic/gin"
+	"quizlet/internal/apperr"
 	"quizlet/internal/auth"
-	"gorm.io/gorm"
+	"quizlet/internal/metrics"
+)
+
+var (
+	ErrInvalidMFAChallenge = apperr.New(apperr.Unauthenticated, "invalid_mfa_token", "invalid or expired mfa token")
+	ErrMFALoginFailed      = apperr.New(apperr.Unauthenticated, "mfa_login_failed", "invalid two-factor code")
 )
 
 type UserHandler struct {
 	userService service.UserService
+	sessions    sessionIssuer
 }
 
-func NewUserHandler(userService service.UserService) *UserHandler {
+func NewUserHandler(userService service.UserService, tokens auth.TokenConfig, cookies auth.CookieConfig) *UserHandler {
 	return &UserHandler{
 		userService: userService,
+		sessions:    sessionIssuer{tokens: tokens, cookies: cookies},
 	}
 }
 
+// sessionIssuer signs the tokens and sets the cookies of the sessions started
+// by the user and single sign-on handlers
+type sessionIssuer struct {
+	tokens  auth.TokenConfig
+	cookies auth.CookieConfig
+}
+
 type LoginRequest struct {
 	Email    string `json:"email" binding:"required,email"`
 	Password string `json:"password" binding:"required"`
 }
 
+// LoginResponse carries the tokens in the body, or only the CSRF token when the
+// client asked for a cookie session with the X-Session-Mode: cookie header
 type LoginResponse struct {
 	User         user.User `json:"user"`
-	AccessToken  string    `json:"access_token"`
-	RefreshToken string    `json:"refresh_token"`
+	AccessToken  string    `json:"access_token,omitempty"`
+	RefreshToken string    `json:"refresh_token,omitempty"`
+	CSRFToken    string    `json:"csrf_token,omitempty"`
 	ExpiresIn    int64     `json:"expires_in"`
 }
 
+// MFAChallengeResponse is returned by Login instead of LoginResponse when the
+// user has two-factor authentication enabled
+type MFAChallengeResponse struct {
+	MFARequired bool   `json:"mfa_required"`
+	MFAToken    string `json:"mfa_token"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+type LoginMFARequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+type MFACodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
 type RefreshTokenRequest struct {
 	RefreshToken string `json:"refresh_token" binding:"required"`
 }
 
 type RefreshTokenResponse struct {
-	AccessToken string `json:"access_token"`
+	AccessToken string `json:"access_token,omitempty"`
+	CSRFToken   string `json:"csrf_token,omitempty"`
 	ExpiresIn   int64  `json:"expires_in"`
 }
 
@@ -50,13 +91,12 @@ type RefreshTokenResponse struct {
 // @Produce json
 // @Param user body user.CreateUserRequest true "User information"
 // @Success 201 {object} user.User
-// @Failure 400 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
 // @Router /users [post]
 func (h *UserHandler) CreateUser(c *gin.Context) {
 	var req user.CreateUserRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(c, &req) {
 		return
 	}
 
@@ -66,8 +106,8 @@ func (h *UserHandler) CreateUser(c *gin.Context) {
 		Password: req.Password,
 	}
 
-	if err := h.userService.CreateUser(u); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.userService.CreateUser(c.Request.Context(), u); err != nil {
+		c.Error(err)
 		return
 	}
 
@@ -80,21 +120,20 @@ func (h *UserHandler) CreateUser(c *gin.Context) {
 // @Produce json
 // @Param id path int true "User ID"
 // @Success 200 {object} user.User
-// @Failure 400 {object} map[string]string
-// @Failure 401 {object} map[string]string
-// @Failure 404 {object} map[string]string
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
 // @Security BearerAuth
 // @Router /users/{id} [get]
 func (h *UserHandler) GetUser(c *gin.Context) {
-	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+	id, ok := paramID(c, "id")
+	if !ok {
 		return
 	}
 
-	u, err := h.userService.GetUserByID(uint(id))
+	u, err := h.userService.GetUserByID(c.Request.Context(), id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		c.Error(err)
 		return
 	}
 
@@ -111,27 +150,30 @@ func (h *UserHandler) GetUser(c *gin.Context) {
 // @Param id path int true "User ID"
 // @Param user body user.User true "User information"
 // @Success 200 {object} user.User
-// @Failure 400 {object} map[string]string
-// @Failure 401 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
 // @Security BearerAuth
 // @Router /users/{id} [put]
 func (h *UserHandler) UpdateUser(c *gin.Context) {
-	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+
+	if !authorizeUser(c, id) {
 		return
 	}
 
 	var u user.User
-	if err := c.ShouldBindJSON(&u); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(c, &u) {
 		return
 	}
 
-	u.ID = uint(id)
-	if err := h.userService.UpdateUser(&u); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	u.ID = id
+	if err := h.userService.UpdateUser(c.Request.Context(), &u); err != nil {
+		c.Error(err)
 		return
 	}
 
@@ -146,153 +188,306 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 // @Produce json
 // @Param id path int true "User ID"
 // @Success 200 {object} map[string]string
-// @Failure 400 {object} map[string]string
-// @Failure 401 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
 // @Security BearerAuth
 // @Router /users/{id} [delete]
 func (h *UserHandler) DeleteUser(c *gin.Context) {
-	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+
+	if !authorizeUser(c, id) {
 		return
 	}
 
-	if err := h.userService.DeleteUser(uint(id)); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.userService.DeleteUser(c.Request.Context(), id); err != nil {
+		c.Error(err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "user deleted successfully"})
 }
 
+// authorizeUser lets callers change their own account, and admins any
+// account; it records an error for everyone else
+func authorizeUser(c *gin.Context, id uint) bool {
+	p, ok := auth.CurrentPrincipal(c)
+	if !ok {
+		_ = c.Error(ErrUnauthenticated)
+		return false
+	}
+	if p.UserID != id && !p.HasRole(auth.RoleAdmin) {
+		_ = c.Error(service.ErrUserForbidden)
+		return false
+	}
+	return true
+}
+
 // @Summary Login user
-// @Description Authenticate user with email and password
+// @Description Authenticate user with email and password. Users with two-factor authentication enabled receive an MFAChallengeResponse and must complete the login at /users/login/mfa. Send X-Session-Mode: cookie to receive httpOnly session cookies instead of tokens in the body.
 // @Tags users
 // @Accept json
 // @Produce json
 // @Param credentials body LoginRequest true "Login credentials"
+// @Param X-Session-Mode header string false "Set to cookie for a browser cookie session"
 // @Success 200 {object} LoginResponse
-// @Failure 400 {object} map[string]string
-// @Failure 401 {object} map[string]string
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
 // @Router /users/login [post]
 func (h *UserHandler) Login(c *gin.Context) {
 	var req LoginRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(c, &req) {
 		return
 	}
 
-	log.Printf("Login attempt for email: %s", req.Email)
+	u, err := h.userService.ValidatePassword(c.Request.Context(), req.Email, req.Password)
+	if err != nil {
+		slog.InfoContext(c.Request.Context(), "login failed", "email", req.Email, "error", err)
+		if errors.Is(err, service.ErrInvalidCredentials) {
+			metrics.LoginFailed(metrics.LoginMethodPassword)
+		}
+		c.Error(err)
+		return
+	}
 
-	u, err := h.userService.ValidatePassword(req.Email, req.Password)
+	h.sessions.completeLogin(c, h.userService, u, metrics.LoginMethodPassword)
+}
+
+// completeLogin finishes a first-factor login: users with two-factor
+// authentication get an MFA challenge, everyone else gets tokens
+func (s sessionIssuer) completeLogin(c *gin.Context, userService service.UserService, u *user.User, method string) {
+	if u.TOTPEnabled {
+		slog.InfoContext(c.Request.Context(), "first factor accepted, two-factor code required", "user_id", u.ID)
+		if err := userService.BeginMFAChallenge(c.Request.Context(), u.ID); err != nil {
+			c.Error(err)
+			return
+		}
+		mfaToken, err := s.tokens.GenerateMFAChallengeToken(u.ID)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, MFAChallengeResponse{
+			MFARequired: true,
+			MFAToken:    mfaToken,
+			ExpiresIn:   int64(auth.MFAChallengeTTL.Seconds()),
+		})
+		return
+	}
+
+	slog.InfoContext(c.Request.Context(), "login succeeded", "user_id", u.ID)
+	metrics.LoginSucceeded(method)
+	s.respondWithTokens(c, userService, u)
+}
+
+// @Summary Complete two-factor login
+// @Description Exchange an MFA challenge token and a TOTP or recovery code for access and refresh tokens. After 5 wrong codes the challenge stops working and the user has to sign in again.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param credentials body LoginMFARequest true "MFA challenge token and code"
+// @Param X-Session-Mode header string false "Set to cookie for a browser cookie session"
+// @Success 200 {object} LoginResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /users/login/mfa [post]
+func (h *UserHandler) LoginMFA(c *gin.Context) {
+	var req LoginMFARequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	claims, err := h.sessions.tokens.ValidateMFAChallengeToken(req.MFAToken)
+	if err != nil {
+		c.Error(ErrInvalidMFAChallenge.Wrap(err))
+		return
+	}
+
+	u, err := h.userService.VerifyMFACode(c.Request.Context(), claims.UserID, req.Code)
 	if err != nil {
-		log.Printf("Login failed for email %s: %v", req.Email, err)
-		if err == gorm.ErrRecordNotFound || err.Error() == "invalid password" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
-		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		// Users without two-factor authentication get the same answer as a
+		// wrong code
+		if errors.Is(err, service.ErrInvalidMFACode) || errors.Is(err, service.ErrMFANotEnabled) {
+			metrics.LoginFailed(metrics.LoginMethodTOTP)
+			err = ErrMFALoginFailed.Wrap(err)
+		}
+		if errors.Is(err, service.ErrTooManyMFAAttempts) {
+			metrics.LoginFailed(metrics.LoginMethodTOTP)
 		}
+		c.Error(err)
 		return
 	}
 
-	log.Printf("Login successful for user: %s", u.Email)
+	slog.InfoContext(c.Request.Context(), "two-factor login succeeded", "user_id", u.ID)
+	metrics.LoginSucceeded(metrics.LoginMethodTOTP)
+	h.sessions.respondWithTokens(c, h.userService, u)
+}
 
-	// Generate access token
-	accessToken, err := auth.GenerateAccessToken(u.ID)
+// respondWithTokens issues a new access/refresh token pair and writes the LoginResponse
+func (s sessionIssuer) respondWithTokens(c *gin.Context, userService service.UserService, u *user.User) {
+	// Generate refresh token; it identifies the session the access tokens belong to
+	refreshToken, err := userService.CreateRefreshToken(c.Request.Context(), u.ID)
 	if err != nil {
-		log.Printf("Failed to generate access token for user %s: %v", u.Email, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate access token"})
+		slog.ErrorContext(c.Request.Context(), "generating refresh token failed", "user_id", u.ID, "error", err)
+		c.Error(err)
 		return
 	}
 
-	// Generate refresh token
-	refreshToken, err := h.userService.CreateRefreshToken(u.ID)
+	// Generate access token
+	accessToken, err := s.tokens.GenerateAccessToken(u.ID, sessionID(refreshToken), roles(u)...)
 	if err != nil {
-		log.Printf("Failed to generate refresh token for user %s: %v", u.Email, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate refresh token"})
+		slog.ErrorContext(c.Request.Context(), "generating access token failed", "user_id", u.ID, "error", err)
+		c.Error(err)
 		return
 	}
 
 	// Don't send password back in response
 	u.Password = ""
+
+	if auth.WantsCookieSession(c) {
+		csrfToken, err := s.cookies.SetSessionCookies(c, s.tokens, accessToken, refreshToken.Token)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, LoginResponse{
+			User:      *u,
+			CSRFToken: csrfToken,
+			ExpiresIn: int64(s.tokens.AccessTokenTTL.Seconds()),
+		})
+		return
+	}
 	
 	response := LoginResponse{
 		User:         *u,
 		AccessToken:  accessToken,
 		RefreshToken: refreshToken.Token,
-		ExpiresIn:    900, // 15 minutes in seconds
+		ExpiresIn:    int64(s.tokens.AccessTokenTTL.Seconds()),
 	}
 	
 	c.JSON(http.StatusOK, response)
 }
 
+// sessionID identifies a login session by the refresh token it was issued
+func sessionID(refreshToken *user.RefreshToken) string {
+	return strconv.FormatUint(uint64(refreshToken.ID), 10)
+}
+
+// roles returns the roles carried by the access tokens of u
+func roles(u *user.User) []string {
+	if u.IsAdmin {
+		return []string{auth.RoleAdmin}
+	}
+	return nil
+}
+
 // @Summary Refresh access token
-// @Description Get a new access token using a refresh token
+// @Description Get a new access token using a refresh token. Cookie sessions send no body; the refresh token cookie is used and a new access token cookie is set.
 // @Tags users
 // @Accept json
 // @Produce json
-// @Param refresh_token body RefreshTokenRequest true "Refresh token"
+// @Param refresh_token body RefreshTokenRequest false "Refresh token (omit for cookie sessions)"
 // @Success 200 {object} RefreshTokenResponse
-// @Failure 400 {object} map[string]string
-// @Failure 401 {object} map[string]string
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
 // @Router /users/refresh [post]
 func (h *UserHandler) RefreshToken(c *gin.Context) {
-	var req RefreshTokenRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
+	token, fromCookie := auth.RefreshTokenFromCookie(c)
+	if !fromCookie {
+		var req RefreshTokenRequest
+		if !bindJSON(c, &req) {
+			return
+		}
+		token = req.RefreshToken
 	}
 
 	// Validate refresh token
-	refreshToken, err := h.userService.ValidateRefreshToken(req.RefreshToken)
+	refreshToken, err := h.userService.ValidateRefreshToken(c.Request.Context(), token)
+	if err != nil {
+		if fromCookie {
+			h.sessions.cookies.ClearSessionCookies(c)
+		}
+		c.Error(err)
+		return
+	}
+
+	// Roles are read again, so changes apply from the next refresh
+	u, err := h.userService.GetUserByID(c.Request.Context(), refreshToken.UserID)
 	if err != nil {
-		if err == auth.ErrExpiredToken {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "refresh token has expired"})
-		} else {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid refresh token"})
+		if errors.Is(err, service.ErrUserNotFound) {
+			// The user was deleted after the token was issued
+			err = auth.ErrInvalidRefreshToken
 		}
+		if fromCookie {
+			h.sessions.cookies.ClearSessionCookies(c)
+		}
+		c.Error(err)
 		return
 	}
 
 	// Generate new access token
-	accessToken, err := auth.GenerateAccessToken(refreshToken.UserID)
+	accessToken, err := h.sessions.tokens.GenerateAccessToken(u.ID, sessionID(refreshToken), roles(u)...)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate access token"})
+		c.Error(err)
+		return
+	}
+
+	if fromCookie {
+		csrfToken, err := h.sessions.cookies.SetSessionCookies(c, h.sessions.tokens, accessToken, "")
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, RefreshTokenResponse{
+			CSRFToken: csrfToken,
+			ExpiresIn: int64(h.sessions.tokens.AccessTokenTTL.Seconds()),
+		})
 		return
 	}
 
 	response := RefreshTokenResponse{
 		AccessToken: accessToken,
-		ExpiresIn:   900, // 15 minutes in seconds
+		ExpiresIn:   int64(h.sessions.tokens.AccessTokenTTL.Seconds()),
 	}
 
 	c.JSON(http.StatusOK, response)
 }
 
 // @Summary Logout user
-// @Description Revoke the refresh token
+// @Description Revoke the refresh token. Cookie sessions send no body; the refresh token cookie is revoked and all session cookies are cleared.
 // @Tags users
 // @Accept json
 // @Produce json
-// @Param refresh_token body RefreshTokenRequest true "Refresh token"
+// @Param refresh_token body RefreshTokenRequest false "Refresh token (omit for cookie sessions)"
 // @Success 200 {object} map[string]string
-// @Failure 400 {object} map[string]string
-// @Failure 401 {object} map[string]string
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
 // @Security BearerAuth
 // @Router /users/logout [post]
 func (h *UserHandler) Logout(c *gin.Context) {
-	var req RefreshTokenRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
+	token, fromCookie := auth.RefreshTokenFromCookie(c)
+	if !fromCookie {
+		var req RefreshTokenRequest
+		if !bindJSON(c, &req) {
+			return
+		}
+		token = req.RefreshToken
 	}
 
-	if err := h.userService.RevokeRefreshToken(req.RefreshToken); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to revoke refresh token"})
+	if err := h.userService.RevokeRefreshToken(c.Request.Context(), token); err != nil {
+		c.Error(err)
 		return
 	}
 
+	if fromCookie {
+		h.sessions.cookies.ClearSessionCookies(c)
+	}
 	c.JSON(http.StatusOK, gin.H{"message": "successfully logged out"})
 }
 
@@ -301,24 +496,108 @@ func (h *UserHandler) Logout(c *gin.Context) {
 // @Tags users
 // @Produce json
 // @Success 200 {object} user.User
-// @Failure 401 {object} map[string]string
+// @Failure 401 {object} ErrorResponse
 // @Security BearerAuth
 // @Router /users/me [get]
 func (h *UserHandler) GetCurrentUser(c *gin.Context) {
 	// Get user ID from context (set by auth middleware)
-	userID, exists := c.Get("userID")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+	userID, ok := currentUserID(c)
+	if !ok {
 		return
 	}
 
-	u, err := h.userService.GetUserByID(userID.(uint))
+	u, err := h.userService.GetUserByID(c.Request.Context(), userID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		c.Error(err)
 		return
 	}
 
 	// Don't send password back in response
 	u.Password = ""
 	c.JSON(http.StatusOK, u)
-} 
\ No newline at end of file
+} 
+// @Summary Start TOTP enrollment
+// @Description Generate a new TOTP secret for the current user. Two-factor authentication is not enabled until the enrollment is confirmed.
+// @Tags users
+// @Produce json
+// @Success 200 {object} user.TOTPEnrollment
+// @Failure 401 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /users/me/mfa/totp [post]
+func (h *UserHandler) BeginTOTPEnrollment(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	enrollment, err := h.userService.BeginTOTPEnrollment(c.Request.Context(), userID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, enrollment)
+}
+
+// @Summary Confirm TOTP enrollment
+// @Description Enable two-factor authentication by submitting a code from the authenticator app. The one-time recovery codes are only returned once.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param code body MFACodeRequest true "TOTP code"
+// @Success 200 {object} RecoveryCodesResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /users/me/mfa/totp/confirm [post]
+func (h *UserHandler) ConfirmTOTPEnrollment(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req MFACodeRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	codes, err := h.userService.ConfirmTOTPEnrollment(c.Request.Context(), userID, req.Code)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// @Summary Disable TOTP
+// @Description Disable two-factor authentication after verifying a TOTP or recovery code
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param code body MFACodeRequest true "TOTP or recovery code"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /users/me/mfa/totp/disable [post]
+func (h *UserHandler) DisableTOTP(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req MFACodeRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	if err := h.userService.DisableTOTP(c.Request.Context(), userID, req.Code); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "two-factor authentication disabled"})
+}
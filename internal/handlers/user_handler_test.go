@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"context"
 	"bytes"
 	"encoding/json"
 	"net/http"
@@ -14,72 +15,128 @@ import (
 	"gorm.io/gorm"
 	"quizlet/internal/models/user"
 	"quizlet/internal/auth"
+	"quizlet/internal/service"
 )
 
 type MockUserService struct {
 	mock.Mock
 }
 
-func (m *MockUserService) CreateUser(user *user.User) error {
-	args := m.Called(user)
+func (m *MockUserService) CreateUser(ctx context.Context, user *user.User) error {
+	args := m.Called(ctx, user)
 	return args.Error(0)
 }
 
-func (m *MockUserService) GetUserByID(id uint) (*user.User, error) {
-	args := m.Called(id)
+func (m *MockUserService) GetUserByID(ctx context.Context, id uint) (*user.User, error) {
+	args := m.Called(ctx, id)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*user.User), args.Error(1)
 }
 
-func (m *MockUserService) GetUserByEmail(email string) (*user.User, error) {
-	args := m.Called(email)
+func (m *MockUserService) GetUserByEmail(ctx context.Context, email string) (*user.User, error) {
+	args := m.Called(ctx, email)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*user.User), args.Error(1)
 }
 
-func (m *MockUserService) UpdateUser(user *user.User) error {
-	args := m.Called(user)
+func (m *MockUserService) UpdateUser(ctx context.Context, user *user.User) error {
+	args := m.Called(ctx, user)
 	return args.Error(0)
 }
 
-func (m *MockUserService) DeleteUser(id uint) error {
-	args := m.Called(id)
+func (m *MockUserService) DeleteUser(ctx context.Context, id uint) error {
+	args := m.Called(ctx, id)
 	return args.Error(0)
 }
 
-func (m *MockUserService) ValidatePassword(email, password string) (*user.User, error) {
-	args := m.Called(email, password)
+func (m *MockUserService) ValidatePassword(ctx context.Context, email, password string) (*user.User, error) {
+	args := m.Called(ctx, email, password)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*user.User), args.Error(1)
 }
 
-func (m *MockUserService) CreateRefreshToken(userID uint) (*user.RefreshToken, error) {
-	args := m.Called(userID)
+func (m *MockUserService) CreateRefreshToken(ctx context.Context, userID uint) (*user.RefreshToken, error) {
+	args := m.Called(ctx, userID)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*user.RefreshToken), args.Error(1)
 }
 
-func (m *MockUserService) ValidateRefreshToken(token string) (*user.RefreshToken, error) {
-	args := m.Called(token)
+func (m *MockUserService) ValidateRefreshToken(ctx context.Context, token string) (*user.RefreshToken, error) {
+	args := m.Called(ctx, token)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*user.RefreshToken), args.Error(1)
 }
 
-func (m *MockUserService) RevokeRefreshToken(token string) error {
-	args := m.Called(token)
+func (m *MockUserService) RevokeRefreshToken(ctx context.Context, token string) error {
+	args := m.Called(ctx, token)
 	return args.Error(0)
 }
 
+func (m *MockUserService) BeginTOTPEnrollment(ctx context.Context, userID uint) (*user.TOTPEnrollment, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*user.TOTPEnrollment), args.Error(1)
+}
+
+func (m *MockUserService) ConfirmTOTPEnrollment(ctx context.Context, userID uint, code string) ([]string, error) {
+	args := m.Called(ctx, userID, code)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockUserService) DisableTOTP(ctx context.Context, userID uint, code string) error {
+	args := m.Called(ctx, userID, code)
+	return args.Error(0)
+}
+
+func (m *MockUserService) BeginMFAChallenge(ctx context.Context, userID uint) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockUserService) VerifyMFACode(ctx context.Context, userID uint, code string) (*user.User, error) {
+	args := m.Called(ctx, userID, code)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*user.User), args.Error(1)
+}
+
+func (m *MockUserService) LoginWithExternalIdentity(ctx context.Context, profile *user.ExternalProfile) (*user.User, error) {
+	args := m.Called(ctx, profile)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*user.User), args.Error(1)
+}
+
+func (m *MockUserService) LinkExternalIdentity(ctx context.Context, userID uint, profile *user.ExternalProfile) (*user.ExternalIdentity, error) {
+	args := m.Called(ctx, userID, profile)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*user.ExternalIdentity), args.Error(1)
+}
+
+// newUserHandler returns a UserHandler with the default token and cookie settings
+func newUserHandler(userService *MockUserService) *UserHandler {
+	return NewUserHandler(userService, auth.DefaultTokenConfig(), auth.DefaultCookieConfig())
+}
+
 func TestCreateUser(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	mockService := new(MockUserService)
@@ -99,7 +156,7 @@ func TestCreateUser(t *testing.T) {
 				"password": "password123",
 			},
 			mockSetup: func() {
-				mockService.On("CreateUser", mock.MatchedBy(func(u *user.User) bool {
+				mockService.On("CreateUser", mock.Anything, mock.MatchedBy(func(u *user.User) bool {
 					u.ID = 1
 					return u.Username == "testuser" &&
 						u.Email == "test@example.com" &&
@@ -125,7 +182,7 @@ func TestCreateUser(t *testing.T) {
 			mockSetup:      func() {},
 			expectedStatus: http.StatusBadRequest,
 			expectedBody: map[string]interface{}{
-				"error": "Key: 'CreateUserRequest.Username' Error:Field validation for 'Username' failed on the 'required' tag\nKey: 'CreateUserRequest.Email' Error:Field validation for 'Email' failed on the 'email' tag\nKey: 'CreateUserRequest.Password' Error:Field validation for 'Password' failed on the 'required' tag",
+				"code": "validation_failed",
 			},
 		},
 		{
@@ -136,11 +193,27 @@ func TestCreateUser(t *testing.T) {
 				"password": "password123",
 			},
 			mockSetup: func() {
-				mockService.On("CreateUser", mock.AnythingOfType("*user.User")).Return(gorm.ErrInvalidDB).Once()
+				mockService.On("CreateUser", mock.Anything, mock.AnythingOfType("*user.User")).Return(gorm.ErrInvalidDB).Once()
 			},
 			expectedStatus: http.StatusInternalServerError,
 			expectedBody: map[string]interface{}{
-				"error": "invalid db",
+				"code": "internal_error",
+			},
+		},
+		{
+			name:   "Weak Password",
+			requestBody: map[string]interface{}{
+				"username": "testuser",
+				"email":    "test@example.com",
+				"password": "short",
+			},
+			mockSetup: func() {
+				mockService.On("CreateUser", mock.Anything, mock.AnythingOfType("*user.User")).
+					Return(service.ErrWeakPassword.WithMessage("password must be at least 8 characters long")).Once()
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody: map[string]interface{}{
+				"code": "weak_password",
 			},
 		},
 	}
@@ -159,8 +232,8 @@ func TestCreateUser(t *testing.T) {
 			tc.mockSetup()
 
 			// Create handler and execute
-			handler := NewUserHandler(mockService)
-			handler.CreateUser(c)
+			handler := newUserHandler(mockService)
+			serve(c, handler.CreateUser)
 
 			// Assert response
 			assert.Equal(t, tc.expectedStatus, w.Code)
@@ -168,7 +241,11 @@ func TestCreateUser(t *testing.T) {
 			var response map[string]interface{}
 			err := json.Unmarshal(w.Body.Bytes(), &response)
 			assert.NoError(t, err)
-			assert.Equal(t, tc.expectedBody, response)
+			if code, ok := tc.expectedBody["code"]; ok {
+				assert.Equal(t, code, response["code"])
+			} else {
+				assert.Equal(t, tc.expectedBody, response)
+			}
 		})
 	}
 }
@@ -196,7 +273,7 @@ func TestGetUser(t *testing.T) {
 					CreatedAt: time.Time{},
 					UpdatedAt: time.Time{},
 				}
-				mockService.On("GetUserByID", uint(1)).Return(mockUser, nil).Once()
+				mockService.On("GetUserByID", mock.Anything, uint(1)).Return(mockUser, nil).Once()
 			},
 			expectedStatus: http.StatusOK,
 			expectedBody: map[string]interface{}{
@@ -213,18 +290,18 @@ func TestGetUser(t *testing.T) {
 			mockSetup:      func() {},
 			expectedStatus: http.StatusBadRequest,
 			expectedBody: map[string]interface{}{
-				"error": "invalid user id",
+				"code": "validation_failed",
 			},
 		},
 		{
 			name:   "User Not Found",
 			userID: "1",
 			mockSetup: func() {
-				mockService.On("GetUserByID", uint(1)).Return(nil, gorm.ErrRecordNotFound).Once()
+				mockService.On("GetUserByID", mock.Anything, uint(1)).Return(nil, service.ErrUserNotFound).Once()
 			},
 			expectedStatus: http.StatusNotFound,
 			expectedBody: map[string]interface{}{
-				"error": "user not found",
+				"code": "user_not_found",
 			},
 		},
 	}
@@ -239,17 +316,121 @@ func TestGetUser(t *testing.T) {
 
 			tc.mockSetup()
 
-			handler := NewUserHandler(mockService)
-			handler.GetUser(c)
+			handler := newUserHandler(mockService)
+			serve(c, handler.GetUser)
 
 			assert.Equal(t, tc.expectedStatus, w.Code)
 
 			var response map[string]interface{}
 			err := json.Unmarshal(w.Body.Bytes(), &response)
 			assert.NoError(t, err)
-			assert.Equal(t, tc.expectedBody, response)
+			if code, ok := tc.expectedBody["code"]; ok {
+				assert.Equal(t, code, response["code"])
+			} else {
+				assert.Equal(t, tc.expectedBody, response)
+			}
+		})
+	}
+}
+
+func TestUserAccountAccess(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := new(MockUserService)
+	handler := newUserHandler(mockService)
+
+	testCases := []struct {
+		name           string
+		method         string
+		principal      *auth.Principal
+		mockSetup      func()
+		expectedStatus int
+		expectedCode   string
+	}{
+		{
+			name:      "Delete Own Account",
+			method:    http.MethodDelete,
+			principal: &auth.Principal{UserID: 1},
+			mockSetup: func() {
+				mockService.On("DeleteUser", mock.Anything, uint(1)).Return(nil).Once()
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Delete Another Account",
+			method:         http.MethodDelete,
+			principal:      &auth.Principal{UserID: 2},
+			mockSetup:      func() {},
+			expectedStatus: http.StatusForbidden,
+			expectedCode:   "user_forbidden",
+		},
+		{
+			name:      "Admin Deletes Another Account",
+			method:    http.MethodDelete,
+			principal: &auth.Principal{UserID: 2, Roles: []string{auth.RoleAdmin}},
+			mockSetup: func() {
+				mockService.On("DeleteUser", mock.Anything, uint(1)).Return(nil).Once()
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Delete Unauthenticated",
+			method:         http.MethodDelete,
+			mockSetup:      func() {},
+			expectedStatus: http.StatusUnauthorized,
+			expectedCode:   "unauthenticated",
+		},
+		{
+			name:           "Update Another Account",
+			method:         http.MethodPut,
+			principal:      &auth.Principal{UserID: 2},
+			mockSetup:      func() {},
+			expectedStatus: http.StatusForbidden,
+			expectedCode:   "user_forbidden",
+		},
+		{
+			name:      "Update Own Account",
+			method:    http.MethodPut,
+			principal: &auth.Principal{UserID: 1},
+			mockSetup: func() {
+				mockService.On("UpdateUser", mock.Anything, mock.MatchedBy(func(u *user.User) bool {
+					return u.ID == 1 && u.Username == "renamed"
+				})).Return(nil).Once()
+			},
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+
+			body, _ := json.Marshal(map[string]interface{}{"username": "renamed"})
+			c.Request = httptest.NewRequest(tc.method, "/users/1", bytes.NewBuffer(body))
+			c.Request.Header.Set("Content-Type", "application/json")
+			c.Params = []gin.Param{{Key: "id", Value: "1"}}
+			if tc.principal != nil {
+				auth.SetPrincipal(c, tc.principal)
+			}
+
+			tc.mockSetup()
+
+			if tc.method == http.MethodDelete {
+				serve(c, handler.DeleteUser)
+			} else {
+				serve(c, handler.UpdateUser)
+			}
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			if tc.expectedCode != "" {
+				var response map[string]interface{}
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, tc.expectedCode, response["code"])
+			}
 		})
 	}
+
+	mockService.AssertExpectations(t)
 }
 
 func TestLogin(t *testing.T) {
@@ -270,7 +451,7 @@ func TestLogin(t *testing.T) {
 				"password": "password123",
 			},
 			mockSetup: func() {
-				mockService.On("ValidatePassword", "test@example.com", "password123").Return(&user.User{
+				mockService.On("ValidatePassword", mock.Anything, "test@example.com", "password123").Return(&user.User{
 					ID:        1,
 					Username:  "testuser",
 					Email:     "test@example.com",
@@ -278,7 +459,7 @@ func TestLogin(t *testing.T) {
 					UpdatedAt: time.Time{},
 				}, nil).Once()
 				
-				mockService.On("CreateRefreshToken", uint(1)).Return(&user.RefreshToken{
+				mockService.On("CreateRefreshToken", mock.Anything, uint(1)).Return(&user.RefreshToken{
 					Token:     "refresh-token-123",
 					UserID:    1,
 					ExpiresAt: time.Now().Add(30 * 24 * time.Hour),
@@ -307,7 +488,7 @@ func TestLogin(t *testing.T) {
 			mockSetup:      func() {},
 			expectedStatus: http.StatusBadRequest,
 			expectedBody: map[string]interface{}{
-				"error": "Key: 'LoginRequest.Email' Error:Field validation for 'Email' failed on the 'required' tag\nKey: 'LoginRequest.Password' Error:Field validation for 'Password' failed on the 'required' tag",
+				"code": "validation_failed",
 			},
 		},
 		{
@@ -317,11 +498,11 @@ func TestLogin(t *testing.T) {
 				"password": "wrongpassword",
 			},
 			mockSetup: func() {
-				mockService.On("ValidatePassword", "test@example.com", "wrongpassword").Return(nil, gorm.ErrRecordNotFound).Once()
+				mockService.On("ValidatePassword", mock.Anything, "test@example.com", "wrongpassword").Return(nil, service.ErrInvalidCredentials).Once()
 			},
 			expectedStatus: http.StatusUnauthorized,
 			expectedBody: map[string]interface{}{
-				"error": "record not found",
+				"code": "invalid_credentials",
 			},
 		},
 		{
@@ -331,11 +512,11 @@ func TestLogin(t *testing.T) {
 				"password": "password123",
 			},
 			mockSetup: func() {
-				mockService.On("ValidatePassword", "test@example.com", "password123").Return(nil, gorm.ErrInvalidDB).Once()
+				mockService.On("ValidatePassword", mock.Anything, "test@example.com", "password123").Return(nil, gorm.ErrInvalidDB).Once()
 			},
 			expectedStatus: http.StatusInternalServerError,
 			expectedBody: map[string]interface{}{
-				"error": "invalid db",
+				"code": "internal_error",
 			},
 		},
 	}
@@ -354,8 +535,8 @@ func TestLogin(t *testing.T) {
 			tc.mockSetup()
 
 			// Create handler and execute
-			handler := NewUserHandler(mockService)
-			handler.Login(c)
+			handler := newUserHandler(mockService)
+			serve(c, handler.Login)
 
 			// Assert response
 			assert.Equal(t, tc.expectedStatus, w.Code)
@@ -372,7 +553,11 @@ func TestLogin(t *testing.T) {
 				assert.Equal(t, tc.expectedBody["expires_in"], response["expires_in"])
 				assert.Equal(t, tc.expectedBody["user"], response["user"])
 			} else {
-				assert.Equal(t, tc.expectedBody, response)
+				if code, ok := tc.expectedBody["code"]; ok {
+					assert.Equal(t, code, response["code"])
+				} else {
+					assert.Equal(t, tc.expectedBody, response)
+				}
 			}
 		})
 	}
@@ -395,9 +580,10 @@ func TestRefreshToken(t *testing.T) {
 				"refresh_token": "valid-refresh-token",
 			},
 			mockSetup: func() {
-				mockService.On("ValidateRefreshToken", "valid-refresh-token").Return(&user.RefreshToken{
+				mockService.On("ValidateRefreshToken", mock.Anything, "valid-refresh-token").Return(&user.RefreshToken{
 					UserID: 1,
 				}, nil).Once()
+				mockService.On("GetUserByID", mock.Anything, uint(1)).Return(&user.User{ID: 1, IsAdmin: true}, nil).Once()
 			},
 			expectedStatus: http.StatusOK,
 			expectedBody: map[string]interface{}{
@@ -413,7 +599,7 @@ func TestRefreshToken(t *testing.T) {
 			mockSetup:      func() {},
 			expectedStatus: http.StatusBadRequest,
 			expectedBody: map[string]interface{}{
-				"error": "Key: 'RefreshTokenRequest.RefreshToken' Error:Field validation for 'RefreshToken' failed on the 'required' tag",
+				"code": "validation_failed",
 			},
 		},
 		{
@@ -422,11 +608,27 @@ func TestRefreshToken(t *testing.T) {
 				"refresh_token": "invalid-token",
 			},
 			mockSetup: func() {
-				mockService.On("ValidateRefreshToken", "invalid-token").Return(nil, gorm.ErrRecordNotFound).Once()
+				mockService.On("ValidateRefreshToken", mock.Anything, "invalid-token").Return(nil, auth.ErrInvalidRefreshToken).Once()
+			},
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody: map[string]interface{}{
+				"code": "invalid_refresh_token",
+			},
+		},
+		{
+			name:   "Deleted User",
+			requestBody: map[string]interface{}{
+				"refresh_token": "orphaned-token",
+			},
+			mockSetup: func() {
+				mockService.On("ValidateRefreshToken", mock.Anything, "orphaned-token").Return(&user.RefreshToken{
+					UserID: 2,
+				}, nil).Once()
+				mockService.On("GetUserByID", mock.Anything, uint(2)).Return(nil, service.ErrUserNotFound).Once()
 			},
 			expectedStatus: http.StatusUnauthorized,
 			expectedBody: map[string]interface{}{
-				"error": "invalid refresh token",
+				"code": "invalid_refresh_token",
 			},
 		},
 		{
@@ -435,11 +637,11 @@ func TestRefreshToken(t *testing.T) {
 				"refresh_token": "expired-token",
 			},
 			mockSetup: func() {
-				mockService.On("ValidateRefreshToken", "expired-token").Return(nil, auth.ErrExpiredToken).Once()
+				mockService.On("ValidateRefreshToken", mock.Anything, "expired-token").Return(nil, auth.ErrExpiredToken).Once()
 			},
 			expectedStatus: http.StatusUnauthorized,
 			expectedBody: map[string]interface{}{
-				"error": "refresh token has expired",
+				"code": "token_expired",
 			},
 		},
 	}
@@ -458,8 +660,8 @@ func TestRefreshToken(t *testing.T) {
 			tc.mockSetup()
 
 			// Create handler and execute
-			handler := NewUserHandler(mockService)
-			handler.RefreshToken(c)
+			handler := newUserHandler(mockService)
+			serve(c, handler.RefreshToken)
 
 			// Assert response
 			assert.Equal(t, tc.expectedStatus, w.Code)
@@ -473,8 +675,15 @@ func TestRefreshToken(t *testing.T) {
 				assert.Contains(t, response, "access_token")
 				assert.NotEmpty(t, response["access_token"])
 				assert.Equal(t, tc.expectedBody["expires_in"], response["expires_in"])
+				claims, err := auth.DefaultTokenConfig().ValidateToken(response["access_token"].(string))
+				assert.NoError(t, err)
+				assert.Equal(t, []string{auth.RoleAdmin}, claims.Roles)
 			} else {
-				assert.Equal(t, tc.expectedBody, response)
+				if code, ok := tc.expectedBody["code"]; ok {
+					assert.Equal(t, code, response["code"])
+				} else {
+					assert.Equal(t, tc.expectedBody, response)
+				}
 			}
 		})
 	}
@@ -497,7 +706,7 @@ func TestLogout(t *testing.T) {
 				"refresh_token": "valid-refresh-token",
 			},
 			mockSetup: func() {
-				mockService.On("RevokeRefreshToken", "valid-refresh-token").Return(nil).Once()
+				mockService.On("RevokeRefreshToken", mock.Anything, "valid-refresh-token").Return(nil).Once()
 			},
 			expectedStatus: http.StatusOK,
 			expectedBody: map[string]interface{}{
@@ -512,7 +721,7 @@ func TestLogout(t *testing.T) {
 			mockSetup:      func() {},
 			expectedStatus: http.StatusBadRequest,
 			expectedBody: map[string]interface{}{
-				"error": "Key: 'RefreshTokenRequest.RefreshToken' Error:Field validation for 'RefreshToken' failed on the 'required' tag",
+				"code": "validation_failed",
 			},
 		},
 		{
@@ -521,11 +730,11 @@ func TestLogout(t *testing.T) {
 				"refresh_token": "invalid-token",
 			},
 			mockSetup: func() {
-				mockService.On("RevokeRefreshToken", "invalid-token").Return(gorm.ErrInvalidDB).Once()
+				mockService.On("RevokeRefreshToken", mock.Anything, "invalid-token").Return(gorm.ErrInvalidDB).Once()
 			},
 			expectedStatus: http.StatusInternalServerError,
 			expectedBody: map[string]interface{}{
-				"error": "failed to revoke refresh token",
+				"code": "internal_error",
 			},
 		},
 	}
@@ -544,8 +753,8 @@ func TestLogout(t *testing.T) {
 			tc.mockSetup()
 
 			// Create handler and execute
-			handler := NewUserHandler(mockService)
-			handler.Logout(c)
+			handler := newUserHandler(mockService)
+			serve(c, handler.Logout)
 
 			// Assert response
 			assert.Equal(t, tc.expectedStatus, w.Code)
@@ -554,7 +763,244 @@ func TestLogout(t *testing.T) {
 			err := json.Unmarshal(w.Body.Bytes(), &response)
 			assert.NoError(t, err)
 
-			assert.Equal(t, tc.expectedBody, response)
+			if code, ok := tc.expectedBody["code"]; ok {
+				assert.Equal(t, code, response["code"])
+			} else {
+				assert.Equal(t, tc.expectedBody, response)
+			}
 		})
 	}
-} 
\ No newline at end of file
+} 
+func TestLoginWithMFA(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := new(MockUserService)
+	handler := newUserHandler(mockService)
+
+	mfaUser := &user.User{
+		ID:          2,
+		Username:    "mfauser",
+		Email:       "mfa@example.com",
+		TOTPEnabled: true,
+	}
+
+	// Password step returns a challenge instead of tokens
+	mockService.On("ValidatePassword", mock.Anything, "mfa@example.com", "password123").Return(mfaUser, nil).Once()
+	mockService.On("BeginMFAChallenge", mock.Anything, uint(2)).Return(nil).Once()
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	body, _ := json.Marshal(map[string]interface{}{"email": "mfa@example.com", "password": "password123"})
+	c.Request = httptest.NewRequest(http.MethodPost, "/login", bytes.NewBuffer(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	serve(c, handler.Login)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var challenge map[string]interface{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &challenge))
+	assert.Equal(t, true, challenge["mfa_required"])
+	assert.NotContains(t, challenge, "access_token")
+	mfaToken, _ := challenge["mfa_token"].(string)
+	assert.NotEmpty(t, mfaToken)
+
+	// The challenge token must not work as an access token
+	_, err := auth.DefaultTokenConfig().ValidateToken(mfaToken)
+	assert.ErrorIs(t, err, auth.ErrInvalidToken)
+
+	testCases := []struct {
+		name           string
+		requestBody    map[string]interface{}
+		mockSetup      func()
+		expectedStatus int
+		expectedBody   map[string]interface{}
+	}{
+		{
+			name: "Success",
+			requestBody: map[string]interface{}{
+				"mfa_token": mfaToken,
+				"code":      "123456",
+			},
+			mockSetup: func() {
+				mockService.On("VerifyMFACode", mock.Anything, uint(2), "123456").Return(mfaUser, nil).Once()
+				mockService.On("CreateRefreshToken", mock.Anything, uint(2)).Return(&user.RefreshToken{
+					Token:  "refresh-token-456",
+					UserID: 2,
+				}, nil).Once()
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody: map[string]interface{}{
+				"refresh_token": "refresh-token-456",
+			},
+		},
+		{
+			name: "Invalid Code",
+			requestBody: map[string]interface{}{
+				"mfa_token": mfaToken,
+				"code":      "000000",
+			},
+			mockSetup: func() {
+				mockService.On("VerifyMFACode", mock.Anything, uint(2), "000000").Return(nil, service.ErrInvalidMFACode).Once()
+			},
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody: map[string]interface{}{
+				"code": "mfa_login_failed",
+			},
+		},
+		{
+			name: "Too Many Attempts",
+			requestBody: map[string]interface{}{
+				"mfa_token": mfaToken,
+				"code":      "111111",
+			},
+			mockSetup: func() {
+				mockService.On("VerifyMFACode", mock.Anything, uint(2), "111111").Return(nil, service.ErrTooManyMFAAttempts).Once()
+			},
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody: map[string]interface{}{
+				"code": "too_many_mfa_attempts",
+			},
+		},
+		{
+			name: "Access Token Instead Of Challenge",
+			requestBody: map[string]interface{}{
+				"mfa_token": func() string {
+					token, _ := auth.DefaultTokenConfig().GenerateAccessToken(2, "1")
+					return token
+				}(),
+				"code": "123456",
+			},
+			mockSetup:      func() {},
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody: map[string]interface{}{
+				"code": "invalid_mfa_token",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+
+			body, _ := json.Marshal(tc.requestBody)
+			c.Request = httptest.NewRequest(http.MethodPost, "/login/mfa", bytes.NewBuffer(body))
+			c.Request.Header.Set("Content-Type", "application/json")
+
+			tc.mockSetup()
+
+			serve(c, handler.LoginMFA)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+
+			var response map[string]interface{}
+			err := json.Unmarshal(w.Body.Bytes(), &response)
+			assert.NoError(t, err)
+
+			if tc.expectedStatus == http.StatusOK {
+				assert.NotEmpty(t, response["access_token"])
+				assert.Equal(t, tc.expectedBody["refresh_token"], response["refresh_token"])
+			} else {
+				if code, ok := tc.expectedBody["code"]; ok {
+					assert.Equal(t, code, response["code"])
+				} else {
+					assert.Equal(t, tc.expectedBody, response)
+				}
+			}
+		})
+	}
+
+	mockService.AssertExpectations(t)
+}
+
+func TestCookieSession(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := new(MockUserService)
+	handler := newUserHandler(mockService)
+
+	cookiesByName := func(w *httptest.ResponseRecorder) map[string]*http.Cookie {
+		cookies := make(map[string]*http.Cookie)
+		for _, cookie := range w.Result().Cookies() {
+			cookies[cookie.Name] = cookie
+		}
+		return cookies
+	}
+
+	// Login with X-Session-Mode: cookie keeps the tokens out of the body
+	mockService.On("ValidatePassword", mock.Anything, "test@example.com", "password123").Return(&user.User{
+		ID:       1,
+		Username: "testuser",
+		Email:    "test@example.com",
+	}, nil).Once()
+	mockService.On("CreateRefreshToken", mock.Anything, uint(1)).Return(&user.RefreshToken{
+		Token:  "refresh-token",
+		UserID: 1,
+	}, nil).Once()
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	body, _ := json.Marshal(map[string]interface{}{"email": "test@example.com", "password": "password123"})
+	c.Request = httptest.NewRequest(http.MethodPost, "/users/login", bytes.NewBuffer(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Request.Header.Set(auth.SessionModeHeader, auth.SessionModeCookie)
+
+	serve(c, handler.Login)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var loginResponse map[string]interface{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &loginResponse))
+	assert.NotContains(t, loginResponse, "access_token")
+	assert.NotContains(t, loginResponse, "refresh_token")
+
+	cookies := cookiesByName(w)
+	if assert.Contains(t, cookies, auth.AccessTokenCookie) {
+		assert.True(t, cookies[auth.AccessTokenCookie].HttpOnly)
+		assert.Equal(t, http.SameSiteLaxMode, cookies[auth.AccessTokenCookie].SameSite)
+	}
+	if assert.Contains(t, cookies, auth.RefreshTokenCookie) {
+		assert.True(t, cookies[auth.RefreshTokenCookie].HttpOnly)
+		assert.Equal(t, "refresh-token", cookies[auth.RefreshTokenCookie].Value)
+	}
+	if assert.Contains(t, cookies, auth.CSRFCookie) {
+		assert.False(t, cookies[auth.CSRFCookie].HttpOnly)
+		assert.Equal(t, cookies[auth.CSRFCookie].Value, loginResponse["csrf_token"])
+	}
+
+	// Refresh reads the refresh token from its cookie and keeps the CSRF token
+	mockService.On("ValidateRefreshToken", mock.Anything, "refresh-token").Return(&user.RefreshToken{
+		UserID: 1,
+	}, nil).Once()
+	mockService.On("GetUserByID", mock.Anything, uint(1)).Return(&user.User{ID: 1}, nil).Once()
+
+	w = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/users/refresh", nil)
+	c.Request.AddCookie(cookies[auth.RefreshTokenCookie])
+	c.Request.AddCookie(cookies[auth.CSRFCookie])
+
+	serve(c, handler.RefreshToken)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var refreshResponse map[string]interface{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &refreshResponse))
+	assert.NotContains(t, refreshResponse, "access_token")
+	assert.Equal(t, loginResponse["csrf_token"], refreshResponse["csrf_token"])
+	assert.Contains(t, cookiesByName(w), auth.AccessTokenCookie)
+
+	// Logout revokes the cookie's refresh token and clears the cookies
+	mockService.On("RevokeRefreshToken", mock.Anything, "refresh-token").Return(nil).Once()
+
+	w = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/users/logout", nil)
+	c.Request.AddCookie(cookies[auth.RefreshTokenCookie])
+
+	serve(c, handler.Logout)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	for _, name := range []string{auth.AccessTokenCookie, auth.RefreshTokenCookie, auth.CSRFCookie} {
+		if assert.Contains(t, cookiesByName(w), name) {
+			assert.Less(t, cookiesByName(w)[name].MaxAge, 0)
+		}
+	}
+
+	mockService.AssertExpectations(t)
+}
@@ -0,0 +1,25 @@
+package health
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Live answers liveness probes. It does not touch dependencies, so a
+// database outage makes the service unready rather than restarting it.
+func Live(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": StatusOK})
+}
+
+// Ready answers readiness probes with the result of every check, and 503
+// when any check fails or the server is shutting down
+func (r *Registry) Ready(c *gin.Context) {
+	report := r.Check(c.Request.Context())
+	status := http.StatusOK
+	if !report.Healthy() {
+		status = http.StatusServiceUnavailable
+	}
+	c.Header("Cache-Control", "no-store")
+	c.JSON(status, report)
+}
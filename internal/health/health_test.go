@@ -0,0 +1,106 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type pinger struct {
+	err error
+}
+
+func (p pinger) PingContext(ctx context.Context) error {
+	return p.err
+}
+
+func TestRegistryCheck(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register("database", Ping(pinger{}))
+	registry.Register("worker:cleanup", func(context.Context) error { return nil })
+
+	report := registry.Check(context.Background())
+	assert.True(t, report.Healthy())
+	assert.Equal(t, StatusOK, report.Checks["database"].Status)
+	assert.Empty(t, report.Checks["database"].Error)
+
+	registry.Register("cache", Ping(pinger{err: errors.New("connection refused")}))
+	report = registry.Check(context.Background())
+	assert.Equal(t, StatusFailing, report.Status)
+	assert.Equal(t, CheckResult{Status: StatusFailing, LatencyMS: report.Checks["cache"].LatencyMS, Error: "connection refused"}, report.Checks["cache"])
+	assert.Equal(t, StatusOK, report.Checks["database"].Status)
+}
+
+func TestRegistryCheckTimesOut(t *testing.T) {
+	registry := NewRegistry()
+	registry.timeout = 10 * time.Millisecond
+	registry.Register("hung", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	report := registry.Check(context.Background())
+	assert.Equal(t, StatusFailing, report.Status)
+	assert.Equal(t, context.DeadlineExceeded.Error(), report.Checks["hung"].Error)
+	assert.GreaterOrEqual(t, report.Checks["hung"].LatencyMS, float64(10))
+}
+
+func TestHandlers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	registry := NewRegistry()
+	healthy := true
+	registry.Register("database", func(context.Context) error {
+		if !healthy {
+			return errors.New("connection refused")
+		}
+		return nil
+	})
+
+	router := gin.New()
+	router.GET("/livez", Live)
+	router.GET("/readyz", registry.Ready)
+
+	get := func(path string) (int, Report) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		var report Report
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+		return w.Code, report
+	}
+
+	code, report := get("/readyz")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, StatusOK, report.Checks["database"].Status)
+
+	healthy = false
+	code, report = get("/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, StatusFailing, report.Status)
+
+	// Failures are logged, not shown to unauthenticated callers
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.NotContains(t, w.Body.String(), "connection refused")
+	assert.NotContains(t, w.Body.String(), `"error"`)
+
+	code, report = get("/livez")
+	assert.Equal(t, http.StatusOK, code, "liveness ignores dependencies")
+	assert.Equal(t, StatusOK, report.Status)
+
+	healthy = true
+	registry.Drain()
+	code, report = get("/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, Report{Status: StatusShuttingDown}, report)
+
+	code, _ = get("/livez")
+	assert.Equal(t, http.StatusOK, code, "the process stays live while draining")
+}
@@ -0,0 +1,230 @@
+// Package idempotency makes POST requests safe to retry. A client sends a
+// unique Idempotency-Key header with each logical request; the first request
+// with a key runs normally and its response is stored, retries with the same
+// key and body get the stored response back instead of running again.
+//
+// Keys are scoped to the authenticated user and expire after a configurable
+// window, after which they can be used again.
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"quizlet/internal/apperr"
+	"quizlet/internal/auth"
+	"quizlet/internal/models/idempotency_key"
+	"quizlet/internal/problem"
+)
+
+// Headers read and set by the middleware
+const (
+	KeyHeader      = "Idempotency-Key"
+	ReplayedHeader = "Idempotent-Replayed"
+)
+
+// maxKeyLength matches the key column
+const maxKeyLength = 255
+
+var (
+	ErrInvalidKey = apperr.New(apperr.Invalid, "invalid_idempotency_key", "Idempotency-Key must be 1 to 255 printable ASCII characters")
+	ErrKeyReused  = apperr.New(apperr.Unprocessable, "idempotency_key_reused", "Idempotency-Key was already used for a different request")
+	ErrInProgress = apperr.New(apperr.Conflict, "idempotency_key_in_progress", "a request with this Idempotency-Key is still being processed")
+)
+
+// Store keeps the idempotency records; it is implemented by
+// repository.IdempotencyKeyRepository
+type Store interface {
+	Create(ctx context.Context, key *idempotency_key.IdempotencyKey) (bool, error)
+	Find(ctx context.Context, userID uint, key string) (*idempotency_key.IdempotencyKey, error)
+	SaveResponse(ctx context.Context, key *idempotency_key.IdempotencyKey) error
+	Delete(ctx context.Context, id uint) error
+}
+
+// Middleware replays stored responses for requests that repeat an
+// Idempotency-Key, and stores the response of the first one. Requests
+// without the header, or without an authenticated user, are not affected.
+//
+// Responses with a 5xx status, and requests whose handler panics, are not
+// stored, so the client can retry them. Replays restore the Location and
+// ETag headers along with the body.
+// A key sent with a different method, path or body is rejected with 422,
+// and a retry that arrives while the first request still runs with 409.
+func Middleware(store Store, ttl time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(KeyHeader)
+		if key == "" {
+			return
+		}
+		userID, ok := auth.CurrentUserID(c)
+		if !ok {
+			return
+		}
+		if !validKey(key) {
+			_ = c.Error(ErrInvalidKey)
+			c.Abort()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			_ = c.Error(err)
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		ctx := c.Request.Context()
+		record, created, err := claim(ctx, store, &idempotency_key.IdempotencyKey{
+			UserID:      userID,
+			Key:         key,
+			RequestHash: fingerprint(c.Request, body),
+			ExpiresAt:   time.Now().Add(ttl),
+		})
+		if err != nil {
+			_ = c.Error(err)
+			c.Abort()
+			return
+		}
+		if !created {
+			replay(c, record)
+			c.Abort()
+			return
+		}
+
+		// The request may be cancelled, the outcome still has to be recorded
+		// or the key would stay in progress until it expires
+		ctx = context.WithoutCancel(ctx)
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		stored := false
+		// Release the key unless the response was stored, also when a
+		// handler panics, so the client can retry
+		defer func() {
+			c.Writer = recorder.ResponseWriter
+			if stored {
+				return
+			}
+			if err := store.Delete(ctx, record.ID); err != nil {
+				slog.ErrorContext(ctx, "releasing idempotency key failed", "error", err)
+			}
+		}()
+
+		c.Next()
+		// Errors are rendered by the problem middleware further out, which
+		// would be too late to store them
+		problem.Render(c)
+
+		status := recorder.Status()
+		if status >= http.StatusInternalServerError || !recorder.Written() {
+			return
+		}
+
+		record.ResponseStatus = status
+		record.ResponseContentType = recorder.Header().Get("Content-Type")
+		record.ResponseLocation = recorder.Header().Get("Location")
+		record.ResponseETag = recorder.Header().Get("ETag")
+		record.ResponseBody = recorder.body.Bytes()
+		if err := store.SaveResponse(ctx, record); err != nil {
+			slog.ErrorContext(ctx, "storing idempotent response failed", "error", err)
+			return
+		}
+		stored = true
+	}
+}
+
+// claim stores record, or returns the record the user already has for the
+// key. An expired record is replaced.
+func claim(ctx context.Context, store Store, record *idempotency_key.IdempotencyKey) (*idempotency_key.IdempotencyKey, bool, error) {
+	for attempt := 0; attempt < 2; attempt++ {
+		created, err := store.Create(ctx, record)
+		if err != nil || created {
+			return record, created, err
+		}
+
+		existing, err := store.Find(ctx, record.UserID, record.Key)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// Removed since Create, e.g. released after a failure
+			continue
+		}
+		if err != nil {
+			return nil, false, err
+		}
+		if !existing.Expired(time.Now()) {
+			return existing, false, checkReplay(existing, record.RequestHash)
+		}
+		if err := store.Delete(ctx, existing.ID); err != nil {
+			return nil, false, err
+		}
+	}
+	return nil, false, ErrInProgress
+}
+
+// checkReplay decides whether existing can answer a request with hash
+func checkReplay(existing *idempotency_key.IdempotencyKey, hash string) error {
+	if existing.RequestHash != hash {
+		return ErrKeyReused
+	}
+	if !existing.Completed() {
+		return ErrInProgress
+	}
+	return nil
+}
+
+// replay writes the stored response of record
+func replay(c *gin.Context, record *idempotency_key.IdempotencyKey) {
+	header := c.Writer.Header()
+	if record.ResponseLocation != "" {
+		header.Set("Location", record.ResponseLocation)
+	}
+	if record.ResponseETag != "" {
+		header.Set("ETag", record.ResponseETag)
+	}
+	header.Set(ReplayedHeader, "true")
+	c.Data(record.ResponseStatus, record.ResponseContentType, record.ResponseBody)
+}
+
+// fingerprint identifies a request by method, path, query and body
+func fingerprint(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	io.WriteString(hash, r.Method+" "+r.URL.RequestURI()+"\n")
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func validKey(key string) bool {
+	if len(key) > maxKeyLength {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] < 0x20 || key[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// responseRecorder keeps a copy of the response body
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *responseRecorder) Write(data []byte) (int, error) {
+	r.body.Write(data)
+	return r.ResponseWriter.Write(data)
+}
+
+func (r *responseRecorder) WriteString(s string) (int, error) {
+	r.body.WriteString(s)
+	return r.ResponseWriter.WriteString(s)
+}
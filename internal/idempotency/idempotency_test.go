@@ -0,0 +1,229 @@
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"quizlet/internal/apperr"
+	"quizlet/internal/auth"
+	"quizlet/internal/models/idempotency_key"
+	"quizlet/internal/problem"
+)
+
+// memoryStore is a Store backed by a map
+type memoryStore struct {
+	mu      sync.Mutex
+	nextID  uint
+	records map[uint]*idempotency_key.IdempotencyKey
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{records: make(map[uint]*idempotency_key.IdempotencyKey)}
+}
+
+func (s *memoryStore) Create(ctx context.Context, key *idempotency_key.IdempotencyKey) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, record := range s.records {
+		if record.UserID == key.UserID && record.Key == key.Key {
+			return false, nil
+		}
+	}
+	s.nextID++
+	key.ID = s.nextID
+	copied := *key
+	s.records[key.ID] = &copied
+	return true, nil
+}
+
+func (s *memoryStore) Find(ctx context.Context, userID uint, key string) (*idempotency_key.IdempotencyKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, record := range s.records {
+		if record.UserID == userID && record.Key == key {
+			copied := *record
+			return &copied, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (s *memoryStore) SaveResponse(ctx context.Context, key *idempotency_key.IdempotencyKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	copied := *key
+	s.records[key.ID] = &copied
+	return nil
+}
+
+func (s *memoryStore) Delete(ctx context.Context, id uint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.records, id)
+	return nil
+}
+
+var errUnavailable = apperr.New(apperr.Upstream, "unavailable", "try again")
+
+func newRouter(store Store, calls *int) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(gin.RecoveryWithWriter(io.Discard))
+	router.Use(problem.Middleware())
+	router.Use(func(c *gin.Context) {
+		if c.GetHeader("X-Test-User") != "" {
+			auth.SetPrincipal(c, &auth.Principal{UserID: 1, Method: auth.AuthMethodBearer})
+		}
+	})
+	router.POST("/quizzes", Middleware(store, time.Hour), func(c *gin.Context) {
+		*calls++
+		var body struct {
+			Question string `json:"question" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&body); err != nil {
+			_ = c.Error(err).SetType(gin.ErrorTypeBind)
+			return
+		}
+		if body.Question == "fail" {
+			_ = c.Error(errUnavailable)
+			return
+		}
+		if body.Question == "panic" {
+			panic("handler bug")
+		}
+		c.Header("Location", "/quizzes/7")
+		c.Header("ETag", `"7-1"`)
+		c.JSON(http.StatusCreated, gin.H{"id": 7, "call": *calls})
+	})
+	return router
+}
+
+func post(router *gin.Engine, key, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/quizzes", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Test-User", "1")
+	if key != "" {
+		req.Header.Set(KeyHeader, key)
+	}
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestMiddlewareReplaysResponse(t *testing.T) {
+	store := newMemoryStore()
+	calls := 0
+	router := newRouter(store, &calls)
+
+	first := post(router, "key-1", `{"question":"Why?"}`)
+	require.Equal(t, http.StatusCreated, first.Code)
+	assert.Empty(t, first.Header().Get(ReplayedHeader))
+
+	retry := post(router, "key-1", `{"question":"Why?"}`)
+	assert.Equal(t, http.StatusCreated, retry.Code)
+	assert.Equal(t, first.Body.String(), retry.Body.String())
+	assert.Equal(t, "application/json; charset=utf-8", retry.Header().Get("Content-Type"))
+	assert.Equal(t, "/quizzes/7", retry.Header().Get("Location"))
+	assert.Equal(t, `"7-1"`, retry.Header().Get("ETag"))
+	assert.Equal(t, "true", retry.Header().Get(ReplayedHeader))
+	assert.Equal(t, 1, calls)
+
+	// Another key runs the handler again
+	other := post(router, "key-2", `{"question":"Why?"}`)
+	assert.Equal(t, http.StatusCreated, other.Code)
+	assert.Equal(t, 2, calls)
+
+	// Requests without a key are never deduplicated
+	post(router, "", `{"question":"Why?"}`)
+	post(router, "", `{"question":"Why?"}`)
+	assert.Equal(t, 4, calls)
+}
+
+func TestMiddlewareRejectsMisuse(t *testing.T) {
+	store := newMemoryStore()
+	calls := 0
+	router := newRouter(store, &calls)
+
+	post(router, "key-1", `{"question":"Why?"}`)
+
+	w := post(router, "key-1", `{"question":"Why not?"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":"idempotency_key_reused"`)
+
+	w = post(router, "key\n1", `{"question":"Why?"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":"invalid_idempotency_key"`)
+
+	// A request still in progress is not run twice
+	_, err := store.Create(context.Background(), &idempotency_key.IdempotencyKey{
+		UserID:      1,
+		Key:         "key-2",
+		RequestHash: fingerprint(httptest.NewRequest(http.MethodPost, "/quizzes", nil), []byte(`{"question":"Why?"}`)),
+		ExpiresAt:   time.Now().Add(time.Hour),
+	})
+	require.NoError(t, err)
+	w = post(router, "key-2", `{"question":"Why?"}`)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":"idempotency_key_in_progress"`)
+	assert.Equal(t, 1, calls)
+}
+
+func TestMiddlewareStoresClientErrorsOnly(t *testing.T) {
+	store := newMemoryStore()
+	calls := 0
+	router := newRouter(store, &calls)
+
+	// Validation errors are part of the outcome and replayed
+	first := post(router, "key-1", `{}`)
+	require.Equal(t, http.StatusBadRequest, first.Code)
+	retry := post(router, "key-1", `{}`)
+	assert.Equal(t, http.StatusBadRequest, retry.Code)
+	assert.Equal(t, problem.ContentType, retry.Header().Get("Content-Type"))
+	assert.Equal(t, first.Body.String(), retry.Body.String())
+	assert.Equal(t, 1, calls)
+
+	// Server errors release the key so the client can retry
+	w := post(router, "key-2", `{"question":"fail"}`)
+	assert.Equal(t, http.StatusBadGateway, w.Code)
+	w = post(router, "key-2", `{"question":"fail"}`)
+	assert.Equal(t, http.StatusBadGateway, w.Code)
+	assert.Empty(t, w.Header().Get(ReplayedHeader))
+	assert.Equal(t, 3, calls)
+
+	// and so do panics
+	w = post(router, "key-3", `{"question":"panic"}`)
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	_, err := store.Find(context.Background(), 1, "key-3")
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	w = post(router, "key-3", `{"question":"panic"}`)
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, 5, calls)
+}
+
+func TestMiddlewareExpiredKey(t *testing.T) {
+	store := newMemoryStore()
+	calls := 0
+	router := newRouter(store, &calls)
+
+	post(router, "key-1", `{"question":"Why?"}`)
+	record, err := store.Find(context.Background(), 1, "key-1")
+	require.NoError(t, err)
+	record.ExpiresAt = time.Now().Add(-time.Second)
+	require.NoError(t, store.SaveResponse(context.Background(), record))
+
+	// An expired key behaves like a new one, even with another body
+	w := post(router, "key-1", `{"question":"Why not?"}`)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Empty(t, w.Header().Get(ReplayedHeader))
+	assert.Equal(t, 2, calls)
+}
@@ -0,0 +1,97 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func decode(t *testing.T, buf *bytes.Buffer) map[string]any {
+	t.Helper()
+	var record map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	return record
+}
+
+func TestNewRejectsBadSettings(t *testing.T) {
+	_, err := New(&bytes.Buffer{}, "xml", "info")
+	assert.ErrorContains(t, err, "invalid log format")
+
+	_, err = New(&bytes.Buffer{}, FormatJSON, "loud")
+	assert.ErrorContains(t, err, "invalid log level")
+}
+
+func TestRedaction(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(&buf, FormatJSON, "info")
+	require.NoError(t, err)
+
+	logger.Info("login",
+		"password", "hunter2",
+		"refresh_token", "abc",
+		"Authorization", "Bearer xyz",
+		"code", "123456",
+		"status_code", 200,
+		"email", "jane@example.com",
+		slog.Group("oidc", "client_secret", "s3cret", "provider", "google"),
+	)
+
+	record := decode(t, &buf)
+	assert.Equal(t, Redacted, record["password"])
+	assert.Equal(t, Redacted, record["refresh_token"])
+	assert.Equal(t, Redacted, record["Authorization"])
+	assert.Equal(t, Redacted, record["code"])
+	assert.Equal(t, float64(200), record["status_code"])
+	assert.Equal(t, "j***@example.com", record["email"])
+	assert.Equal(t, map[string]any{"client_secret": Redacted, "provider": "google"}, record["oidc"])
+	assert.Equal(t, "login", record["msg"])
+}
+
+func TestRequestIDIsAddedFromContext(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(&buf, FormatJSON, "info")
+	require.NoError(t, err)
+
+	logger.With("service", "users").InfoContext(WithRequestID(context.Background(), "req-1"), "hello")
+	record := decode(t, &buf)
+	assert.Equal(t, "req-1", record["request_id"])
+	assert.Equal(t, "users", record["service"])
+
+	buf.Reset()
+	logger.Debug("hidden")
+	assert.Empty(t, buf.String(), "debug records are dropped at info level")
+}
+
+func TestTraceIDsAreAddedFromContext(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(&buf, FormatJSON, "info")
+	require.NoError(t, err)
+
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: traceID,
+		SpanID:  spanID,
+	}))
+
+	logger.InfoContext(ctx, "hello")
+	record := decode(t, &buf)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", record["trace_id"])
+	assert.Equal(t, "00f067aa0ba902b7", record["span_id"])
+
+	buf.Reset()
+	logger.Info("no span")
+	assert.NotContains(t, decode(t, &buf), "trace_id")
+}
+
+func TestMaskEmail(t *testing.T) {
+	assert.Equal(t, "a***@example.com", MaskEmail("alice@example.com"))
+	assert.Equal(t, Redacted, MaskEmail("not-an-email"))
+	assert.Equal(t, Redacted, MaskEmail("@example.com"))
+}
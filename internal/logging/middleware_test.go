@@ -0,0 +1,103 @@
+package logging
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"quizlet/internal/auth"
+)
+
+func newRouter(t *testing.T, buf *bytes.Buffer) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	logger, err := New(buf, FormatJSON, "info")
+	require.NoError(t, err)
+
+	router := gin.New()
+	router.Use(RequestIDMiddleware(), AccessLog(logger), Recovery())
+	return router
+}
+
+func TestRequestIDMiddleware(t *testing.T) {
+	router := newRouter(t, &bytes.Buffer{})
+	var seen, seenIP string
+	router.GET("/ping", func(c *gin.Context) {
+		seen = RequestID(c.Request.Context())
+		seenIP = ClientIP(c.Request.Context())
+		c.Status(http.StatusNoContent)
+	})
+
+	testCases := []struct {
+		name     string
+		header   string
+		expectID string
+	}{
+		{name: "Client ID Kept", header: "abc-123", expectID: "abc-123"},
+		{name: "Generated", header: ""},
+		{name: "Invalid ID Replaced", header: "bad id\n"},
+		{name: "Too Long Replaced", header: strings.Repeat("a", maxRequestIDLength+1)},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+			if tc.header != "" {
+				req.Header.Set(RequestIDHeader, tc.header)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			got := w.Header().Get(RequestIDHeader)
+			assert.Equal(t, seen, got)
+			assert.Equal(t, "192.0.2.1", seenIP)
+			if tc.expectID != "" {
+				assert.Equal(t, tc.expectID, got)
+			} else {
+				assert.Len(t, got, 32)
+			}
+		})
+	}
+}
+
+func TestAccessLog(t *testing.T) {
+	var buf bytes.Buffer
+	router := newRouter(t, &buf)
+	router.GET("/quizzes/:id", func(c *gin.Context) {
+		auth.SetPrincipal(c, &auth.Principal{UserID: 7, Method: auth.AuthMethodBearer})
+		c.JSON(http.StatusNotFound, gin.H{"error": "quiz not found"})
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/quizzes/3?code=secret", nil)
+	req.Header.Set(RequestIDHeader, "req-42")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	record := decode(t, &buf)
+	assert.Equal(t, "request", record["msg"])
+	assert.Equal(t, "WARN", record["level"])
+	assert.Equal(t, "GET", record["method"])
+	assert.Equal(t, "/quizzes/3", record["path"])
+	assert.Equal(t, "/quizzes/:id", record["route"])
+	assert.Equal(t, float64(http.StatusNotFound), record["status"])
+	assert.Equal(t, float64(7), record["user_id"])
+	assert.Equal(t, "req-42", record["request_id"])
+	assert.Contains(t, record, "latency_ms")
+	assert.NotContains(t, buf.String(), "secret")
+}
+
+func TestRecovery(t *testing.T) {
+	var buf bytes.Buffer
+	router := newRouter(t, &buf)
+	router.GET("/boom", func(c *gin.Context) { panic("boom") })
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/boom", nil))
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Contains(t, buf.String(), `"status":500`)
+}
@@ -0,0 +1,101 @@
+// Package metrics exposes Prometheus metrics for HTTP traffic, database
+// queries and domain events on a registry served at /metrics.
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "quizlet"
+
+// Login methods used as the method label of the logins counter
+const (
+	LoginMethodPassword = "password"
+	LoginMethodTOTP     = "totp"
+	LoginMethodOIDC     = "oidc"
+)
+
+// Registry holds every metric of the API, plus the Go runtime and process
+// collectors
+var Registry = prometheus.NewRegistry()
+
+var factory = promauto.With(Registry)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+var (
+	logins = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "logins_total",
+		Help:      "Login attempts by method and result.",
+	}, []string{"method", "result"})
+
+	quizAttemptsStarted = factory.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "quiz_attempts_started_total",
+		Help:      "Quiz attempts started.",
+	})
+
+	quizAttemptsCompleted = factory.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "quiz_attempts_completed_total",
+		Help:      "Quiz attempts completed.",
+	})
+
+	refreshTokensIssued = factory.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "refresh_tokens_issued_total",
+		Help:      "Refresh tokens issued by logins and token rotation.",
+	})
+
+	rateLimited = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rate_limited_requests_total",
+		Help:      "Requests rejected by a rate limit policy.",
+	}, []string{"policy"})
+)
+
+// Handler serves the registry in the Prometheus exposition format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// LoginSucceeded counts a login that issued tokens
+func LoginSucceeded(method string) {
+	logins.WithLabelValues(method, "succeeded").Inc()
+}
+
+// LoginFailed counts a login rejected because of wrong credentials or codes
+func LoginFailed(method string) {
+	logins.WithLabelValues(method, "failed").Inc()
+}
+
+// QuizAttemptStarted counts a new quiz attempt
+func QuizAttemptStarted() {
+	quizAttemptsStarted.Inc()
+}
+
+// QuizAttemptCompleted counts a quiz attempt marked as completed
+func QuizAttemptCompleted() {
+	quizAttemptsCompleted.Inc()
+}
+
+// RefreshTokenIssued counts a new refresh token
+func RefreshTokenIssued() {
+	refreshTokensIssued.Inc()
+}
+
+// RateLimited counts a request rejected by the named rate limit policy
+func RateLimited(policy string) {
+	rateLimited.WithLabelValues(policy).Inc()
+}
@@ -0,0 +1,96 @@
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Middleware())
+	router.GET("/quizzes/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.GET("/metrics", gin.WrapH(Handler()))
+
+	before := testutil.ToFloat64(httpRequests.WithLabelValues("GET", "/quizzes/:id", "200"))
+	beforeUnmatched := testutil.ToFloat64(httpRequests.WithLabelValues("GET", unmatchedRoute, "404"))
+
+	for _, path := range []string{"/quizzes/1", "/quizzes/2", "/nope"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	assert.Equal(t, before+2, testutil.ToFloat64(httpRequests.WithLabelValues("GET", "/quizzes/:id", "200")))
+	assert.Equal(t, beforeUnmatched+1, testutil.ToFloat64(httpRequests.WithLabelValues("GET", unmatchedRoute, "404")))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	body := w.Body.String()
+	assert.Contains(t, body, `quizlet_http_request_duration_seconds_bucket{method="GET",route="/quizzes/:id",status="200"`)
+	assert.Contains(t, body, "go_goroutines")
+	assert.NotContains(t, body, `route="/quizzes/1"`)
+}
+
+func TestDomainCounters(t *testing.T) {
+	succeeded := testutil.ToFloat64(logins.WithLabelValues(LoginMethodPassword, "succeeded"))
+	failed := testutil.ToFloat64(logins.WithLabelValues(LoginMethodTOTP, "failed"))
+	started := testutil.ToFloat64(quizAttemptsStarted)
+	issued := testutil.ToFloat64(refreshTokensIssued)
+
+	LoginSucceeded(LoginMethodPassword)
+	LoginFailed(LoginMethodTOTP)
+	QuizAttemptStarted()
+	RefreshTokenIssued()
+
+	assert.Equal(t, succeeded+1, testutil.ToFloat64(logins.WithLabelValues(LoginMethodPassword, "succeeded")))
+	assert.Equal(t, failed+1, testutil.ToFloat64(logins.WithLabelValues(LoginMethodTOTP, "failed")))
+	assert.Equal(t, started+1, testutil.ToFloat64(quizAttemptsStarted))
+	assert.Equal(t, issued+1, testutil.ToFloat64(refreshTokensIssued))
+}
+
+type widget struct {
+	ID   uint
+	Name string
+}
+
+func sampleCount(t *testing.T, observer prometheus.Observer) uint64 {
+	t.Helper()
+	var m dto.Metric
+	require.NoError(t, observer.(prometheus.Metric).Write(&m))
+	return m.GetHistogram().GetSampleCount()
+}
+
+func TestGormPlugin(t *testing.T) {
+	// A dry run builds the statements and runs the callbacks without a server
+	db, err := gorm.Open(postgres.Open("host=localhost"), &gorm.Config{DryRun: true, DisableAutomaticPing: true})
+	require.NoError(t, err)
+	require.NoError(t, db.Use(gormPlugin{}))
+	require.NoError(t, db.Callback().Delete().Before("metrics:after_delete").Register("test:fail", func(db *gorm.DB) {
+		db.AddError(errors.New("connection reset"))
+	}))
+
+	creates := sampleCount(t, dbQueryDuration.WithLabelValues("create", "widgets"))
+	queries := sampleCount(t, dbQueryDuration.WithLabelValues("query", "widgets"))
+	deleteErrors := testutil.ToFloat64(dbQueryErrors.WithLabelValues("delete", "widgets"))
+	queryErrors := testutil.ToFloat64(dbQueryErrors.WithLabelValues("query", "widgets"))
+
+	db.Create(&widget{Name: "a"})
+	db.Find(&[]widget{})
+	db.Delete(&widget{ID: 1})
+
+	assert.Equal(t, creates+1, sampleCount(t, dbQueryDuration.WithLabelValues("create", "widgets")))
+	assert.Equal(t, queries+1, sampleCount(t, dbQueryDuration.WithLabelValues("query", "widgets")))
+	assert.Equal(t, deleteErrors+1, testutil.ToFloat64(dbQueryErrors.WithLabelValues("delete", "widgets")))
+	assert.Equal(t, queryErrors, testutil.ToFloat64(dbQueryErrors.WithLabelValues("query", "widgets")))
+}
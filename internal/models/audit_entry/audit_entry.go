@@ -0,0 +1,147 @@
+package audit_entry
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// Actions
+const (
+	ActionCreate  = "create"
+	ActionUpdate  = "update"
+	ActionDelete  = "delete"
+	ActionRestore = "restore"
+)
+
+// Resource types
+const (
+	ResourceUser        = "user"
+	ResourceQuiz        = "quiz"
+	ResourceQuizSuite   = "quiz_suite"
+	ResourceQuizAttempt = "quiz_attempt"
+)
+
+// Redacted stands in for values that are changed but never shown, such as
+// passwords
+const Redacted = "[redacted]"
+
+// AuditEntry records one change to a user, quiz, quiz suite or quiz attempt.
+// Entries are only ever appended; they are kept when the resource or the
+// actor is purged.
+// @model AuditEntry
+// @Description A change recorded in the audit log
+type AuditEntry struct {
+	ID        uint      `gorm:"primarykey" json:"id" example:"1"`
+	CreatedAt time.Time `gorm:"index" json:"created_at" example:"2024-03-20T15:04:05Z"`
+	// User who made the change; empty for sign-ups
+	ActorID      *uint  `gorm:"index" json:"actor_id,omitempty" example:"1"`
+	Action       string `gorm:"not null" json:"action" example:"update"`
+	ResourceType string `gorm:"not null;index:idx_audit_entries_resource" json:"resource_type" example:"quiz"`
+	ResourceID   uint   `gorm:"not null;index:idx_audit_entries_resource" json:"resource_id" example:"1"`
+	// Fields that changed, by their JSON name
+	Changes   Changes `gorm:"type:text;not null" json:"changes" swaggertype:"object"`
+	RequestID string  `gorm:"not null;default:''" json:"request_id,omitempty" example:"4f0c9b0e2a7d4e1c"`
+	IP        string  `gorm:"column:ip;not null;default:''" json:"ip,omitempty" example:"203.0.113.7"`
+}
+
+// Change holds the value of a field before and after a change; Before is
+// empty for creates and After for deletes
+type Change struct {
+	Before any `json:"before,omitempty"`
+	After  any `json:"after,omitempty"`
+}
+
+// Changes maps field names to their change, stored as a JSON object
+type Changes map[string]Change
+
+func (c Changes) Value() (driver.Value, error) {
+	if c == nil {
+		return "{}", nil
+	}
+	encoded, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	return string(encoded), nil
+}
+
+func (c *Changes) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case string:
+		return json.Unmarshal([]byte(v), c)
+	case []byte:
+		return json.Unmarshal(v, c)
+	case nil:
+		*c = nil
+		return nil
+	default:
+		return fmt.Errorf("cannot scan %T into Changes", value)
+	}
+}
+
+// unaudited fields change on every write or only repeat other records
+var unaudited = map[string]bool{
+	"created_at": true,
+	"updated_at": true,
+	"deleted_at": true,
+	"created_by": true,
+	"user":       true,
+	"snapshot":   true,
+}
+
+// Diff compares the JSON form of two versions of a resource and returns the
+// top level fields that differ. Either can be nil, for creates and deletes.
+// Fields left out of the JSON, such as password hashes, are never recorded.
+func Diff(before, after any) (Changes, error) {
+	from, err := fields(before)
+	if err != nil {
+		return nil, err
+	}
+	to, err := fields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := Changes{}
+	for name, value := range from {
+		if !unaudited[name] && !reflect.DeepEqual(value, to[name]) {
+			changes[name] = Change{Before: value, After: to[name]}
+		}
+	}
+	for name, value := range to {
+		if _, ok := from[name]; !ok && !unaudited[name] {
+			changes[name] = Change{After: value}
+		}
+	}
+	return changes, nil
+}
+
+// fields decodes the JSON object of v
+func fields(v any) (map[string]any, error) {
+	if v == nil || reflect.ValueOf(v).Kind() == reflect.Pointer && reflect.ValueOf(v).IsNil() {
+		return nil, nil
+	}
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var decoded map[string]any
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		return nil, err
+	}
+	return decoded, nil
+}
+
+// Filter selects audit entries; zero fields match every entry. Since is
+// inclusive and Until exclusive.
+type Filter struct {
+	ResourceType string    `form:"resource_type" json:"resource_type" binding:"omitempty,oneof=user quiz quiz_suite quiz_attempt"`
+	ResourceID   uint      `form:"resource_id" json:"resource_id"`
+	ActorID      uint      `form:"actor_id" json:"actor_id"`
+	Since        time.Time `form:"since" json:"since"`
+	Until        time.Time `form:"until" json:"until"`
+	Limit        int       `form:"limit" json:"limit" binding:"omitempty,min=1,max=500"`
+}
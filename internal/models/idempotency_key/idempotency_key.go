@@ -0,0 +1,31 @@
+package idempotency_key
+
+import "time"
+
+// IdempotencyKey records the first request a user sent with an
+// Idempotency-Key header and, once it finished, the response to replay for
+// retries. A zero ResponseStatus means the request is still in progress.
+type IdempotencyKey struct {
+	ID                  uint      `gorm:"primarykey" json:"id"`
+	CreatedAt           time.Time `json:"created_at"`
+	UpdatedAt           time.Time `json:"updated_at"`
+	UserID              uint      `gorm:"not null;uniqueIndex:idx_idempotency_keys_user_key" json:"user_id"`
+	Key                 string    `gorm:"not null;size:255;uniqueIndex:idx_idempotency_keys_user_key" json:"key"`
+	RequestHash         string    `gorm:"not null;size:64" json:"-"`
+	ResponseStatus      int       `gorm:"not null;default:0" json:"response_status"`
+	ResponseContentType string    `gorm:"not null;default:''" json:"-"`
+	ResponseLocation    string    `gorm:"not null;default:''" json:"-"`
+	ResponseETag        string    `gorm:"column:response_etag;not null;default:''" json:"-"`
+	ResponseBody        []byte    `json:"-"`
+	ExpiresAt           time.Time `gorm:"not null;index" json:"expires_at"`
+}
+
+// Completed reports whether the response has been stored
+func (k *IdempotencyKey) Completed() bool {
+	return k.ResponseStatus != 0
+}
+
+// Expired reports whether the key can be used for a new request again
+func (k *IdempotencyKey) Expired(now time.Time) bool {
+	return !now.Before(k.ExpiresAt)
+}
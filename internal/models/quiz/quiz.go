@@ -20,10 +20,14 @@ type Quiz struct {
 	CreatedAt     time.Time      `json:"created_at"`
 	UpdatedAt     time.Time      `json:"updated_at"`
 	DeletedAt     gorm.DeletedAt `gorm:"index" json:"-"`
+	// Incremented on every change, sent as the ETag of the quiz
+	Version       uint           `gorm:"not null;default:1" json:"version"`
 	Question      string         `gorm:"not null" json:"question"`
 	QuizType      QuizType       `gorm:"not null" json:"quiz_type"`
 	CreatedByID   uint           `gorm:"not null" json:"created_by_id"`
 	CreatedBy     *user.User     `json:"created_by,omitempty"`
+	// Quiz this one was cloned from, which may since have been deleted
+	ClonedFromID  *uint          `json:"cloned_from_id,omitempty"`
 	Selections    []QuizSelection `gorm:"foreignKey:QuizID;constraint:OnDelete:CASCADE" json:"selections,omitempty"`
 }
 
@@ -31,8 +35,11 @@ type QuizSelection struct {
 	ID            uint           `gorm:"primarykey" json:"id"`
 	CreatedAt     time.Time      `json:"created_at"`
 	UpdatedAt     time.Time      `json:"updated_at"`
+	Version       uint           `gorm:"not null;default:1" json:"version"`
 	QuizID        uint           `json:"quiz_id"`
 	Quiz          *Quiz          `json:"quiz,omitempty"`
 	SelectionText string         `gorm:"not null" json:"selection_text"`
+	// Short label shown before the selection, such as "A" or "B"
+	SelectionDisplayName string  `gorm:"size:10" json:"selection_display_name,omitempty"`
 	IsCorrect     bool           `gorm:"not null" json:"is_correct"`
 } 
\ No newline at end of file
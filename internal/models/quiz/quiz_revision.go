@@ -0,0 +1,144 @@
+package quiz
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// QuizRevision is an immutable copy of a quiz and its selections, taken
+// after every change to them. Revisions are numbered per quiz from 1.
+// @model QuizRevision
+// @Description A saved state of a quiz
+type QuizRevision struct {
+	ID        uint      `gorm:"primarykey" json:"id" example:"1"`
+	CreatedAt time.Time `json:"created_at" example:"2024-03-20T15:04:05Z"`
+	QuizID    uint      `gorm:"not null;uniqueIndex:idx_quiz_revisions_quiz_number" json:"quiz_id" example:"1"`
+	Number    uint      `gorm:"not null;uniqueIndex:idx_quiz_revisions_quiz_number" json:"number" example:"2"`
+	// User who made the change; empty for revisions recorded without one
+	AuthorID *uint `json:"author_id,omitempty" example:"1"`
+	// Number of the revision this one reverted to, when it is a revert
+	RevertedFrom *uint    `json:"reverted_from,omitempty" example:"1"`
+	Content      Snapshot `gorm:"type:text;not null" json:"content"`
+}
+
+// Snapshot is the content of a quiz saved by a revision, stored as JSON
+type Snapshot struct {
+	Question   string              `json:"question"`
+	QuizType   QuizType            `json:"quiz_type"`
+	Selections []SnapshotSelection `json:"selections"`
+}
+
+// SnapshotSelection is a selection saved by a revision. ID is the selection
+// it was taken from, which identifies it across revisions.
+type SnapshotSelection struct {
+	ID                   uint   `json:"id"`
+	SelectionText        string `json:"selection_text"`
+	SelectionDisplayName string `json:"selection_display_name,omitempty"`
+	IsCorrect            bool   `json:"is_correct"`
+}
+
+// NewSnapshot copies the content of q
+func NewSnapshot(q *Quiz) Snapshot {
+	snapshot := Snapshot{
+		Question:   q.Question,
+		QuizType:   q.QuizType,
+		Selections: make([]SnapshotSelection, len(q.Selections)),
+	}
+	for i, selection := range q.Selections {
+		snapshot.Selections[i] = SnapshotSelection{
+			ID:                   selection.ID,
+			SelectionText:        selection.SelectionText,
+			SelectionDisplayName: selection.SelectionDisplayName,
+			IsCorrect:            selection.IsCorrect,
+		}
+	}
+	return snapshot
+}
+
+func (s Snapshot) Value() (driver.Value, error) {
+	encoded, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	return string(encoded), nil
+}
+
+func (s *Snapshot) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case string:
+		return json.Unmarshal([]byte(v), s)
+	case []byte:
+		return json.Unmarshal(v, s)
+	default:
+		return fmt.Errorf("quiz: cannot scan %T into Snapshot", value)
+	}
+}
+
+// FieldChange holds the value of a field in the two revisions compared
+type FieldChange struct {
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+// SelectionChange is a selection present in both revisions compared, with
+// different content
+type SelectionChange struct {
+	ID     uint              `json:"id"`
+	Before SnapshotSelection `json:"before"`
+	After  SnapshotSelection `json:"after"`
+}
+
+// RevisionDiff lists what changed from one revision of a quiz to another.
+// Selections are matched by ID.
+// @model RevisionDiff
+// @Description Changes between two revisions of a quiz
+type RevisionDiff struct {
+	From              uint                `json:"from" example:"1"`
+	To                uint                `json:"to" example:"2"`
+	Question          *FieldChange        `json:"question,omitempty"`
+	QuizType          *FieldChange        `json:"quiz_type,omitempty"`
+	SelectionsAdded   []SnapshotSelection `json:"selections_added"`
+	SelectionsRemoved []SnapshotSelection `json:"selections_removed"`
+	SelectionsChanged []SelectionChange   `json:"selections_changed"`
+}
+
+// Diff compares two revisions of the same quiz
+func Diff(from, to *QuizRevision) *RevisionDiff {
+	diff := &RevisionDiff{
+		From:              from.Number,
+		To:                to.Number,
+		SelectionsAdded:   []SnapshotSelection{},
+		SelectionsRemoved: []SnapshotSelection{},
+		SelectionsChanged: []SelectionChange{},
+	}
+	if from.Content.Question != to.Content.Question {
+		diff.Question = &FieldChange{Before: from.Content.Question, After: to.Content.Question}
+	}
+	if from.Content.QuizType != to.Content.QuizType {
+		diff.QuizType = &FieldChange{Before: string(from.Content.QuizType), After: string(to.Content.QuizType)}
+	}
+
+	before := make(map[uint]SnapshotSelection, len(from.Content.Selections))
+	for _, selection := range from.Content.Selections {
+		before[selection.ID] = selection
+	}
+	after := make(map[uint]bool, len(to.Content.Selections))
+	for _, selection := range to.Content.Selections {
+		after[selection.ID] = true
+		old, ok := before[selection.ID]
+		switch {
+		case !ok:
+			diff.SelectionsAdded = append(diff.SelectionsAdded, selection)
+		case old != selection:
+			diff.SelectionsChanged = append(diff.SelectionsChanged, SelectionChange{ID: selection.ID, Before: old, After: selection})
+		}
+	}
+	for _, selection := range from.Content.Selections {
+		if !after[selection.ID] {
+			diff.SelectionsRemoved = append(diff.SelectionsRemoved, selection)
+		}
+	}
+	return diff
+}
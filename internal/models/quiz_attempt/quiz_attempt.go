@@ -3,30 +3,27 @@ package quiz_attempt
 import (
 	"quizlet/internal/models/user"
 	"time"
+
+	"gorm.io/gorm"
 )
 
 // CreateQuizAttemptRequest represents the request body for creating a quiz attempt
 // @model CreateQuizAttemptRequest
 // @Description Request body for creating a new quiz attempt
+// The score is graded from the answers to the quiz suite snapshot taken here.
 type CreateQuizAttemptRequest struct {
-	// The score achieved in this attempt
-	// @example 80
-	// @required true
-	// @minimum 0
-	// @maximum 100
-	Score int `json:"score" binding:"required,min=0,max=100" example:"80"`
-
-	// Whether the attempt is completed
-	// @example true
-	// @required true
-	Completed bool `json:"completed" binding:"required" example:"true"`
+	// Whether the attempt is completed; attempts that take answers start
+	// without it and are completed with an update
+	// @example false
+	Completed bool `json:"completed" example:"false"`
 }
 
 // UpdateQuizAttemptRequest represents the request body for updating a quiz attempt
 // @model UpdateQuizAttemptRequest
 // @Description Request body for updating an existing quiz attempt
 type UpdateQuizAttemptRequest struct {
-	// The score achieved in this attempt
+	// The score achieved in this attempt; only accepted for attempts started
+	// without a snapshot, whose score is not graded from their answers
 	// @example 90
 	// @minimum 0
 	// @maximum 100
@@ -37,6 +34,21 @@ type UpdateQuizAttemptRequest struct {
 	Completed *bool `json:"completed,omitempty" example:"true"`
 }
 
+// SubmitAnswerRequest represents the request body for answering a quiz of an attempt
+// @model SubmitAnswerRequest
+// @Description Request body for answering one quiz of an attempt
+type SubmitAnswerRequest struct {
+	// The ID of the quiz being answered
+	// @example 1
+	// @required true
+	QuizID uint `json:"quiz_id" binding:"required" example:"1"`
+
+	// The IDs of the selections chosen
+	// @example [1]
+	// @required true
+	SelectionIDs []uint `json:"selection_ids" binding:"required,min=1" example:"1"`
+}
+
 // QuizAttempt represents a user's attempt at a quiz suite
 // @model QuizAttempt
 // @Description A record of a user's attempt at completing a quiz suite
@@ -44,7 +56,7 @@ type QuizAttempt struct {
 	// The unique identifier for the quiz attempt
 	// @example 1
 	// @readOnly true
-	ID int64 `json:"id" gorm:"primaryKey" example:"1"`
+	ID uint `json:"id" gorm:"primaryKey" example:"1"`
 
 	// The timestamp when the quiz attempt was created
 	// @example "2024-04-17T00:00:00Z"
@@ -58,12 +70,12 @@ type QuizAttempt struct {
 
 	// The timestamp when the quiz attempt was deleted (soft delete)
 	// @readOnly true
-	DeletedAt *time.Time `json:"deleted_at,omitempty" gorm:"index"`
+	DeletedAt gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
 
 	// The ID of the user who made the attempt
 	// @example 1
 	// @readOnly true
-	UserID int64 `json:"user_id" example:"1"`
+	UserID uint `json:"user_id" example:"1"`
 
 	// The user who made the attempt
 	// @readOnly true
@@ -72,7 +84,7 @@ type QuizAttempt struct {
 	// The ID of the quiz suite being attempted
 	// @example 1
 	// @readOnly true
-	QuizSuiteID int64 `json:"quiz_suite_id" example:"1"`
+	QuizSuiteID uint `json:"quiz_suite_id" example:"1"`
 
 	// The score achieved in this attempt
 	// @example 80
@@ -93,4 +105,55 @@ type QuizAttempt struct {
 	// @example "2024-04-17T00:00:00Z"
 	// @readOnly true
 	CompletedAt *time.Time `json:"completed_at,omitempty" example:"2024-04-17T00:00:00Z"`
+
+	// The quiz suite as it was when the attempt started, which answers are
+	// graded against. The answer key is withheld until the attempt is
+	// completed, and the snapshot is left out of listings. Empty for
+	// attempts started before snapshots were kept.
+	// @readOnly true
+	Snapshot *SuiteSnapshot `json:"snapshot,omitempty" gorm:"type:text"`
+
+	// The answers given during this attempt
+	// @readOnly true
+	Answers []QuizAttemptAnswer `json:"answers,omitempty" gorm:"foreignKey:QuizAttemptID"`
+}
+
+// QuizAttemptAnswer records the answer given to one quiz of an attempt
+// @model QuizAttemptAnswer
+// @Description The answer a user gave to a single quiz during an attempt
+type QuizAttemptAnswer struct {
+	// The unique identifier for the answer
+	// @example 1
+	// @readOnly true
+	ID uint `json:"id" gorm:"primaryKey" example:"1"`
+
+	// The timestamp when the answer was created
+	// @example "2024-04-17T00:00:00Z"
+	// @readOnly true
+	CreatedAt time.Time `json:"created_at" example:"2024-04-17T00:00:00Z"`
+
+	// The timestamp when the answer was last updated
+	// @example "2024-04-17T00:00:00Z"
+	// @readOnly true
+	UpdatedAt time.Time `json:"updated_at" example:"2024-04-17T00:00:00Z"`
+
+	// The ID of the attempt the answer belongs to
+	// @example 1
+	QuizAttemptID uint `json:"quiz_attempt_id" example:"1"`
+
+	// The ID of the quiz that was answered
+	// @example 1
+	QuizID uint `json:"quiz_id" example:"1"`
+
+	// The answer given by the user
+	// @example "Paris"
+	UserAnswer string `json:"user_answer" gorm:"not null" example:"Paris"`
+
+	// The IDs of the selections chosen, from the snapshot of the attempt
+	// @example [1]
+	SelectionIDs IDList `json:"selection_ids" gorm:"type:text;not null;default:'[]'" swaggertype:"array,integer"`
+
+	// Whether the answer was correct
+	// @example true
+	IsCorrect bool `json:"is_correct" gorm:"not null" example:"true"`
 } 
\ No newline at end of file
@@ -0,0 +1,195 @@
+package quiz_attempt
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"quizlet/internal/models/quiz"
+)
+
+// SuiteSnapshot is a copy of the questions and answer key of a quiz suite,
+// taken when an attempt starts. Answers are graded against it, so later
+// changes to the suite or its quizzes never change a result.
+// @model SuiteSnapshot
+// @Description The quiz suite as it was when an attempt started
+type SuiteSnapshot struct {
+	// @example "My Quiz Suite"
+	Title string `json:"title" example:"My Quiz Suite"`
+	// @example "A collection of quizzes about various topics"
+	Description string         `json:"description" example:"A collection of quizzes about various topics"`
+	Quizzes     []QuizSnapshot `json:"quizzes"`
+}
+
+// QuizSnapshot is a quiz of a suite snapshot
+type QuizSnapshot struct {
+	// The ID of the quiz the snapshot was taken from
+	// @example 1
+	QuizID uint `json:"quiz_id" example:"1"`
+	// The revision of the quiz the snapshot matches; empty for quizzes
+	// without revisions
+	// @example 2
+	Revision uint `json:"revision,omitempty" example:"2"`
+	// @example "What is the capital of France?"
+	Question string `json:"question" example:"What is the capital of France?"`
+	// @example "single_choice"
+	QuizType   quiz.QuizType       `json:"quiz_type" example:"single_choice"`
+	Selections []SelectionSnapshot `json:"selections"`
+}
+
+// SelectionSnapshot is a selection of a quiz snapshot. IsCorrect is the
+// answer key, withheld from attempts that are not completed.
+type SelectionSnapshot struct {
+	// @example 1
+	ID uint `json:"id" example:"1"`
+	// @example "Paris"
+	SelectionText string `json:"selection_text" example:"Paris"`
+	// @example "A"
+	SelectionDisplayName string `json:"selection_display_name,omitempty" example:"A"`
+	// @example true
+	IsCorrect *bool `json:"is_correct,omitempty" example:"true"`
+}
+
+// NewQuizSnapshot copies a quiz, with its selections, at a revision
+func NewQuizSnapshot(q *quiz.Quiz, revision uint) QuizSnapshot {
+	snapshot := QuizSnapshot{
+		QuizID:     q.ID,
+		Revision:   revision,
+		Question:   q.Question,
+		QuizType:   q.QuizType,
+		Selections: make([]SelectionSnapshot, len(q.Selections)),
+	}
+	for i, selection := range q.Selections {
+		isCorrect := selection.IsCorrect
+		snapshot.Selections[i] = SelectionSnapshot{
+			ID:                   selection.ID,
+			SelectionText:        selection.SelectionText,
+			SelectionDisplayName: selection.SelectionDisplayName,
+			IsCorrect:            &isCorrect,
+		}
+	}
+	return snapshot
+}
+
+// Quiz returns the snapshot of a quiz of the suite
+func (s *SuiteSnapshot) Quiz(quizID uint) (*QuizSnapshot, bool) {
+	for i := range s.Quizzes {
+		if s.Quizzes[i].QuizID == quizID {
+			return &s.Quizzes[i], true
+		}
+	}
+	return nil, false
+}
+
+// Score is the percentage of the quizzes of the suite answered correctly
+func (s *SuiteSnapshot) Score(answers []QuizAttemptAnswer) int {
+	if len(s.Quizzes) == 0 {
+		return 0
+	}
+	correct := 0
+	for _, answer := range answers {
+		if _, ok := s.Quiz(answer.QuizID); ok && answer.IsCorrect {
+			correct++
+		}
+	}
+	return correct * 100 / len(s.Quizzes)
+}
+
+// WithoutAnswerKey returns a copy of the snapshot without the correct
+// selections
+func (s *SuiteSnapshot) WithoutAnswerKey() *SuiteSnapshot {
+	copied := s.Clone()
+	for i := range copied.Quizzes {
+		for j := range copied.Quizzes[i].Selections {
+			copied.Quizzes[i].Selections[j].IsCorrect = nil
+		}
+	}
+	return copied
+}
+
+// Clone returns a copy of the snapshot sharing no memory with it
+func (s *SuiteSnapshot) Clone() *SuiteSnapshot {
+	copied := *s
+	copied.Quizzes = slices.Clone(s.Quizzes)
+	for i := range copied.Quizzes {
+		selections := slices.Clone(copied.Quizzes[i].Selections)
+		for j := range selections {
+			if isCorrect := selections[j].IsCorrect; isCorrect != nil {
+				value := *isCorrect
+				selections[j].IsCorrect = &value
+			}
+		}
+		copied.Quizzes[i].Selections = selections
+	}
+	return &copied
+}
+
+// Selection returns a selection of the quiz
+func (q *QuizSnapshot) Selection(id uint) (SelectionSnapshot, bool) {
+	for _, selection := range q.Selections {
+		if selection.ID == id {
+			return selection, true
+		}
+	}
+	return SelectionSnapshot{}, false
+}
+
+// IsCorrect reports whether the selections chosen are exactly the correct
+// ones
+func (q *QuizSnapshot) IsCorrect(selectionIDs []uint) bool {
+	for _, selection := range q.Selections {
+		correct := selection.IsCorrect != nil && *selection.IsCorrect
+		if correct != slices.Contains(selectionIDs, selection.ID) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s SuiteSnapshot) Value() (driver.Value, error) {
+	encoded, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	return string(encoded), nil
+}
+
+func (s *SuiteSnapshot) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case string:
+		return json.Unmarshal([]byte(v), s)
+	case []byte:
+		return json.Unmarshal(v, s)
+	default:
+		return fmt.Errorf("quiz_attempt: cannot scan %T into SuiteSnapshot", value)
+	}
+}
+
+// IDList is a list of IDs stored as a JSON array
+type IDList []uint
+
+func (l IDList) Value() (driver.Value, error) {
+	if l == nil {
+		return "[]", nil
+	}
+	encoded, err := json.Marshal(l)
+	if err != nil {
+		return nil, err
+	}
+	return string(encoded), nil
+}
+
+func (l *IDList) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case string:
+		return json.Unmarshal([]byte(v), l)
+	case []byte:
+		return json.Unmarshal(v, l)
+	case nil:
+		*l = nil
+		return nil
+	default:
+		return fmt.Errorf("quiz_attempt: cannot scan %T into IDList", value)
+	}
+}
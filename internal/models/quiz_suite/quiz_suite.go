@@ -45,6 +45,10 @@ type QuizSuite struct {
 	// The timestamp when the quiz suite was deleted (soft delete)
 	DeletedAt   gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
 	
+	// Incremented on every change, sent as the ETag of the quiz suite
+	// @example 1
+	Version     uint           `json:"version" gorm:"not null;default:1" example:"1"`
+	
 	// The title of the quiz suite
 	// @example "My Quiz Suite"
 	// @required true
@@ -62,6 +66,11 @@ type QuizSuite struct {
 	// The user who created the quiz suite
 	CreatedBy   *user.User     `json:"created_by,omitempty" gorm:"foreignKey:CreatedByID"`
 	
+	// The quiz suite this one was cloned from, which may since have been
+	// deleted
+	// @example 1
+	ClonedFromID *uint          `json:"cloned_from_id,omitempty" example:"1"`
+	
 	// The quizzes in this suite
 	Quizzes     []*quiz.Quiz   `json:"quizzes,omitempty" gorm:"many2many:quiz_suite_quizzes;"`
 } 
\ No newline at end of file
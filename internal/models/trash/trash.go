@@ -0,0 +1,27 @@
+package trash
+
+import "time"
+
+// Item types
+const (
+	TypeQuiz        = "quiz"
+	TypeQuizSuite   = "quiz_suite"
+	TypeQuizAttempt = "quiz_attempt"
+)
+
+// Item is a deleted quiz, quiz suite or quiz attempt that can still be
+// restored
+// @model TrashItem
+// @Description A deleted record in the trash
+type Item struct {
+	// One of quiz, quiz_suite or quiz_attempt
+	Type string `json:"type" example:"quiz_suite"`
+	ID   uint   `json:"id" example:"1"`
+	// Question of a quiz or title of a quiz suite
+	Title string `json:"title,omitempty" example:"Basic Math"`
+	// Quiz suite of a quiz attempt
+	QuizSuiteID uint      `json:"quiz_suite_id,omitempty" example:"1"`
+	DeletedAt   time.Time `json:"deleted_at" example:"2024-03-20T15:04:05Z"`
+	// When the item is deleted for good
+	PurgeAt time.Time `json:"purge_at" example:"2024-04-19T15:04:05Z"`
+}
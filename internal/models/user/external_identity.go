@@ -0,0 +1,25 @@
+package user
+
+import (
+	"time"
+)
+
+// ExternalIdentity links a user to an account at an external OpenID Connect provider
+type ExternalIdentity struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	UserID    uint      `gorm:"not null;index" json:"user_id"`
+	Provider  string    `gorm:"not null;uniqueIndex:idx_external_identities_provider_subject" json:"provider"`
+	Subject   string    `gorm:"not null;uniqueIndex:idx_external_identities_provider_subject" json:"subject"`
+	Email     string    `json:"email"`
+}
+
+// ExternalProfile is the verified identity returned by an external provider after login
+type ExternalProfile struct {
+	Provider      string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
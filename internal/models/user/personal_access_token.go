@@ -0,0 +1,58 @@
+package user
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ScopeList is a list of scopes stored as a space separated string
+type ScopeList []string
+
+func (s ScopeList) Value() (driver.Value, error) {
+	return strings.Join(s, " "), nil
+}
+
+func (s *ScopeList) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case string:
+		*s = strings.Fields(v)
+	case []byte:
+		*s = strings.Fields(string(v))
+	case nil:
+		*s = nil
+	default:
+		return fmt.Errorf("cannot scan %T into ScopeList", value)
+	}
+	return nil
+}
+
+// PersonalAccessToken is a named, scoped and expiring API token for automation.
+// Only the SHA-256 hash of the token is stored.
+type PersonalAccessToken struct {
+	ID          uint       `gorm:"primarykey" json:"id"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	UserID      uint       `gorm:"not null;index" json:"user_id"`
+	Name        string     `gorm:"not null" json:"name"`
+	TokenHash   string     `gorm:"uniqueIndex;not null" json:"-"`
+	TokenPrefix string     `gorm:"not null" json:"token_prefix"`
+	Scopes      ScopeList  `gorm:"type:text;not null" json:"scopes"`
+	ExpiresAt   time.Time  `gorm:"not null" json:"expires_at"`
+	LastUsedAt  *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt   *time.Time `json:"revoked_at,omitempty"`
+}
+
+// CreatePersonalAccessTokenRequest represents the request body for creating a personal access token
+type CreatePersonalAccessTokenRequest struct {
+	Name          string   `json:"name" binding:"required,max=100" example:"content pipeline"`
+	Scopes        []string `json:"scopes" binding:"required,min=1" example:"quizzes:read,quizzes:write"`
+	ExpiresInDays int      `json:"expires_in_days" binding:"omitempty,min=1,max=365" example:"90"`
+}
+
+// CreatePersonalAccessTokenResponse contains the plaintext token, which is only shown once
+type CreatePersonalAccessTokenResponse struct {
+	PersonalAccessToken
+	Token string `json:"token" example:"qlt_6ZbGk1..."`
+}
@@ -1,13 +1,12 @@
 package user
 
 import (
-	"encoding/hex"
 	"errors"
-	"log"
+	"log/slog"
 	"time"
 
-	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
+	"quizlet/internal/auth/password"
 )
 
 // CreateUserRequest represents the request body for user registration
@@ -26,51 +25,54 @@ type User struct {
 	Username  string         `gorm:"uniqueIndex;not null" json:"username"`
 	Email     string         `gorm:"uniqueIndex;not null" json:"email"`
 	Password  string         `gorm:"not null" json:"-"`
+
+	// TOTP two-factor authentication; the secret is set during enrollment and
+	// only takes effect once TOTPEnabled is confirmed with a valid code
+	TOTPSecret   string `gorm:"column:totp_secret" json:"-"`
+	TOTPEnabled  bool   `gorm:"column:totp_enabled;not null;default:false" json:"mfa_enabled,omitempty"`
+	TOTPLastStep int64  `gorm:"column:totp_last_step;not null;default:0" json:"-"`
+	// MFAAttempts counts the two-factor codes tried since the current login
+	// challenge began or the last code was accepted
+	MFAAttempts int `gorm:"column:mfa_attempts;not null;default:0" json:"-"`
+
+	// IsAdmin grants the admin role; it is only set in the database
+	IsAdmin bool `gorm:"column:is_admin;not null;default:false" json:"-"`
+}
+
+// LogValue keeps the password hash, TOTP secret and email out of logs when a
+// user is logged as a whole
+func (u User) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.Uint64("id", uint64(u.ID)),
+		slog.String("username", u.Username),
+	)
 }
 
-// HashPassword hashes the password using bcrypt
-func (u *User) HashPassword() error {
-	log.Printf("Hashing password for user (length: %d)", len(u.Password))
+// HashPassword replaces the plaintext password with its encoded hash
+func (u *User) HashPassword(hasher password.Hasher) error {
 	if u.Password == "" {
 		return errors.New("password cannot be empty")
 	}
-	
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(u.Password), bcrypt.DefaultCost)
+
+	hashedPassword, err := hasher.Hash(u.Password)
 	if err != nil {
-		log.Printf("Error hashing password: %v", err)
 		return err
 	}
-	
-	u.Password = string(hashedPassword)
-	log.Printf("Password hashed successfully (hash length: %d, hex: %s)", 
-		len(u.Password), 
-		hex.EncodeToString([]byte(u.Password)))
+
+	u.Password = hashedPassword
 	return nil
 }
 
 // CheckPassword checks if the provided password matches the hashed password
-func (u *User) CheckPassword(password string) bool {
-	log.Printf("Checking password for user ID: %d", u.ID)
-	
-	if u.Password == "" {
-		log.Printf("Error: Stored password hash is empty for user ID: %d", u.ID)
-		return false
-	}
-	
-	if password == "" {
-		log.Printf("Error: Input password is empty for user ID: %d", u.ID)
+func (u *User) CheckPassword(hasher password.Hasher, plaintext string) bool {
+	if u.Password == "" || plaintext == "" {
 		return false
 	}
-	
-	log.Printf("Stored hash (hex): %s", hex.EncodeToString([]byte(u.Password)))
-	log.Printf("Input password (hex): %s", hex.EncodeToString([]byte(password)))
-	
-	err := bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(password))
+
+	ok, err := hasher.Verify(plaintext, u.Password)
 	if err != nil {
-		log.Printf("Password comparison failed for user ID %d: %v", u.ID, err)
+		slog.Warn("password verification failed", "user_id", u.ID, "error", err)
 		return false
 	}
-	
-	log.Printf("Password comparison successful for user ID: %d", u.ID)
-	return true
-} 
\ No newline at end of file
+	return ok
+}
@@ -0,0 +1,147 @@
+// Package problem renders errors as RFC 7807 problem details. Handlers and
+// middleware record errors with c.Error and return; Middleware turns the last
+// one into an application/problem+json response. Only the client-safe message
+// of an apperr.Error is exposed; anything else becomes a generic 500 and the
+// original error is left in c.Errors for the access log.
+package problem
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"quizlet/internal/apperr"
+)
+
+// ContentType is the media type of problem responses
+const ContentType = "application/problem+json"
+
+// typePrefix turns an error code into the problem type URI
+const typePrefix = "urn:quizlet:problem:"
+
+// ErrInternal replaces errors that are not safe to show to clients
+var ErrInternal = apperr.New(apperr.Internal, "internal_error", "an unexpected error occurred")
+
+// ErrRouteNotFound is reported for requests that match no route
+var ErrRouteNotFound = apperr.New(apperr.NotFound, "route_not_found", "no such endpoint")
+
+// Problem is the body of an error response
+// @Description RFC 7807 problem details
+type Problem struct {
+	// A URI identifying the problem type
+	Type string `json:"type" example:"urn:quizlet:problem:quiz_not_found"`
+	// The HTTP status text
+	Title string `json:"title" example:"Not Found"`
+	// The HTTP status code
+	Status int `json:"status" example:"404"`
+	// A human readable explanation of this occurrence
+	Detail string `json:"detail,omitempty" example:"quiz not found"`
+	// The request path
+	Instance string `json:"instance,omitempty" example:"/api/quizzes/42"`
+	// A stable, machine readable error code
+	Code string `json:"code" example:"quiz_not_found"`
+	// The rejected fields of an invalid request
+	Errors []apperr.FieldError `json:"errors,omitempty"`
+}
+
+// Status returns the HTTP status for an error kind
+func Status(kind apperr.Kind) int {
+	switch kind {
+	case apperr.Invalid:
+		return http.StatusBadRequest
+	case apperr.Unauthenticated:
+		return http.StatusUnauthorized
+	case apperr.Forbidden:
+		return http.StatusForbidden
+	case apperr.NotFound:
+		return http.StatusNotFound
+	case apperr.Conflict:
+		return http.StatusConflict
+	case apperr.Unprocessable:
+		return http.StatusUnprocessableEntity
+	case apperr.RateLimited:
+		return http.StatusTooManyRequests
+	case apperr.Upstream:
+		return http.StatusBadGateway
+	case apperr.PreconditionFailed:
+		return http.StatusPreconditionFailed
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// New builds the problem for err. Errors other than apperr.Error are
+// reported as internal errors without their message.
+func New(err error) Problem {
+	appErr, ok := apperr.As(err)
+	if !ok || appErr.Kind == apperr.Internal {
+		appErr = ErrInternal
+	}
+
+	status := Status(appErr.Kind)
+	return Problem{
+		Type:   typePrefix + appErr.Code,
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: appErr.Message,
+		Code:   appErr.Code,
+		Errors: appErr.Fields,
+	}
+}
+
+// Write sends p as the response
+func Write(c *gin.Context, p Problem) {
+	if p.Instance == "" {
+		p.Instance = c.Request.URL.Path
+	}
+	c.Render(p.Status, render{p})
+}
+
+// Render writes the last error recorded on c as a problem, unless a response
+// was already written. Errors recorded with gin.ErrorTypeBind are request
+// binding failures and are reported field by field.
+func Render(c *gin.Context) {
+	if c.Writer.Written() || len(c.Errors) == 0 {
+		return
+	}
+
+	last := c.Errors.Last()
+	err := last.Err
+	if last.IsType(gin.ErrorTypeBind) {
+		err = Binding(err)
+	}
+	Write(c, New(err))
+}
+
+// Middleware renders the errors of every request as problem details. Place
+// it outside Recovery so panics are reported the same way.
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+		Render(c)
+	}
+}
+
+// NoRoute reports unknown endpoints as problems instead of gin's plain text
+func NoRoute(c *gin.Context) {
+	_ = c.Error(ErrRouteNotFound)
+}
+
+// render writes a problem with the problem+json content type
+type render struct {
+	problem Problem
+}
+
+func (r render) Render(w http.ResponseWriter) error {
+	r.WriteContentType(w)
+	body, err := json.Marshal(r.problem)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(body)
+	return err
+}
+
+func (r render) WriteContentType(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", ContentType)
+}
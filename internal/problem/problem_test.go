@@ -0,0 +1,134 @@
+package problem
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"quizlet/internal/apperr"
+)
+
+var errQuizNotFound = apperr.New(apperr.NotFound, "quiz_not_found", "quiz not found")
+
+func TestNew(t *testing.T) {
+	testCases := []struct {
+		name     string
+		err      error
+		expected Problem
+	}{
+		{
+			name: "Domain Error",
+			err:  errQuizNotFound,
+			expected: Problem{
+				Type:   "urn:quizlet:problem:quiz_not_found",
+				Title:  "Not Found",
+				Status: http.StatusNotFound,
+				Detail: "quiz not found",
+				Code:   "quiz_not_found",
+			},
+		},
+		{
+			name: "Wrapped Domain Error",
+			err:  fmt.Errorf("loading quiz: %w", errQuizNotFound.Wrap(errors.New("record not found"))),
+			expected: Problem{
+				Type:   "urn:quizlet:problem:quiz_not_found",
+				Title:  "Not Found",
+				Status: http.StatusNotFound,
+				Detail: "quiz not found",
+				Code:   "quiz_not_found",
+			},
+		},
+		{
+			name: "Internal Error Is Hidden",
+			err:  errors.New("pq: connection refused"),
+			expected: Problem{
+				Type:   "urn:quizlet:problem:internal_error",
+				Title:  "Internal Server Error",
+				Status: http.StatusInternalServerError,
+				Detail: "an unexpected error occurred",
+				Code:   "internal_error",
+			},
+		},
+		{
+			name: "Validation Fields",
+			err:  apperr.Validation(apperr.FieldError{Field: "title", Code: "required", Message: "is required"}),
+			expected: Problem{
+				Type:   "urn:quizlet:problem:validation_failed",
+				Title:  "Bad Request",
+				Status: http.StatusBadRequest,
+				Detail: "request validation failed",
+				Code:   "validation_failed",
+				Errors: []apperr.FieldError{{Field: "title", Code: "required", Message: "is required"}},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, New(tc.err))
+		})
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Middleware())
+	router.NoRoute(NoRoute)
+	router.GET("/quizzes/:id", func(c *gin.Context) {
+		_ = c.Error(errQuizNotFound)
+	})
+	router.GET("/written", func(c *gin.Context) {
+		_ = c.Error(errQuizNotFound)
+		c.JSON(http.StatusOK, gin.H{"ok": true})
+	})
+
+	testCases := []struct {
+		name           string
+		path           string
+		expectedStatus int
+		expectedCode   string
+	}{
+		{
+			name:           "Recorded Error",
+			path:           "/quizzes/42",
+			expectedStatus: http.StatusNotFound,
+			expectedCode:   "quiz_not_found",
+		},
+		{
+			name:           "Unknown Route",
+			path:           "/nope",
+			expectedStatus: http.StatusNotFound,
+			expectedCode:   "route_not_found",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tc.path, nil))
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			assert.Equal(t, ContentType, w.Header().Get("Content-Type"))
+
+			var p Problem
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &p))
+			assert.Equal(t, tc.expectedCode, p.Code)
+			assert.Equal(t, tc.path, p.Instance)
+		})
+	}
+
+	t.Run("Response Already Written", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/written", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"ok":true}`, w.Body.String())
+	})
+}
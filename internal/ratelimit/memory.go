@@ -0,0 +1,54 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepEvery is the number of takes between removals of full buckets
+const sweepEvery = 1024
+
+// MemoryStore keeps buckets in process memory. Limits only hold per
+// replica, so use RedisStore when the API runs more than once.
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]time.Time
+	takes   int
+	now     func() time.Time
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets: make(map[string]time.Time),
+		now:     time.Now,
+	}
+}
+
+func (s *MemoryStore) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.takes++
+	if s.takes%sweepEvery == 0 {
+		s.sweep(now)
+	}
+
+	result, tat := gcra(now, s.buckets[key], limit)
+	if result.Allowed {
+		s.buckets[key] = tat
+	}
+	return result, nil
+}
+
+// sweep forgets buckets that have refilled completely, which behave the
+// same as buckets that were never used
+func (s *MemoryStore) sweep(now time.Time) {
+	for key, tat := range s.buckets {
+		if !tat.After(now) {
+			delete(s.buckets, key)
+		}
+	}
+}
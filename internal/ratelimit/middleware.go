@@ -0,0 +1,93 @@
+package ratelimit
+
+import (
+	"log/slog"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"quizlet/internal/apperr"
+	"quizlet/internal/auth"
+	"quizlet/internal/metrics"
+)
+
+// Response headers, following the IETF RateLimit header fields draft
+const (
+	LimitHeader      = "RateLimit-Limit"
+	RemainingHeader  = "RateLimit-Remaining"
+	ResetHeader      = "RateLimit-Reset"
+	PolicyHeader     = "RateLimit-Policy"
+	RetryAfterHeader = "Retry-After"
+)
+
+// ErrRateLimited is reported when a client has used up its bucket
+var ErrRateLimited = apperr.New(apperr.RateLimited, "rate_limited", "too many requests, retry later")
+
+// KeyFunc tells clients apart
+type KeyFunc func(c *gin.Context) string
+
+// ByIP keys requests by the client IP, as resolved by gin's trusted proxies
+func ByIP(c *gin.Context) string {
+	return "ip:" + c.ClientIP()
+}
+
+// ByUser keys requests by the authenticated user, falling back to the client
+// IP on routes without authentication
+func ByUser(c *gin.Context) string {
+	if userID, ok := auth.CurrentUserID(c); ok {
+		return "user:" + strconv.FormatUint(uint64(userID), 10)
+	}
+	return ByIP(c)
+}
+
+// Policy is a named limit applied to a group of routes. Routes sharing a
+// policy share its buckets.
+type Policy struct {
+	Name  string
+	Limit Limit
+	Key   KeyFunc
+}
+
+// Middleware enforces policy with the buckets in store. It sets the
+// RateLimit-* headers on every response and Retry-After on rejections.
+// When the store fails the request is let through, so an unavailable Redis
+// does not take the API down with it.
+func Middleware(store Store, policy Policy) gin.HandlerFunc {
+	if !policy.Limit.Enabled() {
+		return func(c *gin.Context) {}
+	}
+
+	key := policy.Key
+	if key == nil {
+		key = ByIP
+	}
+	policyHeader := strconv.Itoa(policy.Limit.Burst) + ";w=" + strconv.Itoa(seconds(policy.Limit.Period))
+
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		result, err := store.Take(ctx, policy.Name+":"+key(c), policy.Limit)
+		if err != nil {
+			slog.WarnContext(ctx, "rate limit store failed, allowing request", "policy", policy.Name, "error", err)
+			return
+		}
+
+		header := c.Writer.Header()
+		header.Set(PolicyHeader, policyHeader)
+		header.Set(LimitHeader, strconv.Itoa(policy.Limit.Burst))
+		header.Set(RemainingHeader, strconv.Itoa(result.Remaining))
+		header.Set(ResetHeader, strconv.Itoa(seconds(result.Reset)))
+
+		if !result.Allowed {
+			header.Set(RetryAfterHeader, strconv.Itoa(seconds(result.RetryAfter)))
+			metrics.RateLimited(policy.Name)
+			_ = c.Error(ErrRateLimited)
+			c.Abort()
+		}
+	}
+}
+
+// seconds rounds d up to whole seconds, so clients never retry too early
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,94 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"quizlet/internal/auth"
+	"quizlet/internal/problem"
+)
+
+type failingStore struct{}
+
+func (failingStore) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	return Result{}, errors.New("connection refused")
+}
+
+func newRouter(store Store, policy Policy) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(problem.Middleware())
+	router.Use(func(c *gin.Context) {
+		if user := c.GetHeader("X-Test-User"); user == "1" {
+			auth.SetPrincipal(c, &auth.Principal{UserID: 1, Method: auth.AuthMethodBearer})
+		}
+	})
+	router.POST("/quizzes", Middleware(store, policy), func(c *gin.Context) {
+		c.Status(http.StatusCreated)
+	})
+	return router
+}
+
+func post(router *gin.Engine, remoteAddr, user string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/quizzes", nil)
+	req.RemoteAddr = remoteAddr
+	if user != "" {
+		req.Header.Set("X-Test-User", user)
+	}
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestMiddleware(t *testing.T) {
+	router := newRouter(NewMemoryStore(), Policy{
+		Name:  "write",
+		Limit: Limit{Burst: 2, Period: time.Minute},
+		Key:   ByUser,
+	})
+
+	w := post(router, "10.0.0.1:1234", "1")
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "2;w=60", w.Header().Get(PolicyHeader))
+	assert.Equal(t, "2", w.Header().Get(LimitHeader))
+	assert.Equal(t, "1", w.Header().Get(RemainingHeader))
+	assert.Equal(t, "30", w.Header().Get(ResetHeader))
+	assert.Empty(t, w.Header().Get(RetryAfterHeader))
+
+	// The same user from another address shares the bucket
+	w = post(router, "10.0.0.2:1234", "1")
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "0", w.Header().Get(RemainingHeader))
+
+	w = post(router, "10.0.0.1:1234", "1")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, problem.ContentType, w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), `"code":"rate_limited"`)
+	assert.Equal(t, "30", w.Header().Get(RetryAfterHeader))
+	assert.Equal(t, "0", w.Header().Get(RemainingHeader))
+
+	// Anonymous requests are keyed by IP
+	w = post(router, "10.0.0.1:1234", "")
+	assert.Equal(t, http.StatusCreated, w.Code)
+}
+
+func TestMiddlewareDisabledOrFailing(t *testing.T) {
+	router := newRouter(failingStore{}, Policy{Name: "write", Limit: Limit{}})
+	for i := 0; i < 3; i++ {
+		w := post(router, "10.0.0.1:1234", "")
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Empty(t, w.Header().Get(LimitHeader))
+	}
+
+	router = newRouter(failingStore{}, Policy{Name: "write", Limit: Limit{Burst: 1, Period: time.Minute}})
+	for i := 0; i < 3; i++ {
+		w := post(router, "10.0.0.1:1234", "")
+		assert.Equal(t, http.StatusCreated, w.Code)
+	}
+}
@@ -0,0 +1,114 @@
+// Package ratelimit limits requests per client with token buckets. A policy
+// names a limit and how clients are told apart (by user or by IP); the
+// middleware takes one token per request from the client's bucket in a Store
+// and rejects the request with 429 once the bucket is empty.
+//
+// Buckets are kept as a single timestamp using GCRA, the generic cell rate
+// algorithm, which behaves exactly like a token bucket that holds Burst
+// tokens and refills one token every Period/Burst. That makes every take a
+// single atomic read and write, in memory as well as in Redis.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limit allows Burst requests at once, refilled evenly over Period. The
+// zero Limit allows everything.
+type Limit struct {
+	Burst  int
+	Period time.Duration
+}
+
+// ParseLimit reads a limit written as requests/period, e.g. 10/1m or 5/s.
+// "off" and the empty string disable the limit.
+func ParseLimit(s string) (Limit, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "off" {
+		return Limit{}, nil
+	}
+
+	count, per, ok := strings.Cut(s, "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("invalid rate limit %q, use requests/period such as 10/1m", s)
+	}
+	burst, err := strconv.Atoi(strings.TrimSpace(count))
+	if err != nil || burst < 1 {
+		return Limit{}, fmt.Errorf("invalid rate limit %q, requests must be a positive integer", s)
+	}
+
+	per = strings.TrimSpace(per)
+	// A bare unit such as "s" or "h" means one of it
+	if per != "" && (per[0] < '0' || per[0] > '9') {
+		per = "1" + per
+	}
+	period, err := time.ParseDuration(per)
+	if err != nil || period <= 0 {
+		return Limit{}, fmt.Errorf("invalid rate limit %q, period must be a positive duration", s)
+	}
+	return Limit{Burst: burst, Period: period}, nil
+}
+
+// Enabled reports whether the limit rejects anything
+func (l Limit) Enabled() bool {
+	return l.Burst > 0 && l.Period > 0
+}
+
+func (l Limit) String() string {
+	if !l.Enabled() {
+		return "off"
+	}
+	return fmt.Sprintf("%d/%s", l.Burst, l.Period)
+}
+
+// interval is the time it takes to refill one token
+func (l Limit) interval() time.Duration {
+	return l.Period / time.Duration(l.Burst)
+}
+
+// Result is the outcome of taking a token
+type Result struct {
+	Allowed bool
+	// Remaining is the number of tokens left after this request
+	Remaining int
+	// RetryAfter is how long a rejected client has to wait for a token
+	RetryAfter time.Duration
+	// Reset is how long it takes until the bucket is full again
+	Reset time.Duration
+}
+
+// Store keeps the buckets of all clients
+type Store interface {
+	// Take removes one token from the bucket at key
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// gcra decides a take given the bucket's theoretical arrival time tat, the
+// time at which the bucket would be full again. It returns the result and
+// the new tat, which is only stored when the request is allowed.
+func gcra(now, tat time.Time, limit Limit) (Result, time.Time) {
+	interval := limit.interval()
+	if tat.Before(now) {
+		tat = now
+	}
+
+	newTat := tat.Add(interval)
+	allowAt := newTat.Add(-limit.Period)
+	if now.Before(allowAt) {
+		return Result{
+			RetryAfter: allowAt.Sub(now),
+			Reset:      tat.Sub(now),
+		}, tat
+	}
+
+	reset := newTat.Sub(now)
+	return Result{
+		Allowed:   true,
+		Remaining: int((limit.Period - reset) / interval),
+		Reset:     reset,
+	}, newTat
+}
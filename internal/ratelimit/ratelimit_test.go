@@ -0,0 +1,115 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLimit(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected Limit
+		wantErr  bool
+	}{
+		{input: "10/1m", expected: Limit{Burst: 10, Period: time.Minute}},
+		{input: "5/s", expected: Limit{Burst: 5, Period: time.Second}},
+		{input: " 100 / 1h30m ", expected: Limit{Burst: 100, Period: 90 * time.Minute}},
+		{input: "off", expected: Limit{}},
+		{input: "", expected: Limit{}},
+		{input: "10", wantErr: true},
+		{input: "0/1m", wantErr: true},
+		{input: "ten/1m", wantErr: true},
+		{input: "10/0s", wantErr: true},
+		{input: "10/fortnight", wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			limit, err := ParseLimit(tc.input)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, limit)
+		})
+	}
+}
+
+// testStore runs the same bucket scenario against a store; advance moves
+// the store's clock forward
+func testStore(t *testing.T, store Store, advance func(time.Duration)) {
+	ctx := context.Background()
+	limit := Limit{Burst: 3, Period: 3 * time.Second}
+
+	for i := 2; i >= 0; i-- {
+		result, err := store.Take(ctx, "user:1", limit)
+		require.NoError(t, err)
+		assert.True(t, result.Allowed)
+		assert.Equal(t, i, result.Remaining)
+	}
+
+	result, err := store.Take(ctx, "user:1", limit)
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, time.Second, result.RetryAfter)
+	assert.Equal(t, 3*time.Second, result.Reset)
+
+	// Other clients have their own bucket
+	result, err = store.Take(ctx, "user:2", limit)
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+
+	// One token is refilled per second
+	advance(time.Second)
+	result, err = store.Take(ctx, "user:1", limit)
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 0, result.Remaining)
+
+	// A bucket never holds more than the burst
+	advance(time.Minute)
+	result, err = store.Take(ctx, "user:1", limit)
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 2, result.Remaining)
+	assert.Equal(t, time.Second, result.Reset)
+}
+
+func TestMemoryStore(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+
+	testStore(t, store, func(d time.Duration) { now = now.Add(d) })
+
+	store.sweep(now.Add(time.Hour))
+	assert.Empty(t, store.buckets)
+}
+
+func TestRedisStore(t *testing.T) {
+	server := miniredis.RunT(t)
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	server.SetTime(now)
+
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+	store := NewRedisStore(client, "quizlet:ratelimit:")
+
+	testStore(t, store, func(d time.Duration) {
+		now = now.Add(d)
+		server.SetTime(now)
+	})
+
+	assert.True(t, server.Exists("quizlet:ratelimit:user:1"))
+
+	server.Close()
+	_, err := store.Take(context.Background(), "user:1", Limit{Burst: 1, Period: time.Second})
+	assert.Error(t, err)
+}
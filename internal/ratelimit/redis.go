@@ -0,0 +1,68 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// takeScript runs gcra atomically in Redis, in microseconds and on the
+// server's clock so replicas with skewed clocks share the same buckets. It
+// returns whether the request is allowed, the time until the next token and
+// the time until the bucket is full.
+var takeScript = redis.NewScript(`
+local interval = tonumber(ARGV[1])
+local period = tonumber(ARGV[2])
+local time = redis.call("TIME")
+local now = tonumber(time[1]) * 1000000 + tonumber(time[2])
+
+local tat = tonumber(redis.call("GET", KEYS[1]) or now)
+if tat < now then
+	tat = now
+end
+
+local new_tat = tat + interval
+local allow_at = new_tat - period
+if now < allow_at then
+	return {0, allow_at - now, tat - now}
+end
+
+redis.call("SET", KEYS[1], new_tat, "PX", math.ceil((new_tat - now) / 1000))
+return {1, 0, new_tat - now}
+`)
+
+// RedisStore keeps buckets in Redis, or a compatible server such as Valkey,
+// so limits hold across replicas. Keys expire once their bucket is full.
+type RedisStore struct {
+	client redis.Scripter
+	prefix string
+}
+
+// NewRedisStore creates a store that namespaces its keys with prefix
+func NewRedisStore(client redis.Scripter, prefix string) *RedisStore {
+	return &RedisStore{client: client, prefix: prefix}
+}
+
+func (s *RedisStore) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	interval := limit.interval()
+	reply, err := takeScript.Run(ctx, s.client, []string{s.prefix + key},
+		interval.Microseconds(), limit.Period.Microseconds()).Int64Slice()
+	if err != nil {
+		return Result{}, fmt.Errorf("taking rate limit token: %w", err)
+	}
+	if len(reply) != 3 {
+		return Result{}, fmt.Errorf("taking rate limit token: unexpected reply %v", reply)
+	}
+
+	result := Result{
+		Allowed:    reply[0] == 1,
+		RetryAfter: time.Duration(reply[1]) * time.Microsecond,
+		Reset:      time.Duration(reply[2]) * time.Microsecond,
+	}
+	if result.Allowed {
+		result.Remaining = int((limit.Period - result.Reset) / interval)
+	}
+	return result, nil
+}
@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+	"quizlet/internal/models/audit_entry"
+)
+
+// DefaultAuditLimit caps audit listings that do not set a limit
+const DefaultAuditLimit = 100
+
+// AuditEntryRepository appends to the audit log and queries it; entries are
+// never changed or deleted
+type AuditEntryRepository interface {
+	Create(ctx context.Context, entry *audit_entry.AuditEntry) error
+	// List returns the entries matching filter, newest first
+	List(ctx context.Context, filter audit_entry.Filter) ([]*audit_entry.AuditEntry, error)
+}
+
+type auditEntryRepository struct {
+	db *gorm.DB
+}
+
+func NewAuditEntryRepository(db *gorm.DB) AuditEntryRepository {
+	return &auditEntryRepository{db: db}
+}
+
+func (r *auditEntryRepository) Create(ctx context.Context, entry *audit_entry.AuditEntry) error {
+	return r.db.WithContext(ctx).Create(entry).Error
+}
+
+func (r *auditEntryRepository) List(ctx context.Context, filter audit_entry.Filter) ([]*audit_entry.AuditEntry, error) {
+	query := r.db.WithContext(ctx)
+	if filter.ResourceType != "" {
+		query = query.Where("resource_type = ?", filter.ResourceType)
+	}
+	if filter.ResourceID != 0 {
+		query = query.Where("resource_id = ?", filter.ResourceID)
+	}
+	if filter.ActorID != 0 {
+		query = query.Where("actor_id = ?", filter.ActorID)
+	}
+	// Bounds are compared in local time, the zone timestamps are written
+	// in, as SQLite compares them as text
+	if !filter.Since.IsZero() {
+		query = query.Where("created_at >= ?", filter.Since.Local())
+	}
+	if !filter.Until.IsZero() {
+		query = query.Where("created_at < ?", filter.Until.Local())
+	}
+	limit := filter.Limit
+	if limit == 0 {
+		limit = DefaultAuditLimit
+	}
+
+	var entries []*audit_entry.AuditEntry
+	if err := query.Order("id DESC").Limit(limit).Find(&entries).Error; err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
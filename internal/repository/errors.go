@@ -0,0 +1,7 @@
+package repository
+
+import "errors"
+
+// ErrVersionConflict is returned by conditional writes when the row no
+// longer has the version the caller read
+var ErrVersionConflict = errors.New("record version has changed")
@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"context"
+	"quizlet/internal/models/user"
+
+	"gorm.io/gorm"
+)
+
+type ExternalIdentityRepository interface {
+	Create(ctx context.Context, identity *user.ExternalIdentity) error
+	FindByProviderSubject(ctx context.Context, provider, subject string) (*user.ExternalIdentity, error)
+	FindByUserID(ctx context.Context, userID uint) ([]*user.ExternalIdentity, error)
+}
+
+type externalIdentityRepository struct {
+	db *gorm.DB
+}
+
+func NewExternalIdentityRepository(db *gorm.DB) ExternalIdentityRepository {
+	return &externalIdentityRepository{db: db}
+}
+
+func (r *externalIdentityRepository) Create(ctx context.Context, identity *user.ExternalIdentity) error {
+	return r.db.WithContext(ctx).Create(identity).Error
+}
+
+func (r *externalIdentityRepository) FindByProviderSubject(ctx context.Context, provider, subject string) (*user.ExternalIdentity, error) {
+	var identity user.ExternalIdentity
+	err := r.db.WithContext(ctx).Where("provider = ? AND subject = ?", provider, subject).First(&identity).Error
+	if err != nil {
+		return nil, err
+	}
+	return &identity, nil
+}
+
+func (r *externalIdentityRepository) FindByUserID(ctx context.Context, userID uint) ([]*user.ExternalIdentity, error) {
+	var identities []*user.ExternalIdentity
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Find(&identities).Error
+	if err != nil {
+		return nil, err
+	}
+	return identities, nil
+}
@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"context"
+	"quizlet/internal/models/idempotency_key"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type IdempotencyKeyRepository interface {
+	// Create stores a new key and reports false when the user already has
+	// a record for it
+	Create(ctx context.Context, key *idempotency_key.IdempotencyKey) (bool, error)
+	Find(ctx context.Context, userID uint, key string) (*idempotency_key.IdempotencyKey, error)
+	SaveResponse(ctx context.Context, key *idempotency_key.IdempotencyKey) error
+	Delete(ctx context.Context, id uint) error
+	DeleteExpired(ctx context.Context) error
+}
+
+type idempotencyKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewIdempotencyKeyRepository(db *gorm.DB) IdempotencyKeyRepository {
+	return &idempotencyKeyRepository{db: db}
+}
+
+func (r *idempotencyKeyRepository) Create(ctx context.Context, key *idempotency_key.IdempotencyKey) (bool, error) {
+	result := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(key)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *idempotencyKeyRepository) Find(ctx context.Context, userID uint, key string) (*idempotency_key.IdempotencyKey, error) {
+	var record idempotency_key.IdempotencyKey
+	err := r.db.WithContext(ctx).Where("user_id = ? AND key = ?", userID, key).First(&record).Error
+	if err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+func (r *idempotencyKeyRepository) SaveResponse(ctx context.Context, key *idempotency_key.IdempotencyKey) error {
+	return r.db.WithContext(ctx).Model(key).Select("ResponseStatus", "ResponseContentType", "ResponseLocation", "ResponseETag", "ResponseBody").Updates(key).Error
+}
+
+func (r *idempotencyKeyRepository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&idempotency_key.IdempotencyKey{}, id).Error
+}
+
+func (r *idempotencyKeyRepository) DeleteExpired(ctx context.Context) error {
+	return r.db.WithContext(ctx).Where("expires_at < ?", time.Now()).Delete(&idempotency_key.IdempotencyKey{}).Error
+}
@@ -0,0 +1,83 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"quizlet/internal/models/audit_entry"
+	"quizlet/internal/repository"
+)
+
+type auditEntryRepository struct {
+	store *Store
+	inTx  bool
+}
+
+func NewAuditEntryRepository(store *Store) repository.AuditEntryRepository {
+	return &auditEntryRepository{store: store}
+}
+
+func (r *auditEntryRepository) Create(ctx context.Context, entry *audit_entry.AuditEntry) error {
+	defer r.store.lock(r.inTx)()
+
+	entry.ID = r.store.nextID("audit_entries")
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+	r.store.tables.auditEntries[entry.ID] = copyAuditEntry(*entry)
+	return nil
+}
+
+func (r *auditEntryRepository) List(ctx context.Context, filter audit_entry.Filter) ([]*audit_entry.AuditEntry, error) {
+	defer r.store.lock(r.inTx)()
+
+	limit := filter.Limit
+	if limit == 0 {
+		limit = repository.DefaultAuditLimit
+	}
+
+	ids := sortedKeys(r.store.tables.auditEntries)
+	slices.Reverse(ids)
+	entries := []*audit_entry.AuditEntry{}
+	for _, id := range ids {
+		if len(entries) == limit {
+			break
+		}
+		entry := r.store.tables.auditEntries[id]
+		if matchesFilter(&entry, filter) {
+			entry = copyAuditEntry(entry)
+			entries = append(entries, &entry)
+		}
+	}
+	return entries, nil
+}
+
+func matchesFilter(entry *audit_entry.AuditEntry, filter audit_entry.Filter) bool {
+	switch {
+	case filter.ResourceType != "" && entry.ResourceType != filter.ResourceType:
+		return false
+	case filter.ResourceID != 0 && entry.ResourceID != filter.ResourceID:
+		return false
+	case filter.ActorID != 0 && (entry.ActorID == nil || *entry.ActorID != filter.ActorID):
+		return false
+	case !filter.Since.IsZero() && entry.CreatedAt.Before(filter.Since):
+		return false
+	case !filter.Until.IsZero() && !entry.CreatedAt.Before(filter.Until):
+		return false
+	}
+	return true
+}
+
+// copyAuditEntry copies the actor and the change map, so the stored entry
+// shares no memory with the caller
+func copyAuditEntry(entry audit_entry.AuditEntry) audit_entry.AuditEntry {
+	if entry.ActorID != nil {
+		actorID := *entry.ActorID
+		entry.ActorID = &actorID
+	}
+	if entry.Changes != nil {
+		entry.Changes = copyMap(entry.Changes)
+	}
+	return entry
+}
@@ -0,0 +1,25 @@
+package memory
+
+import (
+	"testing"
+
+	"quizlet/internal/repository/repositorytest"
+)
+
+func TestConformance(t *testing.T) {
+	repositorytest.Run(t, func(t *testing.T) repositorytest.Repositories {
+		store := New()
+		return repositorytest.Repositories{
+			Users:         NewUserRepository(store),
+			RefreshTokens: NewRefreshTokenRepository(store),
+			RecoveryCodes: NewRecoveryCodeRepository(store),
+			Quizzes:       NewQuizRepository(store),
+			QuizRevisions: NewQuizRevisionRepository(store),
+			QuizSuites:    NewQuizSuiteRepository(store),
+			QuizAttempts:  NewQuizAttemptRepository(store),
+			Trash:         NewTrashRepository(store),
+			AuditEntries:  NewAuditEntryRepository(store),
+			UnitOfWork:    NewUnitOfWork(store),
+		}
+	})
+}
@@ -0,0 +1,123 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"gorm.io/gorm"
+	"quizlet/internal/models/quiz_attempt"
+	"quizlet/internal/repository"
+)
+
+type quizAttemptRepository struct {
+	store *Store
+	inTx  bool
+}
+
+func NewQuizAttemptRepository(store *Store) repository.QuizAttemptRepository {
+	return &quizAttemptRepository{store: store}
+}
+
+func (r *quizAttemptRepository) ListByQuizSuite(ctx context.Context, quizSuiteID, userID uint) ([]quiz_attempt.QuizAttempt, error) {
+	defer r.store.lock(r.inTx)()
+
+	attempts := []quiz_attempt.QuizAttempt{}
+	for _, id := range sortedKeys(r.store.tables.attempts) {
+		attempt := r.store.tables.attempts[id]
+		if !attempt.DeletedAt.Valid && attempt.QuizSuiteID == quizSuiteID && attempt.UserID == userID {
+			attempt.Snapshot = nil
+			attempts = append(attempts, copyAttempt(attempt))
+		}
+	}
+	return attempts, nil
+}
+
+func (r *quizAttemptRepository) Create(ctx context.Context, attempt *quiz_attempt.QuizAttempt) (*quiz_attempt.QuizAttempt, error) {
+	defer r.store.lock(r.inTx)()
+
+	attempt.ID = r.store.nextID("quiz_attempts")
+	setTimestamps(&attempt.CreatedAt, &attempt.UpdatedAt)
+	r.store.tables.attempts[attempt.ID] = copyAttempt(*attempt)
+	return attempt, nil
+}
+
+func (r *quizAttemptRepository) Get(ctx context.Context, id uint) (*quiz_attempt.QuizAttempt, error) {
+	defer r.store.lock(r.inTx)()
+
+	attempt, ok := r.store.tables.attempts[id]
+	if !ok || attempt.DeletedAt.Valid {
+		return nil, gorm.ErrRecordNotFound
+	}
+	attempt = copyAttempt(attempt)
+	for _, answerID := range sortedKeys(r.store.tables.answers) {
+		if answer := r.store.tables.answers[answerID]; answer.QuizAttemptID == id {
+			attempt.Answers = append(attempt.Answers, copyAnswer(answer))
+		}
+	}
+	return &attempt, nil
+}
+
+// Update saves every field, inserting the attempt when it does not exist
+func (r *quizAttemptRepository) Update(ctx context.Context, attempt *quiz_attempt.QuizAttempt) (*quiz_attempt.QuizAttempt, error) {
+	defer r.store.lock(r.inTx)()
+
+	if attempt.ID == 0 {
+		attempt.ID = r.store.nextID("quiz_attempts")
+	}
+	attempt.UpdatedAt = time.Now()
+	r.store.tables.attempts[attempt.ID] = copyAttempt(*attempt)
+	return attempt, nil
+}
+
+func (r *quizAttemptRepository) Delete(ctx context.Context, id uint) error {
+	defer r.store.lock(r.inTx)()
+
+	attempt, ok := r.store.tables.attempts[id]
+	if !ok || attempt.DeletedAt.Valid {
+		return nil
+	}
+	attempt.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+	r.store.tables.attempts[id] = attempt
+	return nil
+}
+
+func (r *quizAttemptRepository) CreateAnswer(ctx context.Context, answer *quiz_attempt.QuizAttemptAnswer) error {
+	defer r.store.lock(r.inTx)()
+
+	for _, existing := range r.store.tables.answers {
+		if existing.QuizAttemptID == answer.QuizAttemptID && existing.QuizID == answer.QuizID {
+			return gorm.ErrDuplicatedKey
+		}
+	}
+	answer.ID = r.store.nextID("quiz_attempt_answers")
+	setTimestamps(&answer.CreatedAt, &answer.UpdatedAt)
+	r.store.tables.answers[answer.ID] = copyAnswer(*answer)
+	return nil
+}
+
+// copyAttempt copies the completion time and snapshot, so the stored
+// attempt shares no memory with the caller, and drops the associations,
+// which are stored in their own tables or not preloaded
+func copyAttempt(attempt quiz_attempt.QuizAttempt) quiz_attempt.QuizAttempt {
+	attempt.User = nil
+	attempt.Answers = nil
+	attempt.CompletedAt = copyTime(attempt.CompletedAt)
+	if attempt.Snapshot != nil {
+		attempt.Snapshot = attempt.Snapshot.Clone()
+	}
+	return attempt
+}
+
+func copyAnswer(answer quiz_attempt.QuizAttemptAnswer) quiz_attempt.QuizAttemptAnswer {
+	answer.SelectionIDs = slices.Clone(answer.SelectionIDs)
+	return answer
+}
+
+func copyTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	copied := *t
+	return &copied
+}
@@ -0,0 +1,162 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"quizlet/internal/models/quiz"
+	"quizlet/internal/repository"
+)
+
+type quizRepository struct {
+	store *Store
+	inTx  bool
+}
+
+func NewQuizRepository(store *Store) repository.QuizRepository {
+	return &quizRepository{store: store}
+}
+
+func (r *quizRepository) Create(ctx context.Context, q *quiz.Quiz) error {
+	defer r.store.lock(r.inTx)()
+
+	q.ID = r.store.nextID("quizzes")
+	setTimestamps(&q.CreatedAt, &q.UpdatedAt)
+	if q.Version == 0 {
+		q.Version = 1
+	}
+	for i := range q.Selections {
+		r.createSelection(q.ID, &q.Selections[i])
+	}
+	r.store.tables.quizzes[q.ID] = stripQuiz(*q)
+	return nil
+}
+
+func (r *quizRepository) FindByID(ctx context.Context, id uint) (*quiz.Quiz, error) {
+	defer r.store.lock(r.inTx)()
+
+	q, ok := r.live(id)
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	q.Selections = r.selections(q.ID)
+	if u, ok := r.store.tables.users[q.CreatedByID]; ok && !u.DeletedAt.Valid {
+		q.CreatedBy = &u
+	}
+	return &q, nil
+}
+
+func (r *quizRepository) FindByUserID(ctx context.Context, userID uint) ([]*quiz.Quiz, error) {
+	defer r.store.lock(r.inTx)()
+
+	quizzes := []*quiz.Quiz{}
+	for _, id := range sortedKeys(r.store.tables.quizzes) {
+		q := r.store.tables.quizzes[id]
+		if q.DeletedAt.Valid || q.CreatedByID != userID {
+			continue
+		}
+		q.Selections = r.selections(q.ID)
+		quizzes = append(quizzes, &q)
+	}
+	return quizzes, nil
+}
+
+func (r *quizRepository) Update(ctx context.Context, q *quiz.Quiz) error {
+	defer r.store.lock(r.inTx)()
+
+	stored, ok := r.live(q.ID)
+	if !ok || stored.Version != q.Version {
+		return repository.ErrVersionConflict
+	}
+	q.Version++
+	q.UpdatedAt = time.Now()
+	updated := stripQuiz(*q)
+	updated.CreatedAt = stored.CreatedAt
+	r.store.tables.quizzes[q.ID] = updated
+	return nil
+}
+
+func (r *quizRepository) Delete(ctx context.Context, id uint, version uint) error {
+	defer r.store.lock(r.inTx)()
+
+	q, ok := r.live(id)
+	if version != 0 && (!ok || q.Version != version) {
+		return repository.ErrVersionConflict
+	}
+	if ok {
+		q.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+		r.store.tables.quizzes[id] = q
+	}
+	return nil
+}
+
+func (r *quizRepository) AddSelection(ctx context.Context, quizID uint, selection *quiz.QuizSelection) error {
+	defer r.store.lock(r.inTx)()
+
+	r.createSelection(quizID, selection)
+	return nil
+}
+
+func (r *quizRepository) UpdateSelection(ctx context.Context, quizID uint, selection *quiz.QuizSelection) error {
+	defer r.store.lock(r.inTx)()
+
+	stored, ok := r.store.tables.selections[selection.ID]
+	if !ok || stored.QuizID != quizID {
+		return gorm.ErrRecordNotFound
+	}
+	stored.SelectionText = selection.SelectionText
+	stored.SelectionDisplayName = selection.SelectionDisplayName
+	stored.IsCorrect = selection.IsCorrect
+	stored.Version++
+	stored.UpdatedAt = time.Now()
+	r.store.tables.selections[selection.ID] = stored
+	return nil
+}
+
+func (r *quizRepository) RemoveSelection(ctx context.Context, quizID uint, selectionID uint) error {
+	defer r.store.lock(r.inTx)()
+
+	selection, ok := r.store.tables.selections[selectionID]
+	if !ok || selection.QuizID != quizID {
+		return gorm.ErrRecordNotFound
+	}
+	delete(r.store.tables.selections, selectionID)
+	return nil
+}
+
+// live returns the quiz unless it is missing or soft deleted
+func (r *quizRepository) live(id uint) (quiz.Quiz, bool) {
+	q, ok := r.store.tables.quizzes[id]
+	return q, ok && !q.DeletedAt.Valid
+}
+
+func (r *quizRepository) createSelection(quizID uint, selection *quiz.QuizSelection) {
+	selection.ID = r.store.nextID("quiz_selections")
+	selection.QuizID = quizID
+	setTimestamps(&selection.CreatedAt, &selection.UpdatedAt)
+	if selection.Version == 0 {
+		selection.Version = 1
+	}
+	stored := *selection
+	stored.Quiz = nil
+	r.store.tables.selections[selection.ID] = stored
+}
+
+// selections returns the selections of a quiz in ID order
+func (r *quizRepository) selections(quizID uint) []quiz.QuizSelection {
+	var selections []quiz.QuizSelection
+	for _, id := range sortedKeys(r.store.tables.selections) {
+		if selection := r.store.tables.selections[id]; selection.QuizID == quizID {
+			selections = append(selections, selection)
+		}
+	}
+	return selections
+}
+
+// stripQuiz drops the associations, which are stored in their own tables
+func stripQuiz(q quiz.Quiz) quiz.Quiz {
+	q.CreatedBy = nil
+	q.Selections = nil
+	return q
+}
@@ -0,0 +1,93 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"gorm.io/gorm"
+	"quizlet/internal/models/quiz"
+	"quizlet/internal/repository"
+)
+
+type quizRevisionRepository struct {
+	store *Store
+	inTx  bool
+}
+
+func NewQuizRevisionRepository(store *Store) repository.QuizRevisionRepository {
+	return &quizRevisionRepository{store: store}
+}
+
+func (r *quizRevisionRepository) Create(ctx context.Context, revision *quiz.QuizRevision) error {
+	defer r.store.lock(r.inTx)()
+
+	for _, other := range r.store.tables.quizRevisions {
+		if other.QuizID == revision.QuizID && other.Number == revision.Number {
+			return gorm.ErrDuplicatedKey
+		}
+	}
+	revision.ID = r.store.nextID("quiz_revisions")
+	if revision.CreatedAt.IsZero() {
+		revision.CreatedAt = time.Now()
+	}
+	r.store.tables.quizRevisions[revision.ID] = copyQuizRevision(*revision)
+	return nil
+}
+
+func (r *quizRevisionRepository) List(ctx context.Context, quizID uint) ([]*quiz.QuizRevision, error) {
+	defer r.store.lock(r.inTx)()
+
+	return r.revisions(quizID), nil
+}
+
+func (r *quizRevisionRepository) Find(ctx context.Context, quizID uint, number uint) (*quiz.QuizRevision, error) {
+	defer r.store.lock(r.inTx)()
+
+	for _, revision := range r.revisions(quizID) {
+		if revision.Number == number {
+			return revision, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *quizRevisionRepository) Latest(ctx context.Context, quizID uint) (*quiz.QuizRevision, error) {
+	defer r.store.lock(r.inTx)()
+
+	revisions := r.revisions(quizID)
+	if len(revisions) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return revisions[0], nil
+}
+
+// revisions returns copies of the revisions of a quiz, newest first
+func (r *quizRevisionRepository) revisions(quizID uint) []*quiz.QuizRevision {
+	revisions := []*quiz.QuizRevision{}
+	for _, revision := range r.store.tables.quizRevisions {
+		if revision.QuizID == quizID {
+			revision = copyQuizRevision(revision)
+			revisions = append(revisions, &revision)
+		}
+	}
+	slices.SortFunc(revisions, func(a, b *quiz.QuizRevision) int {
+		return int(b.Number) - int(a.Number)
+	})
+	return revisions
+}
+
+// copyQuizRevision copies the pointers and selections, so the stored
+// revision shares no memory with the caller
+func copyQuizRevision(revision quiz.QuizRevision) quiz.QuizRevision {
+	if revision.AuthorID != nil {
+		authorID := *revision.AuthorID
+		revision.AuthorID = &authorID
+	}
+	if revision.RevertedFrom != nil {
+		revertedFrom := *revision.RevertedFrom
+		revision.RevertedFrom = &revertedFrom
+	}
+	revision.Content.Selections = slices.Clone(revision.Content.Selections)
+	return revision
+}
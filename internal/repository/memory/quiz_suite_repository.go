@@ -0,0 +1,153 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"quizlet/internal/models/quiz"
+	"quizlet/internal/models/quiz_suite"
+	"quizlet/internal/repository"
+)
+
+type quizSuiteRepository struct {
+	store *Store
+	inTx  bool
+}
+
+func NewQuizSuiteRepository(store *Store) repository.QuizSuiteRepository {
+	return &quizSuiteRepository{store: store}
+}
+
+// Create saves the quiz suite and links it to the quizzes it lists by ID
+func (r *quizSuiteRepository) Create(ctx context.Context, quizSuite *quiz_suite.QuizSuite) error {
+	defer r.store.lock(r.inTx)()
+
+	quizSuite.ID = r.store.nextID("quiz_suites")
+	setTimestamps(&quizSuite.CreatedAt, &quizSuite.UpdatedAt)
+	if quizSuite.Version == 0 {
+		quizSuite.Version = 1
+	}
+	for _, q := range quizSuite.Quizzes {
+		r.addQuiz(quizSuite.ID, q.ID)
+	}
+	r.store.tables.quizSuites[quizSuite.ID] = stripQuizSuite(*quizSuite)
+	return nil
+}
+
+func (r *quizSuiteRepository) FindByID(ctx context.Context, id uint) (*quiz_suite.QuizSuite, error) {
+	defer r.store.lock(r.inTx)()
+
+	quizSuite, ok := r.live(id)
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	quizSuite.Quizzes = r.quizzes(quizSuite.ID)
+	if u, ok := r.store.tables.users[quizSuite.CreatedByID]; ok && !u.DeletedAt.Valid {
+		quizSuite.CreatedBy = &u
+	}
+	return &quizSuite, nil
+}
+
+func (r *quizSuiteRepository) FindByUserID(ctx context.Context, userID uint) ([]*quiz_suite.QuizSuite, error) {
+	defer r.store.lock(r.inTx)()
+
+	quizSuites := []*quiz_suite.QuizSuite{}
+	for _, id := range sortedKeys(r.store.tables.quizSuites) {
+		quizSuite := r.store.tables.quizSuites[id]
+		if quizSuite.DeletedAt.Valid || quizSuite.CreatedByID != userID {
+			continue
+		}
+		quizSuite.Quizzes = r.quizzes(quizSuite.ID)
+		quizSuites = append(quizSuites, &quizSuite)
+	}
+	return quizSuites, nil
+}
+
+func (r *quizSuiteRepository) Update(ctx context.Context, quizSuite *quiz_suite.QuizSuite) error {
+	defer r.store.lock(r.inTx)()
+
+	stored, ok := r.live(quizSuite.ID)
+	if !ok || stored.Version != quizSuite.Version {
+		return repository.ErrVersionConflict
+	}
+	quizSuite.Version++
+	quizSuite.UpdatedAt = time.Now()
+	updated := stripQuizSuite(*quizSuite)
+	updated.CreatedAt = stored.CreatedAt
+	r.store.tables.quizSuites[quizSuite.ID] = updated
+	return nil
+}
+
+func (r *quizSuiteRepository) Delete(ctx context.Context, id uint, version uint) error {
+	defer r.store.lock(r.inTx)()
+
+	quizSuite, ok := r.live(id)
+	if version != 0 && (!ok || quizSuite.Version != version) {
+		return repository.ErrVersionConflict
+	}
+	if ok {
+		quizSuite.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+		r.store.tables.quizSuites[id] = quizSuite
+	}
+	return nil
+}
+
+func (r *quizSuiteRepository) AddQuiz(ctx context.Context, quizSuiteID uint, quizID uint) error {
+	defer r.store.lock(r.inTx)()
+
+	r.addQuiz(quizSuiteID, quizID)
+	return nil
+}
+
+// addQuiz links the quiz after the ones already in the suite
+func (r *quizSuiteRepository) addQuiz(quizSuiteID uint, quizID uint) {
+	key := suiteQuiz{quizSuiteID: quizSuiteID, quizID: quizID}
+	if _, ok := r.store.tables.suiteQuizzes[key]; !ok {
+		r.store.tables.suiteQuizzes[key] = r.store.nextID("quiz_suite_quizzes")
+	}
+}
+
+func (r *quizSuiteRepository) RemoveQuiz(ctx context.Context, quizSuiteID uint, quizID uint) error {
+	defer r.store.lock(r.inTx)()
+
+	key := suiteQuiz{quizSuiteID: quizSuiteID, quizID: quizID}
+	if _, ok := r.store.tables.suiteQuizzes[key]; !ok {
+		return gorm.ErrRecordNotFound
+	}
+	delete(r.store.tables.suiteQuizzes, key)
+	return nil
+}
+
+// live returns the quiz suite unless it is missing or soft deleted
+func (r *quizSuiteRepository) live(id uint) (quiz_suite.QuizSuite, bool) {
+	quizSuite, ok := r.store.tables.quizSuites[id]
+	return quizSuite, ok && !quizSuite.DeletedAt.Valid
+}
+
+// quizzes returns the live quizzes of a suite in the order they were added,
+// without their associations as they are not preloaded
+func (r *quizSuiteRepository) quizzes(quizSuiteID uint) []*quiz.Quiz {
+	byJoinID := make(map[uint]uint)
+	for key, joinID := range r.store.tables.suiteQuizzes {
+		if key.quizSuiteID == quizSuiteID {
+			byJoinID[joinID] = key.quizID
+		}
+	}
+
+	var quizzes []*quiz.Quiz
+	for _, joinID := range sortedKeys(byJoinID) {
+		q, ok := r.store.tables.quizzes[byJoinID[joinID]]
+		if ok && !q.DeletedAt.Valid {
+			quizzes = append(quizzes, &q)
+		}
+	}
+	return quizzes
+}
+
+// stripQuizSuite drops the associations, which are stored in their own tables
+func stripQuizSuite(quizSuite quiz_suite.QuizSuite) quiz_suite.QuizSuite {
+	quizSuite.CreatedBy = nil
+	quizSuite.Quizzes = nil
+	return quizSuite
+}
@@ -0,0 +1,75 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"quizlet/internal/models/user"
+	"quizlet/internal/repository"
+)
+
+type recoveryCodeRepository struct {
+	store *Store
+	inTx  bool
+}
+
+func NewRecoveryCodeRepository(store *Store) repository.RecoveryCodeRepository {
+	return &recoveryCodeRepository{store: store}
+}
+
+func (r *recoveryCodeRepository) ReplaceForUser(ctx context.Context, userID uint, codes []*user.RecoveryCode) error {
+	defer r.store.lock(r.inTx)()
+
+	r.deleteByUserID(userID)
+	for _, code := range codes {
+		code.ID = r.store.nextID("recovery_codes")
+		if code.CreatedAt.IsZero() {
+			code.CreatedAt = time.Now()
+		}
+		r.store.tables.recoveryCodes[code.ID] = *code
+	}
+	return nil
+}
+
+func (r *recoveryCodeRepository) FindUnused(ctx context.Context, userID uint, codeHash string) (*user.RecoveryCode, error) {
+	defer r.store.lock(r.inTx)()
+
+	for _, id := range sortedKeys(r.store.tables.recoveryCodes) {
+		code := r.store.tables.recoveryCodes[id]
+		if code.UserID == userID && code.CodeHash == codeHash && code.UsedAt == nil {
+			return &code, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *recoveryCodeRepository) MarkUsed(ctx context.Context, id uint) error {
+	defer r.store.lock(r.inTx)()
+
+	code, ok := r.store.tables.recoveryCodes[id]
+	if !ok || code.UsedAt != nil {
+		return gorm.ErrRecordNotFound
+	}
+	now := time.Now()
+	code.UsedAt = &now
+	r.store.tables.recoveryCodes[id] = code
+	return nil
+}
+
+func (r *recoveryCodeRepository) DeleteByUserID(ctx context.Context, userID uint) error {
+	defer r.store.lock(r.inTx)()
+
+	r.deleteByUserID(userID)
+	return nil
+}
+
+// deleteByUserID removes the codes of a user; recovery codes are not soft
+// deleted
+func (r *recoveryCodeRepository) deleteByUserID(userID uint) {
+	for id, code := range r.store.tables.recoveryCodes {
+		if code.UserID == userID {
+			delete(r.store.tables.recoveryCodes, id)
+		}
+	}
+}
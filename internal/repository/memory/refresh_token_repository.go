@@ -0,0 +1,89 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"quizlet/internal/models/user"
+	"quizlet/internal/repository"
+)
+
+type refreshTokenRepository struct {
+	store *Store
+}
+
+func NewRefreshTokenRepository(store *Store) repository.RefreshTokenRepository {
+	return &refreshTokenRepository{store: store}
+}
+
+func (r *refreshTokenRepository) Create(ctx context.Context, token *user.RefreshToken) error {
+	defer r.store.lock(false)()
+
+	for _, other := range r.store.tables.refreshTokens {
+		if other.Token == token.Token {
+			return gorm.ErrDuplicatedKey
+		}
+	}
+	token.ID = r.store.nextID("refresh_tokens")
+	setTimestamps(&token.CreatedAt, &token.UpdatedAt)
+	r.store.tables.refreshTokens[token.ID] = *token
+	return nil
+}
+
+func (r *refreshTokenRepository) FindByToken(ctx context.Context, token string) (*user.RefreshToken, error) {
+	defer r.store.lock(false)()
+
+	for _, id := range sortedKeys(r.store.tables.refreshTokens) {
+		refreshToken := r.store.tables.refreshTokens[id]
+		if refreshToken.Token == token && r.active(refreshToken) {
+			return &refreshToken, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *refreshTokenRepository) FindByUserID(ctx context.Context, userID uint) ([]*user.RefreshToken, error) {
+	defer r.store.lock(false)()
+
+	tokens := []*user.RefreshToken{}
+	for _, id := range sortedKeys(r.store.tables.refreshTokens) {
+		refreshToken := r.store.tables.refreshTokens[id]
+		if refreshToken.UserID == userID && r.active(refreshToken) {
+			tokens = append(tokens, &refreshToken)
+		}
+	}
+	return tokens, nil
+}
+
+func (r *refreshTokenRepository) Revoke(ctx context.Context, token string) error {
+	defer r.store.lock(false)()
+
+	for id, refreshToken := range r.store.tables.refreshTokens {
+		if refreshToken.Token == token && !refreshToken.DeletedAt.Valid {
+			refreshToken.Revoked = true
+			refreshToken.UpdatedAt = time.Now()
+			r.store.tables.refreshTokens[id] = refreshToken
+		}
+	}
+	return nil
+}
+
+func (r *refreshTokenRepository) DeleteExpired(ctx context.Context) error {
+	defer r.store.lock(false)()
+
+	now := time.Now()
+	for id, refreshToken := range r.store.tables.refreshTokens {
+		if !refreshToken.DeletedAt.Valid && (refreshToken.ExpiresAt.Before(now) || refreshToken.Revoked) {
+			refreshToken.DeletedAt = gorm.DeletedAt{Time: now, Valid: true}
+			r.store.tables.refreshTokens[id] = refreshToken
+		}
+	}
+	return nil
+}
+
+// active reports whether the token is neither revoked nor deleted; expiry
+// is checked by the caller
+func (r *refreshTokenRepository) active(token user.RefreshToken) bool {
+	return !token.Revoked && !token.DeletedAt.Valid
+}
@@ -0,0 +1,177 @@
+// Package memory implements the repositories in memory, for tests and for
+// running services without a database. The repositories behave like their
+// GORM counterparts: IDs are assigned in order, users, quizzes, quiz suites,
+// refresh tokens and quiz attempts are soft deleted, lookups of missing
+// records return gorm.ErrRecordNotFound and conditional writes return
+// repository.ErrVersionConflict.
+package memory
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"quizlet/internal/models/audit_entry"
+	"quizlet/internal/models/quiz"
+	"quizlet/internal/models/quiz_attempt"
+	"quizlet/internal/models/quiz_suite"
+	"quizlet/internal/models/user"
+	"quizlet/internal/repository"
+)
+
+// Store holds the records shared by the repositories created from it, like
+// a database shared by the GORM repositories
+type Store struct {
+	mu     sync.Mutex
+	tables tables
+}
+
+// suiteQuiz is a row of the quiz_suite_quizzes join table
+type suiteQuiz struct {
+	quizSuiteID uint
+	quizID      uint
+}
+
+// tables are the records of a store. Records are kept by value without
+// their associations, and copied on the way in and out, so callers never
+// share memory with the store.
+type tables struct {
+	lastID        map[string]uint
+	users         map[uint]user.User
+	refreshTokens map[uint]user.RefreshToken
+	recoveryCodes map[uint]user.RecoveryCode
+	quizzes       map[uint]quiz.Quiz
+	selections    map[uint]quiz.QuizSelection
+	quizRevisions map[uint]quiz.QuizRevision
+	quizSuites    map[uint]quiz_suite.QuizSuite
+	suiteQuizzes  map[suiteQuiz]uint // join row ID, which orders the quizzes
+	attempts      map[uint]quiz_attempt.QuizAttempt
+	answers       map[uint]quiz_attempt.QuizAttemptAnswer
+	auditEntries  map[uint]audit_entry.AuditEntry
+}
+
+// New returns an empty store
+func New() *Store {
+	return &Store{tables: tables{
+		lastID:        map[string]uint{},
+		users:         map[uint]user.User{},
+		refreshTokens: map[uint]user.RefreshToken{},
+		recoveryCodes: map[uint]user.RecoveryCode{},
+		quizzes:       map[uint]quiz.Quiz{},
+		selections:    map[uint]quiz.QuizSelection{},
+		quizRevisions: map[uint]quiz.QuizRevision{},
+		quizSuites:    map[uint]quiz_suite.QuizSuite{},
+		suiteQuizzes:  map[suiteQuiz]uint{},
+		attempts:      map[uint]quiz_attempt.QuizAttempt{},
+		answers:       map[uint]quiz_attempt.QuizAttemptAnswer{},
+		auditEntries:  map[uint]audit_entry.AuditEntry{},
+	}}
+}
+
+// lock locks the store for one repository call and returns the function
+// that unlocks it. Repositories of a unit of work run with the store already
+// locked, so inTx skips the lock.
+func (s *Store) lock(inTx bool) func() {
+	if inTx {
+		return func() {}
+	}
+	s.mu.Lock()
+	return s.mu.Unlock
+}
+
+// nextID returns the next ID of table, like an auto-increment column
+func (s *Store) nextID(table string) uint {
+	s.tables.lastID[table]++
+	return s.tables.lastID[table]
+}
+
+// snapshot copies the tables, so a failed unit of work can restore them
+func (t tables) snapshot() tables {
+	return tables{
+		lastID:        copyMap(t.lastID),
+		users:         copyMap(t.users),
+		refreshTokens: copyMap(t.refreshTokens),
+		recoveryCodes: copyMap(t.recoveryCodes),
+		quizzes:       copyMap(t.quizzes),
+		selections:    copyMap(t.selections),
+		quizRevisions: copyMap(t.quizRevisions),
+		quizSuites:    copyMap(t.quizSuites),
+		suiteQuizzes:  copyMap(t.suiteQuizzes),
+		attempts:      copyMap(t.attempts),
+		answers:       copyMap(t.answers),
+		auditEntries:  copyMap(t.auditEntries),
+	}
+}
+
+func copyMap[K comparable, V any](m map[K]V) map[K]V {
+	copied := make(map[K]V, len(m))
+	for k, v := range m {
+		copied[k] = v
+	}
+	return copied
+}
+
+// sortedKeys returns the IDs of a table in ascending order, the order the
+// databases return rows in when no order is given
+func sortedKeys[V any](m map[uint]V) []uint {
+	keys := make([]uint, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// setTimestamps fills the timestamps GORM sets on create when they are zero
+func setTimestamps(createdAt, updatedAt *time.Time) {
+	now := time.Now()
+	if createdAt.IsZero() {
+		*createdAt = now
+	}
+	if updatedAt.IsZero() {
+		*updatedAt = now
+	}
+}
+
+type unitOfWork struct {
+	store *Store
+}
+
+// NewUnitOfWork returns a unit of work over the store. Units of work run one
+// at a time and hold the store for their whole duration, so the repositories
+// they hand out see no concurrent changes; a unit of work that fails
+// restores the records it found.
+func NewUnitOfWork(store *Store) repository.UnitOfWork {
+	return &unitOfWork{store: store}
+}
+
+func (u *unitOfWork) Do(ctx context.Context, fn func(ctx context.Context, repos repository.Repositories) error) error {
+	u.store.mu.Lock()
+	defer u.store.mu.Unlock()
+
+	before := u.store.tables.snapshot()
+	committed := false
+	defer func() {
+		// Also rolls back when fn panics
+		if !committed {
+			u.store.tables = before
+		}
+	}()
+
+	err := fn(ctx, repository.Repositories{
+		Users:         &userRepository{store: u.store, inTx: true},
+		RecoveryCodes: &recoveryCodeRepository{store: u.store, inTx: true},
+		Quizzes:       &quizRepository{store: u.store, inTx: true},
+		QuizRevisions: &quizRevisionRepository{store: u.store, inTx: true},
+		QuizSuites:    &quizSuiteRepository{store: u.store, inTx: true},
+		QuizAttempts:  &quizAttemptRepository{store: u.store, inTx: true},
+		Trash:         &trashRepository{store: u.store, inTx: true},
+		AuditEntries:  &auditEntryRepository{store: u.store, inTx: true},
+	})
+	if err != nil {
+		return err
+	}
+	committed = true
+	return nil
+}
@@ -0,0 +1,366 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"gorm.io/gorm"
+	"quizlet/internal/models/quiz"
+	"quizlet/internal/models/quiz_attempt"
+	"quizlet/internal/models/quiz_suite"
+	"quizlet/internal/models/user"
+	"quizlet/internal/repository"
+)
+
+type trashRepository struct {
+	store *Store
+	inTx  bool
+}
+
+func NewTrashRepository(store *Store) repository.TrashRepository {
+	return &trashRepository{store: store}
+}
+
+// inTrash returns the soft-deleted records of table that match, most
+// recently deleted first
+func inTrash[T any](table map[uint]T, deletedAt func(T) gorm.DeletedAt, match func(T) bool) []*T {
+	ids := sortedKeys(table)
+	slices.Reverse(ids)
+	found := []*T{}
+	for _, id := range ids {
+		if record := table[id]; deletedAt(record).Valid && match(record) {
+			found = append(found, &record)
+		}
+	}
+	slices.SortStableFunc(found, func(a, b *T) int {
+		return deletedAt(*b).Time.Compare(deletedAt(*a).Time)
+	})
+	return found
+}
+
+func quizDeletedAt(q quiz.Quiz) gorm.DeletedAt {
+	return q.DeletedAt
+}
+
+func quizSuiteDeletedAt(qs quiz_suite.QuizSuite) gorm.DeletedAt {
+	return qs.DeletedAt
+}
+
+func attemptDeletedAt(a quiz_attempt.QuizAttempt) gorm.DeletedAt {
+	return a.DeletedAt
+}
+
+// deletedSince reports whether a record went to the trash at or after since
+func deletedSince(deletedAt gorm.DeletedAt, since time.Time) bool {
+	return deletedAt.Valid && !deletedAt.Time.Before(since)
+}
+
+// purgeable reports whether a record went to the trash before cutoff
+func purgeable(deletedAt gorm.DeletedAt, cutoff time.Time) bool {
+	return deletedAt.Valid && deletedAt.Time.Before(cutoff)
+}
+
+func (r *trashRepository) ListQuizzes(ctx context.Context, userID uint) ([]*quiz.Quiz, error) {
+	defer r.store.lock(r.inTx)()
+
+	return inTrash(r.store.tables.quizzes, quizDeletedAt, func(q quiz.Quiz) bool {
+		return q.CreatedByID == userID
+	}), nil
+}
+
+func (r *trashRepository) ListQuizSuites(ctx context.Context, userID uint) ([]*quiz_suite.QuizSuite, error) {
+	defer r.store.lock(r.inTx)()
+
+	return inTrash(r.store.tables.quizSuites, quizSuiteDeletedAt, func(qs quiz_suite.QuizSuite) bool {
+		return qs.CreatedByID == userID
+	}), nil
+}
+
+func (r *trashRepository) ListQuizAttempts(ctx context.Context, userID uint) ([]*quiz_attempt.QuizAttempt, error) {
+	defer r.store.lock(r.inTx)()
+
+	attempts := inTrash(r.store.tables.attempts, attemptDeletedAt, func(a quiz_attempt.QuizAttempt) bool {
+		return a.UserID == userID
+	})
+	for _, attempt := range attempts {
+		*attempt = copyAttempt(*attempt)
+	}
+	return attempts, nil
+}
+
+func (r *trashRepository) FindQuiz(ctx context.Context, id uint) (*quiz.Quiz, error) {
+	defer r.store.lock(r.inTx)()
+
+	q, ok := r.store.tables.quizzes[id]
+	if !ok || !q.DeletedAt.Valid {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &q, nil
+}
+
+func (r *trashRepository) FindQuizSuite(ctx context.Context, id uint) (*quiz_suite.QuizSuite, error) {
+	defer r.store.lock(r.inTx)()
+
+	quizSuite, ok := r.store.tables.quizSuites[id]
+	if !ok || !quizSuite.DeletedAt.Valid {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &quizSuite, nil
+}
+
+func (r *trashRepository) FindQuizAttempt(ctx context.Context, id uint) (*quiz_attempt.QuizAttempt, error) {
+	defer r.store.lock(r.inTx)()
+
+	attempt, ok := r.store.tables.attempts[id]
+	if !ok || !attempt.DeletedAt.Valid {
+		return nil, gorm.ErrRecordNotFound
+	}
+	attempt = copyAttempt(attempt)
+	return &attempt, nil
+}
+
+func (r *trashRepository) FindUser(ctx context.Context, id uint) (*user.User, error) {
+	defer r.store.lock(r.inTx)()
+
+	u, ok := r.store.tables.users[id]
+	if !ok || !u.DeletedAt.Valid {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &u, nil
+}
+
+func (r *trashRepository) TrashQuizSuiteAttempts(ctx context.Context, quizSuiteID uint) error {
+	defer r.store.lock(r.inTx)()
+
+	now := time.Now()
+	for id, attempt := range r.store.tables.attempts {
+		if !attempt.DeletedAt.Valid && attempt.QuizSuiteID == quizSuiteID {
+			attempt.DeletedAt = gorm.DeletedAt{Time: now, Valid: true}
+			r.store.tables.attempts[id] = attempt
+		}
+	}
+	return nil
+}
+
+func (r *trashRepository) TrashUserContent(ctx context.Context, userID uint) error {
+	defer r.store.lock(r.inTx)()
+
+	now := time.Now()
+	suites := r.suitesOf(userID)
+	for id, attempt := range r.store.tables.attempts {
+		if !attempt.DeletedAt.Valid && (attempt.UserID == userID || suites[attempt.QuizSuiteID]) {
+			attempt.DeletedAt = gorm.DeletedAt{Time: now, Valid: true}
+			r.store.tables.attempts[id] = attempt
+		}
+	}
+	for id, quizSuite := range r.store.tables.quizSuites {
+		if !quizSuite.DeletedAt.Valid && quizSuite.CreatedByID == userID {
+			quizSuite.DeletedAt = gorm.DeletedAt{Time: now, Valid: true}
+			r.store.tables.quizSuites[id] = quizSuite
+		}
+	}
+	for id, q := range r.store.tables.quizzes {
+		if !q.DeletedAt.Valid && q.CreatedByID == userID {
+			q.DeletedAt = gorm.DeletedAt{Time: now, Valid: true}
+			r.store.tables.quizzes[id] = q
+		}
+	}
+	return nil
+}
+
+func (r *trashRepository) RestoreQuiz(ctx context.Context, q *quiz.Quiz) error {
+	defer r.store.lock(r.inTx)()
+
+	stored, ok := r.store.tables.quizzes[q.ID]
+	if !ok || !stored.DeletedAt.Valid {
+		return gorm.ErrRecordNotFound
+	}
+	stored.DeletedAt = gorm.DeletedAt{}
+	stored.UpdatedAt = time.Now()
+	r.store.tables.quizzes[q.ID] = stored
+	q.DeletedAt = gorm.DeletedAt{}
+	return nil
+}
+
+func (r *trashRepository) RestoreQuizSuite(ctx context.Context, quizSuite *quiz_suite.QuizSuite) error {
+	defer r.store.lock(r.inTx)()
+
+	stored, ok := r.store.tables.quizSuites[quizSuite.ID]
+	if !ok || !stored.DeletedAt.Valid {
+		return gorm.ErrRecordNotFound
+	}
+	since := stored.DeletedAt.Time
+	stored.DeletedAt = gorm.DeletedAt{}
+	stored.UpdatedAt = time.Now()
+	r.store.tables.quizSuites[quizSuite.ID] = stored
+	quizSuite.DeletedAt = gorm.DeletedAt{}
+
+	// Attempts deleted before the suite were deleted on their own
+	r.restoreAttempts(since, func(attempt quiz_attempt.QuizAttempt) bool {
+		return attempt.QuizSuiteID == quizSuite.ID
+	})
+	return nil
+}
+
+func (r *trashRepository) RestoreQuizAttempt(ctx context.Context, attempt *quiz_attempt.QuizAttempt) error {
+	defer r.store.lock(r.inTx)()
+
+	stored, ok := r.store.tables.attempts[attempt.ID]
+	if !ok || !stored.DeletedAt.Valid {
+		return gorm.ErrRecordNotFound
+	}
+	stored.DeletedAt = gorm.DeletedAt{}
+	stored.UpdatedAt = time.Now()
+	r.store.tables.attempts[attempt.ID] = stored
+	attempt.DeletedAt = gorm.DeletedAt{}
+	return nil
+}
+
+func (r *trashRepository) RestoreUser(ctx context.Context, u *user.User) error {
+	defer r.store.lock(r.inTx)()
+
+	stored, ok := r.store.tables.users[u.ID]
+	if !ok || !stored.DeletedAt.Valid {
+		return gorm.ErrRecordNotFound
+	}
+	since := stored.DeletedAt.Time
+	now := time.Now()
+	stored.DeletedAt = gorm.DeletedAt{}
+	stored.UpdatedAt = now
+	r.store.tables.users[u.ID] = stored
+	u.DeletedAt = gorm.DeletedAt{}
+
+	for id, q := range r.store.tables.quizzes {
+		if q.CreatedByID == u.ID && deletedSince(q.DeletedAt, since) {
+			q.DeletedAt = gorm.DeletedAt{}
+			q.UpdatedAt = now
+			r.store.tables.quizzes[id] = q
+		}
+	}
+	for id, quizSuite := range r.store.tables.quizSuites {
+		if quizSuite.CreatedByID == u.ID && deletedSince(quizSuite.DeletedAt, since) {
+			quizSuite.DeletedAt = gorm.DeletedAt{}
+			quizSuite.UpdatedAt = now
+			r.store.tables.quizSuites[id] = quizSuite
+		}
+	}
+	r.restoreAttempts(since, func(attempt quiz_attempt.QuizAttempt) bool {
+		quizSuite, ok := r.store.tables.quizSuites[attempt.QuizSuiteID]
+		return attempt.UserID == u.ID || ok && quizSuite.CreatedByID == u.ID && !quizSuite.DeletedAt.Valid
+	})
+	return nil
+}
+
+// restoreAttempts takes the matching attempts that went to the trash at or
+// after since out of it
+func (r *trashRepository) restoreAttempts(since time.Time, match func(quiz_attempt.QuizAttempt) bool) {
+	now := time.Now()
+	for id, attempt := range r.store.tables.attempts {
+		if deletedSince(attempt.DeletedAt, since) && match(attempt) {
+			attempt.DeletedAt = gorm.DeletedAt{}
+			attempt.UpdatedAt = now
+			r.store.tables.attempts[id] = attempt
+		}
+	}
+}
+
+// suitesOf returns the IDs of the quiz suites of a user, deleted or not
+func (r *trashRepository) suitesOf(userID uint) map[uint]bool {
+	suites := map[uint]bool{}
+	for id, quizSuite := range r.store.tables.quizSuites {
+		if quizSuite.CreatedByID == userID {
+			suites[id] = true
+		}
+	}
+	return suites
+}
+
+// Purge also deletes the rows that reference the purged ones, like the ON
+// DELETE CASCADE foreign keys of the database
+func (r *trashRepository) Purge(ctx context.Context, cutoff time.Time) (int64, error) {
+	defer r.store.lock(r.inTx)()
+
+	t := &r.store.tables
+	var purged int64
+	for id, attempt := range t.attempts {
+		if purgeable(attempt.DeletedAt, cutoff) {
+			delete(t.attempts, id)
+			purged++
+		}
+	}
+	for id, quizSuite := range t.quizSuites {
+		if !purgeable(quizSuite.DeletedAt, cutoff) {
+			continue
+		}
+		delete(t.quizSuites, id)
+		purged++
+		for key := range t.suiteQuizzes {
+			if key.quizSuiteID == id {
+				delete(t.suiteQuizzes, key)
+			}
+		}
+		for attemptID, attempt := range t.attempts {
+			if attempt.QuizSuiteID == id {
+				delete(t.attempts, attemptID)
+			}
+		}
+	}
+	for id, q := range t.quizzes {
+		if !purgeable(q.DeletedAt, cutoff) {
+			continue
+		}
+		delete(t.quizzes, id)
+		purged++
+		for selectionID, selection := range t.selections {
+			if selection.QuizID == id {
+				delete(t.selections, selectionID)
+			}
+		}
+		for key := range t.suiteQuizzes {
+			if key.quizID == id {
+				delete(t.suiteQuizzes, key)
+			}
+		}
+		for revisionID, revision := range t.quizRevisions {
+			if revision.QuizID == id {
+				delete(t.quizRevisions, revisionID)
+			}
+		}
+	}
+
+	for id, u := range t.users {
+		if !purgeable(u.DeletedAt, cutoff) || r.owns(id) {
+			continue
+		}
+		delete(t.users, id)
+		purged++
+		for attemptID, attempt := range t.attempts {
+			if attempt.UserID == id {
+				delete(t.attempts, attemptID)
+			}
+		}
+		for tokenID, token := range t.refreshTokens {
+			if token.UserID == id {
+				delete(t.refreshTokens, tokenID)
+			}
+		}
+	}
+	for answerID, answer := range t.answers {
+		if _, ok := t.attempts[answer.QuizAttemptID]; !ok {
+			delete(t.answers, answerID)
+		}
+	}
+	return purged, nil
+}
+
+// owns reports whether any quiz or quiz suite, deleted or not, was created
+// by the user
+func (r *trashRepository) owns(userID uint) bool {
+	for _, q := range r.store.tables.quizzes {
+		if q.CreatedByID == userID {
+			return true
+		}
+	}
+	return len(r.suitesOf(userID)) > 0
+}
@@ -0,0 +1,176 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"quizlet/internal/models/user"
+	"quizlet/internal/repository"
+)
+
+type userRepository struct {
+	store *Store
+	inTx  bool
+}
+
+func NewUserRepository(store *Store) repository.UserRepository {
+	return &userRepository{store: store}
+}
+
+func (r *userRepository) Create(ctx context.Context, u *user.User) error {
+	defer r.store.lock(r.inTx)()
+
+	if r.taken(u) {
+		return gorm.ErrDuplicatedKey
+	}
+	u.ID = r.store.nextID("users")
+	setTimestamps(&u.CreatedAt, &u.UpdatedAt)
+	r.store.tables.users[u.ID] = *u
+	return nil
+}
+
+func (r *userRepository) FindByID(ctx context.Context, id uint) (*user.User, error) {
+	defer r.store.lock(r.inTx)()
+
+	return r.find(func(u *user.User) bool { return u.ID == id })
+}
+
+func (r *userRepository) FindByEmail(ctx context.Context, email string) (*user.User, error) {
+	defer r.store.lock(r.inTx)()
+
+	return r.find(func(u *user.User) bool { return u.Email == email })
+}
+
+func (r *userRepository) FindByUsername(ctx context.Context, username string) (*user.User, error) {
+	defer r.store.lock(r.inTx)()
+
+	return r.find(func(u *user.User) bool { return u.Username == username })
+}
+
+// Update saves every field, inserting the user when it does not exist
+func (r *userRepository) Update(ctx context.Context, u *user.User) error {
+	defer r.store.lock(r.inTx)()
+
+	if r.taken(u) {
+		return gorm.ErrDuplicatedKey
+	}
+	if u.ID == 0 {
+		u.ID = r.store.nextID("users")
+	}
+	if u.CreatedAt.IsZero() {
+		u.CreatedAt = time.Now()
+	}
+	u.UpdatedAt = time.Now()
+	r.store.tables.users[u.ID] = *u
+	return nil
+}
+
+func (r *userRepository) UpdateProfile(ctx context.Context, u *user.User) error {
+	defer r.store.lock(r.inTx)()
+
+	stored, ok := r.store.tables.users[u.ID]
+	if !ok || stored.DeletedAt.Valid {
+		return nil
+	}
+	if r.taken(u) {
+		return gorm.ErrDuplicatedKey
+	}
+	profile := *u
+	profile.TOTPSecret = stored.TOTPSecret
+	profile.TOTPEnabled = stored.TOTPEnabled
+	profile.TOTPLastStep = stored.TOTPLastStep
+	profile.MFAAttempts = stored.MFAAttempts
+	profile.IsAdmin = stored.IsAdmin
+	profile.CreatedAt = stored.CreatedAt
+	profile.UpdatedAt = time.Now()
+	r.store.tables.users[u.ID] = profile
+	return nil
+}
+
+func (r *userRepository) UpdatePassword(ctx context.Context, id uint, hashedPassword string) error {
+	defer r.store.lock(r.inTx)()
+
+	u, ok := r.store.tables.users[id]
+	if !ok || u.DeletedAt.Valid {
+		return nil
+	}
+	u.Password = hashedPassword
+	u.UpdatedAt = time.Now()
+	r.store.tables.users[id] = u
+	return nil
+}
+
+func (r *userRepository) AdvanceTOTPStep(ctx context.Context, id uint, step int64) error {
+	defer r.store.lock(r.inTx)()
+
+	u, ok := r.store.tables.users[id]
+	if !ok || u.DeletedAt.Valid || u.TOTPLastStep >= step {
+		return gorm.ErrRecordNotFound
+	}
+	u.TOTPLastStep = step
+	u.MFAAttempts = 0
+	u.UpdatedAt = time.Now()
+	r.store.tables.users[id] = u
+	return nil
+}
+
+func (r *userRepository) CountMFAAttempt(ctx context.Context, id uint, limit int) error {
+	defer r.store.lock(r.inTx)()
+
+	u, ok := r.store.tables.users[id]
+	if !ok || u.DeletedAt.Valid || u.MFAAttempts >= limit {
+		return gorm.ErrRecordNotFound
+	}
+	u.MFAAttempts++
+	u.UpdatedAt = time.Now()
+	r.store.tables.users[id] = u
+	return nil
+}
+
+func (r *userRepository) ResetMFAAttempts(ctx context.Context, id uint) error {
+	defer r.store.lock(r.inTx)()
+
+	u, ok := r.store.tables.users[id]
+	if !ok || u.DeletedAt.Valid {
+		return nil
+	}
+	u.MFAAttempts = 0
+	u.UpdatedAt = time.Now()
+	r.store.tables.users[id] = u
+	return nil
+}
+
+func (r *userRepository) Delete(ctx context.Context, id uint) error {
+	defer r.store.lock(r.inTx)()
+
+	u, ok := r.store.tables.users[id]
+	if !ok || u.DeletedAt.Valid {
+		return nil
+	}
+	u.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+	r.store.tables.users[id] = u
+	return nil
+}
+
+// find returns the first live user matching, in ID order
+func (r *userRepository) find(match func(u *user.User) bool) (*user.User, error) {
+	for _, id := range sortedKeys(r.store.tables.users) {
+		u := r.store.tables.users[id]
+		if !u.DeletedAt.Valid && match(&u) {
+			return &u, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+// taken reports whether another user, deleted or not, has the username or
+// email of u, which the unique indexes forbid
+func (r *userRepository) taken(u *user.User) bool {
+	for id, other := range r.store.tables.users {
+		if id != u.ID && (other.Username == u.Username || other.Email == u.Email) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"context"
+	"quizlet/internal/models/user"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type PersonalAccessTokenRepository interface {
+	Create(ctx context.Context, token *user.PersonalAccessToken) error
+	// FindByHash returns the token unless it was revoked or its user was
+	// deleted, in which case gorm.ErrRecordNotFound is returned
+	FindByHash(ctx context.Context, tokenHash string) (*user.PersonalAccessToken, error)
+	FindByUserID(ctx context.Context, userID uint) ([]*user.PersonalAccessToken, error)
+	Revoke(ctx context.Context, userID, id uint) error
+	UpdateLastUsed(ctx context.Context, id uint, usedAt time.Time) error
+}
+
+type personalAccessTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewPersonalAccessTokenRepository(db *gorm.DB) PersonalAccessTokenRepository {
+	return &personalAccessTokenRepository{db: db}
+}
+
+func (r *personalAccessTokenRepository) Create(ctx context.Context, token *user.PersonalAccessToken) error {
+	return r.db.WithContext(ctx).Create(token).Error
+}
+
+func (r *personalAccessTokenRepository) FindByHash(ctx context.Context, tokenHash string) (*user.PersonalAccessToken, error) {
+	var token user.PersonalAccessToken
+	err := r.db.WithContext(ctx).
+		Joins("JOIN users ON users.id = personal_access_tokens.user_id AND users.deleted_at IS NULL").
+		Where("personal_access_tokens.token_hash = ? AND personal_access_tokens.revoked_at IS NULL", tokenHash).
+		First(&token).Error
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *personalAccessTokenRepository) FindByUserID(ctx context.Context, userID uint) ([]*user.PersonalAccessToken, error) {
+	var tokens []*user.PersonalAccessToken
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("created_at DESC").Find(&tokens).Error
+	if err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+func (r *personalAccessTokenRepository) Revoke(ctx context.Context, userID, id uint) error {
+	result := r.db.WithContext(ctx).Model(&user.PersonalAccessToken{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userID).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *personalAccessTokenRepository) UpdateLastUsed(ctx context.Context, id uint, usedAt time.Time) error {
+	return r.db.WithContext(ctx).Model(&user.PersonalAccessToken{}).Where("id = ?", id).UpdateColumn("last_used_at", usedAt).Error
+}
@@ -6,25 +6,40 @@ import (
 
 	"quizlet/internal/models/quiz_attempt"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
-type QuizAttemptRepository struct {
+type QuizAttemptRepository interface {
+	// ListByQuizSuite returns the attempts without their snapshots
+	ListByQuizSuite(ctx context.Context, quizSuiteID, userID uint) ([]quiz_attempt.QuizAttempt, error)
+	Create(ctx context.Context, attempt *quiz_attempt.QuizAttempt) (*quiz_attempt.QuizAttempt, error)
+	// Get returns the attempt with its snapshot and answers
+	Get(ctx context.Context, id uint) (*quiz_attempt.QuizAttempt, error)
+	// Update saves the attempt; its answers are saved with CreateAnswer
+	Update(ctx context.Context, attempt *quiz_attempt.QuizAttempt) (*quiz_attempt.QuizAttempt, error)
+	Delete(ctx context.Context, id uint) error
+	// CreateAnswer stores the answer, or returns gorm.ErrDuplicatedKey when
+	// the attempt already has an answer to the quiz
+	CreateAnswer(ctx context.Context, answer *quiz_attempt.QuizAttemptAnswer) error
+}
+
+type quizAttemptRepository struct {
 	db *gorm.DB
 }
 
-func NewQuizAttemptRepository(db *gorm.DB) *QuizAttemptRepository {
-	return &QuizAttemptRepository{db: db}
+func NewQuizAttemptRepository(db *gorm.DB) QuizAttemptRepository {
+	return &quizAttemptRepository{db: db}
 }
 
-func (r *QuizAttemptRepository) ListByQuizSuite(ctx context.Context, quizSuiteID, userID int64) ([]quiz_attempt.QuizAttempt, error) {
+func (r *quizAttemptRepository) ListByQuizSuite(ctx context.Context, quizSuiteID, userID uint) ([]quiz_attempt.QuizAttempt, error) {
 	var attempts []quiz_attempt.QuizAttempt
 	err := r.db.WithContext(ctx).
 		Where("quiz_suite_id = ? AND user_id = ?", quizSuiteID, userID).
-		Find(&attempts).Error
+		Omit("snapshot").Find(&attempts).Error
 	return attempts, err
 }
 
-func (r *QuizAttemptRepository) Create(ctx context.Context, attempt *quiz_attempt.QuizAttempt) (*quiz_attempt.QuizAttempt, error) {
+func (r *quizAttemptRepository) Create(ctx context.Context, attempt *quiz_attempt.QuizAttempt) (*quiz_attempt.QuizAttempt, error) {
 	err := r.db.WithContext(ctx).Create(attempt).Error
 	if err != nil {
 		return nil, err
@@ -32,24 +47,35 @@ func (r *QuizAttemptRepository) Create(ctx context.Context, attempt *quiz_attemp
 	return attempt, nil
 }
 
-func (r *QuizAttemptRepository) Get(ctx context.Context, id int64) (*quiz_attempt.QuizAttempt, error) {
+func (r *quizAttemptRepository) Get(ctx context.Context, id uint) (*quiz_attempt.QuizAttempt, error) {
 	var attempt quiz_attempt.QuizAttempt
-	err := r.db.WithContext(ctx).First(&attempt, id).Error
+	err := r.db.WithContext(ctx).Preload("Answers", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id")
+	}).First(&attempt, id).Error
 	if err != nil {
 		return nil, err
 	}
 	return &attempt, nil
 }
 
-func (r *QuizAttemptRepository) Update(ctx context.Context, attempt *quiz_attempt.QuizAttempt) (*quiz_attempt.QuizAttempt, error) {
+func (r *quizAttemptRepository) Update(ctx context.Context, attempt *quiz_attempt.QuizAttempt) (*quiz_attempt.QuizAttempt, error) {
 	attempt.UpdatedAt = time.Now()
-	err := r.db.WithContext(ctx).Save(attempt).Error
+	err := r.db.WithContext(ctx).Omit(clause.Associations).Save(attempt).Error
 	if err != nil {
 		return nil, err
 	}
 	return attempt, nil
 }
 
-func (r *QuizAttemptRepository) Delete(ctx context.Context, id int64) error {
+func (r *quizAttemptRepository) Delete(ctx context.Context, id uint) error {
 	return r.db.WithContext(ctx).Delete(&quiz_attempt.QuizAttempt{}, id).Error
-} 
\ No newline at end of file
+}
+
+func (r *quizAttemptRepository) CreateAnswer(ctx context.Context, answer *quiz_attempt.QuizAttemptAnswer) error {
+	// The unique index on the attempt and quiz settles concurrent answers
+	result := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(answer)
+	if result.Error == nil && result.RowsAffected == 0 {
+		return gorm.ErrDuplicatedKey
+	}
+	return result.Error
+}
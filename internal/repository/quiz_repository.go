@@ -1,19 +1,30 @@
 package repository
 
 import (
+	"context"
 	"quizlet/internal/models/quiz"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type QuizRepository interface {
-	Create(quiz *quiz.Quiz) error
-	FindByID(id uint) (*quiz.Quiz, error)
-	FindByUserID(userID uint) ([]*quiz.Quiz, error)
-	Update(quiz *quiz.Quiz) error
-	Delete(id uint) error
-	AddSelection(quizID uint, selection quiz.QuizSelection) error
-	RemoveSelection(quizID uint, selectionID uint) error
+	Create(ctx context.Context, quiz *quiz.Quiz) error
+	FindByID(ctx context.Context, id uint) (*quiz.Quiz, error)
+	FindByUserID(ctx context.Context, userID uint) ([]*quiz.Quiz, error)
+	// Update saves the record if it still has its version, which is then
+	// incremented; ErrVersionConflict is returned otherwise
+	Update(ctx context.Context, quiz *quiz.Quiz) error
+	// Delete removes the record; a non-zero version makes it conditional
+	Delete(ctx context.Context, id uint, version uint) error
+	AddSelection(ctx context.Context, quizID uint, selection *quiz.QuizSelection) error
+	// UpdateSelection saves the text, display name and correctness of the
+	// selection and increments its version, or returns
+	// gorm.ErrRecordNotFound when the quiz has no such selection
+	UpdateSelection(ctx context.Context, quizID uint, selection *quiz.QuizSelection) error
+	// RemoveSelection deletes the selection, or returns
+	// gorm.ErrRecordNotFound when the quiz has no such selection
+	RemoveSelection(ctx context.Context, quizID uint, selectionID uint) error
 }
 
 type quizRepository struct {
@@ -24,41 +35,86 @@ func NewQuizRepository(db *gorm.DB) QuizRepository {
 	return &quizRepository{db: db}
 }
 
-func (r *quizRepository) Create(quiz *quiz.Quiz) error {
-	return r.db.Create(quiz).Error
+func (r *quizRepository) Create(ctx context.Context, quiz *quiz.Quiz) error {
+	return r.db.WithContext(ctx).Create(quiz).Error
 }
 
-func (r *quizRepository) FindByID(id uint) (*quiz.Quiz, error) {
+func (r *quizRepository) FindByID(ctx context.Context, id uint) (*quiz.Quiz, error) {
 	var quiz quiz.Quiz
-	err := r.db.Preload("Selections").Preload("CreatedBy").First(&quiz, id).Error
+	err := r.db.WithContext(ctx).Preload("Selections").Preload("CreatedBy").First(&quiz, id).Error
 	if err != nil {
 		return nil, err
 	}
 	return &quiz, nil
 }
 
-func (r *quizRepository) FindByUserID(userID uint) ([]*quiz.Quiz, error) {
+func (r *quizRepository) FindByUserID(ctx context.Context, userID uint) ([]*quiz.Quiz, error) {
 	var quizzes []*quiz.Quiz
-	err := r.db.Where("created_by_id = ?", userID).Preload("Selections").Find(&quizzes).Error
+	err := r.db.WithContext(ctx).Where("created_by_id = ?", userID).Preload("Selections").Find(&quizzes).Error
 	if err != nil {
 		return nil, err
 	}
 	return quizzes, nil
 }
 
-func (r *quizRepository) Update(quiz *quiz.Quiz) error {
-	return r.db.Save(quiz).Error
+func (r *quizRepository) Update(ctx context.Context, quiz *quiz.Quiz) error {
+	version := quiz.Version
+	quiz.Version++
+	// Associations are changed with their own methods, never saved here
+	result := r.db.WithContext(ctx).Model(quiz).Where("version = ?", version).
+		Select("*").Omit("CreatedAt", clause.Associations).Updates(quiz)
+	if result.Error == nil && result.RowsAffected == 0 {
+		result.Error = ErrVersionConflict
+	}
+	if result.Error != nil {
+		quiz.Version = version
+	}
+	return result.Error
 }
 
-func (r *quizRepository) Delete(id uint) error {
-	return r.db.Delete(&quiz.Quiz{}, id).Error
+func (r *quizRepository) Delete(ctx context.Context, id uint, version uint) error {
+	if version == 0 {
+		return r.db.WithContext(ctx).Delete(&quiz.Quiz{}, id).Error
+	}
+	result := r.db.WithContext(ctx).Where("version = ?", version).Delete(&quiz.Quiz{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrVersionConflict
+	}
+	return nil
 }
 
-func (r *quizRepository) AddSelection(quizID uint, selection quiz.QuizSelection) error {
+func (r *quizRepository) AddSelection(ctx context.Context, quizID uint, selection *quiz.QuizSelection) error {
 	selection.QuizID = quizID
-	return r.db.Create(&selection).Error
+	return r.db.WithContext(ctx).Create(selection).Error
 }
 
-func (r *quizRepository) RemoveSelection(quizID uint, selectionID uint) error {
-	return r.db.Where("quiz_id = ? AND id = ?", quizID, selectionID).Delete(&quiz.QuizSelection{}).Error
+func (r *quizRepository) UpdateSelection(ctx context.Context, quizID uint, selection *quiz.QuizSelection) error {
+	result := r.db.WithContext(ctx).Model(&quiz.QuizSelection{}).Where("quiz_id = ? AND id = ?", quizID, selection.ID).
+		Updates(map[string]any{
+			"selection_text":         selection.SelectionText,
+			"selection_display_name": selection.SelectionDisplayName,
+			"is_correct":             selection.IsCorrect,
+			"version":                gorm.Expr("version + 1"),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *quizRepository) RemoveSelection(ctx context.Context, quizID uint, selectionID uint) error {
+	result := r.db.WithContext(ctx).Where("quiz_id = ? AND id = ?", quizID, selectionID).Delete(&quiz.QuizSelection{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
 } 
\ No newline at end of file
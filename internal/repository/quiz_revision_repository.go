@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+	"quizlet/internal/models/quiz"
+)
+
+// QuizRevisionRepository stores the revisions of quizzes; revisions are
+// never changed, and only deleted with their quiz
+type QuizRevisionRepository interface {
+	// Create stores revision under the number it carries, which must follow
+	// the latest revision of the quiz
+	Create(ctx context.Context, revision *quiz.QuizRevision) error
+	// List returns the revisions of a quiz, newest first
+	List(ctx context.Context, quizID uint) ([]*quiz.QuizRevision, error)
+	// Find returns gorm.ErrRecordNotFound when the quiz has no such revision
+	Find(ctx context.Context, quizID uint, number uint) (*quiz.QuizRevision, error)
+	// Latest returns gorm.ErrRecordNotFound when the quiz has no revisions
+	Latest(ctx context.Context, quizID uint) (*quiz.QuizRevision, error)
+}
+
+type quizRevisionRepository struct {
+	db *gorm.DB
+}
+
+func NewQuizRevisionRepository(db *gorm.DB) QuizRevisionRepository {
+	return &quizRevisionRepository{db: db}
+}
+
+func (r *quizRevisionRepository) Create(ctx context.Context, revision *quiz.QuizRevision) error {
+	return r.db.WithContext(ctx).Create(revision).Error
+}
+
+func (r *quizRevisionRepository) List(ctx context.Context, quizID uint) ([]*quiz.QuizRevision, error) {
+	var revisions []*quiz.QuizRevision
+	err := r.db.WithContext(ctx).Where("quiz_id = ?", quizID).Order("number DESC").Find(&revisions).Error
+	if err != nil {
+		return nil, err
+	}
+	return revisions, nil
+}
+
+func (r *quizRevisionRepository) Find(ctx context.Context, quizID uint, number uint) (*quiz.QuizRevision, error) {
+	var revision quiz.QuizRevision
+	err := r.db.WithContext(ctx).Where("quiz_id = ? AND number = ?", quizID, number).First(&revision).Error
+	if err != nil {
+		return nil, err
+	}
+	return &revision, nil
+}
+
+func (r *quizRevisionRepository) Latest(ctx context.Context, quizID uint) (*quiz.QuizRevision, error) {
+	var revision quiz.QuizRevision
+	err := r.db.WithContext(ctx).Where("quiz_id = ?", quizID).Order("number DESC").First(&revision).Error
+	if err != nil {
+		return nil, err
+	}
+	return &revision, nil
+}
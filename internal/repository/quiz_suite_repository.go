@@ -1,19 +1,34 @@
 package repository
 
 import (
+	"context"
+	"quizlet/internal/models/quiz"
 	"quizlet/internal/models/quiz_suite"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type QuizSuiteRepository interface {
-	Create(quizSuite *quiz_suite.QuizSuite) error
-	FindByID(id uint) (*quiz_suite.QuizSuite, error)
-	FindByUserID(userID uint) ([]*quiz_suite.QuizSuite, error)
-	Update(quizSuite *quiz_suite.QuizSuite) error
-	Delete(id uint) error
+	Create(ctx context.Context, quizSuite *quiz_suite.QuizSuite) error
+	FindByID(ctx context.Context, id uint) (*quiz_suite.QuizSuite, error)
+	FindByUserID(ctx context.Context, userID uint) ([]*quiz_suite.QuizSuite, error)
+	// Update saves the record if it still has its version, which is then
+	// incremented; ErrVersionConflict is returned otherwise
+	Update(ctx context.Context, quizSuite *quiz_suite.QuizSuite) error
+	// Delete removes the record; a non-zero version makes it conditional
+	Delete(ctx context.Context, id uint, version uint) error
+	// AddQuiz puts the quiz after the ones already in the suite, which list
+	// their quizzes in that order; adding it twice is a no-op
+	AddQuiz(ctx context.Context, quizSuiteID uint, quizID uint) error
+	// RemoveQuiz takes the quiz out of the suite, or returns
+	// gorm.ErrRecordNotFound when it is not in the suite
+	RemoveQuiz(ctx context.Context, quizSuiteID uint, quizID uint) error
 }
 
+// quizSuiteQuizzesTable is the join table of QuizSuite.Quizzes
+const quizSuiteQuizzesTable = "quiz_suite_quizzes"
+
 type quizSuiteRepository struct {
 	db *gorm.DB
 }
@@ -22,32 +37,112 @@ func NewQuizSuiteRepository(db *gorm.DB) QuizSuiteRepository {
 	return &quizSuiteRepository{db: db}
 }
 
-func (r *quizSuiteRepository) Create(quizSuite *quiz_suite.QuizSuite) error {
-	return r.db.Create(quizSuite).Error
+func (r *quizSuiteRepository) Create(ctx context.Context, quizSuite *quiz_suite.QuizSuite) error {
+	return r.db.WithContext(ctx).Create(quizSuite).Error
 }
 
-func (r *quizSuiteRepository) FindByID(id uint) (*quiz_suite.QuizSuite, error) {
+func (r *quizSuiteRepository) FindByID(ctx context.Context, id uint) (*quiz_suite.QuizSuite, error) {
 	var quizSuite quiz_suite.QuizSuite
-	err := r.db.Preload("Quizzes").Preload("CreatedBy").First(&quizSuite, id).Error
+	err := r.db.WithContext(ctx).Preload("CreatedBy").First(&quizSuite, id).Error
 	if err != nil {
 		return nil, err
 	}
+	if err := r.loadQuizzes(ctx, &quizSuite); err != nil {
+		return nil, err
+	}
 	return &quizSuite, nil
 }
 
-func (r *quizSuiteRepository) FindByUserID(userID uint) ([]*quiz_suite.QuizSuite, error) {
+func (r *quizSuiteRepository) FindByUserID(ctx context.Context, userID uint) ([]*quiz_suite.QuizSuite, error) {
 	var quizSuites []*quiz_suite.QuizSuite
-	err := r.db.Where("created_by_id = ?", userID).Preload("Quizzes").Find(&quizSuites).Error
+	err := r.db.WithContext(ctx).Where("created_by_id = ?", userID).Find(&quizSuites).Error
 	if err != nil {
 		return nil, err
 	}
+	if err := r.loadQuizzes(ctx, quizSuites...); err != nil {
+		return nil, err
+	}
 	return quizSuites, nil
 }
 
-func (r *quizSuiteRepository) Update(quizSuite *quiz_suite.QuizSuite) error {
-	return r.db.Save(quizSuite).Error
+// suiteQuiz is a quiz together with the suite it was loaded for
+type suiteQuiz struct {
+	quiz.Quiz
+	QuizSuiteID uint
+}
+
+// loadQuizzes sets the quizzes of the suites in the order they were added.
+// Preload cannot do this, it orders by the quizzes and not the join table.
+func (r *quizSuiteRepository) loadQuizzes(ctx context.Context, quizSuites ...*quiz_suite.QuizSuite) error {
+	if len(quizSuites) == 0 {
+		return nil
+	}
+	bySuite := make(map[uint]*quiz_suite.QuizSuite, len(quizSuites))
+	ids := make([]uint, 0, len(quizSuites))
+	for _, quizSuite := range quizSuites {
+		quizSuite.Quizzes = []*quiz.Quiz{}
+		bySuite[quizSuite.ID] = quizSuite
+		ids = append(ids, quizSuite.ID)
+	}
+
+	var rows []suiteQuiz
+	err := r.db.WithContext(ctx).Model(&quiz.Quiz{}).
+		Select("quizzes.*, "+quizSuiteQuizzesTable+".quiz_suite_id").
+		Joins("JOIN "+quizSuiteQuizzesTable+" ON "+quizSuiteQuizzesTable+".quiz_id = quizzes.id").
+		Where(quizSuiteQuizzesTable+".quiz_suite_id IN ?", ids).
+		Order(quizSuiteQuizzesTable + ".id").
+		Find(&rows).Error
+	if err != nil {
+		return err
+	}
+	for i := range rows {
+		quizSuite := bySuite[rows[i].QuizSuiteID]
+		quizSuite.Quizzes = append(quizSuite.Quizzes, &rows[i].Quiz)
+	}
+	return nil
 }
 
-func (r *quizSuiteRepository) Delete(id uint) error {
-	return r.db.Delete(&quiz_suite.QuizSuite{}, id).Error
-} 
\ No newline at end of file
+func (r *quizSuiteRepository) Update(ctx context.Context, quizSuite *quiz_suite.QuizSuite) error {
+	version := quizSuite.Version
+	quizSuite.Version++
+	// Associations are changed with their own methods, never saved here
+	result := r.db.WithContext(ctx).Model(quizSuite).Where("version = ?", version).
+		Select("*").Omit("CreatedAt", clause.Associations).Updates(quizSuite)
+	if result.Error == nil && result.RowsAffected == 0 {
+		result.Error = ErrVersionConflict
+	}
+	if result.Error != nil {
+		quizSuite.Version = version
+	}
+	return result.Error
+}
+
+func (r *quizSuiteRepository) Delete(ctx context.Context, id uint, version uint) error {
+	if version == 0 {
+		return r.db.WithContext(ctx).Delete(&quiz_suite.QuizSuite{}, id).Error
+	}
+	result := r.db.WithContext(ctx).Where("version = ?", version).Delete(&quiz_suite.QuizSuite{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrVersionConflict
+	}
+	return nil
+}
+
+func (r *quizSuiteRepository) AddQuiz(ctx context.Context, quizSuiteID uint, quizID uint) error {
+	return r.db.WithContext(ctx).Table(quizSuiteQuizzesTable).Clauses(clause.OnConflict{DoNothing: true}).
+		Create(map[string]any{"quiz_suite_id": quizSuiteID, "quiz_id": quizID}).Error
+}
+
+func (r *quizSuiteRepository) RemoveQuiz(ctx context.Context, quizSuiteID uint, quizID uint) error {
+	result := r.db.WithContext(ctx).Exec("DELETE FROM "+quizSuiteQuizzesTable+" WHERE quiz_suite_id = ? AND quiz_id = ?", quizSuiteID, quizID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
@@ -1,17 +1,18 @@
 package repository
 
 import (
+	"context"
 	"quizlet/internal/models/user"
 	"gorm.io/gorm"
 	"time"
 )
 
 type RefreshTokenRepository interface {
-	Create(token *user.RefreshToken) error
-	FindByToken(token string) (*user.RefreshToken, error)
-	FindByUserID(userID uint) ([]*user.RefreshToken, error)
-	Revoke(token string) error
-	DeleteExpired() error
+	Create(ctx context.Context, token *user.RefreshToken) error
+	FindByToken(ctx context.Context, token string) (*user.RefreshToken, error)
+	FindByUserID(ctx context.Context, userID uint) ([]*user.RefreshToken, error)
+	Revoke(ctx context.Context, token string) error
+	DeleteExpired(ctx context.Context) error
 }
 
 type refreshTokenRepository struct {
@@ -24,32 +25,32 @@ func NewRefreshTokenRepository(db *gorm.DB) RefreshTokenRepository {
 	}
 }
 
-func (r *refreshTokenRepository) Create(token *user.RefreshToken) error {
-	return r.db.Create(token).Error
+func (r *refreshTokenRepository) Create(ctx context.Context, token *user.RefreshToken) error {
+	return r.db.WithContext(ctx).Create(token).Error
 }
 
-func (r *refreshTokenRepository) FindByToken(token string) (*user.RefreshToken, error) {
+func (r *refreshTokenRepository) FindByToken(ctx context.Context, token string) (*user.RefreshToken, error) {
 	var refreshToken user.RefreshToken
-	err := r.db.Where("token = ? AND revoked = ?", token, false).First(&refreshToken).Error
+	err := r.db.WithContext(ctx).Where("token = ? AND revoked = ?", token, false).First(&refreshToken).Error
 	if err != nil {
 		return nil, err
 	}
 	return &refreshToken, nil
 }
 
-func (r *refreshTokenRepository) FindByUserID(userID uint) ([]*user.RefreshToken, error) {
+func (r *refreshTokenRepository) FindByUserID(ctx context.Context, userID uint) ([]*user.RefreshToken, error) {
 	var tokens []*user.RefreshToken
-	err := r.db.Where("user_id = ? AND revoked = ?", userID, false).Find(&tokens).Error
+	err := r.db.WithContext(ctx).Where("user_id = ? AND revoked = ?", userID, false).Find(&tokens).Error
 	if err != nil {
 		return nil, err
 	}
 	return tokens, nil
 }
 
-func (r *refreshTokenRepository) Revoke(token string) error {
-	return r.db.Model(&user.RefreshToken{}).Where("token = ?", token).Update("revoked", true).Error
+func (r *refreshTokenRepository) Revoke(ctx context.Context, token string) error {
+	return r.db.WithContext(ctx).Model(&user.RefreshToken{}).Where("token = ?", token).Update("revoked", true).Error
 }
 
-func (r *refreshTokenRepository) DeleteExpired() error {
-	return r.db.Where("expires_at < ? OR revoked = ?", time.Now(), true).Delete(&user.RefreshToken{}).Error
+func (r *refreshTokenRepository) DeleteExpired(ctx context.Context) error {
+	return r.db.WithContext(ctx).Where("expires_at < ? OR revoked = ?", time.Now(), true).Delete(&user.RefreshToken{}).Error
 } 
\ No newline at end of file
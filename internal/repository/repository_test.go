@@ -0,0 +1,43 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"quizlet/internal/config"
+	"quizlet/internal/database"
+	"quizlet/internal/repository"
+	"quizlet/internal/repository/repositorytest"
+)
+
+// TestConformance runs the suite against the GORM repositories on an
+// in-memory SQLite database migrated like production
+func TestConformance(t *testing.T) {
+	repositorytest.Run(t, func(t *testing.T) repositorytest.Repositories {
+		ctx := context.Background()
+		cfg := config.Default().Database
+		cfg.Driver = config.DatabaseDriverSQLite
+		cfg.Path = ":memory:"
+		cfg.AutoMigrate = true
+		cfg.SchemaCheck = config.SchemaCheckOff
+
+		db, err := database.Open(ctx, cfg)
+		require.NoError(t, err)
+		t.Cleanup(func() { database.Close(db) })
+		require.NoError(t, database.PrepareSchema(ctx, db, cfg))
+
+		return repositorytest.Repositories{
+			Users:         repository.NewUserRepository(db),
+			RefreshTokens: repository.NewRefreshTokenRepository(db),
+			RecoveryCodes: repository.NewRecoveryCodeRepository(db),
+			Quizzes:       repository.NewQuizRepository(db),
+			QuizRevisions: repository.NewQuizRevisionRepository(db),
+			QuizSuites:    repository.NewQuizSuiteRepository(db),
+			QuizAttempts:  repository.NewQuizAttemptRepository(db),
+			Trash:         repository.NewTrashRepository(db),
+			AuditEntries:  repository.NewAuditEntryRepository(db),
+			UnitOfWork:    repository.NewUnitOfWork(db),
+		}
+	})
+}
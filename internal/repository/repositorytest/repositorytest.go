@@ -0,0 +1,911 @@
+// Package repositorytest is a conformance suite for implementations of the
+// repositories, so the in-memory ones can stand in for GORM in tests.
+package repositorytest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"quizlet/internal/models/audit_entry"
+	"quizlet/internal/models/quiz"
+	"quizlet/internal/models/quiz_attempt"
+	"quizlet/internal/models/quiz_suite"
+	"quizlet/internal/models/user"
+	"quizlet/internal/repository"
+)
+
+// Repositories are the implementations under test. They must share one
+// store, as the GORM repositories share one database.
+type Repositories struct {
+	Users         repository.UserRepository
+	RefreshTokens repository.RefreshTokenRepository
+	RecoveryCodes repository.RecoveryCodeRepository
+	Quizzes       repository.QuizRepository
+	QuizRevisions repository.QuizRevisionRepository
+	QuizSuites    repository.QuizSuiteRepository
+	QuizAttempts  repository.QuizAttemptRepository
+	Trash         repository.TrashRepository
+	AuditEntries  repository.AuditEntryRepository
+	UnitOfWork    repository.UnitOfWork
+}
+
+// Run runs the suite. open is called once per test and must return
+// repositories over a new, empty store.
+func Run(t *testing.T, open func(t *testing.T) Repositories) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, repos Repositories)
+	}{
+		{"Users", testUsers},
+		{"UserMFAState", testUserMFAState},
+		{"UserProfile", testUserProfile},
+		{"RefreshTokens", testRefreshTokens},
+		{"RecoveryCodes", testRecoveryCodes},
+		{"Quizzes", testQuizzes},
+		{"QuizVersions", testQuizVersions},
+		{"QuizSelections", testQuizSelections},
+		{"QuizRevisions", testQuizRevisions},
+		{"QuizSuites", testQuizSuites},
+		{"QuizSuiteVersions", testQuizSuiteVersions},
+		{"ClonedFrom", testClonedFrom},
+		{"QuizAttempts", testQuizAttempts},
+		{"QuizAttemptAnswers", testQuizAttemptAnswers},
+		{"TrashQuizSuite", testTrashQuizSuite},
+		{"TrashUser", testTrashUser},
+		{"TrashPurge", testTrashPurge},
+		{"AuditEntries", testAuditEntries},
+		{"UnitOfWorkCommits", testUnitOfWorkCommits},
+		{"UnitOfWorkRollsBack", testUnitOfWorkRollsBack},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.test(t, open(t))
+		})
+	}
+}
+
+var errRollback = errors.New("roll back")
+
+func createUser(t *testing.T, repos Repositories, username string) *user.User {
+	t.Helper()
+	u := &user.User{Username: username, Email: username + "@example.com", Password: "hash"}
+	require.NoError(t, repos.Users.Create(context.Background(), u))
+	return u
+}
+
+func createQuiz(t *testing.T, repos Repositories, owner *user.User, selections ...string) *quiz.Quiz {
+	t.Helper()
+	q := &quiz.Quiz{Question: "What is 2 + 2?", QuizType: quiz.QuizTypeSingleChoice, CreatedByID: owner.ID}
+	for i, text := range selections {
+		q.Selections = append(q.Selections, quiz.QuizSelection{SelectionText: text, IsCorrect: i == 0})
+	}
+	require.NoError(t, repos.Quizzes.Create(context.Background(), q))
+	return q
+}
+
+func createQuizSuite(t *testing.T, repos Repositories, owner *user.User) *quiz_suite.QuizSuite {
+	t.Helper()
+	qs := &quiz_suite.QuizSuite{Title: "Arithmetic", Description: "Sums", CreatedByID: owner.ID}
+	require.NoError(t, repos.QuizSuites.Create(context.Background(), qs))
+	return qs
+}
+
+func quizIDs(quizzes []*quiz.Quiz) []uint {
+	ids := []uint{}
+	for _, q := range quizzes {
+		ids = append(ids, q.ID)
+	}
+	return ids
+}
+
+func testUsers(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	ann := createUser(t, repos, "ann")
+	bob := createUser(t, repos, "bob")
+	assert.NotZero(t, ann.ID)
+	assert.Greater(t, bob.ID, ann.ID)
+	assert.False(t, ann.CreatedAt.IsZero())
+
+	found, err := repos.Users.FindByEmail(ctx, "ann@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, ann.ID, found.ID)
+	found, err = repos.Users.FindByUsername(ctx, "bob")
+	require.NoError(t, err)
+	assert.Equal(t, bob.ID, found.ID)
+	_, err = repos.Users.FindByEmail(ctx, "nobody@example.com")
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	// Usernames and emails are unique
+	assert.Error(t, repos.Users.Create(ctx, &user.User{Username: "ann", Email: "other@example.com", Password: "hash"}))
+	assert.Error(t, repos.Users.Create(ctx, &user.User{Username: "other", Email: "bob@example.com", Password: "hash"}))
+
+	ann.Username = "annie"
+	require.NoError(t, repos.Users.Update(ctx, ann))
+	require.NoError(t, repos.Users.UpdatePassword(ctx, ann.ID, "rehashed"))
+	found, err = repos.Users.FindByID(ctx, ann.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "annie", found.Username)
+	assert.Equal(t, "rehashed", found.Password)
+
+	// Deleted users are gone but keep their email taken
+	require.NoError(t, repos.Users.Delete(ctx, bob.ID))
+	_, err = repos.Users.FindByID(ctx, bob.ID)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	assert.Error(t, repos.Users.Create(ctx, &user.User{Username: "bobby", Email: "bob@example.com", Password: "hash"}))
+	assert.NoError(t, repos.Users.Delete(ctx, bob.ID))
+}
+
+func testUserProfile(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	ann := createUser(t, repos, "ann")
+
+	// A profile read before the user signed in with two factors
+	stale, err := repos.Users.FindByID(ctx, ann.ID)
+	require.NoError(t, err)
+	require.NoError(t, repos.Users.AdvanceTOTPStep(ctx, ann.ID, 100))
+	require.NoError(t, repos.Users.CountMFAAttempt(ctx, ann.ID, 3))
+
+	stale.Email = "ann@example.org"
+	stale.TOTPSecret = "SECRET"
+	stale.TOTPEnabled = true
+	stale.IsAdmin = true
+	stale.CreatedAt = time.Time{}
+	require.NoError(t, repos.Users.UpdateProfile(ctx, stale))
+
+	found, err := repos.Users.FindByID(ctx, ann.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "ann@example.org", found.Email)
+	assert.Equal(t, int64(100), found.TOTPLastStep)
+	assert.Equal(t, 1, found.MFAAttempts)
+	assert.Empty(t, found.TOTPSecret)
+	assert.False(t, found.TOTPEnabled)
+	assert.False(t, found.IsAdmin)
+	assert.False(t, found.CreatedAt.IsZero())
+
+	bob := createUser(t, repos, "bob")
+	bob.Email = found.Email
+	assert.Error(t, repos.Users.UpdateProfile(ctx, bob))
+}
+
+func testUserMFAState(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	ann := createUser(t, repos, "ann")
+
+	// Attempts are counted up to the limit
+	for i := 0; i < 3; i++ {
+		require.NoError(t, repos.Users.CountMFAAttempt(ctx, ann.ID, 3))
+	}
+	assert.ErrorIs(t, repos.Users.CountMFAAttempt(ctx, ann.ID, 3), gorm.ErrRecordNotFound)
+	found, err := repos.Users.FindByID(ctx, ann.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 3, found.MFAAttempts)
+
+	// A TOTP step is only accepted once and resets the attempts
+	require.NoError(t, repos.Users.AdvanceTOTPStep(ctx, ann.ID, 100))
+	assert.ErrorIs(t, repos.Users.AdvanceTOTPStep(ctx, ann.ID, 100), gorm.ErrRecordNotFound)
+	assert.ErrorIs(t, repos.Users.AdvanceTOTPStep(ctx, ann.ID, 99), gorm.ErrRecordNotFound)
+	found, err = repos.Users.FindByID(ctx, ann.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(100), found.TOTPLastStep)
+	assert.Zero(t, found.MFAAttempts)
+
+	require.NoError(t, repos.Users.CountMFAAttempt(ctx, ann.ID, 3))
+	require.NoError(t, repos.Users.ResetMFAAttempts(ctx, ann.ID))
+	found, err = repos.Users.FindByID(ctx, ann.ID)
+	require.NoError(t, err)
+	assert.Zero(t, found.MFAAttempts)
+}
+
+func testRefreshTokens(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	ann := createUser(t, repos, "ann")
+	tokens := map[string]time.Time{
+		"live":    time.Now().Add(time.Hour),
+		"revoked": time.Now().Add(time.Hour),
+		"expired": time.Now().Add(-time.Hour),
+	}
+	for token, expiresAt := range tokens {
+		require.NoError(t, repos.RefreshTokens.Create(ctx, &user.RefreshToken{Token: token, UserID: ann.ID, ExpiresAt: expiresAt}))
+	}
+	assert.Error(t, repos.RefreshTokens.Create(ctx, &user.RefreshToken{Token: "live", UserID: ann.ID, ExpiresAt: time.Now()}))
+
+	require.NoError(t, repos.RefreshTokens.Revoke(ctx, "revoked"))
+	_, err := repos.RefreshTokens.FindByToken(ctx, "revoked")
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	// Expiry is left to the caller until the cleanup runs
+	found, err := repos.RefreshTokens.FindByToken(ctx, "expired")
+	require.NoError(t, err)
+	assert.Equal(t, ann.ID, found.UserID)
+	active, err := repos.RefreshTokens.FindByUserID(ctx, ann.ID)
+	require.NoError(t, err)
+	assert.Len(t, active, 2)
+
+	require.NoError(t, repos.RefreshTokens.DeleteExpired(ctx))
+	_, err = repos.RefreshTokens.FindByToken(ctx, "expired")
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	active, err = repos.RefreshTokens.FindByUserID(ctx, ann.ID)
+	require.NoError(t, err)
+	require.Len(t, active, 1)
+	assert.Equal(t, "live", active[0].Token)
+}
+
+func testRecoveryCodes(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	ann := createUser(t, repos, "ann")
+	bob := createUser(t, repos, "bob")
+	require.NoError(t, repos.RecoveryCodes.ReplaceForUser(ctx, ann.ID, []*user.RecoveryCode{{UserID: ann.ID, CodeHash: "old"}}))
+	require.NoError(t, repos.RecoveryCodes.ReplaceForUser(ctx, ann.ID, []*user.RecoveryCode{{UserID: ann.ID, CodeHash: "a"}, {UserID: ann.ID, CodeHash: "b"}}))
+	require.NoError(t, repos.RecoveryCodes.ReplaceForUser(ctx, bob.ID, []*user.RecoveryCode{{UserID: bob.ID, CodeHash: "a"}}))
+
+	// Replacing the codes drops the old ones
+	_, err := repos.RecoveryCodes.FindUnused(ctx, ann.ID, "old")
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	// A code is used once
+	code, err := repos.RecoveryCodes.FindUnused(ctx, ann.ID, "a")
+	require.NoError(t, err)
+	require.NoError(t, repos.RecoveryCodes.MarkUsed(ctx, code.ID))
+	assert.ErrorIs(t, repos.RecoveryCodes.MarkUsed(ctx, code.ID), gorm.ErrRecordNotFound)
+	_, err = repos.RecoveryCodes.FindUnused(ctx, ann.ID, "a")
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	// Deleting the codes of a unit of work that fails keeps them
+	err = repos.UnitOfWork.Do(ctx, func(ctx context.Context, tx repository.Repositories) error {
+		require.NoError(t, tx.RecoveryCodes.DeleteByUserID(ctx, ann.ID))
+		return errRollback
+	})
+	require.ErrorIs(t, err, errRollback)
+	_, err = repos.RecoveryCodes.FindUnused(ctx, ann.ID, "b")
+	require.NoError(t, err)
+
+	require.NoError(t, repos.RecoveryCodes.DeleteByUserID(ctx, ann.ID))
+	_, err = repos.RecoveryCodes.FindUnused(ctx, ann.ID, "b")
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	// Other users keep theirs
+	_, err = repos.RecoveryCodes.FindUnused(ctx, bob.ID, "a")
+	assert.NoError(t, err)
+}
+
+func testQuizzes(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	ann := createUser(t, repos, "ann")
+	bob := createUser(t, repos, "bob")
+	q := createQuiz(t, repos, ann, "4", "5")
+	createQuiz(t, repos, ann)
+	createQuiz(t, repos, bob)
+
+	assert.NotZero(t, q.ID)
+	assert.Equal(t, uint(1), q.Version)
+	require.Len(t, q.Selections, 2)
+	assert.NotZero(t, q.Selections[0].ID)
+	assert.Equal(t, q.ID, q.Selections[0].QuizID)
+
+	found, err := repos.Quizzes.FindByID(ctx, q.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "What is 2 + 2?", found.Question)
+	assert.Equal(t, uint(1), found.Version)
+	require.NotNil(t, found.CreatedBy)
+	assert.Equal(t, "ann", found.CreatedBy.Username)
+	require.Len(t, found.Selections, 2)
+	assert.Equal(t, "4", found.Selections[0].SelectionText)
+	assert.True(t, found.Selections[0].IsCorrect)
+	assert.Equal(t, uint(1), found.Selections[0].Version)
+
+	owned, err := repos.Quizzes.FindByUserID(ctx, ann.ID)
+	require.NoError(t, err)
+	require.Len(t, owned, 2)
+	assert.Equal(t, q.ID, owned[0].ID)
+	assert.Len(t, owned[0].Selections, 2)
+	owned, err = repos.Quizzes.FindByUserID(ctx, 999)
+	require.NoError(t, err)
+	assert.Empty(t, owned)
+
+	_, err = repos.Quizzes.FindByID(ctx, 999)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
+
+func testQuizVersions(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	ann := createUser(t, repos, "ann")
+	q := createQuiz(t, repos, ann, "4")
+	stale, err := repos.Quizzes.FindByID(ctx, q.ID)
+	require.NoError(t, err)
+
+	// Update saves the fields but never the selections
+	q.Question = "What is 3 + 3?"
+	q.Selections = nil
+	require.NoError(t, repos.Quizzes.Update(ctx, q))
+	assert.Equal(t, uint(2), q.Version)
+	found, err := repos.Quizzes.FindByID(ctx, q.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "What is 3 + 3?", found.Question)
+	assert.Equal(t, uint(2), found.Version)
+	assert.Len(t, found.Selections, 1)
+
+	stale.Question = "Stale"
+	assert.ErrorIs(t, repos.Quizzes.Update(ctx, stale), repository.ErrVersionConflict)
+	assert.Equal(t, uint(1), stale.Version)
+
+	assert.ErrorIs(t, repos.Quizzes.Delete(ctx, q.ID, 1), repository.ErrVersionConflict)
+	require.NoError(t, repos.Quizzes.Delete(ctx, q.ID, 2))
+	_, err = repos.Quizzes.FindByID(ctx, q.ID)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	assert.ErrorIs(t, repos.Quizzes.Delete(ctx, q.ID, 2), repository.ErrVersionConflict)
+	assert.NoError(t, repos.Quizzes.Delete(ctx, q.ID, 0))
+	assert.ErrorIs(t, repos.Quizzes.Update(ctx, found), repository.ErrVersionConflict)
+}
+
+func testQuizSelections(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	ann := createUser(t, repos, "ann")
+	q := createQuiz(t, repos, ann, "4")
+	other := createQuiz(t, repos, ann)
+
+	selection := &quiz.QuizSelection{SelectionText: "5", SelectionDisplayName: "B"}
+	require.NoError(t, repos.Quizzes.AddSelection(ctx, q.ID, selection))
+	assert.NotZero(t, selection.ID)
+	assert.Equal(t, q.ID, selection.QuizID)
+
+	found, err := repos.Quizzes.FindByID(ctx, q.ID)
+	require.NoError(t, err)
+	require.Len(t, found.Selections, 2)
+	assert.Equal(t, "B", found.Selections[1].SelectionDisplayName)
+
+	// A selection is only updated through its own quiz
+	selection.SelectionText = "five"
+	selection.IsCorrect = true
+	assert.ErrorIs(t, repos.Quizzes.UpdateSelection(ctx, other.ID, selection), gorm.ErrRecordNotFound)
+	require.NoError(t, repos.Quizzes.UpdateSelection(ctx, q.ID, selection))
+	found, err = repos.Quizzes.FindByID(ctx, q.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "five", found.Selections[1].SelectionText)
+	assert.True(t, found.Selections[1].IsCorrect)
+	assert.Equal(t, "B", found.Selections[1].SelectionDisplayName)
+	assert.Equal(t, uint(2), found.Selections[1].Version)
+
+	// A selection is only removed through its own quiz
+	assert.ErrorIs(t, repos.Quizzes.RemoveSelection(ctx, other.ID, selection.ID), gorm.ErrRecordNotFound)
+	require.NoError(t, repos.Quizzes.RemoveSelection(ctx, q.ID, selection.ID))
+	assert.ErrorIs(t, repos.Quizzes.RemoveSelection(ctx, q.ID, selection.ID), gorm.ErrRecordNotFound)
+	found, err = repos.Quizzes.FindByID(ctx, q.ID)
+	require.NoError(t, err)
+	assert.Len(t, found.Selections, 1)
+}
+
+func testQuizRevisions(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	ann := createUser(t, repos, "ann")
+	q := createQuiz(t, repos, ann, "4", "5")
+	other := createQuiz(t, repos, ann)
+
+	_, err := repos.QuizRevisions.Latest(ctx, q.ID)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	first := &quiz.QuizRevision{QuizID: q.ID, Number: 1, AuthorID: &ann.ID, Content: quiz.NewSnapshot(q)}
+	require.NoError(t, repos.QuizRevisions.Create(ctx, first))
+	assert.NotZero(t, first.ID)
+	q.Question = "What is 2 * 2?"
+	reverted := uint(1)
+	second := &quiz.QuizRevision{QuizID: q.ID, Number: 2, RevertedFrom: &reverted, Content: quiz.NewSnapshot(q)}
+	require.NoError(t, repos.QuizRevisions.Create(ctx, second))
+	require.NoError(t, repos.QuizRevisions.Create(ctx, &quiz.QuizRevision{QuizID: other.ID, Number: 1, Content: quiz.NewSnapshot(other)}))
+
+	// Numbers are unique per quiz
+	assert.Error(t, repos.QuizRevisions.Create(ctx, &quiz.QuizRevision{QuizID: q.ID, Number: 2, Content: quiz.NewSnapshot(q)}))
+
+	revisions, err := repos.QuizRevisions.List(ctx, q.ID)
+	require.NoError(t, err)
+	require.Len(t, revisions, 2)
+	assert.Equal(t, []uint{2, 1}, []uint{revisions[0].Number, revisions[1].Number})
+
+	latest, err := repos.QuizRevisions.Latest(ctx, q.ID)
+	require.NoError(t, err)
+	assert.Equal(t, second.ID, latest.ID)
+	assert.Nil(t, latest.AuthorID)
+	require.NotNil(t, latest.RevertedFrom)
+	assert.Equal(t, uint(1), *latest.RevertedFrom)
+
+	found, err := repos.QuizRevisions.Find(ctx, q.ID, 1)
+	require.NoError(t, err)
+	require.NotNil(t, found.AuthorID)
+	assert.Equal(t, ann.ID, *found.AuthorID)
+	assert.Equal(t, "What is 2 + 2?", found.Content.Question)
+	assert.Equal(t, quiz.QuizTypeSingleChoice, found.Content.QuizType)
+	require.Len(t, found.Content.Selections, 2)
+	assert.Equal(t, q.Selections[0].ID, found.Content.Selections[0].ID)
+	assert.Equal(t, "4", found.Content.Selections[0].SelectionText)
+	assert.True(t, found.Content.Selections[0].IsCorrect)
+
+	_, err = repos.QuizRevisions.Find(ctx, q.ID, 3)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	_, err = repos.QuizRevisions.Find(ctx, other.ID, 2)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
+
+func testQuizSuites(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	ann := createUser(t, repos, "ann")
+	bob := createUser(t, repos, "bob")
+	qs := createQuizSuite(t, repos, ann)
+	createQuizSuite(t, repos, bob)
+	first := createQuiz(t, repos, ann, "4")
+	second := createQuiz(t, repos, ann)
+	assert.Equal(t, uint(1), qs.Version)
+
+	// Suites list their quizzes in the order they were added, not by ID
+	require.NoError(t, repos.QuizSuites.AddQuiz(ctx, qs.ID, second.ID))
+	require.NoError(t, repos.QuizSuites.AddQuiz(ctx, qs.ID, first.ID))
+	require.NoError(t, repos.QuizSuites.AddQuiz(ctx, qs.ID, first.ID))
+	other := createQuizSuite(t, repos, ann)
+	require.NoError(t, repos.QuizSuites.AddQuiz(ctx, other.ID, first.ID))
+	require.NoError(t, repos.QuizSuites.AddQuiz(ctx, other.ID, second.ID))
+
+	found, err := repos.QuizSuites.FindByID(ctx, qs.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Arithmetic", found.Title)
+	require.NotNil(t, found.CreatedBy)
+	assert.Equal(t, ann.ID, found.CreatedBy.ID)
+	assert.Equal(t, []uint{second.ID, first.ID}, quizIDs(found.Quizzes))
+
+	owned, err := repos.QuizSuites.FindByUserID(ctx, ann.ID)
+	require.NoError(t, err)
+	require.Len(t, owned, 2)
+	assert.Equal(t, []uint{second.ID, first.ID}, quizIDs(owned[0].Quizzes))
+	assert.Equal(t, []uint{first.ID, second.ID}, quizIDs(owned[1].Quizzes))
+
+	require.NoError(t, repos.QuizSuites.RemoveQuiz(ctx, qs.ID, second.ID))
+	assert.ErrorIs(t, repos.QuizSuites.RemoveQuiz(ctx, qs.ID, second.ID), gorm.ErrRecordNotFound)
+
+	// Deleted quizzes drop out of the suites that hold them
+	require.NoError(t, repos.Quizzes.Delete(ctx, first.ID, 0))
+	found, err = repos.QuizSuites.FindByID(ctx, qs.ID)
+	require.NoError(t, err)
+	assert.Empty(t, found.Quizzes)
+
+	_, err = repos.QuizSuites.FindByID(ctx, 999)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
+
+func testQuizSuiteVersions(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	ann := createUser(t, repos, "ann")
+	qs := createQuizSuite(t, repos, ann)
+	q := createQuiz(t, repos, ann)
+	stale, err := repos.QuizSuites.FindByID(ctx, qs.ID)
+	require.NoError(t, err)
+
+	// Update saves the fields but never the quizzes
+	qs.Title = "Algebra"
+	qs.Quizzes = []*quiz.Quiz{q}
+	require.NoError(t, repos.QuizSuites.Update(ctx, qs))
+	assert.Equal(t, uint(2), qs.Version)
+	found, err := repos.QuizSuites.FindByID(ctx, qs.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Algebra", found.Title)
+	assert.Empty(t, found.Quizzes)
+
+	assert.ErrorIs(t, repos.QuizSuites.Update(ctx, stale), repository.ErrVersionConflict)
+	assert.Equal(t, uint(1), stale.Version)
+
+	assert.ErrorIs(t, repos.QuizSuites.Delete(ctx, qs.ID, 1), repository.ErrVersionConflict)
+	require.NoError(t, repos.QuizSuites.Delete(ctx, qs.ID, 2))
+	_, err = repos.QuizSuites.FindByID(ctx, qs.ID)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	owned, err := repos.QuizSuites.FindByUserID(ctx, ann.ID)
+	require.NoError(t, err)
+	assert.Empty(t, owned)
+}
+
+func testClonedFrom(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	ann := createUser(t, repos, "ann")
+	q := createQuiz(t, repos, ann, "4")
+	qs := createQuizSuite(t, repos, ann)
+
+	quizClone := &quiz.Quiz{Question: q.Question, QuizType: q.QuizType, CreatedByID: ann.ID, ClonedFromID: &q.ID}
+	require.NoError(t, repos.Quizzes.Create(ctx, quizClone))
+	suiteClone := &quiz_suite.QuizSuite{Title: qs.Title, Description: qs.Description, CreatedByID: ann.ID, ClonedFromID: &qs.ID}
+	require.NoError(t, repos.QuizSuites.Create(ctx, suiteClone))
+
+	found, err := repos.Quizzes.FindByID(ctx, quizClone.ID)
+	require.NoError(t, err)
+	require.NotNil(t, found.ClonedFromID)
+	assert.Equal(t, q.ID, *found.ClonedFromID)
+	foundSuite, err := repos.QuizSuites.FindByID(ctx, suiteClone.ID)
+	require.NoError(t, err)
+	require.NotNil(t, foundSuite.ClonedFromID)
+	assert.Equal(t, qs.ID, *foundSuite.ClonedFromID)
+
+	// The originals have none, and updates keep it
+	found, err = repos.Quizzes.FindByID(ctx, q.ID)
+	require.NoError(t, err)
+	assert.Nil(t, found.ClonedFromID)
+	found, err = repos.Quizzes.FindByID(ctx, quizClone.ID)
+	require.NoError(t, err)
+	found.Question = "What is 3 + 3?"
+	require.NoError(t, repos.Quizzes.Update(ctx, found))
+	found, err = repos.Quizzes.FindByID(ctx, quizClone.ID)
+	require.NoError(t, err)
+	require.NotNil(t, found.ClonedFromID)
+	assert.Equal(t, q.ID, *found.ClonedFromID)
+}
+
+func testQuizAttempts(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	ann := createUser(t, repos, "ann")
+	bob := createUser(t, repos, "bob")
+	qs := createQuizSuite(t, repos, ann)
+	other := createQuizSuite(t, repos, ann)
+
+	attempt, err := repos.QuizAttempts.Create(ctx, &quiz_attempt.QuizAttempt{UserID: ann.ID, QuizSuiteID: qs.ID, Score: 40, StartedAt: time.Now()})
+	require.NoError(t, err)
+	assert.NotZero(t, attempt.ID)
+	_, err = repos.QuizAttempts.Create(ctx, &quiz_attempt.QuizAttempt{UserID: bob.ID, QuizSuiteID: qs.ID, StartedAt: time.Now()})
+	require.NoError(t, err)
+	_, err = repos.QuizAttempts.Create(ctx, &quiz_attempt.QuizAttempt{UserID: ann.ID, QuizSuiteID: other.ID, StartedAt: time.Now()})
+	require.NoError(t, err)
+
+	listed, err := repos.QuizAttempts.ListByQuizSuite(ctx, qs.ID, ann.ID)
+	require.NoError(t, err)
+	require.Len(t, listed, 1)
+	assert.Equal(t, attempt.ID, listed[0].ID)
+
+	completedAt := time.Now()
+	attempt.Score = 90
+	attempt.Completed = true
+	attempt.CompletedAt = &completedAt
+	_, err = repos.QuizAttempts.Update(ctx, attempt)
+	require.NoError(t, err)
+	found, err := repos.QuizAttempts.Get(ctx, attempt.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 90, found.Score)
+	assert.True(t, found.Completed)
+	assert.NotNil(t, found.CompletedAt)
+
+	require.NoError(t, repos.QuizAttempts.Delete(ctx, attempt.ID))
+	_, err = repos.QuizAttempts.Get(ctx, attempt.ID)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	listed, err = repos.QuizAttempts.ListByQuizSuite(ctx, qs.ID, ann.ID)
+	require.NoError(t, err)
+	assert.Empty(t, listed)
+}
+
+func testQuizAttemptAnswers(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	ann := createUser(t, repos, "ann")
+	q := createQuiz(t, repos, ann, "4", "5")
+	qs := createQuizSuite(t, repos, ann)
+	require.NoError(t, repos.QuizSuites.AddQuiz(ctx, qs.ID, q.ID))
+
+	snapshot := &quiz_attempt.SuiteSnapshot{
+		Title:   qs.Title,
+		Quizzes: []quiz_attempt.QuizSnapshot{quiz_attempt.NewQuizSnapshot(q, 1)},
+	}
+	attempt, err := repos.QuizAttempts.Create(ctx, &quiz_attempt.QuizAttempt{UserID: ann.ID, QuizSuiteID: qs.ID, StartedAt: time.Now(), Snapshot: snapshot})
+	require.NoError(t, err)
+	legacy := createAttempt(t, repos, ann, qs)
+
+	// Listings leave the snapshots out
+	listed, err := repos.QuizAttempts.ListByQuizSuite(ctx, qs.ID, ann.ID)
+	require.NoError(t, err)
+	require.Len(t, listed, 2)
+	assert.Nil(t, listed[0].Snapshot)
+
+	found, err := repos.QuizAttempts.Get(ctx, attempt.ID)
+	require.NoError(t, err)
+	assert.Equal(t, snapshot, found.Snapshot)
+	assert.Empty(t, found.Answers)
+	found, err = repos.QuizAttempts.Get(ctx, legacy.ID)
+	require.NoError(t, err)
+	assert.Nil(t, found.Snapshot)
+
+	first := &quiz_attempt.QuizAttemptAnswer{QuizAttemptID: attempt.ID, QuizID: q.ID, UserAnswer: "5", SelectionIDs: quiz_attempt.IDList{q.Selections[1].ID}}
+	require.NoError(t, repos.QuizAttempts.CreateAnswer(ctx, first))
+	assert.NotZero(t, first.ID)
+	// A quiz is answered once per attempt
+	second := &quiz_attempt.QuizAttemptAnswer{QuizAttemptID: attempt.ID, QuizID: q.ID, UserAnswer: "4", SelectionIDs: quiz_attempt.IDList{q.Selections[0].ID}, IsCorrect: true}
+	assert.ErrorIs(t, repos.QuizAttempts.CreateAnswer(ctx, second), gorm.ErrDuplicatedKey)
+
+	// Updating the attempt leaves its answers alone
+	found, err = repos.QuizAttempts.Get(ctx, attempt.ID)
+	require.NoError(t, err)
+	found.Answers = nil
+	found.Score = 100
+	_, err = repos.QuizAttempts.Update(ctx, found)
+	require.NoError(t, err)
+
+	// Answers are kept when the quiz is purged
+	require.NoError(t, repos.QuizSuites.RemoveQuiz(ctx, qs.ID, q.ID))
+	require.NoError(t, repos.Quizzes.Delete(ctx, q.ID, 0))
+	_, err = repos.Trash.Purge(ctx, time.Now().Add(time.Second))
+	require.NoError(t, err)
+
+	found, err = repos.QuizAttempts.Get(ctx, attempt.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 100, found.Score)
+	assert.Equal(t, snapshot, found.Snapshot)
+	require.Len(t, found.Answers, 1)
+	assert.Equal(t, "5", found.Answers[0].UserAnswer)
+	assert.Equal(t, quiz_attempt.IDList{q.Selections[1].ID}, found.Answers[0].SelectionIDs)
+	assert.False(t, found.Answers[0].IsCorrect)
+}
+
+func createAttempt(t *testing.T, repos Repositories, u *user.User, qs *quiz_suite.QuizSuite) *quiz_attempt.QuizAttempt {
+	t.Helper()
+	attempt, err := repos.QuizAttempts.Create(context.Background(), &quiz_attempt.QuizAttempt{UserID: u.ID, QuizSuiteID: qs.ID, StartedAt: time.Now()})
+	require.NoError(t, err)
+	return attempt
+}
+
+// tick makes sure the next deletion gets a later timestamp than the last one
+func tick() {
+	time.Sleep(2 * time.Millisecond)
+}
+
+func testTrashQuizSuite(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	ann := createUser(t, repos, "ann")
+	bob := createUser(t, repos, "bob")
+	qs := createQuizSuite(t, repos, ann)
+	kept := createAttempt(t, repos, bob, qs)
+	trashed := createAttempt(t, repos, bob, qs)
+	cascaded := createAttempt(t, repos, bob, qs)
+
+	require.NoError(t, repos.QuizAttempts.Delete(ctx, trashed.ID))
+	tick()
+	require.NoError(t, repos.QuizSuites.Delete(ctx, qs.ID, 0))
+	require.NoError(t, repos.Trash.TrashQuizSuiteAttempts(ctx, qs.ID))
+	_, err := repos.QuizAttempts.Get(ctx, kept.ID)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	suites, err := repos.Trash.ListQuizSuites(ctx, ann.ID)
+	require.NoError(t, err)
+	require.Len(t, suites, 1)
+	assert.True(t, suites[0].DeletedAt.Valid)
+	attempts, err := repos.Trash.ListQuizAttempts(ctx, bob.ID)
+	require.NoError(t, err)
+	assert.Len(t, attempts, 3)
+	assert.Equal(t, trashed.ID, attempts[len(attempts)-1].ID)
+
+	// Attempts come back with their suite unless they were deleted before it
+	found, err := repos.Trash.FindQuizSuite(ctx, qs.ID)
+	require.NoError(t, err)
+	require.NoError(t, repos.Trash.RestoreQuizSuite(ctx, found))
+	assert.False(t, found.DeletedAt.Valid)
+	assert.ErrorIs(t, repos.Trash.RestoreQuizSuite(ctx, found), gorm.ErrRecordNotFound)
+	_, err = repos.Trash.FindQuizSuite(ctx, qs.ID)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	listed, err := repos.QuizAttempts.ListByQuizSuite(ctx, qs.ID, bob.ID)
+	require.NoError(t, err)
+	assert.Len(t, listed, 2)
+	_, err = repos.QuizAttempts.Get(ctx, cascaded.ID)
+	assert.NoError(t, err)
+
+	attempt, err := repos.Trash.FindQuizAttempt(ctx, trashed.ID)
+	require.NoError(t, err)
+	require.NoError(t, repos.Trash.RestoreQuizAttempt(ctx, attempt))
+	_, err = repos.QuizAttempts.Get(ctx, trashed.ID)
+	assert.NoError(t, err)
+	attempts, err = repos.Trash.ListQuizAttempts(ctx, bob.ID)
+	require.NoError(t, err)
+	assert.Empty(t, attempts)
+}
+
+func testTrashUser(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	ann := createUser(t, repos, "ann")
+	bob := createUser(t, repos, "bob")
+	earlier := createQuiz(t, repos, ann)
+	q := createQuiz(t, repos, ann)
+	qs := createQuizSuite(t, repos, ann)
+	own := createAttempt(t, repos, ann, qs)
+	others := createAttempt(t, repos, bob, qs)
+
+	require.NoError(t, repos.Quizzes.Delete(ctx, earlier.ID, 0))
+	tick()
+	require.NoError(t, repos.Users.Delete(ctx, ann.ID))
+	require.NoError(t, repos.Trash.TrashUserContent(ctx, ann.ID))
+	_, err := repos.Quizzes.FindByID(ctx, q.ID)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	_, err = repos.QuizSuites.FindByID(ctx, qs.ID)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	_, err = repos.QuizAttempts.Get(ctx, others.ID)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	quizzes, err := repos.Trash.ListQuizzes(ctx, ann.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []uint{q.ID, earlier.ID}, quizIDs(quizzes))
+
+	u, err := repos.Trash.FindUser(ctx, ann.ID)
+	require.NoError(t, err)
+	require.NoError(t, repos.Trash.RestoreUser(ctx, u))
+	_, err = repos.Users.FindByID(ctx, ann.ID)
+	assert.NoError(t, err)
+	_, err = repos.Quizzes.FindByID(ctx, q.ID)
+	assert.NoError(t, err)
+	_, err = repos.QuizSuites.FindByID(ctx, qs.ID)
+	assert.NoError(t, err)
+	_, err = repos.QuizAttempts.Get(ctx, own.ID)
+	assert.NoError(t, err)
+	_, err = repos.QuizAttempts.Get(ctx, others.ID)
+	assert.NoError(t, err)
+
+	// The quiz deleted before the user stays in the trash
+	quizzes, err = repos.Trash.ListQuizzes(ctx, ann.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []uint{earlier.ID}, quizIDs(quizzes))
+	_, err = repos.Trash.FindUser(ctx, bob.ID)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
+
+func testTrashPurge(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	ann := createUser(t, repos, "ann")
+	bob := createUser(t, repos, "bob")
+	carl := createUser(t, repos, "carl")
+	live := createQuiz(t, repos, ann, "4")
+	purged := createQuiz(t, repos, ann, "4")
+	qs := createQuizSuite(t, repos, ann)
+	require.NoError(t, repos.QuizSuites.AddQuiz(ctx, qs.ID, purged.ID))
+	createAttempt(t, repos, ann, qs)
+	createQuiz(t, repos, carl)
+
+	require.NoError(t, repos.QuizRevisions.Create(ctx, &quiz.QuizRevision{QuizID: purged.ID, Number: 1, Content: quiz.NewSnapshot(purged)}))
+	require.NoError(t, repos.QuizRevisions.Create(ctx, &quiz.QuizRevision{QuizID: live.ID, Number: 1, Content: quiz.NewSnapshot(live)}))
+
+	require.NoError(t, repos.Quizzes.Delete(ctx, purged.ID, 0))
+	require.NoError(t, repos.QuizSuites.Delete(ctx, qs.ID, 0))
+	require.NoError(t, repos.Trash.TrashQuizSuiteAttempts(ctx, qs.ID))
+	require.NoError(t, repos.Users.Delete(ctx, bob.ID))
+	require.NoError(t, repos.Users.Delete(ctx, carl.ID))
+
+	// Nothing is old enough yet
+	count, err := repos.Trash.Purge(ctx, time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Zero(t, count)
+
+	// Carl still owns a quiz, so only his content could go
+	count, err = repos.Trash.Purge(ctx, time.Now().Add(time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), count)
+	_, err = repos.Trash.FindQuiz(ctx, purged.ID)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	_, err = repos.Trash.FindQuizSuite(ctx, qs.ID)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	_, err = repos.Trash.FindUser(ctx, bob.ID)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	_, err = repos.Trash.FindUser(ctx, carl.ID)
+	assert.NoError(t, err)
+	attempts, err := repos.Trash.ListQuizAttempts(ctx, ann.ID)
+	require.NoError(t, err)
+	assert.Empty(t, attempts)
+
+	revisions, err := repos.QuizRevisions.List(ctx, purged.ID)
+	require.NoError(t, err)
+	assert.Empty(t, revisions)
+
+	found, err := repos.Quizzes.FindByID(ctx, live.ID)
+	require.NoError(t, err)
+	assert.Len(t, found.Selections, 1)
+	revisions, err = repos.QuizRevisions.List(ctx, live.ID)
+	require.NoError(t, err)
+	assert.Len(t, revisions, 1)
+
+	// The username of a purged user is free again
+	createUser(t, repos, "bob")
+}
+
+func testAuditEntries(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	annID, bobID := uint(1), uint(2)
+	record := func(actorID *uint, action, resourceType string, resourceID uint) *audit_entry.AuditEntry {
+		t.Helper()
+		entry := &audit_entry.AuditEntry{
+			ActorID:      actorID,
+			Action:       action,
+			ResourceType: resourceType,
+			ResourceID:   resourceID,
+			Changes:      audit_entry.Changes{"title": {Before: "Sums", After: "Arithmetic"}},
+			RequestID:    "req-1",
+			IP:           "203.0.113.7",
+		}
+		require.NoError(t, repos.AuditEntries.Create(ctx, entry))
+		tick()
+		return entry
+	}
+
+	signup := record(nil, audit_entry.ActionCreate, audit_entry.ResourceUser, annID)
+	created := record(&annID, audit_entry.ActionCreate, audit_entry.ResourceQuizSuite, 1)
+	updated := record(&annID, audit_entry.ActionUpdate, audit_entry.ResourceQuizSuite, 1)
+	other := record(&bobID, audit_entry.ActionCreate, audit_entry.ResourceQuiz, 1)
+
+	entryIDs := func(filter audit_entry.Filter) []uint {
+		t.Helper()
+		entries, err := repos.AuditEntries.List(ctx, filter)
+		require.NoError(t, err)
+		ids := make([]uint, len(entries))
+		for i, entry := range entries {
+			ids[i] = entry.ID
+		}
+		return ids
+	}
+
+	// Newest first
+	assert.Equal(t, []uint{other.ID, updated.ID, created.ID, signup.ID}, entryIDs(audit_entry.Filter{}))
+	assert.Equal(t, []uint{updated.ID, created.ID}, entryIDs(audit_entry.Filter{ResourceType: audit_entry.ResourceQuizSuite, ResourceID: 1}))
+	assert.Equal(t, []uint{other.ID}, entryIDs(audit_entry.Filter{ResourceType: audit_entry.ResourceQuiz}))
+	assert.Equal(t, []uint{updated.ID, created.ID}, entryIDs(audit_entry.Filter{ActorID: annID}))
+	assert.Equal(t, []uint{other.ID}, entryIDs(audit_entry.Filter{Limit: 1}))
+	// Since is inclusive and Until exclusive
+	assert.Equal(t, []uint{updated.ID, created.ID}, entryIDs(audit_entry.Filter{Since: created.CreatedAt, Until: other.CreatedAt}))
+	assert.Equal(t, []uint{updated.ID, created.ID}, entryIDs(audit_entry.Filter{Since: created.CreatedAt.UTC(), Until: other.CreatedAt.UTC()}))
+
+	entries, err := repos.AuditEntries.List(ctx, audit_entry.Filter{ResourceType: audit_entry.ResourceUser})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	found := entries[0]
+	assert.Nil(t, found.ActorID)
+	assert.Equal(t, audit_entry.ActionCreate, found.Action)
+	assert.Equal(t, audit_entry.Changes{"title": {Before: "Sums", After: "Arithmetic"}}, found.Changes)
+	assert.Equal(t, "req-1", found.RequestID)
+	assert.Equal(t, "203.0.113.7", found.IP)
+}
+
+func testUnitOfWorkCommits(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	ann := createUser(t, repos, "ann")
+	qs := createQuizSuite(t, repos, ann)
+	q := createQuiz(t, repos, ann)
+
+	err := repos.UnitOfWork.Do(ctx, func(ctx context.Context, tx repository.Repositories) error {
+		if err := tx.QuizSuites.AddQuiz(ctx, qs.ID, q.ID); err != nil {
+			return err
+		}
+		return tx.QuizSuites.Update(ctx, qs)
+	})
+	require.NoError(t, err)
+
+	found, err := repos.QuizSuites.FindByID(ctx, qs.ID)
+	require.NoError(t, err)
+	assert.Equal(t, uint(2), found.Version)
+	assert.Equal(t, []uint{q.ID}, quizIDs(found.Quizzes))
+}
+
+func testUnitOfWorkRollsBack(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	ann := createUser(t, repos, "ann")
+	q := createQuiz(t, repos, ann, "4")
+
+	err := repos.UnitOfWork.Do(ctx, func(ctx context.Context, tx repository.Repositories) error {
+		found, err := tx.Quizzes.FindByID(ctx, q.ID)
+		if err != nil {
+			return err
+		}
+		if err := tx.Quizzes.RemoveSelection(ctx, q.ID, found.Selections[0].ID); err != nil {
+			return err
+		}
+		if err := tx.Quizzes.AddSelection(ctx, q.ID, &quiz.QuizSelection{SelectionText: "5"}); err != nil {
+			return err
+		}
+		found.Question = "Rolled back"
+		if err := tx.Quizzes.Update(ctx, found); err != nil {
+			return err
+		}
+		return errRollback
+	})
+	assert.ErrorIs(t, err, errRollback)
+
+	found, err := repos.Quizzes.FindByID(ctx, q.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "What is 2 + 2?", found.Question)
+	assert.Equal(t, uint(1), found.Version)
+	require.Len(t, found.Selections, 1)
+	assert.Equal(t, q.Selections[0].ID, found.Selections[0].ID)
+}
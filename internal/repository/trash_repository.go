@@ -0,0 +1,214 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"quizlet/internal/models/quiz"
+	"quizlet/internal/models/quiz_attempt"
+	"quizlet/internal/models/quiz_suite"
+	"quizlet/internal/models/user"
+)
+
+// TrashRepository works on soft-deleted records: it moves the dependents of
+// a deleted record to the trash, lists and restores what is there, and
+// purges it for good
+type TrashRepository interface {
+	// ListQuizzes, ListQuizSuites and ListQuizAttempts return the records
+	// of the user in the trash, most recently deleted first
+	ListQuizzes(ctx context.Context, userID uint) ([]*quiz.Quiz, error)
+	ListQuizSuites(ctx context.Context, userID uint) ([]*quiz_suite.QuizSuite, error)
+	ListQuizAttempts(ctx context.Context, userID uint) ([]*quiz_attempt.QuizAttempt, error)
+	// FindQuiz, FindQuizSuite, FindQuizAttempt and FindUser return a record
+	// in the trash, or gorm.ErrRecordNotFound when there is none
+	FindQuiz(ctx context.Context, id uint) (*quiz.Quiz, error)
+	FindQuizSuite(ctx context.Context, id uint) (*quiz_suite.QuizSuite, error)
+	FindQuizAttempt(ctx context.Context, id uint) (*quiz_attempt.QuizAttempt, error)
+	FindUser(ctx context.Context, id uint) (*user.User, error)
+	// TrashQuizSuiteAttempts moves the attempts at a deleted quiz suite to
+	// the trash
+	TrashQuizSuiteAttempts(ctx context.Context, quizSuiteID uint) error
+	// TrashUserContent moves the quizzes, quiz suites and attempts of a
+	// deleted user to the trash, with the attempts at the user's suites
+	TrashUserContent(ctx context.Context, userID uint) error
+	// RestoreQuiz, RestoreQuizSuite, RestoreQuizAttempt and RestoreUser take
+	// a record found in the trash out of it, or return
+	// gorm.ErrRecordNotFound when it was restored meanwhile. Quiz suites and
+	// users bring back the dependents that went to the trash with them.
+	RestoreQuiz(ctx context.Context, quiz *quiz.Quiz) error
+	RestoreQuizSuite(ctx context.Context, quizSuite *quiz_suite.QuizSuite) error
+	RestoreQuizAttempt(ctx context.Context, attempt *quiz_attempt.QuizAttempt) error
+	RestoreUser(ctx context.Context, user *user.User) error
+	// Purge deletes the records that went to the trash before cutoff for
+	// good and returns how many it deleted. Users are kept until nothing
+	// refers to them any more.
+	Purge(ctx context.Context, cutoff time.Time) (int64, error)
+}
+
+type trashRepository struct {
+	db *gorm.DB
+}
+
+func NewTrashRepository(db *gorm.DB) TrashRepository {
+	return &trashRepository{db: db}
+}
+
+// trashed scopes a query to soft-deleted records
+func trashed(db *gorm.DB) *gorm.DB {
+	return db.Unscoped().Where("deleted_at IS NOT NULL")
+}
+
+func (r *trashRepository) ListQuizzes(ctx context.Context, userID uint) ([]*quiz.Quiz, error) {
+	var quizzes []*quiz.Quiz
+	err := r.db.WithContext(ctx).Scopes(trashed).Where("created_by_id = ?", userID).
+		Order("deleted_at DESC, id DESC").Find(&quizzes).Error
+	if err != nil {
+		return nil, err
+	}
+	return quizzes, nil
+}
+
+func (r *trashRepository) ListQuizSuites(ctx context.Context, userID uint) ([]*quiz_suite.QuizSuite, error) {
+	var quizSuites []*quiz_suite.QuizSuite
+	err := r.db.WithContext(ctx).Scopes(trashed).Where("created_by_id = ?", userID).
+		Order("deleted_at DESC, id DESC").Find(&quizSuites).Error
+	if err != nil {
+		return nil, err
+	}
+	return quizSuites, nil
+}
+
+func (r *trashRepository) ListQuizAttempts(ctx context.Context, userID uint) ([]*quiz_attempt.QuizAttempt, error) {
+	var attempts []*quiz_attempt.QuizAttempt
+	err := r.db.WithContext(ctx).Scopes(trashed).Where("user_id = ?", userID).
+		Order("deleted_at DESC, id DESC").Find(&attempts).Error
+	if err != nil {
+		return nil, err
+	}
+	return attempts, nil
+}
+
+func (r *trashRepository) FindQuiz(ctx context.Context, id uint) (*quiz.Quiz, error) {
+	var q quiz.Quiz
+	if err := r.db.WithContext(ctx).Scopes(trashed).First(&q, id).Error; err != nil {
+		return nil, err
+	}
+	return &q, nil
+}
+
+func (r *trashRepository) FindQuizSuite(ctx context.Context, id uint) (*quiz_suite.QuizSuite, error) {
+	var quizSuite quiz_suite.QuizSuite
+	if err := r.db.WithContext(ctx).Scopes(trashed).First(&quizSuite, id).Error; err != nil {
+		return nil, err
+	}
+	return &quizSuite, nil
+}
+
+func (r *trashRepository) FindQuizAttempt(ctx context.Context, id uint) (*quiz_attempt.QuizAttempt, error) {
+	var attempt quiz_attempt.QuizAttempt
+	if err := r.db.WithContext(ctx).Scopes(trashed).First(&attempt, id).Error; err != nil {
+		return nil, err
+	}
+	return &attempt, nil
+}
+
+func (r *trashRepository) FindUser(ctx context.Context, id uint) (*user.User, error) {
+	var u user.User
+	if err := r.db.WithContext(ctx).Scopes(trashed).First(&u, id).Error; err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+func (r *trashRepository) TrashQuizSuiteAttempts(ctx context.Context, quizSuiteID uint) error {
+	return r.db.WithContext(ctx).Where("quiz_suite_id = ?", quizSuiteID).Delete(&quiz_attempt.QuizAttempt{}).Error
+}
+
+func (r *trashRepository) TrashUserContent(ctx context.Context, userID uint) error {
+	db := r.db.WithContext(ctx)
+	suites := db.Unscoped().Model(&quiz_suite.QuizSuite{}).Select("id").Where("created_by_id = ?", userID)
+	if err := db.Where("user_id = ? OR quiz_suite_id IN (?)", userID, suites).Delete(&quiz_attempt.QuizAttempt{}).Error; err != nil {
+		return err
+	}
+	if err := db.Where("created_by_id = ?", userID).Delete(&quiz_suite.QuizSuite{}).Error; err != nil {
+		return err
+	}
+	return db.Where("created_by_id = ?", userID).Delete(&quiz.Quiz{}).Error
+}
+
+func (r *trashRepository) RestoreQuiz(ctx context.Context, q *quiz.Quiz) error {
+	return restore(r.db.WithContext(ctx), &quiz.Quiz{}, q.ID, &q.DeletedAt)
+}
+
+func (r *trashRepository) RestoreQuizSuite(ctx context.Context, quizSuite *quiz_suite.QuizSuite) error {
+	db := r.db.WithContext(ctx)
+	deletedAt := quizSuite.DeletedAt.Time
+	if err := restore(db, &quiz_suite.QuizSuite{}, quizSuite.ID, &quizSuite.DeletedAt); err != nil {
+		return err
+	}
+	// Attempts deleted before the suite were deleted on their own
+	return restoreSince(db.Where("quiz_suite_id = ?", quizSuite.ID), &quiz_attempt.QuizAttempt{}, deletedAt)
+}
+
+func (r *trashRepository) RestoreQuizAttempt(ctx context.Context, attempt *quiz_attempt.QuizAttempt) error {
+	return restore(r.db.WithContext(ctx), &quiz_attempt.QuizAttempt{}, attempt.ID, &attempt.DeletedAt)
+}
+
+func (r *trashRepository) RestoreUser(ctx context.Context, u *user.User) error {
+	db := r.db.WithContext(ctx)
+	deletedAt := u.DeletedAt.Time
+	if err := restore(db, &user.User{}, u.ID, &u.DeletedAt); err != nil {
+		return err
+	}
+	if err := restoreSince(db.Where("created_by_id = ?", u.ID), &quiz.Quiz{}, deletedAt); err != nil {
+		return err
+	}
+	if err := restoreSince(db.Where("created_by_id = ?", u.ID), &quiz_suite.QuizSuite{}, deletedAt); err != nil {
+		return err
+	}
+	suites := db.Model(&quiz_suite.QuizSuite{}).Select("id").Where("created_by_id = ?", u.ID)
+	return restoreSince(db.Where("user_id = ? OR quiz_suite_id IN (?)", u.ID, suites), &quiz_attempt.QuizAttempt{}, deletedAt)
+}
+
+// restore takes one record out of the trash
+func restore(db *gorm.DB, model any, id uint, deletedAt *gorm.DeletedAt) error {
+	result := db.Scopes(trashed).Model(model).Where("id = ?", id).Update("deleted_at", nil)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	*deletedAt = gorm.DeletedAt{}
+	return nil
+}
+
+// restoreSince takes the records matched by db that went to the trash at or
+// after since out of it
+func restoreSince(db *gorm.DB, model any, since time.Time) error {
+	return db.Scopes(trashed).Model(model).Where("deleted_at >= ?", since).Update("deleted_at", nil).Error
+}
+
+func (r *trashRepository) Purge(ctx context.Context, cutoff time.Time) (int64, error) {
+	db := r.db.WithContext(ctx)
+	var purged int64
+	// Selections, suite memberships and answers go with their rows through
+	// ON DELETE CASCADE
+	for _, model := range []any{&quiz_attempt.QuizAttempt{}, &quiz_suite.QuizSuite{}, &quiz.Quiz{}} {
+		result := db.Scopes(trashed).Where("deleted_at < ?", cutoff).Delete(model)
+		if result.Error != nil {
+			return purged, result.Error
+		}
+		purged += result.RowsAffected
+	}
+
+	owned := func(table string) *gorm.DB {
+		return db.Unscoped().Table(table).Select("1").Where("created_by_id = users.id")
+	}
+	result := db.Scopes(trashed).Where("deleted_at < ?", cutoff).
+		Where("NOT EXISTS (?) AND NOT EXISTS (?)", owned("quizzes"), owned("quiz_suites")).
+		Delete(&user.User{})
+	purged += result.RowsAffected
+	return purged, result.Error
+}
@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// Repositories are the repositories a unit of work hands to its function,
+// all bound to the same transaction
+type Repositories struct {
+	Users         UserRepository
+	RecoveryCodes RecoveryCodeRepository
+	Quizzes       QuizRepository
+	QuizRevisions QuizRevisionRepository
+	QuizSuites    QuizSuiteRepository
+	QuizAttempts  QuizAttemptRepository
+	Trash         TrashRepository
+	AuditEntries  AuditEntryRepository
+}
+
+// UnitOfWork runs several repository calls atomically
+type UnitOfWork interface {
+	// Do runs fn in a transaction, which is committed when fn returns nil
+	// and rolled back otherwise. The repositories must not be used after fn
+	// returns.
+	Do(ctx context.Context, fn func(ctx context.Context, repos Repositories) error) error
+}
+
+type unitOfWork struct {
+	db *gorm.DB
+}
+
+func NewUnitOfWork(db *gorm.DB) UnitOfWork {
+	return &unitOfWork{db: db}
+}
+
+func (u *unitOfWork) Do(ctx context.Context, fn func(ctx context.Context, repos Repositories) error) error {
+	return u.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(ctx, Repositories{
+			Users:         NewUserRepository(tx),
+			RecoveryCodes: NewRecoveryCodeRepository(tx),
+			Quizzes:       NewQuizRepository(tx),
+			QuizRevisions: NewQuizRevisionRepository(tx),
+			QuizSuites:    NewQuizSuiteRepository(tx),
+			QuizAttempts:  NewQuizAttemptRepository(tx),
+			Trash:         NewTrashRepository(tx),
+			AuditEntries:  NewAuditEntryRepository(tx),
+		})
+	})
+}
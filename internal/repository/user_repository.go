@@ -1,17 +1,31 @@
 package repository
 
 import (
+	"context"
 	"quizlet/internal/models/user"
 
 	"gorm.io/gorm"
 )
 
 type UserRepository interface {
-	Create(user *user.User) error
-	FindByID(id uint) (*user.User, error)
-	FindByEmail(email string) (*user.User, error)
-	Update(user *user.User) error
-	Delete(id uint) error
+	Create(ctx context.Context, user *user.User) error
+	FindByID(ctx context.Context, id uint) (*user.User, error)
+	FindByEmail(ctx context.Context, email string) (*user.User, error)
+	FindByUsername(ctx context.Context, username string) (*user.User, error)
+	Update(ctx context.Context, user *user.User) error
+	// UpdateProfile saves the user like Update but keeps the stored
+	// two-factor state, roles and creation time
+	UpdateProfile(ctx context.Context, user *user.User) error
+	UpdatePassword(ctx context.Context, id uint, hashedPassword string) error
+	// AdvanceTOTPStep records step as the last used TOTP step and resets the
+	// MFA attempts, or returns gorm.ErrRecordNotFound when step or a later
+	// one was already used
+	AdvanceTOTPStep(ctx context.Context, id uint, step int64) error
+	// CountMFAAttempt counts a two-factor code attempt, or returns
+	// gorm.ErrRecordNotFound when the user already made limit attempts
+	CountMFAAttempt(ctx context.Context, id uint, limit int) error
+	ResetMFAAttempts(ctx context.Context, id uint) error
+	Delete(ctx context.Context, id uint) error
 }
 
 type userRepository struct {
@@ -22,32 +36,87 @@ func NewUserRepository(db *gorm.DB) UserRepository {
 	return &userRepository{db: db}
 }
 
-func (r *userRepository) Create(user *user.User) error {
-	return r.db.Create(user).Error
+func (r *userRepository) Create(ctx context.Context, user *user.User) error {
+	return r.db.WithContext(ctx).Create(user).Error
 }
 
-func (r *userRepository) FindByID(id uint) (*user.User, error) {
+func (r *userRepository) FindByID(ctx context.Context, id uint) (*user.User, error) {
 	var user user.User
-	err := r.db.First(&user, id).Error
+	err := r.db.WithContext(ctx).First(&user, id).Error
 	if err != nil {
 		return nil, err
 	}
 	return &user, nil
 }
 
-func (r *userRepository) FindByEmail(email string) (*user.User, error) {
+func (r *userRepository) FindByEmail(ctx context.Context, email string) (*user.User, error) {
 	var user user.User
-	err := r.db.Where("email = ?", email).First(&user).Error
+	err := r.db.WithContext(ctx).Where("email = ?", email).First(&user).Error
 	if err != nil {
 		return nil, err
 	}
 	return &user, nil
 }
 
-func (r *userRepository) Update(user *user.User) error {
-	return r.db.Save(user).Error
+func (r *userRepository) FindByUsername(ctx context.Context, username string) (*user.User, error) {
+	var user user.User
+	err := r.db.WithContext(ctx).Where("username = ?", username).First(&user).Error
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *userRepository) Update(ctx context.Context, user *user.User) error {
+	return r.db.WithContext(ctx).Save(user).Error
+}
+
+// profileProtectedColumns are left alone by UpdateProfile; the two-factor
+// columns only change through their own conditional updates
+var profileProtectedColumns = []string{"totp_secret", "totp_enabled", "totp_last_step", "mfa_attempts", "is_admin", "created_at"}
+
+func (r *userRepository) UpdateProfile(ctx context.Context, user *user.User) error {
+	return r.db.WithContext(ctx).Model(user).Select("*").Omit(profileProtectedColumns...).Updates(user).Error
+}
+
+// UpdatePassword only touches the password column so a rehash on login
+// cannot overwrite concurrent profile changes
+func (r *userRepository) UpdatePassword(ctx context.Context, id uint, hashedPassword string) error {
+	return r.db.WithContext(ctx).Model(&user.User{}).Where("id = ?", id).Update("password", hashedPassword).Error
+}
+
+// AdvanceTOTPStep compares and sets in one statement so two requests with
+// the same code cannot both succeed
+func (r *userRepository) AdvanceTOTPStep(ctx context.Context, id uint, step int64) error {
+	result := r.db.WithContext(ctx).Model(&user.User{}).
+		Where("id = ? AND totp_last_step < ?", id, step).
+		Updates(map[string]interface{}{"totp_last_step": step, "mfa_attempts": 0})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *userRepository) CountMFAAttempt(ctx context.Context, id uint, limit int) error {
+	result := r.db.WithContext(ctx).Model(&user.User{}).
+		Where("id = ? AND mfa_attempts < ?", id, limit).
+		Update("mfa_attempts", gorm.Expr("mfa_attempts + 1"))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *userRepository) ResetMFAAttempts(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Model(&user.User{}).Where("id = ?", id).Update("mfa_attempts", 0).Error
 }
 
-func (r *userRepository) Delete(id uint) error {
-	return r.db.Delete(&user.User{}, id).Error
+func (r *userRepository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&user.User{}, id).Error
 } 
\ No newline at end of file
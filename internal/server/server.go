@@ -0,0 +1,199 @@
+// Package server runs the HTTP API and its background workers, and shuts
+// both down gracefully when the process is asked to stop.
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"quizlet/internal/config"
+)
+
+// Worker is a background task that runs until ctx is cancelled
+type Worker func(ctx context.Context)
+
+type namedWorker struct {
+	name  string
+	run   Worker
+	state *workerState
+}
+
+// workerState tracks whether a worker is running and how its last run went
+type workerState struct {
+	mu      sync.Mutex
+	running bool
+	lastErr error
+}
+
+type workerStateKey struct{}
+
+// Server is an http.Server with timeouts, background workers and graceful
+// shutdown
+type Server struct {
+	http            *http.Server
+	shutdownTimeout time.Duration
+	shutdownDelay   time.Duration
+	workers         []namedWorker
+	onShutdown      []func()
+}
+
+// New creates a server for handler using the timeouts from cfg
+func New(cfg config.ServerConfig, handler http.Handler) *Server {
+	return &Server{
+		http: &http.Server{
+			Addr:              cfg.Addr,
+			Handler:           handler,
+			ReadTimeout:       cfg.ReadTimeout,
+			ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+			WriteTimeout:      cfg.WriteTimeout,
+			IdleTimeout:       cfg.IdleTimeout,
+		},
+		shutdownTimeout: cfg.ShutdownTimeout,
+		shutdownDelay:   cfg.ShutdownDelay,
+	}
+}
+
+// AddWorker registers a background task started with the server and stopped
+// after the HTTP listener has drained
+func (s *Server) AddWorker(name string, run Worker) {
+	s.workers = append(s.workers, namedWorker{name: name, run: run, state: &workerState{}})
+}
+
+// OnShutdown registers fn to run as soon as shutdown starts, before the
+// listener stops accepting requests
+func (s *Server) OnShutdown(fn func()) {
+	s.onShutdown = append(s.onShutdown, fn)
+}
+
+// WorkerCheck returns a health check for the named worker, failing when the
+// worker is not running or its last run failed
+func (s *Server) WorkerCheck(name string) func(ctx context.Context) error {
+	var state *workerState
+	for _, w := range s.workers {
+		if w.name == name {
+			state = w.state
+		}
+	}
+	return func(ctx context.Context) error {
+		if state == nil {
+			return fmt.Errorf("worker %s is not registered", name)
+		}
+		state.mu.Lock()
+		defer state.mu.Unlock()
+		if !state.running {
+			return fmt.Errorf("worker %s is not running", name)
+		}
+		if state.lastErr != nil {
+			return fmt.Errorf("last run failed: %w", state.lastErr)
+		}
+		return nil
+	}
+}
+
+// Run listens on the configured address and serves until ctx is cancelled
+func (s *Server) Run(ctx context.Context) error {
+	listener, err := net.Listen("tcp", s.http.Addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(ctx, listener)
+}
+
+// Serve accepts connections on listener until ctx is cancelled. It then stops
+// accepting new requests, waits up to the shutdown timeout for in-flight
+// requests and workers to finish, and returns.
+func (s *Server) Serve(ctx context.Context, listener net.Listener) error {
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+
+	var workers sync.WaitGroup
+	for _, w := range s.workers {
+		workers.Add(1)
+		w.state.setRunning(true)
+		go func(w namedWorker) {
+			defer workers.Done()
+			defer w.state.setRunning(false)
+			w.run(context.WithValue(workerCtx, workerStateKey{}, w.state))
+			slog.Info("worker stopped", "worker", w.name)
+		}(w)
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		slog.Info("server listening", "addr", listener.Addr().String())
+		serveErr <- s.http.Serve(listener)
+	}()
+
+	var err error
+	select {
+	case err = <-serveErr:
+		// The listener failed before shutdown was requested
+	case <-ctx.Done():
+		for _, fn := range s.onShutdown {
+			fn()
+		}
+		// Keep serving while load balancers notice the failing readiness probe
+		if s.shutdownDelay > 0 {
+			slog.Info("shutting down, waiting for load balancers", "delay", s.shutdownDelay.String())
+			time.Sleep(s.shutdownDelay)
+		}
+		slog.Info("shutting down, draining in-flight requests", "timeout", s.shutdownTimeout.String())
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.shutdownTimeout)
+	defer cancel()
+
+	if shutdownErr := s.http.Shutdown(shutdownCtx); shutdownErr != nil {
+		err = errors.Join(err, fmt.Errorf("draining requests: %w", shutdownErr))
+	}
+
+	stopWorkers()
+	done := make(chan struct{})
+	go func() {
+		workers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-shutdownCtx.Done():
+		err = errors.Join(err, errors.New("background workers did not stop before the shutdown timeout"))
+	}
+	return err
+}
+
+func (w *workerState) setRunning(running bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.running = running
+}
+
+// Every returns a worker that calls task once per interval, logging failures.
+// The outcome of the last run is reported by WorkerCheck.
+func Every(interval time.Duration, task func(ctx context.Context) error) Worker {
+	return func(ctx context.Context) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				err := task(ctx)
+				if err != nil {
+					slog.ErrorContext(ctx, "background task failed", "error", err)
+				}
+				if state, ok := ctx.Value(workerStateKey{}).(*workerState); ok {
+					state.mu.Lock()
+					state.lastErr = err
+					state.mu.Unlock()
+				}
+			}
+		}
+	}
+}
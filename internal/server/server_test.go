@@ -0,0 +1,170 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"quizlet/internal/config"
+)
+
+func testConfig() config.ServerConfig {
+	cfg := config.Default().Server
+	cfg.ShutdownTimeout = 2 * time.Second
+	return cfg
+}
+
+func TestServeDrainsInFlightRequests(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		io.WriteString(w, "done")
+	})
+
+	srv := New(testConfig(), handler)
+
+	workerStopped := make(chan struct{})
+	srv.AddWorker("test", func(ctx context.Context) {
+		<-ctx.Done()
+		close(workerStopped)
+	})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() { served <- srv.Serve(ctx, listener) }()
+
+	type result struct {
+		body string
+		err  error
+	}
+	response := make(chan result, 1)
+	go func() {
+		resp, err := http.Get("http://" + listener.Addr().String())
+		if err != nil {
+			response <- result{err: err}
+			return
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		response <- result{body: string(body), err: err}
+	}()
+
+	<-started
+	cancel()
+
+	select {
+	case <-workerStopped:
+		t.Fatal("worker stopped before in-flight requests drained")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	got := <-response
+	require.NoError(t, got.err)
+	assert.Equal(t, "done", got.body)
+
+	require.NoError(t, <-served)
+	select {
+	case <-workerStopped:
+	default:
+		t.Fatal("worker was not stopped")
+	}
+
+	_, err = net.DialTimeout("tcp", listener.Addr().String(), 100*time.Millisecond)
+	assert.Error(t, err, "listener should be closed after shutdown")
+}
+
+func TestServeReportsStuckWorkers(t *testing.T) {
+	cfg := testConfig()
+	cfg.ShutdownTimeout = 50 * time.Millisecond
+	srv := New(cfg, http.NotFoundHandler())
+
+	block := make(chan struct{})
+	defer close(block)
+	srv.AddWorker("stuck", func(ctx context.Context) { <-block })
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorContains(t, srv.Serve(ctx, listener), "did not stop")
+}
+
+func TestEvery(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := make(chan struct{}, 10)
+
+	done := make(chan struct{})
+	go func() {
+		Every(time.Millisecond, func(context.Context) error {
+			select {
+			case calls <- struct{}{}:
+			default:
+			}
+			return nil
+		})(ctx)
+		close(done)
+	}()
+
+	<-calls
+	<-calls
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("worker did not stop after cancellation")
+	}
+}
+
+func TestWorkerCheckAndShutdownHooks(t *testing.T) {
+	srv := New(testConfig(), http.NotFoundHandler())
+
+	failed := make(chan struct{}, 1)
+	srv.AddWorker("cleanup", Every(time.Millisecond, func(context.Context) error {
+		select {
+		case failed <- struct{}{}:
+		default:
+		}
+		return errors.New("database is down")
+	}))
+	check := srv.WorkerCheck("cleanup")
+	assert.ErrorContains(t, check(context.Background()), "not running")
+	assert.ErrorContains(t, srv.WorkerCheck("missing")(context.Background()), "not registered")
+
+	var draining atomic.Bool
+	srv.OnShutdown(func() { draining.Store(true) })
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() { served <- srv.Serve(ctx, listener) }()
+
+	<-failed
+	assert.False(t, draining.Load())
+	require.Eventually(t, func() bool {
+		err := check(context.Background())
+		return err != nil && strings.Contains(err.Error(), "last run failed")
+	}, time.Second, time.Millisecond)
+	assert.ErrorContains(t, check(context.Background()), "last run failed: database is down")
+
+	cancel()
+	require.NoError(t, <-served)
+	assert.True(t, draining.Load())
+	assert.ErrorContains(t, check(context.Background()), "not running")
+}
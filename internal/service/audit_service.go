@@ -0,0 +1,62 @@
+package service
+
+import (
+	"context"
+
+	"quizlet/internal/auth"
+	"quizlet/internal/logging"
+	"quizlet/internal/models/audit_entry"
+	"quizlet/internal/repository"
+	"quizlet/internal/tracing"
+)
+
+// AuditService reads the audit log; services append to it in the unit of
+// work of each change, so a change and its entry are committed together
+type AuditService interface {
+	// List returns the entries matching filter, newest first
+	List(ctx context.Context, filter audit_entry.Filter) ([]*audit_entry.AuditEntry, error)
+}
+
+type auditService struct {
+	auditEntryRepo repository.AuditEntryRepository
+}
+
+func NewAuditService(auditEntryRepo repository.AuditEntryRepository) AuditService {
+	return &auditService{
+		auditEntryRepo: auditEntryRepo,
+	}
+}
+
+func (s *auditService) List(ctx context.Context, filter audit_entry.Filter) ([]*audit_entry.AuditEntry, error) {
+	ctx, span := tracing.Start(ctx, "AuditService.List")
+	defer span.End()
+
+	return s.auditEntryRepo.List(ctx, filter)
+}
+
+// recordChange appends an entry with the fields that differ between before
+// and after, either of which is nil for creates and deletes
+func recordChange(ctx context.Context, repos repository.Repositories, action, resourceType string, resourceID uint, before, after any) error {
+	changes, err := audit_entry.Diff(before, after)
+	if err != nil {
+		return err
+	}
+	return record(ctx, repos, action, resourceType, resourceID, changes)
+}
+
+// record appends an entry attributed to the principal and request of ctx
+func record(ctx context.Context, repos repository.Repositories, action, resourceType string, resourceID uint, changes audit_entry.Changes) error {
+	entry := &audit_entry.AuditEntry{
+		Action:       action,
+		ResourceType: resourceType,
+		ResourceID:   resourceID,
+		Changes:      changes,
+		RequestID:    logging.RequestID(ctx),
+		IP:           logging.ClientIP(ctx),
+	}
+	if p, ok := auth.FromContext(ctx); ok {
+		actorID := p.UserID
+		entry.ActorID = &actorID
+	}
+	return repos.AuditEntries.Create(ctx, entry)
+}
@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"quizlet/internal/auth"
+	"quizlet/internal/auth/password"
+	"quizlet/internal/logging"
+	"quizlet/internal/models/audit_entry"
+	"quizlet/internal/models/quiz_attempt"
+	"quizlet/internal/models/user"
+	"quizlet/internal/repository/memory"
+)
+
+func TestAuditQuizChanges(t *testing.T) {
+	kit := newTestKit(t)
+	ann := kit.user(t, "ann")
+	ctx := auth.NewContext(context.Background(), &auth.Principal{UserID: ann.ID})
+	ctx = logging.WithClientIP(logging.WithRequestID(ctx, "req-1"), "203.0.113.7")
+	audit := NewAuditService(memory.NewAuditEntryRepository(kit.store))
+
+	q := kit.quiz(t, ann, "4")
+	require.NoError(t, kit.quizzes.UpdateQuiz(ctx, q))
+	q.Question = "What is 3 + 3?"
+	require.NoError(t, kit.quizzes.UpdateQuiz(ctx, q))
+	// A rejected change leaves no entry
+	q.Version = 1
+	assert.ErrorIs(t, kit.quizzes.UpdateQuiz(ctx, q), ErrQuizModified)
+	require.NoError(t, kit.quizzes.DeleteQuiz(ctx, q.ID, 0))
+
+	entries, err := audit.List(ctx, audit_entry.Filter{ResourceType: audit_entry.ResourceQuiz, ResourceID: q.ID})
+	require.NoError(t, err)
+	require.Len(t, entries, 4)
+	deleted, updated, unchanged, created := entries[0], entries[1], entries[2], entries[3]
+
+	assert.Equal(t, audit_entry.ActionCreate, created.Action)
+	assert.Nil(t, created.ActorID, "created without a principal")
+	assert.Equal(t, "What is 2 + 2?", created.Changes["question"].After)
+	assert.Nil(t, created.Changes["question"].Before)
+
+	// Saving the same question only moves the version on
+	assert.Equal(t, []string{"version"}, keys(unchanged.Changes))
+
+	assert.Equal(t, audit_entry.ActionUpdate, updated.Action)
+	assert.Equal(t, &ann.ID, updated.ActorID)
+	assert.Equal(t, audit_entry.Change{Before: "What is 2 + 2?", After: "What is 3 + 3?"}, updated.Changes["question"])
+	assert.Equal(t, "req-1", updated.RequestID)
+	assert.Equal(t, "203.0.113.7", updated.IP)
+
+	assert.Equal(t, audit_entry.ActionDelete, deleted.Action)
+	assert.Equal(t, "What is 3 + 3?", deleted.Changes["question"].Before)
+	assert.Nil(t, deleted.Changes["question"].After)
+}
+
+func TestAuditAttemptAndRestore(t *testing.T) {
+	kit := newTestKit(t)
+	ann := kit.user(t, "ann")
+	ctx := auth.NewContext(context.Background(), &auth.Principal{UserID: ann.ID})
+	audit := NewAuditService(memory.NewAuditEntryRepository(kit.store))
+	qs := kit.quizSuite(t, ann)
+
+	attempt, err := kit.quizAttempts.Create(ctx, qs.ID, ann.ID, quiz_attempt.CreateQuizAttemptRequest{})
+	require.NoError(t, err)
+	completed := true
+	_, err = kit.quizAttempts.Update(ctx, attempt.ID, ann.ID, quiz_attempt.UpdateQuizAttemptRequest{Completed: &completed})
+	require.NoError(t, err)
+	require.NoError(t, kit.quizSuites.DeleteQuizSuite(ctx, qs.ID, 0))
+	_, err = kit.trash.RestoreQuizSuite(ctx, qs.ID, ann.ID)
+	require.NoError(t, err)
+
+	entries, err := audit.List(ctx, audit_entry.Filter{ActorID: ann.ID})
+	require.NoError(t, err)
+	actions := make([]string, len(entries))
+	for i, entry := range entries {
+		actions[i] = entry.ResourceType + " " + entry.Action
+	}
+	assert.Equal(t, []string{"quiz_suite restore", "quiz_suite delete", "quiz_attempt update", "quiz_attempt create"}, actions)
+	assert.Equal(t, audit_entry.Change{Before: false, After: true}, entries[2].Changes["completed"])
+}
+
+func TestAuditUserChanges(t *testing.T) {
+	store := memory.New()
+	hasher, err := password.NewHasher(password.DefaultConfig())
+	require.NoError(t, err)
+	users := NewUserService(memory.NewUserRepository(store), memory.NewRefreshTokenRepository(store), nil, nil,
+		memory.NewUnitOfWork(store), hasher, password.DefaultPolicy(), auth.DefaultTokenConfig().RefreshTokenTTL, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	audit := NewAuditService(memory.NewAuditEntryRepository(store))
+	ctx := context.Background()
+
+	ann := &user.User{Username: "ann", Email: "ann@example.com", Password: "a long secret 1"}
+	require.NoError(t, users.CreateUser(ctx, ann))
+	// Updates without a password keep the stored one
+	require.NoError(t, users.UpdateUser(ctx, &user.User{ID: ann.ID, Username: "ann", Email: "ann@example.org"}))
+	_, err = users.ValidatePassword(ctx, "ann@example.org", "a long secret 1")
+	require.NoError(t, err)
+	require.NoError(t, users.UpdateUser(ctx, &user.User{ID: ann.ID, Username: "ann", Email: "ann@example.org", Password: "another secret 2"}))
+	_, err = users.BeginTOTPEnrollment(ctx, ann.ID)
+	require.NoError(t, err)
+
+	entries, err := audit.List(ctx, audit_entry.Filter{ResourceType: audit_entry.ResourceUser})
+	require.NoError(t, err)
+	require.Len(t, entries, 4)
+	// Neither the TOTP secret nor the hash is recorded, only that they changed
+	assert.Equal(t, audit_entry.Changes{
+		"totp_secret": {Before: audit_entry.Redacted, After: audit_entry.Redacted},
+	}, entries[0].Changes)
+	assert.Equal(t, audit_entry.Changes{
+		"password": {Before: audit_entry.Redacted, After: audit_entry.Redacted},
+	}, entries[1].Changes)
+	assert.Equal(t, audit_entry.Changes{
+		"email": {Before: "ann@example.com", After: "ann@example.org"},
+	}, entries[2].Changes)
+	assert.NotContains(t, entries[3].Changes, "password")
+	assert.Equal(t, "ann", entries[3].Changes["username"].After)
+}
+
+func keys(changes audit_entry.Changes) []string {
+	names := make([]string, 0, len(changes))
+	for name := range changes {
+		names = append(names, name)
+	}
+	return names
+}
@@ -0,0 +1,27 @@
+package service
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+	"quizlet/internal/apperr"
+	"quizlet/internal/repository"
+)
+
+// notFound replaces a missing record with the domain error of the service,
+// so handlers never see GORM errors for lookups
+func notFound(err error, domainErr *apperr.Error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return domainErr
+	}
+	return err
+}
+
+// versionConflict replaces a failed conditional write with the domain error
+// of the service
+func versionConflict(err error, domainErr *apperr.Error) error {
+	if errors.Is(err, repository.ErrVersionConflict) {
+		return domainErr
+	}
+	return err
+}
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"quizlet/internal/config"
+	"quizlet/internal/database"
+	"quizlet/internal/models/quiz"
+	"quizlet/internal/models/quiz_suite"
+	"quizlet/internal/models/user"
+	"quizlet/internal/repository/memory"
+)
+
+// trashRetention is how long the test kit keeps deleted records
+const trashRetention = 24 * time.Hour
+
+// testKit wires the services to in-memory repositories sharing one store,
+// so tests exercise the services together with the repository behaviour
+type testKit struct {
+	store        *memory.Store
+	quizzes      QuizService
+	quizSuites   QuizSuiteService
+	quizAttempts QuizAttemptService
+	trash        TrashService
+}
+
+func newTestKit(t *testing.T) *testKit {
+	t.Helper()
+	store := memory.New()
+	uow := memory.NewUnitOfWork(store)
+	return &testKit{
+		store:        store,
+		quizzes:      NewQuizService(memory.NewQuizRepository(store), memory.NewQuizRevisionRepository(store), uow),
+		quizSuites:   NewQuizSuiteService(memory.NewQuizSuiteRepository(store), uow),
+		quizAttempts: NewQuizAttemptService(memory.NewQuizAttemptRepository(store), uow),
+		trash:        NewTrashService(memory.NewTrashRepository(store), uow, trashRetention),
+	}
+}
+
+func (k *testKit) user(t *testing.T, username string) *user.User {
+	t.Helper()
+	u := &user.User{Username: username, Email: username + "@example.com", Password: "hash"}
+	require.NoError(t, memory.NewUserRepository(k.store).Create(context.Background(), u))
+	return u
+}
+
+func (k *testKit) quiz(t *testing.T, owner *user.User, selections ...string) *quiz.Quiz {
+	t.Helper()
+	q := &quiz.Quiz{Question: "What is 2 + 2?", QuizType: quiz.QuizTypeSingleChoice, CreatedByID: owner.ID}
+	for i, text := range selections {
+		q.Selections = append(q.Selections, quiz.QuizSelection{SelectionText: text, IsCorrect: i == 0})
+	}
+	require.NoError(t, k.quizzes.CreateQuiz(context.Background(), q))
+	return q
+}
+
+func (k *testKit) quizSuite(t *testing.T, owner *user.User) *quiz_suite.QuizSuite {
+	t.Helper()
+	qs := &quiz_suite.QuizSuite{Title: "Arithmetic", Description: "Sums", CreatedByID: owner.ID}
+	require.NoError(t, k.quizSuites.CreateQuizSuite(context.Background(), qs))
+	return qs
+}
+
+// openTestDB opens an in-memory SQLite database migrated like production,
+// for services whose repositories have no in-memory version
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	ctx := context.Background()
+	cfg := config.Default().Database
+	cfg.Driver = config.DatabaseDriverSQLite
+	cfg.Path = ":memory:"
+	cfg.AutoMigrate = true
+	cfg.SchemaCheck = config.SchemaCheckOff
+
+	db, err := database.Open(ctx, cfg)
+	require.NoError(t, err)
+	t.Cleanup(func() { database.Close(db) })
+	require.NoError(t, database.PrepareSchema(ctx, db, cfg))
+	return db
+}
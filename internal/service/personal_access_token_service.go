@@ -0,0 +1,147 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"quizlet/internal/apperr"
+	"quizlet/internal/auth"
+	"quizlet/internal/models/user"
+	"quizlet/internal/repository"
+	"quizlet/internal/tracing"
+)
+
+const (
+	defaultTokenLifetimeDays = 90
+	// lastUsedResolution limits how often LastUsedAt is written for busy tokens
+	lastUsedResolution = time.Minute
+)
+
+var (
+	ErrPersonalAccessTokenNotFound = apperr.New(apperr.NotFound, "personal_access_token_not_found", "personal access token not found")
+	ErrInvalidScope                = apperr.New(apperr.Invalid, "invalid_scope", "invalid scope")
+)
+
+type PersonalAccessTokenService interface {
+	CreateToken(ctx context.Context, userID uint, req user.CreatePersonalAccessTokenRequest) (*user.PersonalAccessToken, string, error)
+	ListTokens(ctx context.Context, userID uint) ([]*user.PersonalAccessToken, error)
+	RevokeToken(ctx context.Context, userID, id uint) error
+	ValidatePersonalAccessToken(ctx context.Context, token string) (*auth.Principal, error)
+}
+
+type personalAccessTokenService struct {
+	tokenRepo repository.PersonalAccessTokenRepository
+	logger    *slog.Logger
+}
+
+// Ensure personalAccessTokenService can be used by the auth middleware
+var _ auth.PersonalAccessTokenValidator = (*personalAccessTokenService)(nil)
+
+func NewPersonalAccessTokenService(tokenRepo repository.PersonalAccessTokenRepository, logger *slog.Logger) PersonalAccessTokenService {
+	return &personalAccessTokenService{
+		tokenRepo: tokenRepo,
+		logger:    logger,
+	}
+}
+
+func (s *personalAccessTokenService) CreateToken(ctx context.Context, userID uint, req user.CreatePersonalAccessTokenRequest) (*user.PersonalAccessToken, string, error) {
+	ctx, span := tracing.Start(ctx, "PersonalAccessTokenService.CreateToken")
+	defer span.End()
+
+	scopes := make(user.ScopeList, 0, len(req.Scopes))
+	seen := make(map[string]bool)
+	for _, scope := range req.Scopes {
+		if !auth.IsValidScope(scope) {
+			return nil, "", ErrInvalidScope.WithMessage("invalid scope: " + scope)
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			scopes = append(scopes, scope)
+		}
+	}
+
+	days := req.ExpiresInDays
+	if days == 0 {
+		days = defaultTokenLifetimeDays
+	}
+
+	plaintext, err := auth.GeneratePersonalAccessToken()
+	if err != nil {
+		return nil, "", err
+	}
+
+	token := &user.PersonalAccessToken{
+		UserID:      userID,
+		Name:        req.Name,
+		TokenHash:   auth.HashPersonalAccessToken(plaintext),
+		TokenPrefix: plaintext[:len(auth.PersonalAccessTokenPrefix)+4],
+		Scopes:      scopes,
+		ExpiresAt:   time.Now().Add(time.Duration(days) * 24 * time.Hour),
+	}
+	if err := s.tokenRepo.Create(ctx, token); err != nil {
+		return nil, "", err
+	}
+	s.logger.InfoContext(ctx, "personal access token created", "user_id", userID, "token_id", token.ID, "scopes", strings.Join(scopes, " "))
+
+	return token, plaintext, nil
+}
+
+func (s *personalAccessTokenService) ListTokens(ctx context.Context, userID uint) ([]*user.PersonalAccessToken, error) {
+	ctx, span := tracing.Start(ctx, "PersonalAccessTokenService.ListTokens")
+	defer span.End()
+
+	return s.tokenRepo.FindByUserID(ctx, userID)
+}
+
+func (s *personalAccessTokenService) RevokeToken(ctx context.Context, userID, id uint) error {
+	ctx, span := tracing.Start(ctx, "PersonalAccessTokenService.RevokeToken")
+	defer span.End()
+
+	err := s.tokenRepo.Revoke(ctx, userID, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrPersonalAccessTokenNotFound
+	}
+	if err != nil {
+		return err
+	}
+	s.logger.InfoContext(ctx, "personal access token revoked", "user_id", userID, "token_id", id)
+	return nil
+}
+
+func (s *personalAccessTokenService) ValidatePersonalAccessToken(ctx context.Context, plaintext string) (*auth.Principal, error) {
+	ctx, span := tracing.Start(ctx, "PersonalAccessTokenService.ValidatePersonalAccessToken")
+	defer span.End()
+
+	token, err := s.tokenRepo.FindByHash(ctx, auth.HashPersonalAccessToken(plaintext))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, auth.ErrInvalidToken
+		}
+		return nil, err
+	}
+
+	now := time.Now()
+	if token.ExpiresAt.Before(now) {
+		return nil, auth.ErrExpiredToken
+	}
+
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) > lastUsedResolution {
+		// LastUsedAt is only bookkeeping, so failing to write it does not
+		// fail the request
+		if err := s.tokenRepo.UpdateLastUsed(ctx, token.ID, now); err != nil {
+			s.logger.ErrorContext(ctx, "recording personal access token use failed", "token_id", token.ID, "error", err)
+		}
+	}
+
+	return &auth.Principal{
+		UserID:    token.UserID,
+		Scopes:    token.Scopes,
+		SessionID: "pat:" + strconv.FormatUint(uint64(token.ID), 10),
+		Method:    auth.AuthMethodPersonalAccessToken,
+	}, nil
+}
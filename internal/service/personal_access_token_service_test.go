@@ -0,0 +1,65 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"quizlet/internal/auth"
+	"quizlet/internal/models/user"
+	"quizlet/internal/repository"
+)
+
+// failingLastUsedRepository fails every LastUsedAt write
+type failingLastUsedRepository struct {
+	repository.PersonalAccessTokenRepository
+}
+
+func (failingLastUsedRepository) UpdateLastUsed(context.Context, uint, time.Time) error {
+	return errors.New("database is read-only")
+}
+
+func createTokenOwner(t *testing.T, db *gorm.DB) *user.User {
+	t.Helper()
+	owner := &user.User{Username: "ann", Email: "ann@example.com", Password: "hash"}
+	require.NoError(t, repository.NewUserRepository(db).Create(context.Background(), owner))
+	return owner
+}
+
+func TestPersonalAccessTokenOfDeletedUser(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	tokens := NewPersonalAccessTokenService(repository.NewPersonalAccessTokenRepository(db), logger)
+	owner := createTokenOwner(t, db)
+
+	_, plaintext, err := tokens.CreateToken(ctx, owner.ID, user.CreatePersonalAccessTokenRequest{Name: "ci"})
+	require.NoError(t, err)
+	principal, err := tokens.ValidatePersonalAccessToken(ctx, plaintext)
+	require.NoError(t, err)
+	require.Equal(t, owner.ID, principal.UserID)
+
+	require.NoError(t, repository.NewUserRepository(db).Delete(ctx, owner.ID))
+	_, err = tokens.ValidatePersonalAccessToken(ctx, plaintext)
+	require.ErrorIs(t, err, auth.ErrInvalidToken)
+}
+
+func TestPersonalAccessTokenLastUsedFailure(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	repo := failingLastUsedRepository{repository.NewPersonalAccessTokenRepository(db)}
+	tokens := NewPersonalAccessTokenService(repo, logger)
+	owner := createTokenOwner(t, db)
+
+	_, plaintext, err := tokens.CreateToken(ctx, owner.ID, user.CreatePersonalAccessTokenRequest{Name: "ci"})
+	require.NoError(t, err)
+	principal, err := tokens.ValidatePersonalAccessToken(ctx, plaintext)
+	require.NoError(t, err)
+	require.Equal(t, owner.ID, principal.UserID)
+}
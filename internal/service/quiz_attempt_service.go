@@ -3,51 +3,76 @@ package service
 import (
 	"context"
 	"errors"
+	"slices"
+	"strings"
 	"time"
 
+	"gorm.io/gorm"
+
+	"quizlet/internal/apperr"
+	"quizlet/internal/metrics"
+	"quizlet/internal/models/audit_entry"
 	"quizlet/internal/models/quiz_attempt"
 	"quizlet/internal/repository"
-	"gorm.io/gorm"
+	"quizlet/internal/tracing"
 )
 
 var (
-	ErrQuizAttemptNotFound = errors.New("quiz attempt not found")
-	ErrUnauthorized        = errors.New("unauthorized access")
+	ErrQuizAttemptNotFound = apperr.New(apperr.NotFound, "quiz_attempt_not_found", "quiz attempt not found")
+	ErrUnauthorized        = apperr.New(apperr.Forbidden, "quiz_attempt_forbidden", "you do not have access to this quiz attempt")
+
+	ErrQuizAttemptCompleted = apperr.New(apperr.Conflict, "quiz_attempt_completed", "the quiz attempt is already completed")
+	ErrQuizAttemptNotPinned = apperr.New(apperr.Conflict, "quiz_attempt_without_snapshot", "the quiz attempt has no snapshot to grade answers against")
+	ErrQuizAttemptGraded    = apperr.New(apperr.Conflict, "quiz_attempt_graded", "the score of a quiz attempt is graded from its answers")
+	ErrQuizAlreadyAnswered  = apperr.New(apperr.Conflict, "quiz_already_answered", "the quiz was already answered in this quiz attempt")
+	ErrQuizNotInAttempt     = apperr.New(apperr.Unprocessable, "quiz_not_in_attempt", "the quiz is not part of this quiz attempt")
+	ErrUnknownSelection     = apperr.New(apperr.Unprocessable, "unknown_selection", "a selection is not one of the quiz's selections in this quiz attempt")
 )
 
 // QuizAttemptService defines the interface for quiz attempt operations
 type QuizAttemptService interface {
-	ListByQuizSuite(ctx context.Context, quizSuiteID, userID int64) ([]quiz_attempt.QuizAttempt, error)
-	Create(ctx context.Context, quizSuiteID, userID int64, req quiz_attempt.CreateQuizAttemptRequest) (*quiz_attempt.QuizAttempt, error)
-	Get(ctx context.Context, id, userID int64) (*quiz_attempt.QuizAttempt, error)
-	Update(ctx context.Context, id, userID int64, req quiz_attempt.UpdateQuizAttemptRequest) (*quiz_attempt.QuizAttempt, error)
-	Delete(ctx context.Context, id, userID int64) error
+	ListByQuizSuite(ctx context.Context, quizSuiteID, userID uint) ([]quiz_attempt.QuizAttempt, error)
+	Create(ctx context.Context, quizSuiteID, userID uint, req quiz_attempt.CreateQuizAttemptRequest) (*quiz_attempt.QuizAttempt, error)
+	Get(ctx context.Context, id, userID uint) (*quiz_attempt.QuizAttempt, error)
+	Update(ctx context.Context, id, userID uint, req quiz_attempt.UpdateQuizAttemptRequest) (*quiz_attempt.QuizAttempt, error)
+	Delete(ctx context.Context, id, userID uint) error
+	// SubmitAnswer grades an answer against the snapshot the attempt
+	// started with and updates the score of the attempt. Each quiz is
+	// answered once; ErrQuizAlreadyAnswered is returned after that.
+	SubmitAnswer(ctx context.Context, id, userID uint, req quiz_attempt.SubmitAnswerRequest) (*quiz_attempt.QuizAttempt, error)
 }
 
 // QuizAttemptServiceImpl is the concrete implementation of QuizAttemptService
 type QuizAttemptServiceImpl struct {
-	repo *repository.QuizAttemptRepository
+	repo repository.QuizAttemptRepository
+	uow  repository.UnitOfWork
 }
 
 // Ensure QuizAttemptServiceImpl implements QuizAttemptService
 var _ QuizAttemptService = (*QuizAttemptServiceImpl)(nil)
 
-func NewQuizAttemptService(repo *repository.QuizAttemptRepository) QuizAttemptService {
+func NewQuizAttemptService(repo repository.QuizAttemptRepository, uow repository.UnitOfWork) QuizAttemptService {
 	return &QuizAttemptServiceImpl{
 		repo: repo,
+		uow:  uow,
 	}
 }
 
-func (s *QuizAttemptServiceImpl) ListByQuizSuite(ctx context.Context, quizSuiteID, userID int64) ([]quiz_attempt.QuizAttempt, error) {
+func (s *QuizAttemptServiceImpl) ListByQuizSuite(ctx context.Context, quizSuiteID, userID uint) ([]quiz_attempt.QuizAttempt, error) {
+	ctx, span := tracing.Start(ctx, "QuizAttemptService.ListByQuizSuite")
+	defer span.End()
+
 	return s.repo.ListByQuizSuite(ctx, quizSuiteID, userID)
 }
 
-func (s *QuizAttemptServiceImpl) Create(ctx context.Context, quizSuiteID, userID int64, req quiz_attempt.CreateQuizAttemptRequest) (*quiz_attempt.QuizAttempt, error) {
+func (s *QuizAttemptServiceImpl) Create(ctx context.Context, quizSuiteID, userID uint, req quiz_attempt.CreateQuizAttemptRequest) (*quiz_attempt.QuizAttempt, error) {
+	ctx, span := tracing.Start(ctx, "QuizAttemptService.Create")
+	defer span.End()
+
 	now := time.Now()
 	attempt := &quiz_attempt.QuizAttempt{
 		UserID:      userID,
 		QuizSuiteID: quizSuiteID,
-		Score:       req.Score,
 		Completed:   req.Completed,
 		StartedAt:   now,
 	}
@@ -56,65 +81,220 @@ func (s *QuizAttemptServiceImpl) Create(ctx context.Context, quizSuiteID, userID
 		attempt.CompletedAt = &now
 	}
 
-	return s.repo.Create(ctx, attempt)
+	var created *quiz_attempt.QuizAttempt
+	err := s.uow.Do(ctx, func(ctx context.Context, repos repository.Repositories) error {
+		var err error
+		attempt.Snapshot, err = snapshotQuizSuite(ctx, repos, quizSuiteID)
+		if err != nil {
+			return err
+		}
+		created, err = repos.QuizAttempts.Create(ctx, attempt)
+		if err != nil {
+			return err
+		}
+		return recordChange(ctx, repos, audit_entry.ActionCreate, audit_entry.ResourceQuizAttempt, created.ID, nil, created)
+	})
+	if err != nil {
+		return nil, err
+	}
+	metrics.QuizAttemptStarted()
+	if created.Completed {
+		metrics.QuizAttemptCompleted()
+	}
+	return withholdAnswerKey(created), nil
 }
 
-func (s *QuizAttemptServiceImpl) Get(ctx context.Context, id, userID int64) (*quiz_attempt.QuizAttempt, error) {
+func (s *QuizAttemptServiceImpl) Get(ctx context.Context, id, userID uint) (*quiz_attempt.QuizAttempt, error) {
+	ctx, span := tracing.Start(ctx, "QuizAttemptService.Get")
+	defer span.End()
+
 	attempt, err := s.repo.Get(ctx, id)
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, ErrQuizAttemptNotFound
-		}
-		return nil, err
+		return nil, notFound(err, ErrQuizAttemptNotFound)
 	}
 
 	if attempt.UserID != userID {
 		return nil, ErrUnauthorized
 	}
 
-	return attempt, nil
+	return withholdAnswerKey(attempt), nil
 }
 
-func (s *QuizAttemptServiceImpl) Update(ctx context.Context, id, userID int64, req quiz_attempt.UpdateQuizAttemptRequest) (*quiz_attempt.QuizAttempt, error) {
-	attempt, err := s.repo.Get(ctx, id)
-	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, ErrQuizAttemptNotFound
+func (s *QuizAttemptServiceImpl) Update(ctx context.Context, id, userID uint, req quiz_attempt.UpdateQuizAttemptRequest) (*quiz_attempt.QuizAttempt, error) {
+	ctx, span := tracing.Start(ctx, "QuizAttemptService.Update")
+	defer span.End()
+
+	var updated *quiz_attempt.QuizAttempt
+	completing := false
+	err := s.uow.Do(ctx, func(ctx context.Context, repos repository.Repositories) error {
+		attempt, err := repos.QuizAttempts.Get(ctx, id)
+		if err != nil {
+			return notFound(err, ErrQuizAttemptNotFound)
+		}
+
+		if attempt.UserID != userID {
+			return ErrUnauthorized
+		}
+
+		before := *attempt
+		if req.Score != nil {
+			// Attempts with a snapshot are scored from their answers only
+			if attempt.Snapshot != nil {
+				return ErrQuizAttemptGraded
+			}
+			attempt.Score = *req.Score
+		}
+
+		if req.Completed != nil {
+			completing = *req.Completed && !attempt.Completed
+			attempt.Completed = *req.Completed
+			if *req.Completed && attempt.CompletedAt == nil {
+				now := time.Now()
+				attempt.CompletedAt = &now
+			}
+		}
+
+		updated, err = repos.QuizAttempts.Update(ctx, attempt)
+		if err != nil {
+			return err
 		}
+		return recordChange(ctx, repos, audit_entry.ActionUpdate, audit_entry.ResourceQuizAttempt, id, &before, updated)
+	})
+	if err != nil {
 		return nil, err
 	}
-
-	if attempt.UserID != userID {
-		return nil, ErrUnauthorized
+	if completing {
+		metrics.QuizAttemptCompleted()
 	}
+	return withholdAnswerKey(updated), nil
+}
 
-	if req.Score != nil {
-		attempt.Score = *req.Score
-	}
+func (s *QuizAttemptServiceImpl) Delete(ctx context.Context, id, userID uint) error {
+	ctx, span := tracing.Start(ctx, "QuizAttemptService.Delete")
+	defer span.End()
 
-	if req.Completed != nil {
-		attempt.Completed = *req.Completed
-		if *req.Completed && attempt.CompletedAt == nil {
-			now := time.Now()
-			attempt.CompletedAt = &now
+	return s.uow.Do(ctx, func(ctx context.Context, repos repository.Repositories) error {
+		attempt, err := repos.QuizAttempts.Get(ctx, id)
+		if err != nil {
+			return notFound(err, ErrQuizAttemptNotFound)
 		}
-	}
 
-	return s.repo.Update(ctx, attempt)
+		if attempt.UserID != userID {
+			return ErrUnauthorized
+		}
+
+		if err := repos.QuizAttempts.Delete(ctx, id); err != nil {
+			return err
+		}
+		return recordChange(ctx, repos, audit_entry.ActionDelete, audit_entry.ResourceQuizAttempt, id, attempt, nil)
+	})
 }
 
-func (s *QuizAttemptServiceImpl) Delete(ctx context.Context, id, userID int64) error {
-	attempt, err := s.repo.Get(ctx, id)
-	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return ErrQuizAttemptNotFound
+func (s *QuizAttemptServiceImpl) SubmitAnswer(ctx context.Context, id, userID uint, req quiz_attempt.SubmitAnswerRequest) (*quiz_attempt.QuizAttempt, error) {
+	ctx, span := tracing.Start(ctx, "QuizAttemptService.SubmitAnswer")
+	defer span.End()
+
+	var updated *quiz_attempt.QuizAttempt
+	err := s.uow.Do(ctx, func(ctx context.Context, repos repository.Repositories) error {
+		attempt, err := repos.QuizAttempts.Get(ctx, id)
+		if err != nil {
+			return notFound(err, ErrQuizAttemptNotFound)
+		}
+
+		if attempt.UserID != userID {
+			return ErrUnauthorized
+		}
+		if attempt.Completed {
+			return ErrQuizAttemptCompleted
+		}
+		if attempt.Snapshot == nil {
+			return ErrQuizAttemptNotPinned
+		}
+
+		q, ok := attempt.Snapshot.Quiz(req.QuizID)
+		if !ok {
+			return ErrQuizNotInAttempt
+		}
+		for _, answered := range attempt.Answers {
+			if answered.QuizID == q.QuizID {
+				return ErrQuizAlreadyAnswered
+			}
+		}
+		texts := make([]string, len(req.SelectionIDs))
+		for i, selectionID := range req.SelectionIDs {
+			selection, ok := q.Selection(selectionID)
+			if !ok {
+				return ErrUnknownSelection
+			}
+			texts[i] = selection.SelectionText
+		}
+
+		answer := quiz_attempt.QuizAttemptAnswer{
+			QuizAttemptID: attempt.ID,
+			QuizID:        q.QuizID,
+			UserAnswer:    strings.Join(texts, ", "),
+			SelectionIDs:  quiz_attempt.IDList(req.SelectionIDs),
+			IsCorrect:     q.IsCorrect(req.SelectionIDs),
 		}
-		return err
+		if err := repos.QuizAttempts.CreateAnswer(ctx, &answer); err != nil {
+			if errors.Is(err, gorm.ErrDuplicatedKey) {
+				return ErrQuizAlreadyAnswered
+			}
+			return err
+		}
+
+		before := *attempt
+		attempt.Answers = append(slices.Clone(attempt.Answers), answer)
+		attempt.Score = attempt.Snapshot.Score(attempt.Answers)
+		updated, err = repos.QuizAttempts.Update(ctx, attempt)
+		if err != nil {
+			return err
+		}
+		return recordChange(ctx, repos, audit_entry.ActionUpdate, audit_entry.ResourceQuizAttempt, id, &before, updated)
+	})
+	if err != nil {
+		return nil, err
 	}
+	return withholdAnswerKey(updated), nil
+}
 
-	if attempt.UserID != userID {
-		return ErrUnauthorized
+// snapshotQuizSuite copies the quizzes of a suite, with their selections and
+// latest revision, for an attempt to be graded against
+func snapshotQuizSuite(ctx context.Context, repos repository.Repositories, quizSuiteID uint) (*quiz_attempt.SuiteSnapshot, error) {
+	quizSuite, err := repos.QuizSuites.FindByID(ctx, quizSuiteID)
+	if err != nil {
+		return nil, notFound(err, ErrQuizSuiteNotFound)
 	}
 
-	return s.repo.Delete(ctx, id)
-} 
\ No newline at end of file
+	snapshot := &quiz_attempt.SuiteSnapshot{
+		Title:       quizSuite.Title,
+		Description: quizSuite.Description,
+		Quizzes:     []quiz_attempt.QuizSnapshot{},
+	}
+	for _, member := range quizSuite.Quizzes {
+		// The suite only carries the quizzes, not their selections
+		q, err := repos.Quizzes.FindByID(ctx, member.ID)
+		if err != nil {
+			return nil, err
+		}
+		var revision uint
+		latest, err := repos.QuizRevisions.Latest(ctx, q.ID)
+		switch {
+		case err == nil:
+			revision = latest.Number
+		case !errors.Is(err, gorm.ErrRecordNotFound):
+			return nil, err
+		}
+		snapshot.Quizzes = append(snapshot.Quizzes, quiz_attempt.NewQuizSnapshot(q, revision))
+	}
+	return snapshot, nil
+}
+
+// withholdAnswerKey hides the answer key of an attempt that is not completed,
+// so it cannot be read while answering
+func withholdAnswerKey(attempt *quiz_attempt.QuizAttempt) *quiz_attempt.QuizAttempt {
+	if !attempt.Completed && attempt.Snapshot != nil {
+		attempt.Snapshot = attempt.Snapshot.WithoutAnswerKey()
+	}
+	return attempt
+}
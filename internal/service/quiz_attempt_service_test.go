@@ -0,0 +1,145 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"quizlet/internal/models/quiz"
+	"quizlet/internal/models/quiz_attempt"
+	"quizlet/internal/repository/memory"
+)
+
+func TestQuizAttemptOwnership(t *testing.T) {
+	ctx := context.Background()
+	kit := newTestKit(t)
+	ann := kit.user(t, "ann")
+	bob := kit.user(t, "bob")
+	qs := kit.quizSuite(t, ann)
+
+	attempt, err := kit.quizAttempts.Create(ctx, qs.ID, ann.ID, quiz_attempt.CreateQuizAttemptRequest{})
+	require.NoError(t, err)
+	assert.Nil(t, attempt.CompletedAt)
+
+	// Only the user who made the attempt can see or change it
+	_, err = kit.quizAttempts.Get(ctx, attempt.ID, bob.ID)
+	assert.ErrorIs(t, err, ErrUnauthorized)
+	score := 100
+	_, err = kit.quizAttempts.Update(ctx, attempt.ID, bob.ID, quiz_attempt.UpdateQuizAttemptRequest{Score: &score})
+	assert.ErrorIs(t, err, ErrUnauthorized)
+	assert.ErrorIs(t, kit.quizAttempts.Delete(ctx, attempt.ID, bob.ID), ErrUnauthorized)
+	listed, err := kit.quizAttempts.ListByQuizSuite(ctx, qs.ID, bob.ID)
+	require.NoError(t, err)
+	assert.Empty(t, listed)
+
+	// The score is graded from the answers, never set by the client
+	_, err = kit.quizAttempts.Update(ctx, attempt.ID, ann.ID, quiz_attempt.UpdateQuizAttemptRequest{Score: &score})
+	assert.ErrorIs(t, err, ErrQuizAttemptGraded)
+
+	completed := true
+	updated, err := kit.quizAttempts.Update(ctx, attempt.ID, ann.ID, quiz_attempt.UpdateQuizAttemptRequest{Completed: &completed})
+	require.NoError(t, err)
+	assert.Equal(t, 0, updated.Score)
+	assert.NotNil(t, updated.CompletedAt)
+
+	require.NoError(t, kit.quizAttempts.Delete(ctx, attempt.ID, ann.ID))
+	_, err = kit.quizAttempts.Get(ctx, attempt.ID, ann.ID)
+	assert.ErrorIs(t, err, ErrQuizAttemptNotFound)
+}
+
+func TestLegacyQuizAttemptScore(t *testing.T) {
+	ctx := context.Background()
+	kit := newTestKit(t)
+	ann := kit.user(t, "ann")
+	qs := kit.quizSuite(t, ann)
+
+	// Attempts started before snapshots were kept are scored by the client
+	attempt, err := memory.NewQuizAttemptRepository(kit.store).Create(ctx, &quiz_attempt.QuizAttempt{UserID: ann.ID, QuizSuiteID: qs.ID, Score: 40})
+	require.NoError(t, err)
+	score := 90
+	updated, err := kit.quizAttempts.Update(ctx, attempt.ID, ann.ID, quiz_attempt.UpdateQuizAttemptRequest{Score: &score})
+	require.NoError(t, err)
+	assert.Equal(t, 90, updated.Score)
+}
+
+func TestQuizAttemptGradedAgainstSnapshot(t *testing.T) {
+	ctx := context.Background()
+	kit := newTestKit(t)
+	ann := kit.user(t, "ann")
+	bob := kit.user(t, "bob")
+	q := kit.quiz(t, ann, "4", "5")
+	four, five := q.Selections[0].ID, q.Selections[1].ID
+	qs := kit.quizSuite(t, ann)
+	require.NoError(t, kit.quizSuites.AddQuizToSuite(ctx, qs.ID, q.ID))
+
+	_, err := kit.quizAttempts.Create(ctx, 999, bob.ID, quiz_attempt.CreateQuizAttemptRequest{})
+	assert.ErrorIs(t, err, ErrQuizSuiteNotFound)
+
+	attempt, err := kit.quizAttempts.Create(ctx, qs.ID, bob.ID, quiz_attempt.CreateQuizAttemptRequest{})
+	require.NoError(t, err)
+	require.NotNil(t, attempt.Snapshot)
+	require.Len(t, attempt.Snapshot.Quizzes, 1)
+	assert.Equal(t, uint(1), attempt.Snapshot.Quizzes[0].Revision)
+	// The answer key is withheld until the attempt is completed
+	assert.Nil(t, attempt.Snapshot.Quizzes[0].Selections[0].IsCorrect)
+
+	// The author rewrites the quiz while bob is answering
+	q.Question = "What is 2 * 3?"
+	require.NoError(t, kit.quizzes.UpdateQuiz(ctx, q))
+	require.NoError(t, kit.quizzes.RemoveSelection(ctx, q.ID, four))
+	require.NoError(t, kit.quizzes.AddSelection(ctx, q.ID, quiz.QuizSelection{SelectionText: "6", IsCorrect: true}))
+
+	answer := func(quizID uint, selectionIDs ...uint) (*quiz_attempt.QuizAttempt, error) {
+		return kit.quizAttempts.SubmitAnswer(ctx, attempt.ID, bob.ID, quiz_attempt.SubmitAnswerRequest{QuizID: quizID, SelectionIDs: selectionIDs})
+	}
+	_, err = answer(q.ID, 999)
+	assert.ErrorIs(t, err, ErrUnknownSelection)
+	_, err = answer(999, four)
+	assert.ErrorIs(t, err, ErrQuizNotInAttempt)
+	_, err = kit.quizAttempts.SubmitAnswer(ctx, attempt.ID, ann.ID, quiz_attempt.SubmitAnswerRequest{QuizID: q.ID, SelectionIDs: []uint{four}})
+	assert.ErrorIs(t, err, ErrUnauthorized)
+
+	// The removed selection still counts
+	graded, err := answer(q.ID, four)
+	require.NoError(t, err)
+	assert.Equal(t, 100, graded.Score)
+	require.Len(t, graded.Answers, 1)
+	assert.Equal(t, "4", graded.Answers[0].UserAnswer)
+	assert.True(t, graded.Answers[0].IsCorrect)
+	// A quiz cannot be answered again to try another selection
+	_, err = answer(q.ID, five)
+	assert.ErrorIs(t, err, ErrQuizAlreadyAnswered)
+
+	score := 10
+	_, err = kit.quizAttempts.Update(ctx, attempt.ID, bob.ID, quiz_attempt.UpdateQuizAttemptRequest{Score: &score})
+	assert.ErrorIs(t, err, ErrQuizAttemptGraded)
+
+	completed := true
+	_, err = kit.quizAttempts.Update(ctx, attempt.ID, bob.ID, quiz_attempt.UpdateQuizAttemptRequest{Completed: &completed})
+	require.NoError(t, err)
+	_, err = answer(q.ID, five)
+	assert.ErrorIs(t, err, ErrQuizAttemptCompleted)
+
+	// The review shows the quiz as it was, with its answer key
+	reviewed, err := kit.quizAttempts.Get(ctx, attempt.ID, bob.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 100, reviewed.Score)
+	pinned := reviewed.Snapshot.Quizzes[0]
+	assert.Equal(t, "What is 2 + 2?", pinned.Question)
+	require.Len(t, pinned.Selections, 2)
+	require.NotNil(t, pinned.Selections[0].IsCorrect)
+	assert.True(t, *pinned.Selections[0].IsCorrect)
+
+	// A new attempt gets the quiz as it is now
+	next, err := kit.quizAttempts.Create(ctx, qs.ID, bob.ID, quiz_attempt.CreateQuizAttemptRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, uint(4), next.Snapshot.Quizzes[0].Revision)
+	assert.Equal(t, "What is 2 * 3?", next.Snapshot.Quizzes[0].Question)
+
+	// Attempts started before snapshots were kept cannot take answers
+	legacy, err := memory.NewQuizAttemptRepository(kit.store).Create(ctx, &quiz_attempt.QuizAttempt{UserID: bob.ID, QuizSuiteID: qs.ID})
+	require.NoError(t, err)
+	_, err = kit.quizAttempts.SubmitAnswer(ctx, legacy.ID, bob.ID, quiz_attempt.SubmitAnswerRequest{QuizID: q.ID, SelectionIDs: []uint{four}})
+	assert.ErrorIs(t, err, ErrQuizAttemptNotPinned)
+}
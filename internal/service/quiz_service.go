@@ -1,89 +1,416 @@
 package service
 
 import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+	"quizlet/internal/apperr"
+	"quizlet/internal/auth"
+	"quizlet/internal/models/audit_entry"
 	"quizlet/internal/models/quiz"
 	"quizlet/internal/repository"
+	"quizlet/internal/tracing"
+)
+
+var (
+	ErrQuizNotFound = apperr.New(apperr.NotFound, "quiz_not_found", "quiz not found")
+	ErrQuizModified = apperr.New(apperr.PreconditionFailed, "quiz_modified", "the quiz was changed since it was read")
+
+	ErrSelectionNotFound = apperr.New(apperr.NotFound, "selection_not_found", "selection not found")
+	ErrRevisionNotFound  = apperr.New(apperr.NotFound, "revision_not_found", "revision not found")
 )
 
 // QuizSelection is a type alias for quiz.QuizSelection to ensure type compatibility
 type QuizSelection = quiz.QuizSelection
 
 type QuizService interface {
-	CreateQuiz(quiz *quiz.Quiz) error
-	GetQuizByID(id uint) (*quiz.Quiz, error)
-	GetQuizzesByUserID(userID uint) ([]*quiz.Quiz, error)
-	UpdateQuiz(quiz *quiz.Quiz) error
-	DeleteQuiz(id uint) error
-	AddSelection(quizID uint, selection QuizSelection) error
-	RemoveSelection(quizID uint, selectionID uint) error
+	CreateQuiz(ctx context.Context, quiz *quiz.Quiz) error
+	GetQuizByID(ctx context.Context, id uint) (*quiz.Quiz, error)
+	GetQuizzesByUserID(ctx context.Context, userID uint) ([]*quiz.Quiz, error)
+	// UpdateQuiz saves the question and type of quiz. A non-zero
+	// quiz.Version must match the stored one, or ErrQuizModified is
+	// returned; on success quiz is replaced with the stored quiz.
+	UpdateQuiz(ctx context.Context, quiz *quiz.Quiz) error
+	// DeleteQuiz deletes the quiz; a non-zero version must match
+	DeleteQuiz(ctx context.Context, id uint, version uint) error
+	AddSelection(ctx context.Context, quizID uint, selection QuizSelection) error
+	RemoveSelection(ctx context.Context, quizID uint, selectionID uint) error
+	// ListRevisions returns the revisions of a quiz, newest first. Every
+	// change to the quiz or its selections adds one.
+	ListRevisions(ctx context.Context, quizID uint) ([]*quiz.QuizRevision, error)
+	GetRevision(ctx context.Context, quizID uint, number uint) (*quiz.QuizRevision, error)
+	// DiffRevisions compares revision from of a quiz with revision to
+	DiffRevisions(ctx context.Context, quizID uint, from uint, to uint) (*quiz.RevisionDiff, error)
+	// RevertQuiz restores the content of a revision, which is recorded as
+	// a new revision; a non-zero version must match the stored one
+	RevertQuiz(ctx context.Context, quizID uint, number uint, version uint) (*quiz.Quiz, error)
+	// CloneQuiz copies a quiz and its selections into a new quiz owned by
+	// userID; provenance records the original in ClonedFromID
+	CloneQuiz(ctx context.Context, id uint, userID uint, provenance bool) (*quiz.Quiz, error)
 }
 
 type quizService struct {
-	quizRepo repository.QuizRepository
+	quizRepo         repository.QuizRepository
+	quizRevisionRepo repository.QuizRevisionRepository
+	uow              repository.UnitOfWork
 }
 
-func NewQuizService(quizRepo repository.QuizRepository) QuizService {
+func NewQuizService(quizRepo repository.QuizRepository, quizRevisionRepo repository.QuizRevisionRepository, uow repository.UnitOfWork) QuizService {
 	return &quizService{
-		quizRepo: quizRepo,
+		quizRepo:         quizRepo,
+		quizRevisionRepo: quizRevisionRepo,
+		uow:              uow,
+	}
+}
+
+func (s *quizService) CreateQuiz(ctx context.Context, quiz *quiz.Quiz) error {
+	ctx, span := tracing.Start(ctx, "QuizService.CreateQuiz")
+	defer span.End()
+
+	// Versions start at the column default whatever the client sent
+	quiz.Version = 0
+	for i := range quiz.Selections {
+		quiz.Selections[i].Version = 0
 	}
+	return s.uow.Do(ctx, func(ctx context.Context, repos repository.Repositories) error {
+		if err := repos.Quizzes.Create(ctx, quiz); err != nil {
+			return err
+		}
+		if err := recordRevision(ctx, repos, nil, quiz, nil); err != nil {
+			return err
+		}
+		return recordChange(ctx, repos, audit_entry.ActionCreate, audit_entry.ResourceQuiz, quiz.ID, nil, quiz)
+	})
 }
 
-func (s *quizService) CreateQuiz(quiz *quiz.Quiz) error {
-	return s.quizRepo.Create(quiz)
+func (s *quizService) GetQuizByID(ctx context.Context, id uint) (*quiz.Quiz, error) {
+	ctx, span := tracing.Start(ctx, "QuizService.GetQuizByID")
+	defer span.End()
+
+	quiz, err := s.quizRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, notFound(err, ErrQuizNotFound)
+	}
+	return quiz, nil
 }
 
-func (s *quizService) GetQuizByID(id uint) (*quiz.Quiz, error) {
-	return s.quizRepo.FindByID(id)
+func (s *quizService) GetQuizzesByUserID(ctx context.Context, userID uint) ([]*quiz.Quiz, error) {
+	ctx, span := tracing.Start(ctx, "QuizService.GetQuizzesByUserID")
+	defer span.End()
+
+	return s.quizRepo.FindByUserID(ctx, userID)
 }
 
-func (s *quizService) GetQuizzesByUserID(userID uint) ([]*quiz.Quiz, error) {
-	return s.quizRepo.FindByUserID(userID)
+func (s *quizService) UpdateQuiz(ctx context.Context, quiz *quiz.Quiz) error {
+	ctx, span := tracing.Start(ctx, "QuizService.UpdateQuiz")
+	defer span.End()
+
+	return s.uow.Do(ctx, func(ctx context.Context, repos repository.Repositories) error {
+		existing, err := repos.Quizzes.FindByID(ctx, quiz.ID)
+		if err != nil {
+			return notFound(err, ErrQuizNotFound)
+		}
+
+		if quiz.Version != 0 && quiz.Version != existing.Version {
+			return ErrQuizModified
+		}
+
+		before := *existing
+		existing.Question = quiz.Question
+		existing.QuizType = quiz.QuizType
+		if err := repos.Quizzes.Update(ctx, existing); err != nil {
+			return versionConflict(err, ErrQuizModified)
+		}
+		updated, err := recordQuizUpdate(ctx, repos, &before, nil)
+		if err != nil {
+			return err
+		}
+		*quiz = *updated
+		return nil
+	})
 }
 
-func (s *quizService) UpdateQuiz(quiz *quiz.Quiz) error {
-	existing, err := s.quizRepo.FindByID(quiz.ID)
-	if err != nil {
+func (s *quizService) DeleteQuiz(ctx context.Context, id uint, version uint) error {
+	ctx, span := tracing.Start(ctx, "QuizService.DeleteQuiz")
+	defer span.End()
+
+	return s.uow.Do(ctx, func(ctx context.Context, repos repository.Repositories) error {
+		existing, err := repos.Quizzes.FindByID(ctx, id)
+		if err != nil {
+			return notFound(err, ErrQuizNotFound)
+		}
+		if err := versionConflict(repos.Quizzes.Delete(ctx, id, version), ErrQuizModified); err != nil {
+			return err
+		}
+		return recordChange(ctx, repos, audit_entry.ActionDelete, audit_entry.ResourceQuiz, id, existing, nil)
+	})
+}
+
+func (s *quizService) AddSelection(ctx context.Context, quizID uint, selection QuizSelection) error {
+	ctx, span := tracing.Start(ctx, "QuizService.AddSelection")
+	defer span.End()
+
+	return s.uow.Do(ctx, func(ctx context.Context, repos repository.Repositories) error {
+		// Verify the quiz exists
+		quiz, err := repos.Quizzes.FindByID(ctx, quizID)
+		if err != nil {
+			return notFound(err, ErrQuizNotFound)
+		}
+
+		before := *quiz
+		selection.ID = 0
+		selection.Version = 0
+		if err := repos.Quizzes.AddSelection(ctx, quiz.ID, &selection); err != nil {
+			return err
+		}
+		// The selections are part of the quiz, so its version moves on too
+		if err := versionConflict(repos.Quizzes.Update(ctx, quiz), ErrQuizModified); err != nil {
+			return err
+		}
+		_, err = recordQuizUpdate(ctx, repos, &before, nil)
 		return err
+	})
+}
+
+func (s *quizService) RemoveSelection(ctx context.Context, quizID uint, selectionID uint) error {
+	ctx, span := tracing.Start(ctx, "QuizService.RemoveSelection")
+	defer span.End()
+
+	return s.uow.Do(ctx, func(ctx context.Context, repos repository.Repositories) error {
+		// Verify the quiz exists
+		quiz, err := repos.Quizzes.FindByID(ctx, quizID)
+		if err != nil {
+			return notFound(err, ErrQuizNotFound)
+		}
+
+		before := *quiz
+		if err := repos.Quizzes.RemoveSelection(ctx, quiz.ID, selectionID); err != nil {
+			return notFound(err, ErrSelectionNotFound)
+		}
+		if err := versionConflict(repos.Quizzes.Update(ctx, quiz), ErrQuizModified); err != nil {
+			return err
+		}
+		_, err = recordQuizUpdate(ctx, repos, &before, nil)
+		return err
+	})
+}
+
+func (s *quizService) ListRevisions(ctx context.Context, quizID uint) ([]*quiz.QuizRevision, error) {
+	ctx, span := tracing.Start(ctx, "QuizService.ListRevisions")
+	defer span.End()
+
+	if _, err := s.quizRepo.FindByID(ctx, quizID); err != nil {
+		return nil, notFound(err, ErrQuizNotFound)
 	}
+	return s.quizRevisionRepo.List(ctx, quizID)
+}
+
+func (s *quizService) GetRevision(ctx context.Context, quizID uint, number uint) (*quiz.QuizRevision, error) {
+	ctx, span := tracing.Start(ctx, "QuizService.GetRevision")
+	defer span.End()
 
-	existing.Question = quiz.Question
-	existing.QuizType = quiz.QuizType
-	return s.quizRepo.Update(existing)
+	if _, err := s.quizRepo.FindByID(ctx, quizID); err != nil {
+		return nil, notFound(err, ErrQuizNotFound)
+	}
+	revision, err := s.quizRevisionRepo.Find(ctx, quizID, number)
+	if err != nil {
+		return nil, notFound(err, ErrRevisionNotFound)
+	}
+	return revision, nil
 }
 
-func (s *quizService) DeleteQuiz(id uint) error {
-	return s.quizRepo.Delete(id)
+func (s *quizService) DiffRevisions(ctx context.Context, quizID uint, from uint, to uint) (*quiz.RevisionDiff, error) {
+	ctx, span := tracing.Start(ctx, "QuizService.DiffRevisions")
+	defer span.End()
+
+	fromRevision, err := s.GetRevision(ctx, quizID, from)
+	if err != nil {
+		return nil, err
+	}
+	toRevision, err := s.quizRevisionRepo.Find(ctx, quizID, to)
+	if err != nil {
+		return nil, notFound(err, ErrRevisionNotFound)
+	}
+	return quiz.Diff(fromRevision, toRevision), nil
 }
 
-func (s *quizService) AddSelection(quizID uint, selection QuizSelection) error {
-	// Verify the quiz exists
-	quiz, err := s.quizRepo.FindByID(quizID)
+func (s *quizService) RevertQuiz(ctx context.Context, quizID uint, number uint, version uint) (*quiz.Quiz, error) {
+	ctx, span := tracing.Start(ctx, "QuizService.RevertQuiz")
+	defer span.End()
+
+	var reverted *quiz.Quiz
+	err := s.uow.Do(ctx, func(ctx context.Context, repos repository.Repositories) error {
+		existing, err := repos.Quizzes.FindByID(ctx, quizID)
+		if err != nil {
+			return notFound(err, ErrQuizNotFound)
+		}
+		if version != 0 && version != existing.Version {
+			return ErrQuizModified
+		}
+		revision, err := repos.QuizRevisions.Find(ctx, quizID, number)
+		if err != nil {
+			return notFound(err, ErrRevisionNotFound)
+		}
+
+		before := *existing
+		if err := revertSelections(ctx, repos, existing, revision.Content.Selections); err != nil {
+			return err
+		}
+		existing.Question = revision.Content.Question
+		existing.QuizType = revision.Content.QuizType
+		if err := versionConflict(repos.Quizzes.Update(ctx, existing), ErrQuizModified); err != nil {
+			return err
+		}
+		reverted, err = recordQuizUpdate(ctx, repos, &before, &number)
+		return err
+	})
 	if err != nil {
+		return nil, err
+	}
+	return reverted, nil
+}
+
+func (s *quizService) CloneQuiz(ctx context.Context, id uint, userID uint, provenance bool) (*quiz.Quiz, error) {
+	ctx, span := tracing.Start(ctx, "QuizService.CloneQuiz")
+	defer span.End()
+
+	var clone *quiz.Quiz
+	err := s.uow.Do(ctx, func(ctx context.Context, repos repository.Repositories) error {
+		source, err := repos.Quizzes.FindByID(ctx, id)
+		if err != nil {
+			return notFound(err, ErrQuizNotFound)
+		}
+		clone, err = cloneQuiz(ctx, repos, source, userID, provenance)
 		return err
+	})
+	if err != nil {
+		return nil, err
 	}
+	return clone, nil
+}
 
-	// Add selection to quiz
-	if quiz.Selections == nil {
-		quiz.Selections = make([]QuizSelection, 0)
+// cloneQuiz creates a copy of source and its selections owned by userID,
+// recorded in the audit log and revisions like a quiz created by them
+func cloneQuiz(ctx context.Context, repos repository.Repositories, source *quiz.Quiz, userID uint, provenance bool) (*quiz.Quiz, error) {
+	clone := &quiz.Quiz{
+		Question:    source.Question,
+		QuizType:    source.QuizType,
+		CreatedByID: userID,
+		Selections:  make([]quiz.QuizSelection, len(source.Selections)),
+	}
+	if provenance {
+		sourceID := source.ID
+		clone.ClonedFromID = &sourceID
+	}
+	for i, selection := range source.Selections {
+		clone.Selections[i] = quiz.QuizSelection{
+			SelectionText:        selection.SelectionText,
+			SelectionDisplayName: selection.SelectionDisplayName,
+			IsCorrect:            selection.IsCorrect,
+		}
 	}
-	quiz.Selections = append(quiz.Selections, selection)
-	return s.quizRepo.Update(quiz)
+
+	if err := repos.Quizzes.Create(ctx, clone); err != nil {
+		return nil, err
+	}
+	if err := recordRevision(ctx, repos, nil, clone, nil); err != nil {
+		return nil, err
+	}
+	if err := recordChange(ctx, repos, audit_entry.ActionCreate, audit_entry.ResourceQuiz, clone.ID, nil, clone); err != nil {
+		return nil, err
+	}
+	return clone, nil
 }
 
-func (s *quizService) RemoveSelection(quizID uint, selectionID uint) error {
-	// Verify the quiz exists
-	quiz, err := s.quizRepo.FindByID(quizID)
+// revertSelections makes the selections of q match those saved by a
+// revision. Selections the quiz still has are updated in place; removed ones
+// are added again under new IDs.
+func revertSelections(ctx context.Context, repos repository.Repositories, q *quiz.Quiz, saved []quiz.SnapshotSelection) error {
+	current := make(map[uint]quiz.QuizSelection, len(q.Selections))
+	for _, selection := range q.Selections {
+		current[selection.ID] = selection
+	}
+
+	for _, want := range saved {
+		selection, ok := current[want.ID]
+		if ok {
+			delete(current, want.ID)
+			if selection.SelectionText == want.SelectionText && selection.SelectionDisplayName == want.SelectionDisplayName && selection.IsCorrect == want.IsCorrect {
+				continue
+			}
+		}
+		selection = quiz.QuizSelection{
+			ID:                   want.ID,
+			SelectionText:        want.SelectionText,
+			SelectionDisplayName: want.SelectionDisplayName,
+			IsCorrect:            want.IsCorrect,
+		}
+		if ok {
+			if err := repos.Quizzes.UpdateSelection(ctx, q.ID, &selection); err != nil {
+				return err
+			}
+			continue
+		}
+		selection.ID = 0
+		if err := repos.Quizzes.AddSelection(ctx, q.ID, &selection); err != nil {
+			return err
+		}
+	}
+
+	// What is left was added after the revision
+	for id := range current {
+		if err := repos.Quizzes.RemoveSelection(ctx, q.ID, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// recordQuizUpdate reads the quiz again after a change to it or its
+// selections, and records the change in the audit log and as a revision
+func recordQuizUpdate(ctx context.Context, repos repository.Repositories, before *quiz.Quiz, revertedFrom *uint) (*quiz.Quiz, error) {
+	after, err := repos.Quizzes.FindByID(ctx, before.ID)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	if err := recordRevision(ctx, repos, before, after, revertedFrom); err != nil {
+		return nil, err
 	}
+	if err := recordChange(ctx, repos, audit_entry.ActionUpdate, audit_entry.ResourceQuiz, before.ID, before, after); err != nil {
+		return nil, err
+	}
+	return after, nil
+}
 
-	// Remove selection from quiz
-	for i, selection := range quiz.Selections {
-		if selection.ID == selectionID {
-			quiz.Selections = append(quiz.Selections[:i], quiz.Selections[i+1:]...)
-			break
+// recordRevision stores after as the next revision of the quiz, authored by
+// the principal of ctx. A quiz created before revisions were kept has none,
+// so its state before the change is stored first, as revision 1.
+func recordRevision(ctx context.Context, repos repository.Repositories, before, after *quiz.Quiz, revertedFrom *uint) error {
+	number := uint(1)
+	latest, err := repos.QuizRevisions.Latest(ctx, after.ID)
+	switch {
+	case err == nil:
+		number = latest.Number + 1
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return err
+	case before != nil:
+		baseline := &quiz.QuizRevision{QuizID: before.ID, Number: number, Content: quiz.NewSnapshot(before)}
+		if err := repos.QuizRevisions.Create(ctx, baseline); err != nil {
+			return err
 		}
+		number++
 	}
 
-	return s.quizRepo.Update(quiz)
-} 
\ No newline at end of file
+	revision := &quiz.QuizRevision{
+		QuizID:       after.ID,
+		Number:       number,
+		RevertedFrom: revertedFrom,
+		Content:      quiz.NewSnapshot(after),
+	}
+	if p, ok := auth.FromContext(ctx); ok {
+		authorID := p.UserID
+		revision.AuthorID = &authorID
+	}
+	return repos.QuizRevisions.Create(ctx, revision)
+}
\ No newline at end of file
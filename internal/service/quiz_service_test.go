@@ -0,0 +1,177 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"quizlet/internal/auth"
+	"quizlet/internal/models/quiz"
+	"quizlet/internal/repository/memory"
+)
+
+func TestQuizSelections(t *testing.T) {
+	ctx := context.Background()
+	kit := newTestKit(t)
+	q := kit.quiz(t, kit.user(t, "ann"), "4")
+
+	// The client cannot pick the ID or version of a new selection
+	require.NoError(t, kit.quizzes.AddSelection(ctx, q.ID, quiz.QuizSelection{ID: 99, Version: 7, SelectionText: "5"}))
+	found, err := kit.quizzes.GetQuizByID(ctx, q.ID)
+	require.NoError(t, err)
+	assert.Equal(t, uint(2), found.Version)
+	require.Len(t, found.Selections, 2)
+	assert.NotEqual(t, uint(99), found.Selections[1].ID)
+	assert.Equal(t, uint(1), found.Selections[1].Version)
+
+	require.NoError(t, kit.quizzes.RemoveSelection(ctx, q.ID, found.Selections[1].ID))
+	assert.ErrorIs(t, kit.quizzes.RemoveSelection(ctx, q.ID, found.Selections[1].ID), ErrSelectionNotFound)
+	assert.ErrorIs(t, kit.quizzes.AddSelection(ctx, 999, quiz.QuizSelection{SelectionText: "6"}), ErrQuizNotFound)
+
+	found, err = kit.quizzes.GetQuizByID(ctx, q.ID)
+	require.NoError(t, err)
+	assert.Equal(t, uint(3), found.Version)
+	assert.Len(t, found.Selections, 1)
+}
+
+func TestUpdateQuizVersions(t *testing.T) {
+	ctx := context.Background()
+	kit := newTestKit(t)
+	q := kit.quiz(t, kit.user(t, "ann"))
+
+	update := &quiz.Quiz{ID: q.ID, Version: 1, Question: "What is 3 + 3?", QuizType: quiz.QuizTypeSingleChoice}
+	require.NoError(t, kit.quizzes.UpdateQuiz(ctx, update))
+	assert.Equal(t, uint(2), update.Version)
+	assert.Equal(t, q.CreatedByID, update.CreatedByID)
+
+	stale := &quiz.Quiz{ID: q.ID, Version: 1, Question: "Stale"}
+	assert.ErrorIs(t, kit.quizzes.UpdateQuiz(ctx, stale), ErrQuizModified)
+	assert.ErrorIs(t, kit.quizzes.DeleteQuiz(ctx, q.ID, 1), ErrQuizModified)
+	require.NoError(t, kit.quizzes.DeleteQuiz(ctx, q.ID, 2))
+	_, err := kit.quizzes.GetQuizByID(ctx, q.ID)
+	assert.ErrorIs(t, err, ErrQuizNotFound)
+}
+
+func TestCloneQuiz(t *testing.T) {
+	ctx := context.Background()
+	kit := newTestKit(t)
+	q := kit.quiz(t, kit.user(t, "ann"), "4", "5")
+	bob := kit.user(t, "bob")
+
+	clone, err := kit.quizzes.CloneQuiz(ctx, q.ID, bob.ID, true)
+	require.NoError(t, err)
+	assert.NotEqual(t, q.ID, clone.ID)
+	assert.Equal(t, bob.ID, clone.CreatedByID)
+	assert.Equal(t, uint(1), clone.Version)
+	require.NotNil(t, clone.ClonedFromID)
+	assert.Equal(t, q.ID, *clone.ClonedFromID)
+	require.Len(t, clone.Selections, 2)
+	assert.Equal(t, clone.ID, clone.Selections[0].QuizID)
+	assert.Equal(t, "5", clone.Selections[1].SelectionText)
+	assert.False(t, clone.Selections[1].IsCorrect)
+
+	clone, err = kit.quizzes.CloneQuiz(ctx, q.ID, bob.ID, false)
+	require.NoError(t, err)
+	assert.Nil(t, clone.ClonedFromID)
+
+	_, err = kit.quizzes.CloneQuiz(ctx, 999, bob.ID, true)
+	assert.ErrorIs(t, err, ErrQuizNotFound)
+}
+
+func TestQuizRevisions(t *testing.T) {
+	kit := newTestKit(t)
+	ann := kit.user(t, "ann")
+	ctx := auth.NewContext(context.Background(), &auth.Principal{UserID: ann.ID})
+	q := kit.quiz(t, ann, "4", "5")
+	four, five := q.Selections[0].ID, q.Selections[1].ID
+
+	q.Question = "What is 2 * 2?"
+	require.NoError(t, kit.quizzes.UpdateQuiz(ctx, q))
+	require.NoError(t, kit.quizzes.AddSelection(ctx, q.ID, quiz.QuizSelection{SelectionText: "6"}))
+	require.NoError(t, kit.quizzes.RemoveSelection(ctx, q.ID, five))
+
+	revisions, err := kit.quizzes.ListRevisions(ctx, q.ID)
+	require.NoError(t, err)
+	require.Len(t, revisions, 4)
+	assert.Equal(t, []uint{4, 3, 2, 1}, revisionNumbers(revisions))
+	assert.Nil(t, revisions[3].AuthorID, "created without a principal")
+	assert.Equal(t, &ann.ID, revisions[2].AuthorID)
+	assert.Equal(t, "What is 2 * 2?", revisions[2].Content.Question)
+
+	diff, err := kit.quizzes.DiffRevisions(ctx, q.ID, 1, 4)
+	require.NoError(t, err)
+	assert.Equal(t, &quiz.FieldChange{Before: "What is 2 + 2?", After: "What is 2 * 2?"}, diff.Question)
+	assert.Nil(t, diff.QuizType)
+	require.Len(t, diff.SelectionsAdded, 1)
+	assert.Equal(t, "6", diff.SelectionsAdded[0].SelectionText)
+	require.Len(t, diff.SelectionsRemoved, 1)
+	assert.Equal(t, five, diff.SelectionsRemoved[0].ID)
+	assert.Empty(t, diff.SelectionsChanged)
+
+	_, err = kit.quizzes.DiffRevisions(ctx, q.ID, 1, 9)
+	assert.ErrorIs(t, err, ErrRevisionNotFound)
+	_, err = kit.quizzes.GetRevision(ctx, 999, 1)
+	assert.ErrorIs(t, err, ErrQuizNotFound)
+
+	// Change a selection behind the service's back, so the revert updates it
+	changed := quiz.QuizSelection{ID: four, SelectionText: "four", IsCorrect: true}
+	require.NoError(t, memory.NewQuizRepository(kit.store).UpdateSelection(ctx, q.ID, &changed))
+
+	_, err = kit.quizzes.RevertQuiz(ctx, q.ID, 1, 3)
+	assert.ErrorIs(t, err, ErrQuizModified)
+	_, err = kit.quizzes.RevertQuiz(ctx, q.ID, 9, 0)
+	assert.ErrorIs(t, err, ErrRevisionNotFound)
+
+	reverted, err := kit.quizzes.RevertQuiz(ctx, q.ID, 1, 4)
+	require.NoError(t, err)
+	assert.Equal(t, uint(5), reverted.Version)
+	assert.Equal(t, "What is 2 + 2?", reverted.Question)
+	require.Len(t, reverted.Selections, 2)
+	assert.Equal(t, four, reverted.Selections[0].ID, "kept selections keep their ID")
+	assert.Equal(t, "4", reverted.Selections[0].SelectionText)
+	assert.Equal(t, "5", reverted.Selections[1].SelectionText)
+	assert.NotEqual(t, five, reverted.Selections[1].ID, "removed selections come back under a new ID")
+
+	latest, err := kit.quizzes.GetRevision(ctx, q.ID, 5)
+	require.NoError(t, err)
+	require.NotNil(t, latest.RevertedFrom)
+	assert.Equal(t, uint(1), *latest.RevertedFrom)
+	assert.Equal(t, quiz.NewSnapshot(reverted), latest.Content)
+
+	// The failed reverts added no revisions
+	revisions, err = kit.quizzes.ListRevisions(ctx, q.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []uint{5, 4, 3, 2, 1}, revisionNumbers(revisions))
+}
+
+func TestQuizRevisionsOfExistingQuiz(t *testing.T) {
+	ctx := context.Background()
+	kit := newTestKit(t)
+	ann := kit.user(t, "ann")
+	// Stored without the service, like quizzes created before revisions
+	q := &quiz.Quiz{Question: "What is 2 + 2?", QuizType: quiz.QuizTypeSingleChoice, CreatedByID: ann.ID}
+	require.NoError(t, memory.NewQuizRepository(kit.store).Create(ctx, q))
+
+	revisions, err := kit.quizzes.ListRevisions(ctx, q.ID)
+	require.NoError(t, err)
+	assert.Empty(t, revisions)
+
+	q.Question = "What is 3 + 3?"
+	require.NoError(t, kit.quizzes.UpdateQuiz(ctx, q))
+
+	// The state before the first change is kept as revision 1
+	revisions, err = kit.quizzes.ListRevisions(ctx, q.ID)
+	require.NoError(t, err)
+	require.Len(t, revisions, 2)
+	assert.Equal(t, "What is 3 + 3?", revisions[0].Content.Question)
+	assert.Equal(t, "What is 2 + 2?", revisions[1].Content.Question)
+}
+
+func revisionNumbers(revisions []*quiz.QuizRevision) []uint {
+	numbers := make([]uint, len(revisions))
+	for i, revision := range revisions {
+		numbers[i] = revision.Number
+	}
+	return numbers
+}
@@ -1,93 +1,255 @@
 package service
 
 import (
+	"context"
+	"quizlet/internal/apperr"
+	"quizlet/internal/models/audit_entry"
 	"quizlet/internal/models/quiz_suite"
 	"quizlet/internal/repository"
+	"quizlet/internal/tracing"
 )
 
+var (
+	ErrQuizSuiteNotFound  = apperr.New(apperr.NotFound, "quiz_suite_not_found", "quiz suite not found")
+	ErrQuizSuiteForbidden = apperr.New(apperr.Forbidden, "quiz_suite_forbidden", "you don't have permission to access this quiz suite")
+	ErrQuizSuiteModified  = apperr.New(apperr.PreconditionFailed, "quiz_suite_modified", "the quiz suite was changed since it was read")
+	ErrQuizNotInSuite     = apperr.New(apperr.NotFound, "quiz_not_in_suite", "the quiz is not in this quiz suite")
+)
+
+// CloneOptions control how CloneQuizSuite copies a suite
+type CloneOptions struct {
+	// ShareQuizzes puts the quizzes of the suite in the clone as they are,
+	// instead of copies of them
+	ShareQuizzes bool
+	// Provenance records the original of the suite and of each copied quiz
+	// in their ClonedFromID
+	Provenance bool
+}
+
 type QuizSuiteService interface {
-	CreateQuizSuite(quizSuite *quiz_suite.QuizSuite) error
-	GetQuizSuite(id uint) (*quiz_suite.QuizSuite, error)
-	GetUserQuizSuites(userID uint) ([]*quiz_suite.QuizSuite, error)
-	UpdateQuizSuite(quizSuite *quiz_suite.QuizSuite) error
-	DeleteQuizSuite(id uint) error
-	AddQuizToSuite(quizSuiteID uint, quizID uint) error
-	RemoveQuizFromSuite(quizSuiteID uint, quizID uint) error
+	CreateQuizSuite(ctx context.Context, quizSuite *quiz_suite.QuizSuite) error
+	GetQuizSuite(ctx context.Context, id uint) (*quiz_suite.QuizSuite, error)
+	GetUserQuizSuites(ctx context.Context, userID uint) ([]*quiz_suite.QuizSuite, error)
+	// UpdateQuizSuite saves the title and description of quizSuite. A
+	// non-zero quizSuite.Version must match the stored one, or
+	// ErrQuizSuiteModified is returned; on success quizSuite is replaced
+	// with the stored suite.
+	UpdateQuizSuite(ctx context.Context, quizSuite *quiz_suite.QuizSuite) error
+	// DeleteQuizSuite moves the suite and its attempts to the trash; a
+	// non-zero version must match
+	DeleteQuizSuite(ctx context.Context, id uint, version uint) error
+	AddQuizToSuite(ctx context.Context, quizSuiteID uint, quizID uint) error
+	RemoveQuizFromSuite(ctx context.Context, quizSuiteID uint, quizID uint) error
+	// CloneQuizSuite creates a suite owned by userID with the title,
+	// description and quizzes of another, in the same order. Unless shared,
+	// the quizzes are copied with their selections and owned by userID too.
+	CloneQuizSuite(ctx context.Context, id uint, userID uint, opts CloneOptions) (*quiz_suite.QuizSuite, error)
 }
 
 type quizSuiteService struct {
 	quizSuiteRepo repository.QuizSuiteRepository
-	quizRepo      repository.QuizRepository
+	uow           repository.UnitOfWork
 }
 
-func NewQuizSuiteService(quizSuiteRepo repository.QuizSuiteRepository, quizRepo repository.QuizRepository) QuizSuiteService {
+func NewQuizSuiteService(quizSuiteRepo repository.QuizSuiteRepository, uow repository.UnitOfWork) QuizSuiteService {
 	return &quizSuiteService{
 		quizSuiteRepo: quizSuiteRepo,
-		quizRepo:      quizRepo,
+		uow:           uow,
 	}
 }
 
-func (s *quizSuiteService) CreateQuizSuite(quizSuite *quiz_suite.QuizSuite) error {
-	return s.quizSuiteRepo.Create(quizSuite)
-}
+func (s *quizSuiteService) CreateQuizSuite(ctx context.Context, quizSuite *quiz_suite.QuizSuite) error {
+	ctx, span := tracing.Start(ctx, "QuizSuiteService.CreateQuizSuite")
+	defer span.End()
 
-func (s *quizSuiteService) GetQuizSuite(id uint) (*quiz_suite.QuizSuite, error) {
-	return s.quizSuiteRepo.FindByID(id)
+	return s.uow.Do(ctx, func(ctx context.Context, repos repository.Repositories) error {
+		if err := repos.QuizSuites.Create(ctx, quizSuite); err != nil {
+			return err
+		}
+		return recordChange(ctx, repos, audit_entry.ActionCreate, audit_entry.ResourceQuizSuite, quizSuite.ID, nil, quizSuite)
+	})
 }
 
-func (s *quizSuiteService) GetUserQuizSuites(userID uint) ([]*quiz_suite.QuizSuite, error) {
-	return s.quizSuiteRepo.FindByUserID(userID)
-}
+func (s *quizSuiteService) GetQuizSuite(ctx context.Context, id uint) (*quiz_suite.QuizSuite, error) {
+	ctx, span := tracing.Start(ctx, "QuizSuiteService.GetQuizSuite")
+	defer span.End()
 
-func (s *quizSuiteService) UpdateQuizSuite(quizSuite *quiz_suite.QuizSuite) error {
-	// Verify the quiz suite exists
-	existing, err := s.quizSuiteRepo.FindByID(quizSuite.ID)
+	quizSuite, err := s.quizSuiteRepo.FindByID(ctx, id)
 	if err != nil {
-		return err
+		return nil, notFound(err, ErrQuizSuiteNotFound)
 	}
+	return quizSuite, nil
+}
 
-	// Only allow updating certain fields
-	existing.Title = quizSuite.Title
-	existing.Description = quizSuite.Description
+func (s *quizSuiteService) GetUserQuizSuites(ctx context.Context, userID uint) ([]*quiz_suite.QuizSuite, error) {
+	ctx, span := tracing.Start(ctx, "QuizSuiteService.GetUserQuizSuites")
+	defer span.End()
 
-	return s.quizSuiteRepo.Update(existing)
+	return s.quizSuiteRepo.FindByUserID(ctx, userID)
 }
 
-func (s *quizSuiteService) DeleteQuizSuite(id uint) error {
-	return s.quizSuiteRepo.Delete(id)
+func (s *quizSuiteService) UpdateQuizSuite(ctx context.Context, quizSuite *quiz_suite.QuizSuite) error {
+	ctx, span := tracing.Start(ctx, "QuizSuiteService.UpdateQuizSuite")
+	defer span.End()
+
+	return s.uow.Do(ctx, func(ctx context.Context, repos repository.Repositories) error {
+		// Verify the quiz suite exists
+		existing, err := repos.QuizSuites.FindByID(ctx, quizSuite.ID)
+		if err != nil {
+			return notFound(err, ErrQuizSuiteNotFound)
+		}
+
+		if quizSuite.Version != 0 && quizSuite.Version != existing.Version {
+			return ErrQuizSuiteModified
+		}
+
+		// Only allow updating certain fields
+		before := *existing
+		existing.Title = quizSuite.Title
+		existing.Description = quizSuite.Description
+
+		if err := repos.QuizSuites.Update(ctx, existing); err != nil {
+			return versionConflict(err, ErrQuizSuiteModified)
+		}
+		if err := recordChange(ctx, repos, audit_entry.ActionUpdate, audit_entry.ResourceQuizSuite, existing.ID, &before, existing); err != nil {
+			return err
+		}
+		*quizSuite = *existing
+		return nil
+	})
 }
 
-func (s *quizSuiteService) AddQuizToSuite(quizSuiteID uint, quizID uint) error {
-	// Verify both quiz suite and quiz exist
-	quizSuite, err := s.quizSuiteRepo.FindByID(quizSuiteID)
-	if err != nil {
-		return err
-	}
+func (s *quizSuiteService) DeleteQuizSuite(ctx context.Context, id uint, version uint) error {
+	ctx, span := tracing.Start(ctx, "QuizSuiteService.DeleteQuizSuite")
+	defer span.End()
 
-	quiz, err := s.quizRepo.FindByID(quizID)
-	if err != nil {
-		return err
-	}
+	return s.uow.Do(ctx, func(ctx context.Context, repos repository.Repositories) error {
+		existing, err := repos.QuizSuites.FindByID(ctx, id)
+		if err != nil {
+			return notFound(err, ErrQuizSuiteNotFound)
+		}
+		if err := versionConflict(repos.QuizSuites.Delete(ctx, id, version), ErrQuizSuiteModified); err != nil {
+			return err
+		}
+		// The attempts go to the trash with the suite and come back with it
+		if err := repos.Trash.TrashQuizSuiteAttempts(ctx, id); err != nil {
+			return err
+		}
+		return recordChange(ctx, repos, audit_entry.ActionDelete, audit_entry.ResourceQuizSuite, id, existing, nil)
+	})
+}
+
+func (s *quizSuiteService) AddQuizToSuite(ctx context.Context, quizSuiteID uint, quizID uint) error {
+	ctx, span := tracing.Start(ctx, "QuizSuiteService.AddQuizToSuite")
+	defer span.End()
 
-	// Add quiz to suite
-	quizSuite.Quizzes = append(quizSuite.Quizzes, quiz)
-	return s.quizSuiteRepo.Update(quizSuite)
+	return s.uow.Do(ctx, func(ctx context.Context, repos repository.Repositories) error {
+		// Verify both quiz suite and quiz exist
+		quizSuite, err := repos.QuizSuites.FindByID(ctx, quizSuiteID)
+		if err != nil {
+			return notFound(err, ErrQuizSuiteNotFound)
+		}
+
+		if _, err := repos.Quizzes.FindByID(ctx, quizID); err != nil {
+			return notFound(err, ErrQuizNotFound)
+		}
+
+		before := *quizSuite
+		if err := repos.QuizSuites.AddQuiz(ctx, quizSuite.ID, quizID); err != nil {
+			return err
+		}
+		if err := versionConflict(repos.QuizSuites.Update(ctx, quizSuite), ErrQuizSuiteModified); err != nil {
+			return err
+		}
+		return recordQuizSuiteUpdate(ctx, repos, &before)
+	})
 }
 
-func (s *quizSuiteService) RemoveQuizFromSuite(quizSuiteID uint, quizID uint) error {
-	// Verify quiz suite exists
-	quizSuite, err := s.quizSuiteRepo.FindByID(quizSuiteID)
-	if err != nil {
-		return err
-	}
+func (s *quizSuiteService) RemoveQuizFromSuite(ctx context.Context, quizSuiteID uint, quizID uint) error {
+	ctx, span := tracing.Start(ctx, "QuizSuiteService.RemoveQuizFromSuite")
+	defer span.End()
+
+	return s.uow.Do(ctx, func(ctx context.Context, repos repository.Repositories) error {
+		// Verify quiz suite exists
+		quizSuite, err := repos.QuizSuites.FindByID(ctx, quizSuiteID)
+		if err != nil {
+			return notFound(err, ErrQuizSuiteNotFound)
+		}
+
+		before := *quizSuite
+		if err := repos.QuizSuites.RemoveQuiz(ctx, quizSuite.ID, quizID); err != nil {
+			return notFound(err, ErrQuizNotInSuite)
+		}
+		if err := versionConflict(repos.QuizSuites.Update(ctx, quizSuite), ErrQuizSuiteModified); err != nil {
+			return err
+		}
+		return recordQuizSuiteUpdate(ctx, repos, &before)
+	})
+}
 
-	// Remove quiz from suite
-	for i, quiz := range quizSuite.Quizzes {
-		if quiz.ID == quizID {
-			quizSuite.Quizzes = append(quizSuite.Quizzes[:i], quizSuite.Quizzes[i+1:]...)
-			break
+func (s *quizSuiteService) CloneQuizSuite(ctx context.Context, id uint, userID uint, opts CloneOptions) (*quiz_suite.QuizSuite, error) {
+	ctx, span := tracing.Start(ctx, "QuizSuiteService.CloneQuizSuite")
+	defer span.End()
+
+	var clone *quiz_suite.QuizSuite
+	err := s.uow.Do(ctx, func(ctx context.Context, repos repository.Repositories) error {
+		source, err := repos.QuizSuites.FindByID(ctx, id)
+		if err != nil {
+			return notFound(err, ErrQuizSuiteNotFound)
+		}
+
+		created := &quiz_suite.QuizSuite{
+			Title:       source.Title,
+			Description: source.Description,
+			CreatedByID: userID,
+		}
+		if opts.Provenance {
+			sourceID := source.ID
+			created.ClonedFromID = &sourceID
+		}
+		if err := repos.QuizSuites.Create(ctx, created); err != nil {
+			return err
+		}
+
+		// Quizzes are added in the order of the source suite, which is the
+		// order suites list them in
+		for _, q := range source.Quizzes {
+			quizID := q.ID
+			if !opts.ShareQuizzes {
+				// The suite lists its quizzes without their selections
+				original, err := repos.Quizzes.FindByID(ctx, q.ID)
+				if err != nil {
+					return err
+				}
+				copied, err := cloneQuiz(ctx, repos, original, userID, opts.Provenance)
+				if err != nil {
+					return err
+				}
+				quizID = copied.ID
+			}
+			if err := repos.QuizSuites.AddQuiz(ctx, created.ID, quizID); err != nil {
+				return err
+			}
 		}
+
+		clone, err = repos.QuizSuites.FindByID(ctx, created.ID)
+		if err != nil {
+			return err
+		}
+		return recordChange(ctx, repos, audit_entry.ActionCreate, audit_entry.ResourceQuizSuite, clone.ID, nil, clone)
+	})
+	if err != nil {
+		return nil, err
 	}
+	return clone, nil
+}
 
-	return s.quizSuiteRepo.Update(quizSuite)
-} 
\ No newline at end of file
+// recordQuizSuiteUpdate records a change to the quizzes of a suite, reading
+// the suite again to compare them
+func recordQuizSuiteUpdate(ctx context.Context, repos repository.Repositories, before *quiz_suite.QuizSuite) error {
+	after, err := repos.QuizSuites.FindByID(ctx, before.ID)
+	if err != nil {
+		return err
+	}
+	return recordChange(ctx, repos, audit_entry.ActionUpdate, audit_entry.ResourceQuizSuite, before.ID, before, after)
+}
\ No newline at end of file
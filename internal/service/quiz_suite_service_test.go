@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuizSuiteQuizzes(t *testing.T) {
+	ctx := context.Background()
+	kit := newTestKit(t)
+	ann := kit.user(t, "ann")
+	qs := kit.quizSuite(t, ann)
+	q := kit.quiz(t, ann)
+
+	require.NoError(t, kit.quizSuites.AddQuizToSuite(ctx, qs.ID, q.ID))
+	assert.ErrorIs(t, kit.quizSuites.AddQuizToSuite(ctx, qs.ID, 999), ErrQuizNotFound)
+	assert.ErrorIs(t, kit.quizSuites.AddQuizToSuite(ctx, 999, q.ID), ErrQuizSuiteNotFound)
+
+	found, err := kit.quizSuites.GetQuizSuite(ctx, qs.ID)
+	require.NoError(t, err)
+	assert.Equal(t, uint(2), found.Version)
+	require.Len(t, found.Quizzes, 1)
+
+	// Deleting a quiz takes it out of the suite without changing the suite
+	require.NoError(t, kit.quizzes.DeleteQuiz(ctx, q.ID, 0))
+	found, err = kit.quizSuites.GetQuizSuite(ctx, qs.ID)
+	require.NoError(t, err)
+	assert.Empty(t, found.Quizzes)
+	assert.Equal(t, uint(2), found.Version)
+
+	require.NoError(t, kit.quizSuites.RemoveQuizFromSuite(ctx, qs.ID, q.ID))
+	assert.ErrorIs(t, kit.quizSuites.RemoveQuizFromSuite(ctx, qs.ID, q.ID), ErrQuizNotInSuite)
+}
+
+func TestDeleteQuizSuiteVersions(t *testing.T) {
+	ctx := context.Background()
+	kit := newTestKit(t)
+	ann := kit.user(t, "ann")
+	qs := kit.quizSuite(t, ann)
+	kit.quizSuite(t, kit.user(t, "bob"))
+
+	assert.ErrorIs(t, kit.quizSuites.DeleteQuizSuite(ctx, qs.ID, 2), ErrQuizSuiteModified)
+	require.NoError(t, kit.quizSuites.DeleteQuizSuite(ctx, qs.ID, 1))
+	_, err := kit.quizSuites.GetQuizSuite(ctx, qs.ID)
+	assert.ErrorIs(t, err, ErrQuizSuiteNotFound)
+
+	owned, err := kit.quizSuites.GetUserQuizSuites(ctx, ann.ID)
+	require.NoError(t, err)
+	assert.Empty(t, owned)
+}
+
+func TestCloneQuizSuite(t *testing.T) {
+	ctx := context.Background()
+	kit := newTestKit(t)
+	ann := kit.user(t, "ann")
+	bob := kit.user(t, "bob")
+	qs := kit.quizSuite(t, ann)
+	first := kit.quiz(t, ann, "4", "5")
+	second := kit.quiz(t, ann, "6")
+	require.NoError(t, kit.quizSuites.AddQuizToSuite(ctx, qs.ID, second.ID))
+	require.NoError(t, kit.quizSuites.AddQuizToSuite(ctx, qs.ID, first.ID))
+
+	clone, err := kit.quizSuites.CloneQuizSuite(ctx, qs.ID, bob.ID, CloneOptions{Provenance: true})
+	require.NoError(t, err)
+	assert.NotEqual(t, qs.ID, clone.ID)
+	assert.Equal(t, "Arithmetic", clone.Title)
+	assert.Equal(t, bob.ID, clone.CreatedByID)
+	assert.Equal(t, uint(1), clone.Version)
+	require.NotNil(t, clone.ClonedFromID)
+	assert.Equal(t, qs.ID, *clone.ClonedFromID)
+
+	// The quizzes are copies owned by bob, in the order of the original
+	require.Len(t, clone.Quizzes, 2)
+	for i, original := range []uint{second.ID, first.ID} {
+		copied, err := kit.quizzes.GetQuizByID(ctx, clone.Quizzes[i].ID)
+		require.NoError(t, err)
+		assert.NotEqual(t, original, copied.ID)
+		assert.Equal(t, bob.ID, copied.CreatedByID)
+		require.NotNil(t, copied.ClonedFromID)
+		assert.Equal(t, original, *copied.ClonedFromID)
+	}
+	copied, err := kit.quizzes.GetQuizByID(ctx, clone.Quizzes[1].ID)
+	require.NoError(t, err)
+	require.Len(t, copied.Selections, 2)
+	assert.Equal(t, "4", copied.Selections[0].SelectionText)
+	assert.True(t, copied.Selections[0].IsCorrect)
+	assert.NotEqual(t, first.Selections[0].ID, copied.Selections[0].ID)
+	revisions, err := kit.quizzes.ListRevisions(ctx, copied.ID)
+	require.NoError(t, err)
+	assert.Len(t, revisions, 1)
+
+	// Changing a copy leaves the original alone
+	require.NoError(t, kit.quizzes.RemoveSelection(ctx, copied.ID, copied.Selections[1].ID))
+	original, err := kit.quizzes.GetQuizByID(ctx, first.ID)
+	require.NoError(t, err)
+	assert.Len(t, original.Selections, 2)
+
+	owned, err := kit.quizSuites.GetUserQuizSuites(ctx, bob.ID)
+	require.NoError(t, err)
+	assert.Len(t, owned, 1)
+
+	_, err = kit.quizSuites.CloneQuizSuite(ctx, 999, bob.ID, CloneOptions{})
+	assert.ErrorIs(t, err, ErrQuizSuiteNotFound)
+}
+
+func TestCloneQuizSuiteSharingQuizzes(t *testing.T) {
+	ctx := context.Background()
+	kit := newTestKit(t)
+	ann := kit.user(t, "ann")
+	bob := kit.user(t, "bob")
+	qs := kit.quizSuite(t, ann)
+	first := kit.quiz(t, ann, "4")
+	second := kit.quiz(t, ann, "6")
+	require.NoError(t, kit.quizSuites.AddQuizToSuite(ctx, qs.ID, second.ID))
+	require.NoError(t, kit.quizSuites.AddQuizToSuite(ctx, qs.ID, first.ID))
+
+	// The shared quizzes keep the order of the suite, not of their IDs
+	clone, err := kit.quizSuites.CloneQuizSuite(ctx, qs.ID, bob.ID, CloneOptions{ShareQuizzes: true})
+	require.NoError(t, err)
+	assert.Nil(t, clone.ClonedFromID)
+	require.Len(t, clone.Quizzes, 2)
+	assert.Equal(t, second.ID, clone.Quizzes[0].ID)
+	assert.Equal(t, first.ID, clone.Quizzes[1].ID)
+
+	// No quizzes were created for bob
+	quizzes, err := kit.quizzes.GetQuizzesByUserID(ctx, bob.ID)
+	require.NoError(t, err)
+	assert.Empty(t, quizzes)
+}
@@ -0,0 +1,217 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"quizlet/internal/apperr"
+	"quizlet/internal/models/audit_entry"
+	"quizlet/internal/models/quiz"
+	"quizlet/internal/models/quiz_attempt"
+	"quizlet/internal/models/quiz_suite"
+	"quizlet/internal/models/trash"
+	"quizlet/internal/models/user"
+	"quizlet/internal/repository"
+	"quizlet/internal/tracing"
+)
+
+var (
+	ErrTrashItemNotFound = apperr.New(apperr.NotFound, "trash_item_not_found", "item not found in the trash")
+	ErrQuizSuiteInTrash  = apperr.New(apperr.Conflict, "quiz_suite_in_trash", "the quiz suite of the attempt is in the trash; restore it first")
+)
+
+// TrashService lists, restores and purges deleted content. Items of other
+// users are reported as not found, as with other endpoints.
+type TrashService interface {
+	// List returns the user's items in the trash, most recently deleted first
+	List(ctx context.Context, userID uint) ([]trash.Item, error)
+	RestoreQuiz(ctx context.Context, id, userID uint) (*quiz.Quiz, error)
+	// RestoreQuizSuite brings back the attempts deleted with the suite too
+	RestoreQuizSuite(ctx context.Context, id, userID uint) (*quiz_suite.QuizSuite, error)
+	RestoreQuizAttempt(ctx context.Context, id, userID uint) (*quiz_attempt.QuizAttempt, error)
+	// RestoreUser is for administrators; it brings back the content deleted
+	// with the user too
+	RestoreUser(ctx context.Context, id uint) (*user.User, error)
+	// Purge deletes what has been in the trash longer than the retention
+	// period for good and returns how many records it deleted
+	Purge(ctx context.Context) (int64, error)
+}
+
+type trashService struct {
+	trashRepo repository.TrashRepository
+	uow       repository.UnitOfWork
+	retention time.Duration
+}
+
+func NewTrashService(trashRepo repository.TrashRepository, uow repository.UnitOfWork, retention time.Duration) TrashService {
+	return &trashService{
+		trashRepo: trashRepo,
+		uow:       uow,
+		retention: retention,
+	}
+}
+
+func (s *trashService) List(ctx context.Context, userID uint) ([]trash.Item, error) {
+	ctx, span := tracing.Start(ctx, "TrashService.List")
+	defer span.End()
+
+	quizzes, err := s.trashRepo.ListQuizzes(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	quizSuites, err := s.trashRepo.ListQuizSuites(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	attempts, err := s.trashRepo.ListQuizAttempts(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]trash.Item, 0, len(quizzes)+len(quizSuites)+len(attempts))
+	for _, q := range quizzes {
+		items = append(items, s.item(trash.TypeQuiz, q.ID, q.Question, 0, q.DeletedAt.Time))
+	}
+	for _, qs := range quizSuites {
+		items = append(items, s.item(trash.TypeQuizSuite, qs.ID, qs.Title, 0, qs.DeletedAt.Time))
+	}
+	for _, attempt := range attempts {
+		items = append(items, s.item(trash.TypeQuizAttempt, attempt.ID, "", attempt.QuizSuiteID, attempt.DeletedAt.Time))
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].DeletedAt.After(items[j].DeletedAt)
+	})
+	return items, nil
+}
+
+func (s *trashService) item(itemType string, id uint, title string, quizSuiteID uint, deletedAt time.Time) trash.Item {
+	return trash.Item{
+		Type:        itemType,
+		ID:          id,
+		Title:       title,
+		QuizSuiteID: quizSuiteID,
+		DeletedAt:   deletedAt,
+		PurgeAt:     deletedAt.Add(s.retention),
+	}
+}
+
+func (s *trashService) RestoreQuiz(ctx context.Context, id, userID uint) (*quiz.Quiz, error) {
+	ctx, span := tracing.Start(ctx, "TrashService.RestoreQuiz")
+	defer span.End()
+
+	var restored *quiz.Quiz
+	err := s.uow.Do(ctx, func(ctx context.Context, repos repository.Repositories) error {
+		q, err := repos.Trash.FindQuiz(ctx, id)
+		if err != nil {
+			return notFound(err, ErrTrashItemNotFound)
+		}
+		if q.CreatedByID != userID {
+			return ErrTrashItemNotFound
+		}
+		if err := repos.Trash.RestoreQuiz(ctx, q); err != nil {
+			return notFound(err, ErrTrashItemNotFound)
+		}
+		if err := record(ctx, repos, audit_entry.ActionRestore, audit_entry.ResourceQuiz, id, audit_entry.Changes{}); err != nil {
+			return err
+		}
+		restored, err = repos.Quizzes.FindByID(ctx, id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return restored, nil
+}
+
+func (s *trashService) RestoreQuizSuite(ctx context.Context, id, userID uint) (*quiz_suite.QuizSuite, error) {
+	ctx, span := tracing.Start(ctx, "TrashService.RestoreQuizSuite")
+	defer span.End()
+
+	var restored *quiz_suite.QuizSuite
+	err := s.uow.Do(ctx, func(ctx context.Context, repos repository.Repositories) error {
+		quizSuite, err := repos.Trash.FindQuizSuite(ctx, id)
+		if err != nil {
+			return notFound(err, ErrTrashItemNotFound)
+		}
+		if quizSuite.CreatedByID != userID {
+			return ErrTrashItemNotFound
+		}
+		if err := repos.Trash.RestoreQuizSuite(ctx, quizSuite); err != nil {
+			return notFound(err, ErrTrashItemNotFound)
+		}
+		if err := record(ctx, repos, audit_entry.ActionRestore, audit_entry.ResourceQuizSuite, id, audit_entry.Changes{}); err != nil {
+			return err
+		}
+		restored, err = repos.QuizSuites.FindByID(ctx, id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return restored, nil
+}
+
+func (s *trashService) RestoreQuizAttempt(ctx context.Context, id, userID uint) (*quiz_attempt.QuizAttempt, error) {
+	ctx, span := tracing.Start(ctx, "TrashService.RestoreQuizAttempt")
+	defer span.End()
+
+	var restored *quiz_attempt.QuizAttempt
+	err := s.uow.Do(ctx, func(ctx context.Context, repos repository.Repositories) error {
+		attempt, err := repos.Trash.FindQuizAttempt(ctx, id)
+		if err != nil {
+			return notFound(err, ErrTrashItemNotFound)
+		}
+		if attempt.UserID != userID {
+			return ErrTrashItemNotFound
+		}
+		// An attempt cannot come back without its suite
+		if _, err := repos.QuizSuites.FindByID(ctx, attempt.QuizSuiteID); err != nil {
+			return notFound(err, ErrQuizSuiteInTrash)
+		}
+		if err := repos.Trash.RestoreQuizAttempt(ctx, attempt); err != nil {
+			return notFound(err, ErrTrashItemNotFound)
+		}
+		if err := record(ctx, repos, audit_entry.ActionRestore, audit_entry.ResourceQuizAttempt, id, audit_entry.Changes{}); err != nil {
+			return err
+		}
+		restored, err = repos.QuizAttempts.Get(ctx, id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return restored, nil
+}
+
+func (s *trashService) RestoreUser(ctx context.Context, id uint) (*user.User, error) {
+	ctx, span := tracing.Start(ctx, "TrashService.RestoreUser")
+	defer span.End()
+
+	var restored *user.User
+	err := s.uow.Do(ctx, func(ctx context.Context, repos repository.Repositories) error {
+		u, err := repos.Trash.FindUser(ctx, id)
+		if err != nil {
+			return notFound(err, ErrTrashItemNotFound)
+		}
+		if err := repos.Trash.RestoreUser(ctx, u); err != nil {
+			return notFound(err, ErrTrashItemNotFound)
+		}
+		if err := record(ctx, repos, audit_entry.ActionRestore, audit_entry.ResourceUser, id, audit_entry.Changes{}); err != nil {
+			return err
+		}
+		restored, err = repos.Users.FindByID(ctx, id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return restored, nil
+}
+
+func (s *trashService) Purge(ctx context.Context) (int64, error) {
+	ctx, span := tracing.Start(ctx, "TrashService.Purge")
+	defer span.End()
+
+	return s.trashRepo.Purge(ctx, time.Now().Add(-s.retention))
+}
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"quizlet/internal/models/quiz_attempt"
+	"quizlet/internal/models/trash"
+	"quizlet/internal/repository/memory"
+)
+
+func TestTrashQuizSuite(t *testing.T) {
+	ctx := context.Background()
+	kit := newTestKit(t)
+	ann := kit.user(t, "ann")
+	bob := kit.user(t, "bob")
+	qs := kit.quizSuite(t, ann)
+	attempt, err := kit.quizAttempts.Create(ctx, qs.ID, ann.ID, quiz_attempt.CreateQuizAttemptRequest{})
+	require.NoError(t, err)
+
+	require.NoError(t, kit.quizSuites.DeleteQuizSuite(ctx, qs.ID, 0))
+	_, err = kit.quizAttempts.Get(ctx, attempt.ID, ann.ID)
+	assert.ErrorIs(t, err, ErrQuizAttemptNotFound)
+
+	items, err := kit.trash.List(ctx, ann.ID)
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+	types := []string{items[0].Type, items[1].Type}
+	assert.ElementsMatch(t, []string{trash.TypeQuizSuite, trash.TypeQuizAttempt}, types)
+	for _, item := range items {
+		assert.Equal(t, item.DeletedAt.Add(trashRetention), item.PurgeAt)
+	}
+	others, err := kit.trash.List(ctx, bob.ID)
+	require.NoError(t, err)
+	assert.Empty(t, others)
+
+	// The attempt cannot come back before its suite, and the suite is only
+	// restored by its owner
+	_, err = kit.trash.RestoreQuizAttempt(ctx, attempt.ID, ann.ID)
+	assert.ErrorIs(t, err, ErrQuizSuiteInTrash)
+	_, err = kit.trash.RestoreQuizSuite(ctx, qs.ID, bob.ID)
+	assert.ErrorIs(t, err, ErrTrashItemNotFound)
+
+	restored, err := kit.trash.RestoreQuizSuite(ctx, qs.ID, ann.ID)
+	require.NoError(t, err)
+	assert.Equal(t, qs.Title, restored.Title)
+	_, err = kit.quizAttempts.Get(ctx, attempt.ID, ann.ID)
+	require.NoError(t, err)
+	_, err = kit.trash.RestoreQuizSuite(ctx, qs.ID, ann.ID)
+	assert.ErrorIs(t, err, ErrTrashItemNotFound)
+
+	items, err = kit.trash.List(ctx, ann.ID)
+	require.NoError(t, err)
+	assert.Empty(t, items)
+}
+
+func TestTrashQuizAndAttempt(t *testing.T) {
+	ctx := context.Background()
+	kit := newTestKit(t)
+	ann := kit.user(t, "ann")
+	bob := kit.user(t, "bob")
+	q := kit.quiz(t, ann, "4", "5")
+	qs := kit.quizSuite(t, ann)
+	attempt, err := kit.quizAttempts.Create(ctx, qs.ID, bob.ID, quiz_attempt.CreateQuizAttemptRequest{})
+	require.NoError(t, err)
+
+	require.NoError(t, kit.quizzes.DeleteQuiz(ctx, q.ID, 0))
+	require.NoError(t, kit.quizAttempts.Delete(ctx, attempt.ID, bob.ID))
+
+	_, err = kit.trash.RestoreQuiz(ctx, q.ID, bob.ID)
+	assert.ErrorIs(t, err, ErrTrashItemNotFound)
+	restoredQuiz, err := kit.trash.RestoreQuiz(ctx, q.ID, ann.ID)
+	require.NoError(t, err)
+	assert.Len(t, restoredQuiz.Selections, 2)
+
+	_, err = kit.trash.RestoreQuizAttempt(ctx, attempt.ID, ann.ID)
+	assert.ErrorIs(t, err, ErrTrashItemNotFound)
+	restoredAttempt, err := kit.trash.RestoreQuizAttempt(ctx, attempt.ID, bob.ID)
+	require.NoError(t, err)
+	assert.Equal(t, attempt.ID, restoredAttempt.ID)
+}
+
+func TestTrashUserAndPurge(t *testing.T) {
+	ctx := context.Background()
+	kit := newTestKit(t)
+	ann := kit.user(t, "ann")
+	users := memory.NewUserRepository(kit.store)
+
+	_, err := kit.trash.RestoreUser(ctx, ann.ID)
+	assert.ErrorIs(t, err, ErrTrashItemNotFound)
+	require.NoError(t, users.Delete(ctx, ann.ID))
+	restored, err := kit.trash.RestoreUser(ctx, ann.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "ann", restored.Username)
+
+	// Nothing has been in the trash for the retention period yet
+	qs := kit.quizSuite(t, ann)
+	require.NoError(t, kit.quizSuites.DeleteQuizSuite(ctx, qs.ID, 0))
+	require.NoError(t, users.Delete(ctx, ann.ID))
+	purged, err := kit.trash.Purge(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, purged)
+
+	expired := NewTrashService(memory.NewTrashRepository(kit.store), memory.NewUnitOfWork(kit.store), 0)
+	purged, err = expired.Purge(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), purged)
+	_, err = kit.trash.RestoreUser(ctx, ann.ID)
+	assert.ErrorIs(t, err, ErrTrashItemNotFound)
+}
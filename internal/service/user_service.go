@@ -1,101 +1,244 @@
 package service
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
-	"log"
+	"log/slog"
+	"strings"
+	"unicode"
+	"quizlet/internal/apperr"
+	"quizlet/internal/models/audit_entry"
 	"quizlet/internal/models/user"
 	"quizlet/internal/repository"
+	"quizlet/internal/tracing"
 	"quizlet/internal/auth"
+	"quizlet/internal/auth/password"
+	"quizlet/internal/metrics"
 	"time"
+
+	"gorm.io/gorm"
 )
 
 type UserService interface {
-	CreateUser(user *user.User) error
-	GetUserByID(id uint) (*user.User, error)
-	GetUserByEmail(email string) (*user.User, error)
-	UpdateUser(user *user.User) error
-	DeleteUser(id uint) error
-	ValidatePassword(email, password string) (*user.User, error)
-	CreateRefreshToken(userID uint) (*user.RefreshToken, error)
-	ValidateRefreshToken(token string) (*user.RefreshToken, error)
-	RevokeRefreshToken(token string) error
+	CreateUser(ctx context.Context, user *user.User) error
+	GetUserByID(ctx context.Context, id uint) (*user.User, error)
+	GetUserByEmail(ctx context.Context, email string) (*user.User, error)
+	UpdateUser(ctx context.Context, user *user.User) error
+	DeleteUser(ctx context.Context, id uint) error
+	ValidatePassword(ctx context.Context, email, password string) (*user.User, error)
+	CreateRefreshToken(ctx context.Context, userID uint) (*user.RefreshToken, error)
+	ValidateRefreshToken(ctx context.Context, token string) (*user.RefreshToken, error)
+	RevokeRefreshToken(ctx context.Context, token string) error
+	BeginTOTPEnrollment(ctx context.Context, userID uint) (*user.TOTPEnrollment, error)
+	ConfirmTOTPEnrollment(ctx context.Context, userID uint, code string) ([]string, error)
+	DisableTOTP(ctx context.Context, userID uint, code string) error
+	BeginMFAChallenge(ctx context.Context, userID uint) error
+	VerifyMFACode(ctx context.Context, userID uint, code string) (*user.User, error)
+	LoginWithExternalIdentity(ctx context.Context, profile *user.ExternalProfile) (*user.User, error)
+	LinkExternalIdentity(ctx context.Context, userID uint, profile *user.ExternalProfile) (*user.ExternalIdentity, error)
 }
 
+// maxMFAAttempts is how many codes may be tried per two-factor login challenge
+const maxMFAAttempts = 5
+
+var (
+	ErrUserNotFound       = apperr.New(apperr.NotFound, "user_not_found", "user not found")
+	ErrUserForbidden      = apperr.New(apperr.Forbidden, "user_forbidden", "you can only change your own account")
+	ErrEmailTaken         = apperr.New(apperr.Conflict, "email_taken", "user with this email already exists")
+	ErrInvalidCredentials = apperr.New(apperr.Unauthenticated, "invalid_credentials", "invalid email or password")
+
+	ErrMFAAlreadyEnabled  = apperr.New(apperr.Conflict, "mfa_already_enabled", "two-factor authentication is already enabled")
+	ErrMFANotEnabled      = apperr.New(apperr.Invalid, "mfa_not_enabled", "two-factor authentication is not enabled")
+	ErrMFANotEnrolled     = apperr.New(apperr.Invalid, "mfa_not_enrolled", "two-factor enrollment has not been started")
+	ErrInvalidMFACode     = apperr.New(apperr.Invalid, "invalid_mfa_code", "invalid two-factor code")
+	ErrTooManyMFAAttempts = apperr.New(apperr.Unauthenticated, "too_many_mfa_attempts", "too many two-factor codes tried, sign in again")
+
+	// ErrWeakPassword wraps the password.PolicyError, so errors.Is matches
+	// both this error and password.ErrWeakPassword
+	ErrWeakPassword = apperr.New(apperr.Invalid, "weak_password", password.ErrWeakPassword.Error())
+
+	ErrExternalEmailMissing    = apperr.New(apperr.Unauthenticated, "external_email_missing", "identity provider did not return an email address")
+	ErrExternalEmailUnverified = apperr.New(apperr.Unauthenticated, "external_email_unverified", "identity provider email address is not verified")
+	ErrExternalAccountExists   = apperr.New(apperr.Conflict, "external_account_exists", "an account with this email already exists, sign in to it and link the identity provider")
+	ErrExternalIdentityLinked  = apperr.New(apperr.Conflict, "external_identity_linked", "this identity provider account is linked to another user")
+)
+
 type userService struct {
 	userRepo repository.UserRepository
 	refreshTokenRepo repository.RefreshTokenRepository
+	recoveryCodeRepo repository.RecoveryCodeRepository
+	externalIdentityRepo repository.ExternalIdentityRepository
+	uow repository.UnitOfWork
+	hasher password.Hasher
+	policy password.Policy
+	refreshTokenTTL time.Duration
+	logger *slog.Logger
 }
 
-func NewUserService(userRepo repository.UserRepository, refreshTokenRepo repository.RefreshTokenRepository) UserService {
+func NewUserService(userRepo repository.UserRepository, refreshTokenRepo repository.RefreshTokenRepository, recoveryCodeRepo repository.RecoveryCodeRepository, externalIdentityRepo repository.ExternalIdentityRepository, uow repository.UnitOfWork, hasher password.Hasher, policy password.Policy, refreshTokenTTL time.Duration, logger *slog.Logger) UserService {
 	return &userService{
 		userRepo: userRepo,
 		refreshTokenRepo: refreshTokenRepo,
+		recoveryCodeRepo: recoveryCodeRepo,
+		externalIdentityRepo: externalIdentityRepo,
+		uow: uow,
+		hasher: hasher,
+		policy: policy,
+		refreshTokenTTL: refreshTokenTTL,
+		logger: logger,
 	}
 }
 
-func (s *userService) CreateUser(user *user.User) error {
+func (s *userService) CreateUser(ctx context.Context, user *user.User) error {
+	ctx, span := tracing.Start(ctx, "UserService.CreateUser")
+	defer span.End()
+
 	// Check if user already exists
-	existingUser, err := s.userRepo.FindByEmail(user.Email)
+	existingUser, err := s.userRepo.FindByEmail(ctx, user.Email)
 	if err == nil && existingUser != nil {
-		return errors.New("user with this email already exists")
+		return ErrEmailTaken
+	}
+
+	if err := s.policy.Validate(user.Password, user.Username, user.Email); err != nil {
+		return weakPassword(err)
 	}
 
 	// Hash the password before saving
-	if err := user.HashPassword(); err != nil {
+	if err := user.HashPassword(s.hasher); err != nil {
 		return err
 	}
 
-	return s.userRepo.Create(user)
+	return s.uow.Do(ctx, func(ctx context.Context, repos repository.Repositories) error {
+		if err := repos.Users.Create(ctx, user); err != nil {
+			return err
+		}
+		return recordChange(ctx, repos, audit_entry.ActionCreate, audit_entry.ResourceUser, user.ID, nil, user)
+	})
 }
 
-func (s *userService) GetUserByID(id uint) (*user.User, error) {
-	return s.userRepo.FindByID(id)
+func (s *userService) GetUserByID(ctx context.Context, id uint) (*user.User, error) {
+	ctx, span := tracing.Start(ctx, "UserService.GetUserByID")
+	defer span.End()
+
+	u, err := s.userRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, notFound(err, ErrUserNotFound)
+	}
+	return u, nil
 }
 
-func (s *userService) GetUserByEmail(email string) (*user.User, error) {
-	return s.userRepo.FindByEmail(email)
+func (s *userService) GetUserByEmail(ctx context.Context, email string) (*user.User, error) {
+	ctx, span := tracing.Start(ctx, "UserService.GetUserByEmail")
+	defer span.End()
+
+	return s.userRepo.FindByEmail(ctx, email)
 }
 
-func (s *userService) UpdateUser(user *user.User) error {
-	// If password is being updated, hash it
+func (s *userService) UpdateUser(ctx context.Context, user *user.User) error {
+	ctx, span := tracing.Start(ctx, "UserService.UpdateUser")
+	defer span.End()
+
+	existing, err := s.userRepo.FindByID(ctx, user.ID)
+	if err != nil {
+		return notFound(err, ErrUserNotFound)
+	}
+
+	// If password is being updated, hash it; otherwise keep the stored hash
 	if user.Password != "" {
-		if err := user.HashPassword(); err != nil {
+		if err := s.policy.Validate(user.Password, user.Username, user.Email); err != nil {
+			return weakPassword(err)
+		}
+		if err := user.HashPassword(s.hasher); err != nil {
 			return err
 		}
+	} else {
+		user.Password = existing.Password
 	}
-	return s.userRepo.Update(user)
+	return s.uow.Do(ctx, func(ctx context.Context, repos repository.Repositories) error {
+		// Two-factor state only changes through the enrollment endpoints and
+		// the MFA checks, and roles only in the database, so they are not
+		// written here
+		if err := repos.Users.UpdateProfile(ctx, user); err != nil {
+			return err
+		}
+		updated, err := repos.Users.FindByID(ctx, user.ID)
+		if err != nil {
+			return notFound(err, ErrUserNotFound)
+		}
+		*user = *updated
+		changes, err := audit_entry.Diff(existing, user)
+		if err != nil {
+			return err
+		}
+		// The hash is left out of the JSON, so the diff cannot show it
+		if user.Password != existing.Password {
+			changes["password"] = audit_entry.Change{Before: audit_entry.Redacted, After: audit_entry.Redacted}
+		}
+		return record(ctx, repos, audit_entry.ActionUpdate, audit_entry.ResourceUser, user.ID, changes)
+	})
 }
 
-func (s *userService) DeleteUser(id uint) error {
-	return s.userRepo.Delete(id)
+func (s *userService) DeleteUser(ctx context.Context, id uint) error {
+	ctx, span := tracing.Start(ctx, "UserService.DeleteUser")
+	defer span.End()
+
+	return s.uow.Do(ctx, func(ctx context.Context, repos repository.Repositories) error {
+		existing, err := repos.Users.FindByID(ctx, id)
+		if err != nil {
+			return notFound(err, ErrUserNotFound)
+		}
+		if err := repos.Users.Delete(ctx, id); err != nil {
+			return err
+		}
+		// The user's content goes to the trash too, so restoring the user
+		// brings it back
+		if err := repos.Trash.TrashUserContent(ctx, id); err != nil {
+			return err
+		}
+		return recordChange(ctx, repos, audit_entry.ActionDelete, audit_entry.ResourceUser, id, existing, nil)
+	})
 }
 
-func (s *userService) ValidatePassword(email, password string) (*user.User, error) {
-	log.Printf("Validating password for email: %s (password length: %d)", email, len(password))
-	
-	user, err := s.userRepo.FindByEmail(email)
+func (s *userService) ValidatePassword(ctx context.Context, email, password string) (*user.User, error) {
+	ctx, span := tracing.Start(ctx, "UserService.ValidatePassword")
+	defer span.End()
+
+	// Unknown emails and wrong passwords get the same error so the login
+	// form cannot be used to find out which emails have an account
+	user, err := s.userRepo.FindByEmail(ctx, email)
 	if err != nil {
-		log.Printf("Error finding user by email %s: %v", email, err)
-		return nil, err
+		return nil, notFound(err, ErrInvalidCredentials)
 	}
 	if user == nil {
-		log.Printf("User not found for email: %s", email)
-		return nil, errors.New("user not found")
+		return nil, ErrInvalidCredentials
+	}
+
+	if !user.CheckPassword(s.hasher, password) {
+		s.logger.InfoContext(ctx, "password login rejected", "user_id", user.ID)
+		return nil, ErrInvalidCredentials
 	}
 
-	log.Printf("Found user with email %s (ID: %d), checking password", email, user.ID)
-	log.Printf("Stored password hash length: %d", len(user.Password))
-	
-	if !user.CheckPassword(password) {
-		log.Printf("Invalid password for user: %s (ID: %d)", email, user.ID)
-		return nil, errors.New("invalid password")
+	// Upgrade hashes made with an older algorithm or weaker parameters while
+	// the plaintext is at hand; a failure here must not block the login
+	if s.hasher.NeedsRehash(user.Password) {
+		if hashed, err := s.hasher.Hash(password); err != nil {
+			s.logger.ErrorContext(ctx, "rehashing password failed", "user_id", user.ID, "error", err)
+		} else if err := s.userRepo.UpdatePassword(ctx, user.ID, hashed); err != nil {
+			s.logger.ErrorContext(ctx, "storing rehashed password failed", "user_id", user.ID, "error", err)
+		} else {
+			user.Password = hashed
+		}
 	}
 
-	log.Printf("Password validation successful for user: %s (ID: %d)", email, user.ID)
 	return user, nil
 }
 
-func (s *userService) CreateRefreshToken(userID uint) (*user.RefreshToken, error) {
+func (s *userService) CreateRefreshToken(ctx context.Context, userID uint) (*user.RefreshToken, error) {
+	ctx, span := tracing.Start(ctx, "UserService.CreateRefreshToken")
+	defer span.End()
+
 	token, err := auth.GenerateRefreshToken()
 	if err != nil {
 		return nil, err
@@ -104,29 +247,393 @@ func (s *userService) CreateRefreshToken(userID uint) (*user.RefreshToken, error
 	refreshToken := &user.RefreshToken{
 		Token:     token,
 		UserID:    userID,
-		ExpiresAt: time.Now().Add(30 * 24 * time.Hour), // 30 days
+		ExpiresAt: time.Now().Add(s.refreshTokenTTL),
 	}
 
-	if err := s.refreshTokenRepo.Create(refreshToken); err != nil {
+	if err := s.refreshTokenRepo.Create(ctx, refreshToken); err != nil {
 		return nil, err
 	}
+	metrics.RefreshTokenIssued()
 
 	return refreshToken, nil
 }
 
-func (s *userService) ValidateRefreshToken(token string) (*user.RefreshToken, error) {
-	refreshToken, err := s.refreshTokenRepo.FindByToken(token)
+func (s *userService) ValidateRefreshToken(ctx context.Context, token string) (*user.RefreshToken, error) {
+	ctx, span := tracing.Start(ctx, "UserService.ValidateRefreshToken")
+	defer span.End()
+
+	refreshToken, err := s.refreshTokenRepo.FindByToken(ctx, token)
 	if err != nil {
-		return nil, err
+		return nil, notFound(err, auth.ErrInvalidRefreshToken)
 	}
 
 	if refreshToken.ExpiresAt.Before(time.Now()) {
-		return nil, auth.ErrExpiredToken
+		return nil, auth.ErrExpiredToken.WithMessage("refresh token has expired")
 	}
 
 	return refreshToken, nil
 }
 
-func (s *userService) RevokeRefreshToken(token string) error {
-	return s.refreshTokenRepo.Revoke(token)
-} 
\ No newline at end of file
+func (s *userService) RevokeRefreshToken(ctx context.Context, token string) error {
+	ctx, span := tracing.Start(ctx, "UserService.RevokeRefreshToken")
+	defer span.End()
+
+	return s.refreshTokenRepo.Revoke(ctx, token)
+} 
+func (s *userService) BeginTOTPEnrollment(ctx context.Context, userID uint) (*user.TOTPEnrollment, error) {
+	ctx, span := tracing.Start(ctx, "UserService.BeginTOTPEnrollment")
+	defer span.End()
+
+	u, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, notFound(err, ErrUserNotFound)
+	}
+	if u.TOTPEnabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	// The secret stays pending until the user proves their app generates valid codes
+	before := *u
+	u.TOTPSecret = secret
+	u.TOTPLastStep = 0
+	err = s.uow.Do(ctx, func(ctx context.Context, repos repository.Repositories) error {
+		return s.updateUser(ctx, repos, &before, u)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &user.TOTPEnrollment{
+		Secret:     secret,
+		OTPAuthURI: auth.TOTPURI(u.Email, secret),
+	}, nil
+}
+
+func (s *userService) ConfirmTOTPEnrollment(ctx context.Context, userID uint, code string) ([]string, error) {
+	ctx, span := tracing.Start(ctx, "UserService.ConfirmTOTPEnrollment")
+	defer span.End()
+
+	u, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, notFound(err, ErrUserNotFound)
+	}
+	if u.TOTPEnabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+	if u.TOTPSecret == "" {
+		return nil, ErrMFANotEnrolled
+	}
+
+	step, ok := auth.ValidateTOTPCode(u.TOTPSecret, code, time.Now())
+	if !ok {
+		return nil, ErrInvalidMFACode
+	}
+
+	codes, err := auth.GenerateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	recoveryCodes := make([]*user.RecoveryCode, len(codes))
+	for i, code := range codes {
+		recoveryCodes[i] = &user.RecoveryCode{
+			UserID:   userID,
+			CodeHash: auth.HashRecoveryCode(code),
+		}
+	}
+
+	before := *u
+	u.TOTPEnabled = true
+	u.TOTPLastStep = step
+	err = s.uow.Do(ctx, func(ctx context.Context, repos repository.Repositories) error {
+		if err := repos.RecoveryCodes.ReplaceForUser(ctx, userID, recoveryCodes); err != nil {
+			return err
+		}
+		return s.updateUser(ctx, repos, &before, u)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+func (s *userService) DisableTOTP(ctx context.Context, userID uint, code string) error {
+	ctx, span := tracing.Start(ctx, "UserService.DisableTOTP")
+	defer span.End()
+
+	if _, err := s.VerifyMFACode(ctx, userID, code); err != nil {
+		return err
+	}
+
+	u, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return notFound(err, ErrUserNotFound)
+	}
+
+	before := *u
+	u.TOTPEnabled = false
+	u.TOTPSecret = ""
+	u.TOTPLastStep = 0
+	// The recovery codes go with the second factor, so they cannot come
+	// back to life when the user enrolls again
+	return s.uow.Do(ctx, func(ctx context.Context, repos repository.Repositories) error {
+		if err := repos.RecoveryCodes.DeleteByUserID(ctx, userID); err != nil {
+			return err
+		}
+		return s.updateUser(ctx, repos, &before, u)
+	})
+}
+
+// updateUser saves u within a unit of work and records how it differs from
+// before
+func (s *userService) updateUser(ctx context.Context, repos repository.Repositories, before, u *user.User) error {
+	if err := repos.Users.Update(ctx, u); err != nil {
+		return err
+	}
+	changes, err := audit_entry.Diff(before, u)
+	if err != nil {
+		return err
+	}
+	// The secret is left out of the JSON like the password hash, so the
+	// diff cannot show it
+	if u.TOTPSecret != before.TOTPSecret {
+		changes["totp_secret"] = audit_entry.Change{Before: audit_entry.Redacted, After: audit_entry.Redacted}
+	}
+	return record(ctx, repos, audit_entry.ActionUpdate, audit_entry.ResourceUser, u.ID, changes)
+}
+
+// BeginMFAChallenge gives a new two-factor login challenge a fresh allowance
+// of codes
+func (s *userService) BeginMFAChallenge(ctx context.Context, userID uint) error {
+	ctx, span := tracing.Start(ctx, "UserService.BeginMFAChallenge")
+	defer span.End()
+
+	return s.userRepo.ResetMFAAttempts(ctx, userID)
+}
+
+// VerifyMFACode accepts either a current TOTP code or an unused recovery code.
+// Only maxMFAAttempts codes are checked per challenge, after which the user
+// has to sign in again.
+func (s *userService) VerifyMFACode(ctx context.Context, userID uint, code string) (*user.User, error) {
+	ctx, span := tracing.Start(ctx, "UserService.VerifyMFACode")
+	defer span.End()
+
+	u, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, notFound(err, ErrUserNotFound)
+	}
+	if !u.TOTPEnabled {
+		return nil, ErrMFANotEnabled
+	}
+
+	// Count the attempt before checking the code so concurrent guesses
+	// cannot exceed the limit
+	if err := s.userRepo.CountMFAAttempt(ctx, userID, maxMFAAttempts); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTooManyMFAAttempts
+		}
+		return nil, err
+	}
+
+	if step, ok := auth.ValidateTOTPCode(u.TOTPSecret, code, time.Now()); ok {
+		// Each code may only be used once, even by concurrent requests
+		if err := s.userRepo.AdvanceTOTPStep(ctx, userID, step); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, ErrInvalidMFACode
+			}
+			return nil, err
+		}
+		u.TOTPLastStep = step
+		u.MFAAttempts = 0
+		return u, nil
+	}
+
+	recoveryCode, err := s.recoveryCodeRepo.FindUnused(ctx, userID, auth.HashRecoveryCode(code))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidMFACode
+		}
+		return nil, err
+	}
+	if err := s.recoveryCodeRepo.MarkUsed(ctx, recoveryCode.ID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidMFACode
+		}
+		return nil, err
+	}
+	if err := s.userRepo.ResetMFAAttempts(ctx, userID); err != nil {
+		return nil, err
+	}
+	u.MFAAttempts = 0
+
+	return u, nil
+}
+
+// LoginWithExternalIdentity resolves the local user for an identity verified by
+// an external provider. Known identities map straight to their user and
+// otherwise a new account is created just in time. An email that already
+// belongs to an account is not enough to sign in to it; the owner has to
+// sign in and link the provider with LinkExternalIdentity first.
+func (s *userService) LoginWithExternalIdentity(ctx context.Context, profile *user.ExternalProfile) (*user.User, error) {
+	ctx, span := tracing.Start(ctx, "UserService.LoginWithExternalIdentity")
+	defer span.End()
+
+	identity, err := s.externalIdentityRepo.FindByProviderSubject(ctx, profile.Provider, profile.Subject)
+	if err == nil {
+		// The linked user may be in the trash
+		u, err := s.userRepo.FindByID(ctx, identity.UserID)
+		if err != nil {
+			return nil, notFound(err, ErrUserNotFound)
+		}
+		return u, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	if profile.Email == "" {
+		return nil, ErrExternalEmailMissing
+	}
+	// Creating an account by email is only safe when the provider vouches for it
+	if !profile.EmailVerified {
+		return nil, ErrExternalEmailUnverified
+	}
+
+	_, err = s.userRepo.FindByEmail(ctx, profile.Email)
+	if err == nil {
+		return nil, ErrExternalAccountExists
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	u, err := s.createExternalUser(ctx, profile)
+	if err != nil {
+		return nil, err
+	}
+	s.logger.InfoContext(ctx, "user created from external login", "user_id", u.ID, "provider", profile.Provider)
+
+	if _, err := s.linkExternalIdentity(ctx, u.ID, profile); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// LinkExternalIdentity links an identity verified by an external provider to
+// the signed-in user, so they can sign in with the provider from then on
+func (s *userService) LinkExternalIdentity(ctx context.Context, userID uint, profile *user.ExternalProfile) (*user.ExternalIdentity, error) {
+	ctx, span := tracing.Start(ctx, "UserService.LinkExternalIdentity")
+	defer span.End()
+
+	identity, err := s.externalIdentityRepo.FindByProviderSubject(ctx, profile.Provider, profile.Subject)
+	if err == nil {
+		if identity.UserID != userID {
+			return nil, ErrExternalIdentityLinked
+		}
+		return identity, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	if _, err := s.userRepo.FindByID(ctx, userID); err != nil {
+		return nil, notFound(err, ErrUserNotFound)
+	}
+	return s.linkExternalIdentity(ctx, userID, profile)
+}
+
+func (s *userService) linkExternalIdentity(ctx context.Context, userID uint, profile *user.ExternalProfile) (*user.ExternalIdentity, error) {
+	identity := &user.ExternalIdentity{
+		UserID:   userID,
+		Provider: profile.Provider,
+		Subject:  profile.Subject,
+		Email:    profile.Email,
+	}
+	if err := s.externalIdentityRepo.Create(ctx, identity); err != nil {
+		return nil, err
+	}
+	s.logger.InfoContext(ctx, "external identity linked", "user_id", userID, "provider", profile.Provider)
+	return identity, nil
+}
+
+func (s *userService) createExternalUser(ctx context.Context, profile *user.ExternalProfile) (*user.User, error) {
+	username, err := s.availableUsername(ctx, profile.Email)
+	if err != nil {
+		return nil, err
+	}
+
+	// The account gets a random password nobody knows; the user signs in through the provider
+	password, err := auth.GenerateRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+
+	u := &user.User{
+		Username: username,
+		Email:    profile.Email,
+		Password: password,
+	}
+	if err := u.HashPassword(s.hasher); err != nil {
+		return nil, err
+	}
+	err = s.uow.Do(ctx, func(ctx context.Context, repos repository.Repositories) error {
+		if err := repos.Users.Create(ctx, u); err != nil {
+			return err
+		}
+		return recordChange(ctx, repos, audit_entry.ActionCreate, audit_entry.ResourceUser, u.ID, nil, u)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// availableUsername derives a unique username from the local part of an email address
+func (s *userService) availableUsername(ctx context.Context, email string) (string, error) {
+	base := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '.' || r == '_' || r == '-' {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, strings.SplitN(email, "@", 2)[0])
+	if base == "" {
+		base = "user"
+	}
+
+	candidate := base
+	for i := 0; i < 5; i++ {
+		_, err := s.userRepo.FindByUsername(ctx, candidate)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return candidate, nil
+		}
+		if err != nil {
+			return "", err
+		}
+
+		suffix := make([]byte, 3)
+		if _, err := rand.Read(suffix); err != nil {
+			return "", err
+		}
+		candidate = base + "-" + hex.EncodeToString(suffix)
+	}
+	return "", errors.New("could not find an available username")
+}
+
+// weakPassword reports a policy violation as a validation error on the
+// password field
+func weakPassword(err error) error {
+	weak := ErrWeakPassword.WithMessage(err.Error()).Wrap(err)
+	var policyErr *password.PolicyError
+	if errors.As(err, &policyErr) {
+		weak.Fields = []apperr.FieldError{{
+			Field:   "password",
+			Code:    "weak_password",
+			Message: "must " + strings.Join(policyErr.Violations, ", "),
+		}}
+	}
+	return weak
+}
@@ -0,0 +1,218 @@
+package service
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"quizlet/internal/auth"
+	"quizlet/internal/auth/password"
+	"quizlet/internal/models/user"
+	"quizlet/internal/repository"
+)
+
+func newUserService(t *testing.T, db *gorm.DB) (UserService, repository.UserRepository) {
+	t.Helper()
+	hasher, err := password.NewHasher(password.DefaultConfig())
+	require.NoError(t, err)
+	userRepo := repository.NewUserRepository(db)
+	users := NewUserService(
+		userRepo,
+		repository.NewRefreshTokenRepository(db),
+		repository.NewRecoveryCodeRepository(db),
+		repository.NewExternalIdentityRepository(db),
+		repository.NewUnitOfWork(db),
+		hasher,
+		password.DefaultPolicy(),
+		auth.DefaultTokenConfig().RefreshTokenTTL,
+		slog.New(slog.NewTextHandler(io.Discard, nil)),
+	)
+	return users, userRepo
+}
+
+// newMFAUser returns a user service over SQLite and a user who just enrolled
+// in two-factor authentication, with their recovery codes
+func newMFAUser(t *testing.T) (UserService, *user.User, []string) {
+	t.Helper()
+	users, userRepo := newUserService(t, openTestDB(t))
+	u, recoveryCodes := enrollMFAUser(t, users, userRepo)
+	return users, u, recoveryCodes
+}
+
+// enrollMFAUser creates a user and enrolls them in two-factor
+// authentication, returning their recovery codes
+func enrollMFAUser(t *testing.T, users UserService, userRepo repository.UserRepository) (*user.User, []string) {
+	t.Helper()
+	ctx := context.Background()
+
+	u := &user.User{Username: "ann", Email: "ann@example.com", Password: "hash"}
+	require.NoError(t, userRepo.Create(ctx, u))
+	enrollment, err := users.BeginTOTPEnrollment(ctx, u.ID)
+	require.NoError(t, err)
+	u.TOTPSecret = enrollment.Secret
+	code, err := auth.GenerateTOTPCode(u.TOTPSecret, auth.TOTPStep(time.Now()))
+	require.NoError(t, err)
+	recoveryCodes, err := users.ConfirmTOTPEnrollment(ctx, u.ID, code)
+	require.NoError(t, err)
+	return u, recoveryCodes
+}
+
+// nextTOTPCode returns the code of the next time step, which is accepted for
+// clock skew but not used yet
+func nextTOTPCode(t *testing.T, u *user.User) string {
+	t.Helper()
+	code, err := auth.GenerateTOTPCode(u.TOTPSecret, auth.TOTPStep(time.Now())+1)
+	require.NoError(t, err)
+	return code
+}
+
+func TestVerifyMFACodeReplay(t *testing.T) {
+	ctx := context.Background()
+	users, u, _ := newMFAUser(t)
+
+	// The code that confirmed the enrollment is already used
+	used, err := auth.GenerateTOTPCode(u.TOTPSecret, auth.TOTPStep(time.Now()))
+	require.NoError(t, err)
+	_, err = users.VerifyMFACode(ctx, u.ID, used)
+	assert.ErrorIs(t, err, ErrInvalidMFACode)
+
+	code := nextTOTPCode(t, u)
+	verified, err := users.VerifyMFACode(ctx, u.ID, code)
+	require.NoError(t, err)
+	assert.Equal(t, u.ID, verified.ID)
+	_, err = users.VerifyMFACode(ctx, u.ID, code)
+	assert.ErrorIs(t, err, ErrInvalidMFACode)
+}
+
+func TestVerifyMFACodeRecoveryCode(t *testing.T) {
+	ctx := context.Background()
+	users, u, recoveryCodes := newMFAUser(t)
+	require.NotEmpty(t, recoveryCodes)
+
+	verified, err := users.VerifyMFACode(ctx, u.ID, recoveryCodes[0])
+	require.NoError(t, err)
+	assert.Equal(t, u.ID, verified.ID)
+
+	// Each recovery code works once
+	_, err = users.VerifyMFACode(ctx, u.ID, recoveryCodes[0])
+	assert.ErrorIs(t, err, ErrInvalidMFACode)
+	_, err = users.VerifyMFACode(ctx, u.ID, recoveryCodes[1])
+	assert.NoError(t, err)
+}
+
+func TestVerifyMFACodeAttemptLimit(t *testing.T) {
+	ctx := context.Background()
+	users, u, recoveryCodes := newMFAUser(t)
+
+	for i := 0; i < maxMFAAttempts; i++ {
+		_, err := users.VerifyMFACode(ctx, u.ID, "not-a-code")
+		assert.ErrorIs(t, err, ErrInvalidMFACode)
+	}
+
+	// Once the challenge is used up even valid codes are refused
+	_, err := users.VerifyMFACode(ctx, u.ID, nextTOTPCode(t, u))
+	assert.ErrorIs(t, err, ErrTooManyMFAAttempts)
+	_, err = users.VerifyMFACode(ctx, u.ID, recoveryCodes[0])
+	assert.ErrorIs(t, err, ErrTooManyMFAAttempts)
+
+	// until the user signs in again
+	require.NoError(t, users.BeginMFAChallenge(ctx, u.ID))
+	_, err = users.VerifyMFACode(ctx, u.ID, nextTOTPCode(t, u))
+	assert.NoError(t, err)
+}
+
+func TestDisableTOTPDeletesRecoveryCodes(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+	users, userRepo := newUserService(t, db)
+	u, recoveryCodes := enrollMFAUser(t, users, userRepo)
+
+	// Two-factor authentication stays on when its recovery codes cannot be
+	// deleted
+	require.NoError(t, db.Exec("CREATE TRIGGER keep_recovery_codes BEFORE DELETE ON recovery_codes BEGIN SELECT RAISE(ABORT, 'read-only'); END").Error)
+	assert.Error(t, users.DisableTOTP(ctx, u.ID, nextTOTPCode(t, u)))
+	stored, err := userRepo.FindByID(ctx, u.ID)
+	require.NoError(t, err)
+	assert.True(t, stored.TOTPEnabled)
+	_, err = users.VerifyMFACode(ctx, u.ID, recoveryCodes[0])
+	require.NoError(t, err)
+	require.NoError(t, db.Exec("DROP TRIGGER keep_recovery_codes").Error)
+
+	require.NoError(t, users.DisableTOTP(ctx, u.ID, recoveryCodes[1]))
+
+	// Enrolling again hands out new recovery codes; the old ones are gone
+	enrollment, err := users.BeginTOTPEnrollment(ctx, u.ID)
+	require.NoError(t, err)
+	u.TOTPSecret = enrollment.Secret
+	code, err := auth.GenerateTOTPCode(u.TOTPSecret, auth.TOTPStep(time.Now()))
+	require.NoError(t, err)
+	_, err = users.ConfirmTOTPEnrollment(ctx, u.ID, code)
+	require.NoError(t, err)
+	_, err = users.VerifyMFACode(ctx, u.ID, recoveryCodes[2])
+	assert.ErrorIs(t, err, ErrInvalidMFACode)
+}
+
+func TestUpdateUserKeepsMFAState(t *testing.T) {
+	ctx := context.Background()
+	users, u, _ := newMFAUser(t)
+
+	for i := 0; i < maxMFAAttempts-1; i++ {
+		_, err := users.VerifyMFACode(ctx, u.ID, "not-a-code")
+		assert.ErrorIs(t, err, ErrInvalidMFACode)
+	}
+
+	// Two-factor state and roles sent with a profile update are not saved
+	update := &user.User{ID: u.ID, Username: "annie", Email: u.Email, IsAdmin: true}
+	require.NoError(t, users.UpdateUser(ctx, update))
+	assert.Equal(t, "annie", update.Username)
+	assert.True(t, update.TOTPEnabled)
+	assert.False(t, update.IsAdmin)
+
+	// The update neither restored the attempts nor reopened the used step
+	used, err := auth.GenerateTOTPCode(u.TOTPSecret, auth.TOTPStep(time.Now()))
+	require.NoError(t, err)
+	_, err = users.VerifyMFACode(ctx, u.ID, used)
+	assert.ErrorIs(t, err, ErrInvalidMFACode)
+	_, err = users.VerifyMFACode(ctx, u.ID, nextTOTPCode(t, u))
+	assert.ErrorIs(t, err, ErrTooManyMFAAttempts)
+}
+
+func TestLoginWithExternalIdentity(t *testing.T) {
+	ctx := context.Background()
+	users, userRepo := newUserService(t, openTestDB(t))
+	ann := &user.User{Username: "ann", Email: "ann@example.com", Password: "hash"}
+	require.NoError(t, userRepo.Create(ctx, ann))
+
+	// A verified email of an existing account does not sign in to it
+	profile := &user.ExternalProfile{Provider: "azure", Subject: "azure-1", Email: "ann@example.com", EmailVerified: true}
+	_, err := users.LoginWithExternalIdentity(ctx, profile)
+	assert.ErrorIs(t, err, ErrExternalAccountExists)
+
+	// until its owner links the provider
+	identity, err := users.LinkExternalIdentity(ctx, ann.ID, profile)
+	require.NoError(t, err)
+	assert.Equal(t, ann.ID, identity.UserID)
+	u, err := users.LoginWithExternalIdentity(ctx, profile)
+	require.NoError(t, err)
+	assert.Equal(t, ann.ID, u.ID)
+
+	// A new email creates an account
+	created, err := users.LoginWithExternalIdentity(ctx, &user.ExternalProfile{Provider: "azure", Subject: "azure-2", Email: "bob@example.com", EmailVerified: true})
+	require.NoError(t, err)
+	assert.NotEqual(t, ann.ID, created.ID)
+	assert.Equal(t, "bob@example.com", created.Email)
+
+	// An identity belongs to one user
+	_, err = users.LinkExternalIdentity(ctx, created.ID, profile)
+	assert.ErrorIs(t, err, ErrExternalIdentityLinked)
+
+	// The identity of a user in the trash does not sign in
+	require.NoError(t, users.DeleteUser(ctx, ann.ID))
+	_, err = users.LoginWithExternalIdentity(ctx, profile)
+	assert.ErrorIs(t, err, ErrUserNotFound)
+}
@@ -1,7 +1,9 @@
 package services
 
 import (
+	"context"
 	"quizlet/internal/models/quiz_suite"
+	"quizlet/internal/service"
 	"github.com/stretchr/testify/mock"
 )
 
@@ -13,49 +15,57 @@ func NewMockQuizSuiteService() *MockQuizSuiteService {
 	return &MockQuizSuiteService{}
 }
 
-func (m *MockQuizSuiteService) CreateQuizSuite(quizSuite *quiz_suite.QuizSuite) error {
-	args := m.Called(quizSuite)
+func (m *MockQuizSuiteService) CreateQuizSuite(ctx context.Context, quizSuite *quiz_suite.QuizSuite) error {
+	args := m.Called(ctx, quizSuite)
 	return args.Error(0)
 }
 
-func (m *MockQuizSuiteService) GetQuizSuite(id uint) (*quiz_suite.QuizSuite, error) {
-	args := m.Called(id)
+func (m *MockQuizSuiteService) GetQuizSuite(ctx context.Context, id uint) (*quiz_suite.QuizSuite, error) {
+	args := m.Called(ctx, id)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*quiz_suite.QuizSuite), args.Error(1)
 }
 
-func (m *MockQuizSuiteService) GetUserQuizSuites(userID uint) ([]*quiz_suite.QuizSuite, error) {
-	args := m.Called(userID)
+func (m *MockQuizSuiteService) GetUserQuizSuites(ctx context.Context, userID uint) ([]*quiz_suite.QuizSuite, error) {
+	args := m.Called(ctx, userID)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).([]*quiz_suite.QuizSuite), args.Error(1)
 }
 
-func (m *MockQuizSuiteService) UpdateQuizSuite(quizSuite *quiz_suite.QuizSuite) error {
-	args := m.Called(quizSuite)
+func (m *MockQuizSuiteService) UpdateQuizSuite(ctx context.Context, quizSuite *quiz_suite.QuizSuite) error {
+	args := m.Called(ctx, quizSuite)
 	return args.Error(0)
 }
 
-func (m *MockQuizSuiteService) DeleteQuizSuite(id uint) error {
-	args := m.Called(id)
+func (m *MockQuizSuiteService) DeleteQuizSuite(ctx context.Context, id uint, version uint) error {
+	args := m.Called(ctx, id, version)
 	return args.Error(0)
 }
 
-func (m *MockQuizSuiteService) AddQuizToSuite(quizSuiteID uint, quizID uint) error {
-	args := m.Called(quizSuiteID, quizID)
+func (m *MockQuizSuiteService) AddQuizToSuite(ctx context.Context, quizSuiteID uint, quizID uint) error {
+	args := m.Called(ctx, quizSuiteID, quizID)
 	return args.Error(0)
 }
 
-func (m *MockQuizSuiteService) RemoveQuizFromSuite(quizSuiteID uint, quizID uint) error {
-	args := m.Called(quizSuiteID, quizID)
+func (m *MockQuizSuiteService) RemoveQuizFromSuite(ctx context.Context, quizSuiteID uint, quizID uint) error {
+	args := m.Called(ctx, quizSuiteID, quizID)
 	return args.Error(0)
 }
 
-func (m *MockQuizSuiteService) GetQuizSuites(userID uint) ([]*quiz_suite.QuizSuite, error) {
-	args := m.Called(userID)
+func (m *MockQuizSuiteService) CloneQuizSuite(ctx context.Context, id uint, userID uint, opts service.CloneOptions) (*quiz_suite.QuizSuite, error) {
+	args := m.Called(ctx, id, userID, opts)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*quiz_suite.QuizSuite), args.Error(1)
+}
+
+func (m *MockQuizSuiteService) GetQuizSuites(ctx context.Context, userID uint) ([]*quiz_suite.QuizSuite, error) {
+	args := m.Called(ctx, userID)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
@@ -0,0 +1,128 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"quizlet/internal/config"
+)
+
+// recordSpans installs a tracer provider that keeps ended spans in memory
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previous)
+		provider.Shutdown(context.Background())
+	})
+	return recorder
+}
+
+func attributes(span sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+	values := make(map[attribute.Key]attribute.Value)
+	for _, kv := range span.Attributes() {
+		values[kv.Key] = kv.Value
+	}
+	return values
+}
+
+func TestSetup(t *testing.T) {
+	shutdown, err := Setup(context.Background(), config.TracingConfig{Exporter: config.TracingExporterNone})
+	require.NoError(t, err)
+	assert.NoError(t, shutdown(context.Background()))
+
+	_, err = Setup(context.Background(), config.TracingConfig{Exporter: "jaeger"})
+	assert.ErrorContains(t, err, "unknown tracing exporter")
+}
+
+func TestMiddleware(t *testing.T) {
+	recorder := recordSpans(t)
+	// Setup installs the W3C propagator even when nothing is exported
+	_, err := Setup(context.Background(), config.TracingConfig{Exporter: config.TracingExporterNone})
+	require.NoError(t, err)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Middleware())
+	router.GET("/quizzes/:id", func(c *gin.Context) {
+		_, span := Start(c.Request.Context(), "QuizService.GetQuizByID")
+		span.End()
+		c.Status(http.StatusInternalServerError)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/quizzes/7?code=123456", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	child, server := spans[0], spans[1]
+
+	assert.Equal(t, "GET /quizzes/:id", server.Name())
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", server.SpanContext().TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", server.Parent().SpanID().String())
+	assert.Equal(t, codes.Error, server.Status().Code)
+
+	attrs := attributes(server)
+	assert.Equal(t, "/quizzes/:id", attrs["http.route"].AsString())
+	assert.Equal(t, "/quizzes/7", attrs["url.path"].AsString())
+	assert.Equal(t, int64(500), attrs["http.response.status_code"].AsInt64())
+
+	assert.Equal(t, "QuizService.GetQuizByID", child.Name())
+	assert.Equal(t, server.SpanContext().SpanID(), child.Parent().SpanID())
+}
+
+type widget struct {
+	ID   uint
+	Name string
+}
+
+func TestGormPlugin(t *testing.T) {
+	recorder := recordSpans(t)
+
+	// A dry run builds the statements and runs the callbacks without a server
+	db, err := gorm.Open(postgres.Open("host=localhost"), &gorm.Config{DryRun: true, DisableAutomaticPing: true})
+	require.NoError(t, err)
+	require.NoError(t, InstrumentDB(db))
+	require.NoError(t, db.Callback().Delete().Before("tracing:after_delete").Register("test:fail", func(db *gorm.DB) {
+		db.AddError(errors.New("connection reset"))
+	}))
+
+	ctx, parent := Start(context.Background(), "WidgetService.Rename")
+	db.WithContext(ctx).Where("name = ?", "secret value").Find(&[]widget{})
+	db.WithContext(ctx).Delete(&widget{}, 3)
+	parent.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 3)
+	query, del := spans[0], spans[1]
+
+	assert.Equal(t, "gorm.query widgets", query.Name())
+	assert.Equal(t, parent.SpanContext().SpanID(), query.Parent().SpanID())
+	attrs := attributes(query)
+	assert.Equal(t, "postgres", attrs["db.system"].AsString())
+	assert.Equal(t, "widgets", attrs["db.collection.name"].AsString())
+	assert.Equal(t, `SELECT * FROM "widgets" WHERE name = $1`, attrs["db.query.text"].AsString())
+	assert.Equal(t, codes.Unset, query.Status().Code)
+
+	assert.Equal(t, "gorm.delete widgets", del.Name())
+	assert.Equal(t, codes.Error, del.Status().Code)
+	assert.Contains(t, del.Status().Description, "connection reset")
+}
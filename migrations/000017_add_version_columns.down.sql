@@ -0,0 +1,3 @@
+ALTER TABLE quiz_selections DROP COLUMN IF EXISTS version;
+ALTER TABLE quizzes DROP COLUMN IF EXISTS version;
+ALTER TABLE quiz_suites DROP COLUMN IF EXISTS version;
@@ -0,0 +1,3 @@
+ALTER TABLE quiz_suites ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE quizzes ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE quiz_selections ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS audit_entries (
+    id SERIAL PRIMARY KEY,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    actor_id INTEGER,
+    action VARCHAR(20) NOT NULL,
+    resource_type VARCHAR(20) NOT NULL,
+    resource_id INTEGER NOT NULL,
+    changes TEXT NOT NULL,
+    request_id VARCHAR(128) NOT NULL DEFAULT '',
+    ip VARCHAR(45) NOT NULL DEFAULT ''
+);
+
+-- No foreign keys: entries outlive the users and content they describe
+CREATE INDEX idx_audit_entries_created_at ON audit_entries(created_at);
+CREATE INDEX idx_audit_entries_actor_id ON audit_entries(actor_id);
+CREATE INDEX idx_audit_entries_resource ON audit_entries(resource_type, resource_id);
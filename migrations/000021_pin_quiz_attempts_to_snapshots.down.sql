@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS idx_quiz_attempt_answers_attempt_quiz;
+
+DELETE FROM quiz_attempt_answers WHERE quiz_id NOT IN (SELECT id FROM quizzes);
+ALTER TABLE quiz_attempt_answers ADD CONSTRAINT quiz_attempt_answers_quiz_id_fkey
+    FOREIGN KEY (quiz_id) REFERENCES quizzes(id) ON DELETE CASCADE;
+
+ALTER TABLE quiz_attempt_answers DROP COLUMN selection_ids;
+ALTER TABLE quiz_attempts DROP COLUMN snapshot;
@@ -0,0 +1,8 @@
+ALTER TABLE quiz_attempts ADD COLUMN snapshot TEXT;
+ALTER TABLE quiz_attempt_answers ADD COLUMN selection_ids TEXT NOT NULL DEFAULT '[]';
+
+-- Answers are graded against the snapshot of their attempt, so they are kept
+-- when the quiz itself is purged
+ALTER TABLE quiz_attempt_answers DROP CONSTRAINT IF EXISTS quiz_attempt_answers_quiz_id_fkey;
+
+CREATE UNIQUE INDEX idx_quiz_attempt_answers_attempt_quiz ON quiz_attempt_answers(quiz_attempt_id, quiz_id);
@@ -0,0 +1,2 @@
+ALTER TABLE quizzes ADD COLUMN cloned_from_id INTEGER;
+ALTER TABLE quiz_suites ADD COLUMN cloned_from_id INTEGER;
@@ -0,0 +1,42 @@
+// Package migrations embeds the SQL schema migrations so the API binary can
+// apply them without the files on disk.
+package migrations
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+)
+
+// FS holds the NNNNNN_name.up.sql and NNNNNN_name.down.sql files written for
+// PostgreSQL
+//
+//go:embed *.sql
+var FS embed.FS
+
+//go:embed sqlite/*.sql
+var sqliteFS embed.FS
+
+// SQLite holds the same migrations written for SQLite. Every migration
+// exists in both sets under the same version and name.
+var SQLite fs.FS
+
+func init() {
+	sub, err := fs.Sub(sqliteFS, "sqlite")
+	if err != nil {
+		panic(err)
+	}
+	SQLite = sub
+}
+
+// ForDialect returns the migrations for a GORM dialect name
+func ForDialect(name string) (fs.FS, error) {
+	switch name {
+	case "postgres":
+		return FS, nil
+	case "sqlite":
+		return SQLite, nil
+	default:
+		return nil, fmt.Errorf("no migrations for the %s dialect", name)
+	}
+}
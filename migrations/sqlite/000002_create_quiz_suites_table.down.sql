@@ -0,0 +1 @@
+DROP TABLE IF EXISTS quiz_suites; 
\ No newline at end of file
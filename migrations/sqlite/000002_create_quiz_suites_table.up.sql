@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS quiz_suites (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    deleted_at DATETIME,
+    title VARCHAR(255) NOT NULL,
+    description TEXT,
+    created_by_id INTEGER REFERENCES users(id)
+);
+
+CREATE INDEX idx_quiz_suites_deleted_at ON quiz_suites(deleted_at);
+CREATE INDEX idx_quiz_suites_created_by_id ON quiz_suites(created_by_id); 
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS quiz_selections; 
\ No newline at end of file
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS quiz_selections (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    deleted_at DATETIME,
+    quiz_id INTEGER REFERENCES quizzes(id) ON DELETE CASCADE,
+    selection_text TEXT NOT NULL,
+    is_correct BOOLEAN NOT NULL
+);
+
+CREATE INDEX idx_quiz_selections_deleted_at ON quiz_selections(deleted_at);
+CREATE INDEX idx_quiz_selections_quiz_id ON quiz_selections(quiz_id); 
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS quiz_suite_quizzes; 
\ No newline at end of file
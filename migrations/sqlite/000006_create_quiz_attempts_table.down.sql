@@ -0,0 +1 @@
+DROP TABLE IF EXISTS quiz_attempts; 
\ No newline at end of file
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS quiz_attempts (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    deleted_at DATETIME,
+    user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+    quiz_suite_id INTEGER REFERENCES quiz_suites(id) ON DELETE CASCADE,
+    score INTEGER DEFAULT 0,
+    completed BOOLEAN DEFAULT FALSE,
+    started_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    completed_at DATETIME
+);
+
+CREATE INDEX idx_quiz_attempts_deleted_at ON quiz_attempts(deleted_at);
+CREATE INDEX idx_quiz_attempts_user_id ON quiz_attempts(user_id);
+CREATE INDEX idx_quiz_attempts_quiz_suite_id ON quiz_attempts(quiz_suite_id); 
\ No newline at end of file
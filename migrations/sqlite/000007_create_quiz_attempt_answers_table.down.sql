@@ -0,0 +1 @@
+DROP TABLE IF EXISTS quiz_attempt_answers; 
\ No newline at end of file
@@ -0,0 +1,5 @@
+-- Add correct_answer column back to quizzes
+ALTER TABLE quizzes ADD COLUMN correct_answer TEXT NOT NULL DEFAULT '';
+
+-- Drop selection_display_name column from quiz_selections
+ALTER TABLE quiz_selections DROP COLUMN selection_display_name;
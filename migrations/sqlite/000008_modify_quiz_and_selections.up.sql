@@ -0,0 +1,7 @@
+-- Add selection_display_name column to quiz_selections
+ALTER TABLE quiz_selections ADD COLUMN selection_display_name VARCHAR(10);
+
+-- Drop correct_answer column from quizzes. PostgreSQL moves it to
+-- correct_selection_id here, which migration 11 drops again, so SQLite
+-- databases never get that column.
+ALTER TABLE quizzes DROP COLUMN correct_answer;
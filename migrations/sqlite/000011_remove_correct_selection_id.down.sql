@@ -0,0 +1,2 @@
+-- Changes quizzes.correct_selection_id, which SQLite databases never have
+SELECT 1;
@@ -0,0 +1,3 @@
+ALTER TABLE quiz_selections DROP COLUMN version;
+ALTER TABLE quizzes DROP COLUMN version;
+ALTER TABLE quiz_suites DROP COLUMN version;
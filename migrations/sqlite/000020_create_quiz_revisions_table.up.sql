@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS quiz_revisions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    quiz_id INTEGER NOT NULL REFERENCES quizzes(id) ON DELETE CASCADE,
+    number INTEGER NOT NULL,
+    author_id INTEGER,
+    reverted_from INTEGER,
+    content TEXT NOT NULL
+);
+
+CREATE UNIQUE INDEX idx_quiz_revisions_quiz_number ON quiz_revisions(quiz_id, number);
@@ -0,0 +1,21 @@
+CREATE TABLE quiz_attempt_answers_old (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    quiz_attempt_id INTEGER REFERENCES quiz_attempts(id) ON DELETE CASCADE,
+    quiz_id INTEGER REFERENCES quizzes(id) ON DELETE CASCADE,
+    user_answer TEXT NOT NULL,
+    is_correct BOOLEAN NOT NULL
+);
+
+INSERT INTO quiz_attempt_answers_old (id, created_at, updated_at, quiz_attempt_id, quiz_id, user_answer, is_correct)
+SELECT id, created_at, updated_at, quiz_attempt_id, quiz_id, user_answer, is_correct FROM quiz_attempt_answers
+WHERE quiz_id IN (SELECT id FROM quizzes);
+
+DROP TABLE quiz_attempt_answers;
+ALTER TABLE quiz_attempt_answers_old RENAME TO quiz_attempt_answers;
+
+CREATE INDEX idx_quiz_attempt_answers_quiz_attempt_id ON quiz_attempt_answers(quiz_attempt_id);
+CREATE INDEX idx_quiz_attempt_answers_quiz_id ON quiz_attempt_answers(quiz_id);
+
+ALTER TABLE quiz_attempts DROP COLUMN snapshot;
@@ -0,0 +1,25 @@
+ALTER TABLE quiz_attempts ADD COLUMN snapshot TEXT;
+
+-- SQLite cannot drop a foreign key, so the answers table is rebuilt without
+-- the one on quiz_id: answers are graded against the snapshot of their
+-- attempt and are kept when the quiz itself is purged
+CREATE TABLE quiz_attempt_answers_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    quiz_attempt_id INTEGER REFERENCES quiz_attempts(id) ON DELETE CASCADE,
+    quiz_id INTEGER,
+    user_answer TEXT NOT NULL,
+    is_correct BOOLEAN NOT NULL,
+    selection_ids TEXT NOT NULL DEFAULT '[]'
+);
+
+INSERT INTO quiz_attempt_answers_new (id, created_at, updated_at, quiz_attempt_id, quiz_id, user_answer, is_correct)
+SELECT id, created_at, updated_at, quiz_attempt_id, quiz_id, user_answer, is_correct FROM quiz_attempt_answers;
+
+DROP TABLE quiz_attempt_answers;
+ALTER TABLE quiz_attempt_answers_new RENAME TO quiz_attempt_answers;
+
+CREATE INDEX idx_quiz_attempt_answers_quiz_attempt_id ON quiz_attempt_answers(quiz_attempt_id);
+CREATE INDEX idx_quiz_attempt_answers_quiz_id ON quiz_attempt_answers(quiz_id);
+CREATE UNIQUE INDEX idx_quiz_attempt_answers_attempt_quiz ON quiz_attempt_answers(quiz_attempt_id, quiz_id);
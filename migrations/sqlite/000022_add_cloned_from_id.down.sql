@@ -0,0 +1,2 @@
+ALTER TABLE quiz_suites DROP COLUMN cloned_from_id;
+ALTER TABLE quizzes DROP COLUMN cloned_from_id;
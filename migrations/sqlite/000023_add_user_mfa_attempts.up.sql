@@ -0,0 +1 @@
+ALTER TABLE users ADD COLUMN mfa_attempts INTEGER NOT NULL DEFAULT 0;
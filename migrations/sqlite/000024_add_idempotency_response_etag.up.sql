@@ -0,0 +1 @@
+ALTER TABLE idempotency_keys ADD COLUMN response_etag TEXT NOT NULL DEFAULT '';
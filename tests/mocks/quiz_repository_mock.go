@@ -5,6 +5,7 @@
 package mocks
 
 import (
+	context "context"
 	quiz "quizlet/internal/models/quiz"
 	reflect "reflect"
 
@@ -35,101 +36,115 @@ func (m *MockQuizRepository) EXPECT() *MockQuizRepositoryMockRecorder {
 }
 
 // AddSelection mocks base method.
-func (m *MockQuizRepository) AddSelection(quizID uint, selection quiz.QuizSelection) error {
+func (m *MockQuizRepository) AddSelection(ctx context.Context, quizID uint, selection *quiz.QuizSelection) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "AddSelection", quizID, selection)
+	ret := m.ctrl.Call(m, "AddSelection", ctx, quizID, selection)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // AddSelection indicates an expected call of AddSelection.
-func (mr *MockQuizRepositoryMockRecorder) AddSelection(quizID, selection interface{}) *gomock.Call {
+func (mr *MockQuizRepositoryMockRecorder) AddSelection(ctx, quizID, selection interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddSelection", reflect.TypeOf((*MockQuizRepository)(nil).AddSelection), quizID, selection)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddSelection", reflect.TypeOf((*MockQuizRepository)(nil).AddSelection), ctx, quizID, selection)
 }
 
 // Create mocks base method.
-func (m *MockQuizRepository) Create(quiz *quiz.Quiz) error {
+func (m *MockQuizRepository) Create(ctx context.Context, quiz *quiz.Quiz) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Create", quiz)
+	ret := m.ctrl.Call(m, "Create", ctx, quiz)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Create indicates an expected call of Create.
-func (mr *MockQuizRepositoryMockRecorder) Create(quiz interface{}) *gomock.Call {
+func (mr *MockQuizRepositoryMockRecorder) Create(ctx, quiz interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockQuizRepository)(nil).Create), quiz)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockQuizRepository)(nil).Create), ctx, quiz)
 }
 
 // Delete mocks base method.
-func (m *MockQuizRepository) Delete(id uint) error {
+func (m *MockQuizRepository) Delete(ctx context.Context, id, version uint) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Delete", id)
+	ret := m.ctrl.Call(m, "Delete", ctx, id, version)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Delete indicates an expected call of Delete.
-func (mr *MockQuizRepositoryMockRecorder) Delete(id interface{}) *gomock.Call {
+func (mr *MockQuizRepositoryMockRecorder) Delete(ctx, id, version interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockQuizRepository)(nil).Delete), id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockQuizRepository)(nil).Delete), ctx, id, version)
 }
 
 // FindByID mocks base method.
-func (m *MockQuizRepository) FindByID(id uint) (*quiz.Quiz, error) {
+func (m *MockQuizRepository) FindByID(ctx context.Context, id uint) (*quiz.Quiz, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "FindByID", id)
+	ret := m.ctrl.Call(m, "FindByID", ctx, id)
 	ret0, _ := ret[0].(*quiz.Quiz)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // FindByID indicates an expected call of FindByID.
-func (mr *MockQuizRepositoryMockRecorder) FindByID(id interface{}) *gomock.Call {
+func (mr *MockQuizRepositoryMockRecorder) FindByID(ctx, id interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByID", reflect.TypeOf((*MockQuizRepository)(nil).FindByID), id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByID", reflect.TypeOf((*MockQuizRepository)(nil).FindByID), ctx, id)
 }
 
 // FindByUserID mocks base method.
-func (m *MockQuizRepository) FindByUserID(userID uint) ([]*quiz.Quiz, error) {
+func (m *MockQuizRepository) FindByUserID(ctx context.Context, userID uint) ([]*quiz.Quiz, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "FindByUserID", userID)
+	ret := m.ctrl.Call(m, "FindByUserID", ctx, userID)
 	ret0, _ := ret[0].([]*quiz.Quiz)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // FindByUserID indicates an expected call of FindByUserID.
-func (mr *MockQuizRepositoryMockRecorder) FindByUserID(userID interface{}) *gomock.Call {
+func (mr *MockQuizRepositoryMockRecorder) FindByUserID(ctx, userID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByUserID", reflect.TypeOf((*MockQuizRepository)(nil).FindByUserID), userID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByUserID", reflect.TypeOf((*MockQuizRepository)(nil).FindByUserID), ctx, userID)
 }
 
 // RemoveSelection mocks base method.
-func (m *MockQuizRepository) RemoveSelection(quizID, selectionID uint) error {
+func (m *MockQuizRepository) RemoveSelection(ctx context.Context, quizID, selectionID uint) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "RemoveSelection", quizID, selectionID)
+	ret := m.ctrl.Call(m, "RemoveSelection", ctx, quizID, selectionID)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // RemoveSelection indicates an expected call of RemoveSelection.
-func (mr *MockQuizRepositoryMockRecorder) RemoveSelection(quizID, selectionID interface{}) *gomock.Call {
+func (mr *MockQuizRepositoryMockRecorder) RemoveSelection(ctx, quizID, selectionID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveSelection", reflect.TypeOf((*MockQuizRepository)(nil).RemoveSelection), quizID, selectionID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveSelection", reflect.TypeOf((*MockQuizRepository)(nil).RemoveSelection), ctx, quizID, selectionID)
 }
 
 // Update mocks base method.
-func (m *MockQuizRepository) Update(quiz *quiz.Quiz) error {
+func (m *MockQuizRepository) Update(ctx context.Context, quiz *quiz.Quiz) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Update", quiz)
+	ret := m.ctrl.Call(m, "Update", ctx, quiz)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Update indicates an expected call of Update.
-func (mr *MockQuizRepositoryMockRecorder) Update(quiz interface{}) *gomock.Call {
+func (mr *MockQuizRepositoryMockRecorder) Update(ctx, quiz interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockQuizRepository)(nil).Update), quiz)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockQuizRepository)(nil).Update), ctx, quiz)
+}
+
+// UpdateSelection mocks base method.
+func (m *MockQuizRepository) UpdateSelection(ctx context.Context, quizID uint, selection *quiz.QuizSelection) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateSelection", ctx, quizID, selection)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateSelection indicates an expected call of UpdateSelection.
+func (mr *MockQuizRepositoryMockRecorder) UpdateSelection(ctx, quizID, selection interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateSelection", reflect.TypeOf((*MockQuizRepository)(nil).UpdateSelection), ctx, quizID, selection)
 }
@@ -5,6 +5,7 @@
 package mocks
 
 import (
+	context "context"
 	quiz "quizlet/internal/models/quiz"
 	service "quizlet/internal/service"
 	reflect "reflect"
@@ -36,101 +37,176 @@ func (m *MockQuizService) EXPECT() *MockQuizServiceMockRecorder {
 }
 
 // AddSelection mocks base method.
-func (m *MockQuizService) AddSelection(quizID uint, selection service.QuizSelection) error {
+func (m *MockQuizService) AddSelection(ctx context.Context, quizID uint, selection service.QuizSelection) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "AddSelection", quizID, selection)
+	ret := m.ctrl.Call(m, "AddSelection", ctx, quizID, selection)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // AddSelection indicates an expected call of AddSelection.
-func (mr *MockQuizServiceMockRecorder) AddSelection(quizID, selection interface{}) *gomock.Call {
+func (mr *MockQuizServiceMockRecorder) AddSelection(ctx, quizID, selection interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddSelection", reflect.TypeOf((*MockQuizService)(nil).AddSelection), quizID, selection)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddSelection", reflect.TypeOf((*MockQuizService)(nil).AddSelection), ctx, quizID, selection)
+}
+
+// CloneQuiz mocks base method.
+func (m *MockQuizService) CloneQuiz(ctx context.Context, id, userID uint, provenance bool) (*quiz.Quiz, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CloneQuiz", ctx, id, userID, provenance)
+	ret0, _ := ret[0].(*quiz.Quiz)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CloneQuiz indicates an expected call of CloneQuiz.
+func (mr *MockQuizServiceMockRecorder) CloneQuiz(ctx, id, userID, provenance interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloneQuiz", reflect.TypeOf((*MockQuizService)(nil).CloneQuiz), ctx, id, userID, provenance)
 }
 
 // CreateQuiz mocks base method.
-func (m *MockQuizService) CreateQuiz(quiz *quiz.Quiz) error {
+func (m *MockQuizService) CreateQuiz(ctx context.Context, quiz *quiz.Quiz) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateQuiz", quiz)
+	ret := m.ctrl.Call(m, "CreateQuiz", ctx, quiz)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // CreateQuiz indicates an expected call of CreateQuiz.
-func (mr *MockQuizServiceMockRecorder) CreateQuiz(quiz interface{}) *gomock.Call {
+func (mr *MockQuizServiceMockRecorder) CreateQuiz(ctx, quiz interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateQuiz", reflect.TypeOf((*MockQuizService)(nil).CreateQuiz), quiz)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateQuiz", reflect.TypeOf((*MockQuizService)(nil).CreateQuiz), ctx, quiz)
 }
 
 // DeleteQuiz mocks base method.
-func (m *MockQuizService) DeleteQuiz(id uint) error {
+func (m *MockQuizService) DeleteQuiz(ctx context.Context, id, version uint) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteQuiz", id)
+	ret := m.ctrl.Call(m, "DeleteQuiz", ctx, id, version)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // DeleteQuiz indicates an expected call of DeleteQuiz.
-func (mr *MockQuizServiceMockRecorder) DeleteQuiz(id interface{}) *gomock.Call {
+func (mr *MockQuizServiceMockRecorder) DeleteQuiz(ctx, id, version interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteQuiz", reflect.TypeOf((*MockQuizService)(nil).DeleteQuiz), id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteQuiz", reflect.TypeOf((*MockQuizService)(nil).DeleteQuiz), ctx, id, version)
+}
+
+// DiffRevisions mocks base method.
+func (m *MockQuizService) DiffRevisions(ctx context.Context, quizID, from, to uint) (*quiz.RevisionDiff, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DiffRevisions", ctx, quizID, from, to)
+	ret0, _ := ret[0].(*quiz.RevisionDiff)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DiffRevisions indicates an expected call of DiffRevisions.
+func (mr *MockQuizServiceMockRecorder) DiffRevisions(ctx, quizID, from, to interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DiffRevisions", reflect.TypeOf((*MockQuizService)(nil).DiffRevisions), ctx, quizID, from, to)
 }
 
 // GetQuizByID mocks base method.
-func (m *MockQuizService) GetQuizByID(id uint) (*quiz.Quiz, error) {
+func (m *MockQuizService) GetQuizByID(ctx context.Context, id uint) (*quiz.Quiz, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetQuizByID", id)
+	ret := m.ctrl.Call(m, "GetQuizByID", ctx, id)
 	ret0, _ := ret[0].(*quiz.Quiz)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetQuizByID indicates an expected call of GetQuizByID.
-func (mr *MockQuizServiceMockRecorder) GetQuizByID(id interface{}) *gomock.Call {
+func (mr *MockQuizServiceMockRecorder) GetQuizByID(ctx, id interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetQuizByID", reflect.TypeOf((*MockQuizService)(nil).GetQuizByID), id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetQuizByID", reflect.TypeOf((*MockQuizService)(nil).GetQuizByID), ctx, id)
 }
 
 // GetQuizzesByUserID mocks base method.
-func (m *MockQuizService) GetQuizzesByUserID(userID uint) ([]*quiz.Quiz, error) {
+func (m *MockQuizService) GetQuizzesByUserID(ctx context.Context, userID uint) ([]*quiz.Quiz, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetQuizzesByUserID", userID)
+	ret := m.ctrl.Call(m, "GetQuizzesByUserID", ctx, userID)
 	ret0, _ := ret[0].([]*quiz.Quiz)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetQuizzesByUserID indicates an expected call of GetQuizzesByUserID.
-func (mr *MockQuizServiceMockRecorder) GetQuizzesByUserID(userID interface{}) *gomock.Call {
+func (mr *MockQuizServiceMockRecorder) GetQuizzesByUserID(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetQuizzesByUserID", reflect.TypeOf((*MockQuizService)(nil).GetQuizzesByUserID), ctx, userID)
+}
+
+// GetRevision mocks base method.
+func (m *MockQuizService) GetRevision(ctx context.Context, quizID, number uint) (*quiz.QuizRevision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRevision", ctx, quizID, number)
+	ret0, _ := ret[0].(*quiz.QuizRevision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRevision indicates an expected call of GetRevision.
+func (mr *MockQuizServiceMockRecorder) GetRevision(ctx, quizID, number interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetQuizzesByUserID", reflect.TypeOf((*MockQuizService)(nil).GetQuizzesByUserID), userID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRevision", reflect.TypeOf((*MockQuizService)(nil).GetRevision), ctx, quizID, number)
+}
+
+// ListRevisions mocks base method.
+func (m *MockQuizService) ListRevisions(ctx context.Context, quizID uint) ([]*quiz.QuizRevision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListRevisions", ctx, quizID)
+	ret0, _ := ret[0].([]*quiz.QuizRevision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListRevisions indicates an expected call of ListRevisions.
+func (mr *MockQuizServiceMockRecorder) ListRevisions(ctx, quizID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListRevisions", reflect.TypeOf((*MockQuizService)(nil).ListRevisions), ctx, quizID)
 }
 
 // RemoveSelection mocks base method.
-func (m *MockQuizService) RemoveSelection(quizID, selectionID uint) error {
+func (m *MockQuizService) RemoveSelection(ctx context.Context, quizID, selectionID uint) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "RemoveSelection", quizID, selectionID)
+	ret := m.ctrl.Call(m, "RemoveSelection", ctx, quizID, selectionID)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // RemoveSelection indicates an expected call of RemoveSelection.
-func (mr *MockQuizServiceMockRecorder) RemoveSelection(quizID, selectionID interface{}) *gomock.Call {
+func (mr *MockQuizServiceMockRecorder) RemoveSelection(ctx, quizID, selectionID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveSelection", reflect.TypeOf((*MockQuizService)(nil).RemoveSelection), ctx, quizID, selectionID)
+}
+
+// RevertQuiz mocks base method.
+func (m *MockQuizService) RevertQuiz(ctx context.Context, quizID, number, version uint) (*quiz.Quiz, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevertQuiz", ctx, quizID, number, version)
+	ret0, _ := ret[0].(*quiz.Quiz)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RevertQuiz indicates an expected call of RevertQuiz.
+func (mr *MockQuizServiceMockRecorder) RevertQuiz(ctx, quizID, number, version interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveSelection", reflect.TypeOf((*MockQuizService)(nil).RemoveSelection), quizID, selectionID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevertQuiz", reflect.TypeOf((*MockQuizService)(nil).RevertQuiz), ctx, quizID, number, version)
 }
 
 // UpdateQuiz mocks base method.
-func (m *MockQuizService) UpdateQuiz(quiz *quiz.Quiz) error {
+func (m *MockQuizService) UpdateQuiz(ctx context.Context, quiz *quiz.Quiz) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateQuiz", quiz)
+	ret := m.ctrl.Call(m, "UpdateQuiz", ctx, quiz)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // UpdateQuiz indicates an expected call of UpdateQuiz.
-func (mr *MockQuizServiceMockRecorder) UpdateQuiz(quiz interface{}) *gomock.Call {
+func (mr *MockQuizServiceMockRecorder) UpdateQuiz(ctx, quiz interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateQuiz", reflect.TypeOf((*MockQuizService)(nil).UpdateQuiz), quiz)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateQuiz", reflect.TypeOf((*MockQuizService)(nil).UpdateQuiz), ctx, quiz)
 }
@@ -5,6 +5,7 @@
 package mocks
 
 import (
+	context "context"
 	quiz_suite "quizlet/internal/models/quiz_suite"
 	reflect "reflect"
 
@@ -34,74 +35,102 @@ func (m *MockQuizSuiteRepository) EXPECT() *MockQuizSuiteRepositoryMockRecorder
 	return m.recorder
 }
 
+// AddQuiz mocks base method.
+func (m *MockQuizSuiteRepository) AddQuiz(ctx context.Context, quizSuiteID, quizID uint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddQuiz", ctx, quizSuiteID, quizID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddQuiz indicates an expected call of AddQuiz.
+func (mr *MockQuizSuiteRepositoryMockRecorder) AddQuiz(ctx, quizSuiteID, quizID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddQuiz", reflect.TypeOf((*MockQuizSuiteRepository)(nil).AddQuiz), ctx, quizSuiteID, quizID)
+}
+
 // Create mocks base method.
-func (m *MockQuizSuiteRepository) Create(quizSuite *quiz_suite.QuizSuite) error {
+func (m *MockQuizSuiteRepository) Create(ctx context.Context, quizSuite *quiz_suite.QuizSuite) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Create", quizSuite)
+	ret := m.ctrl.Call(m, "Create", ctx, quizSuite)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Create indicates an expected call of Create.
-func (mr *MockQuizSuiteRepositoryMockRecorder) Create(quizSuite interface{}) *gomock.Call {
+func (mr *MockQuizSuiteRepositoryMockRecorder) Create(ctx, quizSuite interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockQuizSuiteRepository)(nil).Create), quizSuite)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockQuizSuiteRepository)(nil).Create), ctx, quizSuite)
 }
 
 // Delete mocks base method.
-func (m *MockQuizSuiteRepository) Delete(id uint) error {
+func (m *MockQuizSuiteRepository) Delete(ctx context.Context, id, version uint) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Delete", id)
+	ret := m.ctrl.Call(m, "Delete", ctx, id, version)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Delete indicates an expected call of Delete.
-func (mr *MockQuizSuiteRepositoryMockRecorder) Delete(id interface{}) *gomock.Call {
+func (mr *MockQuizSuiteRepositoryMockRecorder) Delete(ctx, id, version interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockQuizSuiteRepository)(nil).Delete), id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockQuizSuiteRepository)(nil).Delete), ctx, id, version)
 }
 
 // FindByID mocks base method.
-func (m *MockQuizSuiteRepository) FindByID(id uint) (*quiz_suite.QuizSuite, error) {
+func (m *MockQuizSuiteRepository) FindByID(ctx context.Context, id uint) (*quiz_suite.QuizSuite, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "FindByID", id)
+	ret := m.ctrl.Call(m, "FindByID", ctx, id)
 	ret0, _ := ret[0].(*quiz_suite.QuizSuite)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // FindByID indicates an expected call of FindByID.
-func (mr *MockQuizSuiteRepositoryMockRecorder) FindByID(id interface{}) *gomock.Call {
+func (mr *MockQuizSuiteRepositoryMockRecorder) FindByID(ctx, id interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByID", reflect.TypeOf((*MockQuizSuiteRepository)(nil).FindByID), id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByID", reflect.TypeOf((*MockQuizSuiteRepository)(nil).FindByID), ctx, id)
 }
 
 // FindByUserID mocks base method.
-func (m *MockQuizSuiteRepository) FindByUserID(userID uint) ([]*quiz_suite.QuizSuite, error) {
+func (m *MockQuizSuiteRepository) FindByUserID(ctx context.Context, userID uint) ([]*quiz_suite.QuizSuite, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "FindByUserID", userID)
+	ret := m.ctrl.Call(m, "FindByUserID", ctx, userID)
 	ret0, _ := ret[0].([]*quiz_suite.QuizSuite)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // FindByUserID indicates an expected call of FindByUserID.
-func (mr *MockQuizSuiteRepositoryMockRecorder) FindByUserID(userID interface{}) *gomock.Call {
+func (mr *MockQuizSuiteRepositoryMockRecorder) FindByUserID(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByUserID", reflect.TypeOf((*MockQuizSuiteRepository)(nil).FindByUserID), ctx, userID)
+}
+
+// RemoveQuiz mocks base method.
+func (m *MockQuizSuiteRepository) RemoveQuiz(ctx context.Context, quizSuiteID, quizID uint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveQuiz", ctx, quizSuiteID, quizID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveQuiz indicates an expected call of RemoveQuiz.
+func (mr *MockQuizSuiteRepositoryMockRecorder) RemoveQuiz(ctx, quizSuiteID, quizID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByUserID", reflect.TypeOf((*MockQuizSuiteRepository)(nil).FindByUserID), userID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveQuiz", reflect.TypeOf((*MockQuizSuiteRepository)(nil).RemoveQuiz), ctx, quizSuiteID, quizID)
 }
 
 // Update mocks base method.
-func (m *MockQuizSuiteRepository) Update(quizSuite *quiz_suite.QuizSuite) error {
+func (m *MockQuizSuiteRepository) Update(ctx context.Context, quizSuite *quiz_suite.QuizSuite) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Update", quizSuite)
+	ret := m.ctrl.Call(m, "Update", ctx, quizSuite)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Update indicates an expected call of Update.
-func (mr *MockQuizSuiteRepositoryMockRecorder) Update(quizSuite interface{}) *gomock.Call {
+func (mr *MockQuizSuiteRepositoryMockRecorder) Update(ctx, quizSuite interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockQuizSuiteRepository)(nil).Update), quizSuite)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockQuizSuiteRepository)(nil).Update), ctx, quizSuite)
 }
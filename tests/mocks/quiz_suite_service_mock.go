@@ -5,7 +5,9 @@
 package mocks
 
 import (
+	context "context"
 	quiz_suite "quizlet/internal/models/quiz_suite"
+	service "quizlet/internal/service"
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
@@ -35,101 +37,116 @@ func (m *MockQuizSuiteService) EXPECT() *MockQuizSuiteServiceMockRecorder {
 }
 
 // AddQuizToSuite mocks base method.
-func (m *MockQuizSuiteService) AddQuizToSuite(quizSuiteID, quizID uint) error {
+func (m *MockQuizSuiteService) AddQuizToSuite(ctx context.Context, quizSuiteID, quizID uint) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "AddQuizToSuite", quizSuiteID, quizID)
+	ret := m.ctrl.Call(m, "AddQuizToSuite", ctx, quizSuiteID, quizID)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // AddQuizToSuite indicates an expected call of AddQuizToSuite.
-func (mr *MockQuizSuiteServiceMockRecorder) AddQuizToSuite(quizSuiteID, quizID interface{}) *gomock.Call {
+func (mr *MockQuizSuiteServiceMockRecorder) AddQuizToSuite(ctx, quizSuiteID, quizID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddQuizToSuite", reflect.TypeOf((*MockQuizSuiteService)(nil).AddQuizToSuite), quizSuiteID, quizID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddQuizToSuite", reflect.TypeOf((*MockQuizSuiteService)(nil).AddQuizToSuite), ctx, quizSuiteID, quizID)
+}
+
+// CloneQuizSuite mocks base method.
+func (m *MockQuizSuiteService) CloneQuizSuite(ctx context.Context, id, userID uint, opts service.CloneOptions) (*quiz_suite.QuizSuite, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CloneQuizSuite", ctx, id, userID, opts)
+	ret0, _ := ret[0].(*quiz_suite.QuizSuite)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CloneQuizSuite indicates an expected call of CloneQuizSuite.
+func (mr *MockQuizSuiteServiceMockRecorder) CloneQuizSuite(ctx, id, userID, opts interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloneQuizSuite", reflect.TypeOf((*MockQuizSuiteService)(nil).CloneQuizSuite), ctx, id, userID, opts)
 }
 
 // CreateQuizSuite mocks base method.
-func (m *MockQuizSuiteService) CreateQuizSuite(quizSuite *quiz_suite.QuizSuite) error {
+func (m *MockQuizSuiteService) CreateQuizSuite(ctx context.Context, quizSuite *quiz_suite.QuizSuite) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateQuizSuite", quizSuite)
+	ret := m.ctrl.Call(m, "CreateQuizSuite", ctx, quizSuite)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // CreateQuizSuite indicates an expected call of CreateQuizSuite.
-func (mr *MockQuizSuiteServiceMockRecorder) CreateQuizSuite(quizSuite interface{}) *gomock.Call {
+func (mr *MockQuizSuiteServiceMockRecorder) CreateQuizSuite(ctx, quizSuite interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateQuizSuite", reflect.TypeOf((*MockQuizSuiteService)(nil).CreateQuizSuite), quizSuite)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateQuizSuite", reflect.TypeOf((*MockQuizSuiteService)(nil).CreateQuizSuite), ctx, quizSuite)
 }
 
 // DeleteQuizSuite mocks base method.
-func (m *MockQuizSuiteService) DeleteQuizSuite(id uint) error {
+func (m *MockQuizSuiteService) DeleteQuizSuite(ctx context.Context, id, version uint) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteQuizSuite", id)
+	ret := m.ctrl.Call(m, "DeleteQuizSuite", ctx, id, version)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // DeleteQuizSuite indicates an expected call of DeleteQuizSuite.
-func (mr *MockQuizSuiteServiceMockRecorder) DeleteQuizSuite(id interface{}) *gomock.Call {
+func (mr *MockQuizSuiteServiceMockRecorder) DeleteQuizSuite(ctx, id, version interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteQuizSuite", reflect.TypeOf((*MockQuizSuiteService)(nil).DeleteQuizSuite), id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteQuizSuite", reflect.TypeOf((*MockQuizSuiteService)(nil).DeleteQuizSuite), ctx, id, version)
 }
 
 // GetQuizSuite mocks base method.
-func (m *MockQuizSuiteService) GetQuizSuite(id uint) (*quiz_suite.QuizSuite, error) {
+func (m *MockQuizSuiteService) GetQuizSuite(ctx context.Context, id uint) (*quiz_suite.QuizSuite, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetQuizSuite", id)
+	ret := m.ctrl.Call(m, "GetQuizSuite", ctx, id)
 	ret0, _ := ret[0].(*quiz_suite.QuizSuite)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetQuizSuite indicates an expected call of GetQuizSuite.
-func (mr *MockQuizSuiteServiceMockRecorder) GetQuizSuite(id interface{}) *gomock.Call {
+func (mr *MockQuizSuiteServiceMockRecorder) GetQuizSuite(ctx, id interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetQuizSuite", reflect.TypeOf((*MockQuizSuiteService)(nil).GetQuizSuite), id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetQuizSuite", reflect.TypeOf((*MockQuizSuiteService)(nil).GetQuizSuite), ctx, id)
 }
 
 // GetUserQuizSuites mocks base method.
-func (m *MockQuizSuiteService) GetUserQuizSuites(userID uint) ([]*quiz_suite.QuizSuite, error) {
+func (m *MockQuizSuiteService) GetUserQuizSuites(ctx context.Context, userID uint) ([]*quiz_suite.QuizSuite, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUserQuizSuites", userID)
+	ret := m.ctrl.Call(m, "GetUserQuizSuites", ctx, userID)
 	ret0, _ := ret[0].([]*quiz_suite.QuizSuite)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetUserQuizSuites indicates an expected call of GetUserQuizSuites.
-func (mr *MockQuizSuiteServiceMockRecorder) GetUserQuizSuites(userID interface{}) *gomock.Call {
+func (mr *MockQuizSuiteServiceMockRecorder) GetUserQuizSuites(ctx, userID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserQuizSuites", reflect.TypeOf((*MockQuizSuiteService)(nil).GetUserQuizSuites), userID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserQuizSuites", reflect.TypeOf((*MockQuizSuiteService)(nil).GetUserQuizSuites), ctx, userID)
 }
 
 // RemoveQuizFromSuite mocks base method.
-func (m *MockQuizSuiteService) RemoveQuizFromSuite(quizSuiteID, quizID uint) error {
+func (m *MockQuizSuiteService) RemoveQuizFromSuite(ctx context.Context, quizSuiteID, quizID uint) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "RemoveQuizFromSuite", quizSuiteID, quizID)
+	ret := m.ctrl.Call(m, "RemoveQuizFromSuite", ctx, quizSuiteID, quizID)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // RemoveQuizFromSuite indicates an expected call of RemoveQuizFromSuite.
-func (mr *MockQuizSuiteServiceMockRecorder) RemoveQuizFromSuite(quizSuiteID, quizID interface{}) *gomock.Call {
+func (mr *MockQuizSuiteServiceMockRecorder) RemoveQuizFromSuite(ctx, quizSuiteID, quizID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveQuizFromSuite", reflect.TypeOf((*MockQuizSuiteService)(nil).RemoveQuizFromSuite), quizSuiteID, quizID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveQuizFromSuite", reflect.TypeOf((*MockQuizSuiteService)(nil).RemoveQuizFromSuite), ctx, quizSuiteID, quizID)
 }
 
 // UpdateQuizSuite mocks base method.
-func (m *MockQuizSuiteService) UpdateQuizSuite(quizSuite *quiz_suite.QuizSuite) error {
+func (m *MockQuizSuiteService) UpdateQuizSuite(ctx context.Context, quizSuite *quiz_suite.QuizSuite) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateQuizSuite", quizSuite)
+	ret := m.ctrl.Call(m, "UpdateQuizSuite", ctx, quizSuite)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // UpdateQuizSuite indicates an expected call of UpdateQuizSuite.
-func (mr *MockQuizSuiteServiceMockRecorder) UpdateQuizSuite(quizSuite interface{}) *gomock.Call {
+func (mr *MockQuizSuiteServiceMockRecorder) UpdateQuizSuite(ctx, quizSuite interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateQuizSuite", reflect.TypeOf((*MockQuizSuiteService)(nil).UpdateQuizSuite), quizSuite)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateQuizSuite", reflect.TypeOf((*MockQuizSuiteService)(nil).UpdateQuizSuite), ctx, quizSuite)
 }
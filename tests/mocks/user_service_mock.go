@@ -5,6 +5,7 @@
 package mocks
 
 import (
+	context "context"
 	user "quizlet/internal/models/user"
 	reflect "reflect"
 
@@ -34,89 +35,236 @@ func (m *MockUserService) EXPECT() *MockUserServiceMockRecorder {
 	return m.recorder
 }
 
+// BeginMFAChallenge mocks base method.
+func (m *MockUserService) BeginMFAChallenge(ctx context.Context, userID uint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BeginMFAChallenge", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// BeginMFAChallenge indicates an expected call of BeginMFAChallenge.
+func (mr *MockUserServiceMockRecorder) BeginMFAChallenge(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BeginMFAChallenge", reflect.TypeOf((*MockUserService)(nil).BeginMFAChallenge), ctx, userID)
+}
+
+// BeginTOTPEnrollment mocks base method.
+func (m *MockUserService) BeginTOTPEnrollment(ctx context.Context, userID uint) (*user.TOTPEnrollment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BeginTOTPEnrollment", ctx, userID)
+	ret0, _ := ret[0].(*user.TOTPEnrollment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BeginTOTPEnrollment indicates an expected call of BeginTOTPEnrollment.
+func (mr *MockUserServiceMockRecorder) BeginTOTPEnrollment(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BeginTOTPEnrollment", reflect.TypeOf((*MockUserService)(nil).BeginTOTPEnrollment), ctx, userID)
+}
+
+// ConfirmTOTPEnrollment mocks base method.
+func (m *MockUserService) ConfirmTOTPEnrollment(ctx context.Context, userID uint, code string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConfirmTOTPEnrollment", ctx, userID, code)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConfirmTOTPEnrollment indicates an expected call of ConfirmTOTPEnrollment.
+func (mr *MockUserServiceMockRecorder) ConfirmTOTPEnrollment(ctx, userID, code interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfirmTOTPEnrollment", reflect.TypeOf((*MockUserService)(nil).ConfirmTOTPEnrollment), ctx, userID, code)
+}
+
+// CreateRefreshToken mocks base method.
+func (m *MockUserService) CreateRefreshToken(ctx context.Context, userID uint) (*user.RefreshToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateRefreshToken", ctx, userID)
+	ret0, _ := ret[0].(*user.RefreshToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateRefreshToken indicates an expected call of CreateRefreshToken.
+func (mr *MockUserServiceMockRecorder) CreateRefreshToken(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateRefreshToken", reflect.TypeOf((*MockUserService)(nil).CreateRefreshToken), ctx, userID)
+}
+
 // CreateUser mocks base method.
-func (m *MockUserService) CreateUser(user *user.User) error {
+func (m *MockUserService) CreateUser(ctx context.Context, user *user.User) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateUser", user)
+	ret := m.ctrl.Call(m, "CreateUser", ctx, user)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // CreateUser indicates an expected call of CreateUser.
-func (mr *MockUserServiceMockRecorder) CreateUser(user interface{}) *gomock.Call {
+func (mr *MockUserServiceMockRecorder) CreateUser(ctx, user interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockUserService)(nil).CreateUser), user)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockUserService)(nil).CreateUser), ctx, user)
 }
 
 // DeleteUser mocks base method.
-func (m *MockUserService) DeleteUser(id uint) error {
+func (m *MockUserService) DeleteUser(ctx context.Context, id uint) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteUser", id)
+	ret := m.ctrl.Call(m, "DeleteUser", ctx, id)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // DeleteUser indicates an expected call of DeleteUser.
-func (mr *MockUserServiceMockRecorder) DeleteUser(id interface{}) *gomock.Call {
+func (mr *MockUserServiceMockRecorder) DeleteUser(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUser", reflect.TypeOf((*MockUserService)(nil).DeleteUser), ctx, id)
+}
+
+// DisableTOTP mocks base method.
+func (m *MockUserService) DisableTOTP(ctx context.Context, userID uint, code string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DisableTOTP", ctx, userID, code)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DisableTOTP indicates an expected call of DisableTOTP.
+func (mr *MockUserServiceMockRecorder) DisableTOTP(ctx, userID, code interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUser", reflect.TypeOf((*MockUserService)(nil).DeleteUser), id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DisableTOTP", reflect.TypeOf((*MockUserService)(nil).DisableTOTP), ctx, userID, code)
 }
 
 // GetUserByEmail mocks base method.
-func (m *MockUserService) GetUserByEmail(email string) (*user.User, error) {
+func (m *MockUserService) GetUserByEmail(ctx context.Context, email string) (*user.User, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUserByEmail", email)
+	ret := m.ctrl.Call(m, "GetUserByEmail", ctx, email)
 	ret0, _ := ret[0].(*user.User)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetUserByEmail indicates an expected call of GetUserByEmail.
-func (mr *MockUserServiceMockRecorder) GetUserByEmail(email interface{}) *gomock.Call {
+func (mr *MockUserServiceMockRecorder) GetUserByEmail(ctx, email interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByEmail", reflect.TypeOf((*MockUserService)(nil).GetUserByEmail), email)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByEmail", reflect.TypeOf((*MockUserService)(nil).GetUserByEmail), ctx, email)
 }
 
 // GetUserByID mocks base method.
-func (m *MockUserService) GetUserByID(id uint) (*user.User, error) {
+func (m *MockUserService) GetUserByID(ctx context.Context, id uint) (*user.User, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUserByID", id)
+	ret := m.ctrl.Call(m, "GetUserByID", ctx, id)
 	ret0, _ := ret[0].(*user.User)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetUserByID indicates an expected call of GetUserByID.
-func (mr *MockUserServiceMockRecorder) GetUserByID(id interface{}) *gomock.Call {
+func (mr *MockUserServiceMockRecorder) GetUserByID(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByID", reflect.TypeOf((*MockUserService)(nil).GetUserByID), ctx, id)
+}
+
+// LinkExternalIdentity mocks base method.
+func (m *MockUserService) LinkExternalIdentity(ctx context.Context, userID uint, profile *user.ExternalProfile) (*user.ExternalIdentity, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LinkExternalIdentity", ctx, userID, profile)
+	ret0, _ := ret[0].(*user.ExternalIdentity)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LinkExternalIdentity indicates an expected call of LinkExternalIdentity.
+func (mr *MockUserServiceMockRecorder) LinkExternalIdentity(ctx, userID, profile interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByID", reflect.TypeOf((*MockUserService)(nil).GetUserByID), id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LinkExternalIdentity", reflect.TypeOf((*MockUserService)(nil).LinkExternalIdentity), ctx, userID, profile)
+}
+
+// LoginWithExternalIdentity mocks base method.
+func (m *MockUserService) LoginWithExternalIdentity(ctx context.Context, profile *user.ExternalProfile) (*user.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LoginWithExternalIdentity", ctx, profile)
+	ret0, _ := ret[0].(*user.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LoginWithExternalIdentity indicates an expected call of LoginWithExternalIdentity.
+func (mr *MockUserServiceMockRecorder) LoginWithExternalIdentity(ctx, profile interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoginWithExternalIdentity", reflect.TypeOf((*MockUserService)(nil).LoginWithExternalIdentity), ctx, profile)
+}
+
+// RevokeRefreshToken mocks base method.
+func (m *MockUserService) RevokeRefreshToken(ctx context.Context, token string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeRefreshToken", ctx, token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeRefreshToken indicates an expected call of RevokeRefreshToken.
+func (mr *MockUserServiceMockRecorder) RevokeRefreshToken(ctx, token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeRefreshToken", reflect.TypeOf((*MockUserService)(nil).RevokeRefreshToken), ctx, token)
 }
 
 // UpdateUser mocks base method.
-func (m *MockUserService) UpdateUser(user *user.User) error {
+func (m *MockUserService) UpdateUser(ctx context.Context, user *user.User) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateUser", user)
+	ret := m.ctrl.Call(m, "UpdateUser", ctx, user)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // UpdateUser indicates an expected call of UpdateUser.
-func (mr *MockUserServiceMockRecorder) UpdateUser(user interface{}) *gomock.Call {
+func (mr *MockUserServiceMockRecorder) UpdateUser(ctx, user interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUser", reflect.TypeOf((*MockUserService)(nil).UpdateUser), user)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUser", reflect.TypeOf((*MockUserService)(nil).UpdateUser), ctx, user)
 }
 
 // ValidatePassword mocks base method.
-func (m *MockUserService) ValidatePassword(email, password string) (*user.User, error) {
+func (m *MockUserService) ValidatePassword(ctx context.Context, email, password string) (*user.User, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ValidatePassword", email, password)
+	ret := m.ctrl.Call(m, "ValidatePassword", ctx, email, password)
 	ret0, _ := ret[0].(*user.User)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // ValidatePassword indicates an expected call of ValidatePassword.
-func (mr *MockUserServiceMockRecorder) ValidatePassword(email, password interface{}) *gomock.Call {
+func (mr *MockUserServiceMockRecorder) ValidatePassword(ctx, email, password interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ValidatePassword", reflect.TypeOf((*MockUserService)(nil).ValidatePassword), ctx, email, password)
+}
+
+// ValidateRefreshToken mocks base method.
+func (m *MockUserService) ValidateRefreshToken(ctx context.Context, token string) (*user.RefreshToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ValidateRefreshToken", ctx, token)
+	ret0, _ := ret[0].(*user.RefreshToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ValidateRefreshToken indicates an expected call of ValidateRefreshToken.
+func (mr *MockUserServiceMockRecorder) ValidateRefreshToken(ctx, token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ValidateRefreshToken", reflect.TypeOf((*MockUserService)(nil).ValidateRefreshToken), ctx, token)
+}
+
+// VerifyMFACode mocks base method.
+func (m *MockUserService) VerifyMFACode(ctx context.Context, userID uint, code string) (*user.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyMFACode", ctx, userID, code)
+	ret0, _ := ret[0].(*user.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VerifyMFACode indicates an expected call of VerifyMFACode.
+func (mr *MockUserServiceMockRecorder) VerifyMFACode(ctx, userID, code interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ValidatePassword", reflect.TypeOf((*MockUserService)(nil).ValidatePassword), email, password)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyMFACode", reflect.TypeOf((*MockUserService)(nil).VerifyMFACode), ctx, userID, code)
 }